@@ -0,0 +1,323 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	cfg := Default()
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	got, args, err := Load(nil, env(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Port != cfg.Port || got.Database.URL != cfg.Database.URL || len(args) != 0 {
+		t.Fatalf("Load with no input = %+v, %v; want the defaults", got, args)
+	}
+}
+
+func TestValidateRouteNames(t *testing.T) {
+	cfg := Default()
+	cfg.Server.RouteTimeouts = map[string]time.Duration{"list-tasks": 5 * time.Second, "list-taks": time.Second}
+	// Without the routes the names cannot be checked.
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.Validate("list-tasks", "create-task")
+	if err == nil || !strings.Contains(err.Error(), `route timeout for "list-taks" names no route`) {
+		t.Fatalf("Validate = %v, want the misspelt route rejected", err)
+	}
+	if strings.Contains(err.Error(), `"list-tasks"`) {
+		t.Fatalf("Validate = %v, rejected a registered route", err)
+	}
+	if err := cfg.Validate("list-tasks", "list-taks"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+port: 9000
+log_level: warn
+database:
+  max_conns: 20
+  min_conns: 2
+chat:
+  timeout: 30s
+`)
+	cfg, args, err := Load(
+		[]string{"-config", file, "-port", "9200", "migrate", "up"},
+		env(map[string]string{
+			"PORT":               "9100",
+			"LOG_LEVEL":          "debug",
+			"DATABASE_MAX_CONNS": "30",
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flags beat the environment, which beats the file, which beats the
+	// defaults.
+	if cfg.Port != 9200 {
+		t.Errorf("port = %d, want the flag's 9200", cfg.Port)
+	}
+	if cfg.LogLevel != "debug" {
+		t.Errorf("log level = %q, want the environment's debug", cfg.LogLevel)
+	}
+	if cfg.Database.MaxConns != 30 || cfg.Database.MinConns != 2 {
+		t.Errorf("pool = %d..%d, want 2..30", cfg.Database.MinConns, cfg.Database.MaxConns)
+	}
+	if cfg.Chat.Timeout != 30*time.Second {
+		t.Errorf("chat timeout = %v, want the file's 30s", cfg.Chat.Timeout)
+	}
+	if cfg.Chat.URL != Default().Chat.URL {
+		t.Errorf("chat url = %q, want the default", cfg.Chat.URL)
+	}
+	if !slices.Equal(args, []string{"migrate", "up"}) {
+		t.Errorf("leftover args = %v, want [migrate up]", args)
+	}
+}
+
+func TestLoadConfigFileFromEnvironment(t *testing.T) {
+	file := writeFile(t, "config.toml", `
+port = 9300
+cors_origins = ["https://app.example.com"]
+
+[database]
+url = "postgresql://db.internal/tasks"
+`)
+	cfg, _, err := Load(nil, env(map[string]string{"CONFIG_FILE": file}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 9300 || cfg.Database.URL != "postgresql://db.internal/tasks" {
+		t.Fatalf("got %+v", cfg)
+	}
+	if !slices.Equal(cfg.CORSOrigins, []string{"https://app.example.com"}) {
+		t.Fatalf("cors origins = %v", cfg.CORSOrigins)
+	}
+}
+
+func TestLoadSetters(t *testing.T) {
+	cfg, _, err := Load(
+		[]string{"-auto-migrate=false", "-database-connect-timeout", "3s", "-server-shutdown-timeout", "10s", "-scheduler-enabled=false"},
+		env(map[string]string{
+			"CORS_ORIGINS":               " https://a.example.com , ,http://b.example.com:8080 ",
+			"SERVER_READ_HEADER_TIMEOUT": "2s",
+			"ROUTE_TIMEOUTS":             "list-tasks=5s, generate-tasks = 1m",
+			"SCHEDULER_INTERVAL":         "30s",
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AutoMigrate {
+		t.Error("auto migrate is still on")
+	}
+	if cfg.Server.ReadHeaderTimeout != 2*time.Second || cfg.Server.ShutdownTimeout != 10*time.Second {
+		t.Errorf("server timeouts = %+v", cfg.Server)
+	}
+	if got := cfg.Server.RouteTimeouts; len(got) != 2 || got["list-tasks"] != 5*time.Second || got["generate-tasks"] != time.Minute {
+		t.Errorf("route timeouts = %v", got)
+	}
+	if cfg.Database.ConnectTimeout != 3*time.Second {
+		t.Errorf("connect timeout = %v, want 3s", cfg.Database.ConnectTimeout)
+	}
+	if want := []string{"https://a.example.com", "http://b.example.com:8080"}; !slices.Equal(cfg.CORSOrigins, want) {
+		t.Errorf("cors origins = %v, want %v", cfg.CORSOrigins, want)
+	}
+	if cfg.Scheduler.Enabled || cfg.Scheduler.Interval != 30*time.Second {
+		t.Errorf("scheduler = %+v", cfg.Scheduler)
+	}
+
+	// A boolean flag given alone turns the setting on.
+	cfg, _, err = Load([]string{"-auto-migrate", "-chat-readiness-check"}, env(map[string]string{"AUTO_MIGRATE": "false"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.AutoMigrate {
+		t.Error("-auto-migrate did not override AUTO_MIGRATE=false")
+	}
+	if !cfg.Chat.ReadinessCheck {
+		t.Error("-chat-readiness-check did not enable the check")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		file string
+		want []string
+	}{
+		{
+			name: "bad values",
+			env:  map[string]string{"PORT": "eighty", "CHAT_TIMEOUT": "soon", "AUTO_MIGRATE": "maybe"},
+			want: []string{`PORT: "eighty" is not an integer`, `CHAT_TIMEOUT: "soon" is not a duration`, `AUTO_MIGRATE: "maybe" is not a boolean`},
+		},
+		{
+			name: "bad flag value",
+			args: []string{"-database-max-conns", "many"},
+			want: []string{`-database-max-conns: "many" is not an integer`},
+		},
+		{
+			name: "every invalid setting at once",
+			env: map[string]string{
+				"PORT":               "70000",
+				"LOG_LEVEL":          "loud",
+				"DATABASE_URL":       "mysql://localhost/db",
+				"DATABASE_MIN_CONNS": "20",
+				"CORS_ORIGINS":       "example.com",
+				"CHAT_URL":           "ftp://chat",
+			},
+			want: []string{
+				"port must be between 1 and 65535",
+				"log level must be",
+				"database url must be a postgres:// URL",
+				"database min_conns must be between 0 and max_conns",
+				`cors origin "example.com"`,
+				"chat url must be",
+			},
+		},
+		{
+			name: "write timeout shorter than chat timeout",
+			env:  map[string]string{"SERVER_WRITE_TIMEOUT": "1m", "CHAT_TIMEOUT": "2m"},
+			want: []string{"server write_timeout (1m0s) must be longer than chat timeout (2m0s)"},
+		},
+		{
+			name: "bad route timeouts",
+			env:  map[string]string{"ROUTE_TIMEOUTS": "list-tasks"},
+			want: []string{`ROUTE_TIMEOUTS: "list-tasks" is not a name=duration pair`},
+		},
+		{
+			name: "route timeouts out of range",
+			env: map[string]string{
+				"ROUTE_TIMEOUTS":  "list-tasks=0s,generate-tasks=10m",
+				"REQUEST_TIMEOUT": "1h",
+			},
+			want: []string{
+				`route timeout for "list-tasks" must be positive`,
+				`route timeout for "generate-tasks" (10m0s) exceeds server write_timeout`,
+				"server request_timeout (1h0m0s) exceeds server write_timeout",
+			},
+		},
+		{
+			name: "non-positive duration",
+			env:  map[string]string{"DATABASE_MAX_CONN_LIFETIME": "0s", "SCHEDULER_INTERVAL": "-1m"},
+			want: []string{"database max_conn_lifetime must be positive", "scheduler interval must be positive"},
+		},
+		{
+			name: "unknown yaml key",
+			file: "config.yaml:prot: 80\n",
+			want: []string{"field prot not found"},
+		},
+		{
+			name: "unknown toml key",
+			file: "config.toml:prot = 80\n",
+			want: []string{"unknown keys [prot]"},
+		},
+		{
+			name: "unsupported file type",
+			file: "config.json:{}",
+			want: []string{"must end in .yaml, .yml or .toml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				name, contents, _ := strings.Cut(tt.file, ":")
+				args = append([]string{"-config", writeFile(t, name, contents)}, args...)
+			}
+			_, _, err := Load(args, env(tt.env))
+			if err == nil {
+				t.Fatal("Load succeeded")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadTaskTransitions(t *testing.T) {
+	cfg, _, err := Load(nil, env(map[string]string{
+		"TASK_TRANSITIONS": "TODO=IN_PROGRESS|CANCELLED, IN_PROGRESS=DONE, DONE=",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"TODO":        {"IN_PROGRESS", "CANCELLED"},
+		"IN_PROGRESS": {"DONE"},
+		"DONE":        {},
+	}
+	if len(cfg.Tasks.Transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", cfg.Tasks.Transitions, want)
+	}
+	for from, targets := range want {
+		if !slices.Equal(cfg.Tasks.Transitions[from], targets) {
+			t.Fatalf("transitions = %v, want %v", cfg.Tasks.Transitions, want)
+		}
+	}
+	workflow, err := cfg.Tasks.Workflow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !workflow.Allows("TODO", "CANCELLED") || workflow.Allows("IN_PROGRESS", "IN_REVIEW") {
+		t.Fatalf("workflow = %v", workflow)
+	}
+
+	// Without configuration the built-in workflow applies.
+	cfg, _, err = Load(nil, env(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if workflow, _ := cfg.Tasks.Workflow(); !workflow.Allows("IN_PROGRESS", "IN_REVIEW") {
+		t.Fatalf("default workflow = %v", workflow)
+	}
+
+	_, _, err = Load(nil, env(map[string]string{"TASK_TRANSITIONS": "TODO=FINISHED"}))
+	if err == nil || !strings.Contains(err.Error(), `tasks transitions: unknown task status "FINISHED"`) {
+		t.Fatalf("got %v, want an unknown status error", err)
+	}
+	_, _, err = Load(nil, env(map[string]string{"TASK_TRANSITIONS": "TODO"}))
+	if err == nil || !strings.Contains(err.Error(), "is not a status=next|next pair") {
+		t.Fatalf("got %v, want a syntax error", err)
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for _, level := range []string{"debug", "INFO", "warn", "warning", "error"} {
+		if _, err := ParseLogLevel(level); err != nil {
+			t.Errorf("ParseLogLevel(%q): %v", level, err)
+		}
+	}
+	if _, err := ParseLogLevel("trace"); err == nil {
+		t.Error("ParseLogLevel(trace) succeeded")
+	}
+}
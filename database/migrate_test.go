@@ -0,0 +1,77 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d (%s) has version %d, want %d", i, m.Name, m.Version, i+1)
+		}
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %d (%s) has an empty script", m.Version, m.Name)
+		}
+	}
+}
+
+func TestLoadMigrationsOrdersByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0010_later.up.sql":    {Data: []byte("up 10")},
+		"migrations/0010_later.down.sql":  {Data: []byte("down 10")},
+		"migrations/0002_second.up.sql":   {Data: []byte("up 2")},
+		"migrations/0002_second.down.sql": {Data: []byte("down 2")},
+	}
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("got %d migrations, want 2", len(migrations))
+	}
+	want := []Migration{
+		{Version: 2, Name: "second", Up: "up 2", Down: "down 2"},
+		{Version: 10, Name: "later", Up: "up 10", Down: "down 10"},
+	}
+	for i := range want {
+		if migrations[i] != want[i] {
+			t.Errorf("migration %d = %+v, want %+v", i, migrations[i], want[i])
+		}
+	}
+}
+
+func TestLoadMigrationsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"missing down", []string{"0001_init.up.sql"}, "needs both up and down"},
+		{"bad direction", []string{"0001_init.sideways.sql"}, "invalid migration file name"},
+		{"no name", []string{"0001.up.sql"}, "invalid migration file name"},
+		{"bad version", []string{"abcd_init.up.sql"}, "invalid migration version"},
+		{"zero version", []string{"0000_init.up.sql"}, "invalid migration version"},
+		{"conflicting names", []string{"0001_init.up.sql", "0001_other.down.sql"}, "conflicting names"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for _, name := range tt.files {
+				fsys["migrations/"+name] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+			}
+			_, err := loadMigrations(fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS employee_projects;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS employees;
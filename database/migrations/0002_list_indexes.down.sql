@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_tasks_created_at;
+DROP INDEX IF EXISTS idx_tasks_status;
+DROP INDEX IF EXISTS idx_projects_created_at;
+DROP INDEX IF EXISTS idx_employees_created_at;
+DROP INDEX IF EXISTS idx_employees_skills;
+DROP INDEX IF EXISTS idx_employees_role;
@@ -0,0 +1,7 @@
+-- Support the filters and keyset sort orders of the list endpoints.
+CREATE INDEX idx_employees_role ON employees(role);
+CREATE INDEX idx_employees_skills ON employees USING GIN (skills);
+CREATE INDEX idx_employees_created_at ON employees(created_at, id);
+CREATE INDEX idx_projects_created_at ON projects(created_at, id);
+CREATE INDEX idx_tasks_status ON tasks(status);
+CREATE INDEX idx_tasks_created_at ON tasks(created_at, id);
@@ -0,0 +1,3 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS updated_at, DROP COLUMN IF EXISTS version;
+ALTER TABLE projects DROP COLUMN IF EXISTS updated_at, DROP COLUMN IF EXISTS version;
+ALTER TABLE employees DROP COLUMN IF EXISTS updated_at, DROP COLUMN IF EXISTS version;
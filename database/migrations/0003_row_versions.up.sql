@@ -0,0 +1,17 @@
+-- Row versions for optimistic concurrency control. Every write bumps
+-- version and updated_at; clients see the version as the ETag.
+ALTER TABLE employees
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE projects
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE tasks
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE employees SET updated_at = COALESCE(created_at, updated_at);
+UPDATE projects SET updated_at = COALESCE(created_at, updated_at);
+UPDATE tasks SET updated_at = COALESCE(created_at, updated_at);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS task_transitions;
+
+ALTER TABLE tasks
+    DROP CONSTRAINT IF EXISTS tasks_status_check,
+    ALTER COLUMN status DROP NOT NULL;
@@ -0,0 +1,26 @@
+-- Restrict tasks.status to the workflow statuses. Free-form values written
+-- before this migration are normalised where the intent is clear and
+-- otherwise reset to TODO.
+UPDATE tasks SET status = UPPER(REPLACE(TRIM(status), ' ', '_'));
+UPDATE tasks SET status = 'DONE' WHERE status IN ('COMPLETE', 'COMPLETED', 'CLOSED');
+UPDATE tasks SET status = 'CANCELLED' WHERE status = 'CANCELED';
+UPDATE tasks SET status = 'TODO'
+WHERE status IS NULL
+   OR status NOT IN ('TODO', 'IN_PROGRESS', 'IN_REVIEW', 'BLOCKED', 'DONE', 'CANCELLED');
+
+ALTER TABLE tasks
+    ALTER COLUMN status SET NOT NULL,
+    ADD CONSTRAINT tasks_status_check
+        CHECK (status IN ('TODO', 'IN_PROGRESS', 'IN_REVIEW', 'BLOCKED', 'DONE', 'CANCELLED'));
+
+CREATE TABLE task_transitions (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    from_status VARCHAR(50) NOT NULL,
+    to_status VARCHAR(50) NOT NULL,
+    actor_id INTEGER REFERENCES employees(id) ON DELETE SET NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_transitions_task ON task_transitions(task_id, id);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_tasks_assignee_due_date;
+DROP INDEX IF EXISTS idx_tasks_due_date;
+DROP INDEX IF EXISTS idx_tasks_priority;
+
+ALTER TABLE tasks
+    DROP CONSTRAINT IF EXISTS tasks_due_date_check,
+    DROP COLUMN IF EXISTS remaining_hours,
+    DROP COLUMN IF EXISTS estimate_hours,
+    DROP COLUMN IF EXISTS due_date,
+    DROP COLUMN IF EXISTS start_date,
+    DROP COLUMN IF EXISTS priority;
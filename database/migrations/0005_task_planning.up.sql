@@ -0,0 +1,15 @@
+-- Priority, schedule and estimates for tasks. P0 is the most urgent.
+ALTER TABLE tasks
+    ADD COLUMN priority VARCHAR(2) NOT NULL DEFAULT 'P2'
+        CONSTRAINT tasks_priority_check CHECK (priority IN ('P0', 'P1', 'P2', 'P3')),
+    ADD COLUMN start_date DATE,
+    ADD COLUMN due_date DATE,
+    ADD COLUMN estimate_hours NUMERIC(7, 2)
+        CONSTRAINT tasks_estimate_hours_check CHECK (estimate_hours >= 0),
+    ADD COLUMN remaining_hours NUMERIC(7, 2)
+        CONSTRAINT tasks_remaining_hours_check CHECK (remaining_hours >= 0),
+    ADD CONSTRAINT tasks_due_date_check CHECK (due_date >= start_date);
+
+CREATE INDEX idx_tasks_priority ON tasks(priority, id);
+CREATE INDEX idx_tasks_due_date ON tasks(due_date, id);
+CREATE INDEX idx_tasks_assignee_due_date ON tasks(assigned_to, due_date) WHERE status NOT IN ('DONE', 'CANCELLED');
@@ -0,0 +1,10 @@
+-- task_id cannot proceed until depends_on_id is finished.
+CREATE TABLE task_dependencies (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    depends_on_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (task_id, depends_on_id),
+    CONSTRAINT task_dependencies_depends_on_id_check CHECK (depends_on_id <> task_id)
+);
+
+CREATE INDEX idx_task_dependencies_depends_on ON task_dependencies(depends_on_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_tasks_parent_task_id;
+
+ALTER TABLE tasks
+    DROP CONSTRAINT IF EXISTS tasks_parent_task_id_check,
+    DROP COLUMN IF EXISTS parent_task_id;
@@ -0,0 +1,7 @@
+-- Subtasks. Deleting a task deletes its whole subtree; the API only does
+-- that when asked to.
+ALTER TABLE tasks
+    ADD COLUMN parent_task_id INTEGER REFERENCES tasks(id) ON DELETE CASCADE,
+    ADD CONSTRAINT tasks_parent_task_id_check CHECK (parent_task_id <> id);
+
+CREATE INDEX idx_tasks_parent_task_id ON tasks(parent_task_id);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS tasks_assignee_change ON tasks;
+DROP FUNCTION IF EXISTS record_task_assignee_change();
+DROP TABLE IF EXISTS task_assignee_changes;
+DROP TABLE IF EXISTS comment_mentions;
+DROP TABLE IF EXISTS task_comments;
@@ -0,0 +1,47 @@
+-- Discussion on tasks. Comments outlive their author.
+CREATE TABLE task_comments (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    author_id INTEGER REFERENCES employees(id) ON DELETE SET NULL,
+    body TEXT NOT NULL CONSTRAINT task_comments_body_check CHECK (body <> ''),
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_comments_task ON task_comments(task_id, id);
+
+-- Employees @mentioned in a comment, resolved when it is written.
+CREATE TABLE comment_mentions (
+    comment_id INTEGER NOT NULL REFERENCES task_comments(id) ON DELETE CASCADE,
+    employee_id INTEGER NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    PRIMARY KEY (comment_id, employee_id)
+);
+
+CREATE INDEX idx_comment_mentions_employee ON comment_mentions(employee_id);
+
+-- Every reassignment of a task, for the activity feed. Written by a trigger
+-- so that no update path can miss it.
+CREATE TABLE task_assignee_changes (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    from_assignee INTEGER REFERENCES employees(id) ON DELETE SET NULL,
+    to_assignee INTEGER REFERENCES employees(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_assignee_changes_task ON task_assignee_changes(task_id, id);
+
+CREATE FUNCTION record_task_assignee_change() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO task_assignee_changes (task_id, from_assignee, to_assignee)
+    VALUES (NEW.id, OLD.assigned_to, NEW.assigned_to);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_assignee_change
+    AFTER UPDATE OF assigned_to ON tasks
+    FOR EACH ROW
+    WHEN (OLD.assigned_to IS DISTINCT FROM NEW.assigned_to)
+    EXECUTE FUNCTION record_task_assignee_change();
@@ -0,0 +1,34 @@
+-- Labels group tasks and projects. A label with a project_id can only be
+-- used within that project; one without is global.
+CREATE TABLE labels (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL CONSTRAINT labels_name_check CHECK (name <> ''),
+    color VARCHAR(7) NOT NULL DEFAULT '#808080'
+        CONSTRAINT labels_color_check CHECK (color ~ '^#[0-9a-f]{6}$'),
+    description TEXT NOT NULL DEFAULT '',
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Names are unique within a project and among global labels, ignoring case.
+CREATE UNIQUE INDEX labels_name_key ON labels(COALESCE(project_id, 0), lower(name));
+
+CREATE TABLE task_labels (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    label_id INTEGER NOT NULL REFERENCES labels(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (task_id, label_id)
+);
+
+CREATE INDEX idx_task_labels_label ON task_labels(label_id);
+
+CREATE TABLE project_labels (
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    label_id INTEGER NOT NULL REFERENCES labels(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (project_id, label_id)
+);
+
+CREATE INDEX idx_project_labels_label ON project_labels(label_id);
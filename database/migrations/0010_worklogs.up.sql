@@ -0,0 +1,20 @@
+-- Time spent on tasks. duration_seconds is NULL while the entry is a
+-- running timer. Worklogs keep their employee from being deleted.
+CREATE TABLE worklogs (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    employee_id INTEGER NOT NULL REFERENCES employees(id),
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    duration_seconds INTEGER
+        CONSTRAINT worklogs_duration_seconds_check CHECK (duration_seconds >= 0),
+    note TEXT NOT NULL DEFAULT '',
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- At most one running timer per employee.
+CREATE UNIQUE INDEX worklogs_running_timer_key ON worklogs(employee_id) WHERE duration_seconds IS NULL;
+
+CREATE INDEX idx_worklogs_employee_started ON worklogs(employee_id, started_at);
+CREATE INDEX idx_worklogs_task ON worklogs(task_id, started_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS recurring_task_runs;
+DROP TABLE IF EXISTS recurring_tasks;
@@ -0,0 +1,39 @@
+-- Definitions from which the scheduler creates a task every time their
+-- cron schedule comes due. next_run_at is NULL while paused.
+CREATE TABLE recurring_tasks (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    assigned_to INTEGER NOT NULL REFERENCES employees(id),
+    title VARCHAR(200) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    priority VARCHAR(2) NOT NULL DEFAULT 'P2'
+        CONSTRAINT recurring_tasks_priority_check CHECK (priority IN ('P0', 'P1', 'P2', 'P3')),
+    estimate_hours NUMERIC(7, 2)
+        CONSTRAINT recurring_tasks_estimate_hours_check CHECK (estimate_hours >= 0),
+    due_in_days INTEGER
+        CONSTRAINT recurring_tasks_due_in_days_check CHECK (due_in_days >= 0),
+    schedule VARCHAR(100) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_recurring_tasks_next_run_at ON recurring_tasks(next_run_at) WHERE NOT paused;
+CREATE INDEX idx_recurring_tasks_project ON recurring_tasks(project_id);
+
+-- One row per occurrence a task was created for. The primary key is what
+-- keeps a restarted or second scheduler from creating the same task twice;
+-- the row outlives the task so a deleted task is not recreated.
+CREATE TABLE recurring_task_runs (
+    recurring_task_id INTEGER NOT NULL REFERENCES recurring_tasks(id) ON DELETE CASCADE,
+    occurrence_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    task_id INTEGER REFERENCES tasks(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (recurring_task_id, occurrence_at)
+);
+
+CREATE INDEX idx_recurring_task_runs_task ON recurring_task_runs(task_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_tasks_board;
+
+ALTER TABLE tasks
+    DROP CONSTRAINT IF EXISTS tasks_rank_check,
+    DROP COLUMN IF EXISTS rank;
@@ -0,0 +1,19 @@
+-- Board order. A task's rank orders it within its project's column for its
+-- status; see models.RankBetween. Ranks compare byte-wise, hence the "C"
+-- collation. Existing tasks keep their insertion order.
+ALTER TABLE tasks
+    ADD COLUMN rank VARCHAR(64) COLLATE "C";
+
+UPDATE tasks t
+SET rank = r.rank
+FROM (
+    SELECT id, lpad(row_number() OVER (PARTITION BY project_id, status ORDER BY id)::text, 10, '0') || 'i' AS rank
+    FROM tasks
+) r
+WHERE t.id = r.id;
+
+ALTER TABLE tasks
+    ALTER COLUMN rank SET NOT NULL,
+    ADD CONSTRAINT tasks_rank_check CHECK (rank ~ '^[0-9a-z]*[1-9a-z]$');
+
+CREATE INDEX idx_tasks_board ON tasks(project_id, status, rank, id);
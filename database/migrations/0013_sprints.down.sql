@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_tasks_sprint_id;
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS sprint_id;
+
+DROP TABLE IF EXISTS sprints;
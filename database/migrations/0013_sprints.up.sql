@@ -0,0 +1,35 @@
+-- Timeboxes within a project. committed_hours is recorded when a sprint
+-- starts, and completed_hours and completed_tasks when it closes, so that
+-- reopening work later does not rewrite a sprint's velocity.
+CREATE TABLE sprints (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(200) NOT NULL,
+    goal TEXT NOT NULL DEFAULT '',
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    state VARCHAR(20) NOT NULL DEFAULT 'PLANNED'
+        CONSTRAINT sprints_state_check CHECK (state IN ('PLANNED', 'ACTIVE', 'CLOSED')),
+    started_at TIMESTAMP WITH TIME ZONE,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    committed_hours NUMERIC(9, 2),
+    completed_hours NUMERIC(9, 2),
+    completed_tasks INTEGER,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT sprints_end_date_check CHECK (end_date >= start_date)
+);
+
+-- At most one active sprint per project.
+CREATE UNIQUE INDEX sprints_state_key ON sprints(project_id) WHERE state = 'ACTIVE';
+
+CREATE INDEX idx_sprints_project_start ON sprints(project_id, start_date);
+CREATE INDEX idx_sprints_start_date ON sprints(start_date, id);
+CREATE INDEX idx_sprints_end_date ON sprints(end_date, id);
+
+-- Deleting a sprint returns its tasks to the backlog.
+ALTER TABLE tasks
+    ADD COLUMN sprint_id INTEGER REFERENCES sprints(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_tasks_sprint_id ON tasks(sprint_id);
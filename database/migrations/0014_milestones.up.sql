@@ -0,0 +1,24 @@
+-- Target dates within a project. Progress and risk are computed from the
+-- linked tasks when read, so only the status a milestone was given is
+-- stored.
+CREATE TABLE milestones (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    title VARCHAR(200) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    target_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN'
+        CONSTRAINT milestones_status_check CHECK (status IN ('OPEN', 'COMPLETED', 'CANCELLED')),
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_milestones_project_target ON milestones(project_id, target_date);
+CREATE INDEX idx_milestones_target_date ON milestones(target_date, id);
+
+-- Deleting a milestone unlinks its tasks.
+ALTER TABLE tasks
+    ADD COLUMN milestone_id INTEGER REFERENCES milestones(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_tasks_milestone_id ON tasks(milestone_id);
@@ -0,0 +1,20 @@
+-- Soft-deleted rows become visible again. Restoring the email constraint
+-- fails if a deleted employee's email has been given to someone else.
+DROP INDEX IF EXISTS idx_tasks_deleted_at;
+DROP INDEX IF EXISTS idx_projects_deleted_at;
+DROP INDEX IF EXISTS idx_employees_deleted_at;
+
+DROP INDEX IF EXISTS employees_email_key;
+
+ALTER TABLE employees
+    ADD CONSTRAINT employees_email_key UNIQUE (email);
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE projects
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS archived_at;
+
+ALTER TABLE employees
+    DROP COLUMN IF EXISTS deleted_at;
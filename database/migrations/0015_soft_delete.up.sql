@@ -0,0 +1,24 @@
+-- Soft delete. Rows with deleted_at set are hidden from the API until they
+-- are restored, or hard-deleted by the purge job once deleted for longer
+-- than the retention period. Deleting a project or a task stamps its tasks
+-- or subtasks with the same deleted_at, so restoring it brings back exactly
+-- those.
+ALTER TABLE employees
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE projects
+    ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE tasks
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- A deleted employee's email can be given to someone else.
+ALTER TABLE employees
+    DROP CONSTRAINT employees_email_key;
+
+CREATE UNIQUE INDEX employees_email_key ON employees(email) WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_employees_deleted_at ON employees(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_projects_deleted_at ON projects(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_tasks_deleted_at ON tasks(deleted_at) WHERE deleted_at IS NOT NULL;
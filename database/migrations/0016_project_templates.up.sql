@@ -0,0 +1,17 @@
+-- Templates are snapshots of a project's plan from which new projects are
+-- created. The plan is only ever read and written whole, so it is kept as
+-- one JSON document rather than spread over tables of its own; the IDs in
+-- it are those of the source rows and are not foreign keys.
+CREATE TABLE project_templates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    source_project_id INTEGER REFERENCES projects(id) ON DELETE SET NULL,
+    content JSONB NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_project_templates_name ON project_templates(name, id);
+CREATE INDEX idx_project_templates_created_at ON project_templates(created_at, id);
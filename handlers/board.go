@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
+)
+
+// GetProjectBoard returns a project's tasks as board columns, one per
+// status in workflow order, each in rank order. Tasks can be narrowed to
+// one assignee with assigned_to.
+func (h *ProjectHandler) GetProjectBoard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID, err := pathID(r, "id", "project")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	q := newListQuery(r)
+	assignedTo := q.idParam("assigned_to")
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if _, err := h.projects.GetByID(ctx, projectID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, notFound("Project not found"))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	tasks, err := h.tasks.Board(ctx, projectID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if assignedTo != 0 {
+		var assigned []models.Task
+		for _, task := range tasks {
+			if task.AssignedTo == assignedTo {
+				assigned = append(assigned, task)
+			}
+		}
+		tasks = assigned
+	}
+
+	writeJSON(w, http.StatusOK, models.NewBoard(projectID, tasks))
+}
+
+// moveRequest places a task in the column for To, or its current column if
+// To is empty. ActorID is only required when the status changes.
+type moveRequest struct {
+	To       models.TaskStatus `json:"to" validate:"enum"`
+	AfterID  int               `json:"after_id" validate:"min=1"`
+	BeforeID int               `json:"before_id" validate:"min=1"`
+	ActorID  int               `json:"actor_id" validate:"min=1"`
+	Note     string            `json:"note" validate:"max=1000"`
+}
+
+// MoveTask drops a task onto its project's board, directly after or before
+// another task of the target column or at its bottom, changing the task's
+// status on the way if the workflow allows it. The status and position
+// change together or not at all.
+func (h *TaskHandler) MoveTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	taskID, err := pathID(r, "id", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req moveRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if req.To != "" {
+		req.To, _ = parseStatus(string(req.To))
+	}
+	errs := validation.Struct(&req)
+	if req.AfterID != 0 && req.BeforeID != 0 {
+		errs.Add("before_id", "cannot be combined with after_id")
+	}
+	if req.AfterID == taskID {
+		errs.Add("after_id", "must be another task")
+	}
+	if req.BeforeID == taskID {
+		errs.Add("before_id", "must be another task")
+	}
+	if err := checkExists(ctx, &errs, "actor_id", req.ActorID, h.employees.GetByID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := errs.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	current, err := h.tasks.GetByID(ctx, taskID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Task not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if req.To == "" {
+		req.To = current.Status
+	}
+	if req.To != current.Status {
+		if req.ActorID == 0 {
+			writeError(w, r, validation.Errors{{Field: "actor_id", Message: "is required when the status changes"}})
+			return
+		}
+		if err := h.checkTransition(ctx, current, req.To); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+	if err := h.checkNeighbour(r, current.ProjectID, req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	transition := models.TaskTransition{
+		TaskID: taskID,
+		From:   current.Status,
+		To:     req.To,
+		Note:   req.Note,
+	}
+	if req.ActorID != 0 {
+		transition.ActorID = &req.ActorID
+	}
+	position := repository.TaskPosition{AfterID: req.AfterID, BeforeID: req.BeforeID}
+	task, err := h.tasks.Move(ctx, &transition, position, current.Version)
+	if err != nil {
+		writeError(w, r, changeError(err, version, "Task"))
+		return
+	}
+	if task.Status != current.Status {
+		h.afterTransition(ctx, current.Status, task, req.ActorID)
+	}
+
+	setETag(w, task.Version)
+	writeJSON(w, http.StatusOK, task)
+}
+
+// checkNeighbour rejects a move relative to a task that is not in the
+// target column of the same project.
+func (h *TaskHandler) checkNeighbour(r *http.Request, projectID int, req moveRequest) error {
+	field, id := "after_id", req.AfterID
+	if req.BeforeID != 0 {
+		field, id = "before_id", req.BeforeID
+	}
+	if id == 0 {
+		return nil
+	}
+
+	neighbour, err := h.tasks.GetByID(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return validation.Errors{{Field: field, Message: "does not exist"}}
+	}
+	if err != nil {
+		return err
+	}
+	if neighbour.ProjectID != projectID || neighbour.Status != req.To {
+		return validation.Errors{{Field: field, Message: "must be a task in the " + string(req.To) + " column of the same project"}}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+)
+
+// column returns the IDs of the tasks in one column of a project's board.
+func (s *testServer) column(projectID int, status models.TaskStatus, query string) []int {
+	s.t.Helper()
+	var board models.Board
+	s.expect(s.do("GET", fmt.Sprintf("/projects/%d/board%s", projectID, query), nil), http.StatusOK, &board)
+	if len(board.Columns) != len(models.TaskStatuses) {
+		s.t.Fatalf("board has %d columns, want %d", len(board.Columns), len(models.TaskStatuses))
+	}
+	for _, column := range board.Columns {
+		if column.Status == status {
+			return ids(column.Tasks, taskID)
+		}
+	}
+	s.t.Fatalf("board has no %s column", status)
+	return nil
+}
+
+func (s *testServer) move(task models.Task, req map[string]any) *models.Task {
+	s.t.Helper()
+	var moved models.Task
+	s.expect(s.do("POST", fmt.Sprintf("/tasks/%d/move", task.ID), req), http.StatusOK, &moved)
+	return &moved
+}
+
+func TestProjectBoard(t *testing.T) {
+	s := newTestServer(t)
+	grace := s.createEmployee("Grace Hopper", "grace@example.com")
+	ada := s.createEmployee("Ada Lovelace", "ada@example.com")
+	project := s.createProject("Compiler", grace.ID)
+	s.expect(s.do("POST", fmt.Sprintf("/employees/%d/projects/%d", ada.ID, project.ID), nil), http.StatusCreated, nil)
+	lexer := s.createTask("Write the lexer", project.ID, grace.ID)
+	parser := s.createTask("Write the parser", project.ID, ada.ID)
+	codegen := s.createTask("Write the code generator", project.ID, grace.ID)
+	s.transition(parser, models.StatusInProgress, ada.ID)
+
+	var board models.Board
+	s.expect(s.do("GET", fmt.Sprintf("/projects/%d/board", project.ID), nil), http.StatusOK, &board)
+	var statuses []models.TaskStatus
+	for _, column := range board.Columns {
+		statuses = append(statuses, column.Status)
+	}
+	if board.ProjectID != project.ID || !slices.Equal(statuses, models.TaskStatuses) {
+		t.Fatalf("board %d has columns %v", board.ProjectID, statuses)
+	}
+
+	// New tasks go to the bottom of their column.
+	if got := s.column(project.ID, models.StatusTodo, ""); !slices.Equal(got, []int{lexer.ID, codegen.ID}) {
+		t.Fatalf("TODO column = %v", got)
+	}
+	if got := s.column(project.ID, models.StatusInProgress, ""); !slices.Equal(got, []int{parser.ID}) {
+		t.Fatalf("IN_PROGRESS column = %v", got)
+	}
+	if got := s.column(project.ID, models.StatusTodo, fmt.Sprintf("?assigned_to=%d", ada.ID)); len(got) != 0 {
+		t.Fatalf("TODO column for %d = %v", ada.ID, got)
+	}
+
+	s.expectError(s.do("GET", "/projects/999/board", nil), http.StatusNotFound, CodeNotFound)
+}
+
+func TestMoveTask(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	lexer := s.createTask("Write the lexer", project.ID, lead.ID)
+	parser := s.createTask("Write the parser", project.ID, lead.ID)
+	codegen := s.createTask("Write the code generator", project.ID, lead.ID)
+	linker := s.createTask("Write the linker", project.ID, lead.ID)
+
+	// Reordering within a column needs no actor and leaves the status.
+	moved := s.move(linker, map[string]any{"before_id": lexer.ID})
+	if moved.Status != models.StatusTodo || moved.Version != linker.Version+1 {
+		t.Fatalf("moved task = %+v", moved)
+	}
+	s.move(lexer, map[string]any{"after_id": parser.ID})
+	if got := s.column(project.ID, models.StatusTodo, ""); !slices.Equal(got, []int{linker.ID, parser.ID, lexer.ID, codegen.ID}) {
+		t.Fatalf("TODO column = %v", got)
+	}
+	s.move(linker, map[string]any{})
+	if got := s.column(project.ID, models.StatusTodo, ""); !slices.Equal(got, []int{parser.ID, lexer.ID, codegen.ID, linker.ID}) {
+		t.Fatalf("TODO column after moving to the bottom = %v", got)
+	}
+
+	// Moving to another column transitions the task.
+	moved = s.move(parser, map[string]any{"to": "in_progress", "actor_id": lead.ID, "note": "starting"})
+	if moved.Status != models.StatusInProgress {
+		t.Fatalf("moved task = %+v", moved)
+	}
+	s.move(codegen, map[string]any{"to": models.StatusInProgress, "before_id": parser.ID, "actor_id": lead.ID})
+	if got := s.column(project.ID, models.StatusInProgress, ""); !slices.Equal(got, []int{codegen.ID, parser.ID}) {
+		t.Fatalf("IN_PROGRESS column = %v", got)
+	}
+	var history []models.TaskTransition
+	s.expect(s.do("GET", fmt.Sprintf("/tasks/%d/transitions", parser.ID), nil), http.StatusOK, &history)
+	if len(history) != 1 || history[0].Note != "starting" || *history[0].ActorID != lead.ID {
+		t.Fatalf("history = %+v", history)
+	}
+
+	// The workflow still applies, and a rejected move leaves the task where
+	// it was.
+	apiErr := s.expectError(s.do("POST", fmt.Sprintf("/tasks/%d/move", lexer.ID), map[string]any{
+		"to":        models.StatusDone,
+		"actor_id":  lead.ID,
+		"before_id": codegen.ID,
+	}), http.StatusConflict, CodeInvalidTransition)
+	if apiErr.Details[0].Field != "to" {
+		t.Fatalf("got %+v", apiErr)
+	}
+	if got := s.getTask(lexer.ID); got.Status != models.StatusTodo || got.Version != lexer.Version+1 {
+		t.Fatalf("task after rejected move = %+v", got)
+	}
+}
+
+func TestMoveTaskValidation(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	other := s.createProject("Debugger", lead.ID)
+	lexer := s.createTask("Write the lexer", project.ID, lead.ID)
+	parser := s.createTask("Write the parser", project.ID, lead.ID)
+	stepper := s.createTask("Write the stepper", other.ID, lead.ID)
+	path := fmt.Sprintf("/tasks/%d/move", lexer.ID)
+
+	tests := []struct {
+		name string
+		req  map[string]any
+		want []FieldError
+	}{
+		{
+			name: "both neighbours",
+			req:  map[string]any{"after_id": parser.ID, "before_id": parser.ID},
+			want: []FieldError{{Field: "before_id", Message: "cannot be combined with after_id"}},
+		},
+		{
+			name: "itself",
+			req:  map[string]any{"after_id": lexer.ID},
+			want: []FieldError{{Field: "after_id", Message: "must be another task"}},
+		},
+		{
+			name: "unknown status",
+			req:  map[string]any{"to": "SOMEDAY"},
+			want: []FieldError{{Field: "to", Message: "SOMEDAY is not a valid value"}},
+		},
+		{
+			name: "status change without actor",
+			req:  map[string]any{"to": models.StatusInProgress},
+			want: []FieldError{{Field: "actor_id", Message: "is required when the status changes"}},
+		},
+		{
+			name: "missing neighbour",
+			req:  map[string]any{"before_id": 999},
+			want: []FieldError{{Field: "before_id", Message: "does not exist"}},
+		},
+		{
+			name: "neighbour in another project",
+			req:  map[string]any{"after_id": stepper.ID},
+			want: []FieldError{{Field: "after_id", Message: "must be a task in the TODO column of the same project"}},
+		},
+		{
+			name: "neighbour in another column",
+			req:  map[string]any{"to": models.StatusInProgress, "actor_id": lead.ID, "after_id": parser.ID},
+			want: []FieldError{{Field: "after_id", Message: "must be a task in the IN_PROGRESS column of the same project"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := s.expectError(s.do("POST", path, tt.req), http.StatusBadRequest, CodeValidation)
+			if !slices.Equal(apiErr.Details, tt.want) {
+				t.Fatalf("details = %+v, want %+v", apiErr.Details, tt.want)
+			}
+		})
+	}
+
+	s.expectError(s.do("POST", path, map[string]any{}, "If-Match", `"9"`), http.StatusPreconditionFailed, CodePreconditionFailed)
+	s.expectError(s.do("POST", "/tasks/999/move", map[string]any{}), http.StatusNotFound, CodeNotFound)
+
+	// A task's rank only changes by moving it.
+	apiErr := s.expectError(s.do("PATCH", fmt.Sprintf("/tasks/%d", lexer.ID), map[string]any{"rank": "0"}), http.StatusBadRequest, CodeBadRequest)
+	if apiErr.Details[0] != (FieldError{Field: "rank", Message: "is read-only"}) {
+		t.Fatalf("details = %+v", apiErr.Details)
+	}
+}
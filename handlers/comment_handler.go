@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
+)
+
+type CommentHandler struct {
+	comments  repository.CommentRepository
+	tasks     repository.TaskRepository
+	employees repository.EmployeeRepository
+}
+
+func NewCommentHandler(repos *repository.Repositories) *CommentHandler {
+	return &CommentHandler{
+		comments:  repos.Comments,
+		tasks:     repos.Tasks,
+		employees: repos.Employees,
+	}
+}
+
+type commentRequest struct {
+	AuthorID int    `json:"author_id" validate:"required,min=1"`
+	Body     string `json:"body" validate:"required,max=10000"`
+}
+
+type commentEditRequest struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
+
+// CreateComment adds a comment to a task, resolving its @mentions.
+func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	taskID, ok := h.taskID(w, r)
+	if !ok {
+		return
+	}
+
+	var req commentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	errs := validation.Struct(&req)
+	if err := checkExists(ctx, &errs, "author_id", req.AuthorID, h.employees.GetByID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := errs.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	mentions, err := h.mentions(ctx, req.Body)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	comment := models.Comment{
+		TaskID:   taskID,
+		AuthorID: &req.AuthorID,
+		Body:     req.Body,
+		Mentions: mentions,
+	}
+	if err := h.comments.Create(ctx, &comment); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, comment.Version)
+	writeJSON(w, http.StatusOK, comment)
+}
+
+// GetComment returns one comment of a task.
+func (h *CommentHandler) GetComment(w http.ResponseWriter, r *http.Request) {
+	comment, ok := h.comment(w, r)
+	if !ok {
+		return
+	}
+
+	if notModified(w, r, comment.Version) {
+		return
+	}
+	writeJSON(w, http.StatusOK, comment)
+}
+
+// GetComments lists a task's comments, oldest first unless sorted
+// otherwise, optionally only those by author_id or mentioning mentions.
+func (h *CommentHandler) GetComments(w http.ResponseWriter, r *http.Request) {
+	taskID, ok := h.taskID(w, r)
+	if !ok {
+		return
+	}
+
+	q := newListQuery(r)
+	filter := repository.CommentFilter{
+		TaskID:      taskID,
+		AuthorID:    q.idParam("author_id"),
+		MentionedID: q.idParam("mentions"),
+		Page:        page(q, repository.CommentSorts),
+	}
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	comments, err := h.comments.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, comments)
+}
+
+// UpdateComment replaces the body of a comment and re-resolves its
+// @mentions. The author cannot be changed.
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current, ok := h.comment(w, r)
+	if !ok {
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req commentEditRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if err := validation.Struct(&req).Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	mentions, err := h.mentions(ctx, req.Body)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	comment := models.Comment{ID: current.ID, Body: req.Body, Mentions: mentions, Version: version}
+	err = h.comments.Update(ctx, &comment)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Comment not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, comment.Version)
+	writeJSON(w, http.StatusOK, comment)
+}
+
+// DeleteComment removes a comment from a task.
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	current, ok := h.comment(w, r)
+	if !ok {
+		return
+	}
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = h.comments.Delete(r.Context(), current.ID, version)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Comment not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetTaskActivity returns a task's comments, status changes and
+// reassignments as one feed, oldest first.
+func (h *CommentHandler) GetTaskActivity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	taskID, ok := h.taskID(w, r)
+	if !ok {
+		return
+	}
+
+	comments, err := h.comments.ListByTask(ctx, taskID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	transitions, err := h.tasks.Transitions(ctx, taskID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	changes, err := h.tasks.AssigneeChanges(ctx, taskID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.MergeActivity(comments, transitions, changes))
+}
+
+// taskID reads the task ID from the path and checks that the task exists,
+// writing the error response and returning false if not.
+func (h *CommentHandler) taskID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	taskID, err := pathID(r, "id", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return 0, false
+	}
+
+	if _, err := h.tasks.GetByID(r.Context(), taskID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, notFound("Task not found"))
+			return 0, false
+		}
+		writeError(w, r, err)
+		return 0, false
+	}
+	return taskID, true
+}
+
+// comment loads the comment named in the path, which must belong to the
+// task named in the path.
+func (h *CommentHandler) comment(w http.ResponseWriter, r *http.Request) (*models.Comment, bool) {
+	taskID, err := pathID(r, "id", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+	commentID, err := pathID(r, "commentId", "comment")
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+
+	comment, err := h.comments.GetByID(r.Context(), commentID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && comment.TaskID != taskID) {
+		writeError(w, r, notFound("Comment not found"))
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+	return comment, true
+}
+
+// mentions resolves the @mentions in body to employee IDs.
+func (h *CommentHandler) mentions(ctx context.Context, body string) ([]int, error) {
+	handles := models.ParseMentions(body)
+	if len(handles) == 0 {
+		return []int{}, nil
+	}
+	employees, err := h.employees.ListByHandles(ctx, handles)
+	if err != nil {
+		return nil, err
+	}
+	return models.ResolveMentions(handles, employees), nil
+}
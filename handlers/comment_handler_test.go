@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"nstorm.com/main-backend/models"
+)
+
+func (s *testServer) createComment(taskID, authorID int, body string) models.Comment {
+	s.t.Helper()
+	var comment models.Comment
+	s.expect(s.do("POST", fmt.Sprintf("/tasks/%d/comments", taskID), map[string]any{
+		"author_id": authorID,
+		"body":      body,
+	}), http.StatusOK, &comment)
+	return comment
+}
+
+func TestCommentMentions(t *testing.T) {
+	s := newTestServer(t)
+	grace := s.createEmployee("Grace Hopper", "grace@example.com")
+	ada := s.createEmployee("Ada Lovelace", "ada@example.com")
+	alan := s.createEmployee("Alan Turing", "alan@example.com")
+	project := s.createProject("Compiler", grace.ID)
+	task := s.createTask("Write the parser", project.ID, grace.ID)
+
+	comment := s.createComment(task.ID, grace.ID, "  @ada and @ALAN@example.com, have a look. Not @nobody.  ")
+	if comment.Body != "@ada and @ALAN@example.com, have a look. Not @nobody." || comment.AuthorID == nil || *comment.AuthorID != grace.ID {
+		t.Fatalf("comment = %+v", comment)
+	}
+	if !slices.Equal(comment.Mentions, []int{ada.ID, alan.ID}) {
+		t.Fatalf("mentions = %v, want [%d %d]", comment.Mentions, ada.ID, alan.ID)
+	}
+	other := s.createComment(task.ID, ada.ID, "Looks good to me")
+	if len(other.Mentions) != 0 {
+		t.Fatalf("mentions = %v, want none", other.Mentions)
+	}
+
+	path := fmt.Sprintf("/tasks/%d/comments", task.ID)
+	if got := ids(list[models.Comment](s, path).Items, commentID); !slices.Equal(got, []int{comment.ID, other.ID}) {
+		t.Fatalf("comments = %v", got)
+	}
+	if got := ids(list[models.Comment](s, fmt.Sprintf("%s?mentions=%d", path, alan.ID)).Items, commentID); !slices.Equal(got, []int{comment.ID}) {
+		t.Fatalf("comments mentioning %d = %v", alan.ID, got)
+	}
+	if got := ids(list[models.Comment](s, fmt.Sprintf("%s?author_id=%d", path, ada.ID)).Items, commentID); !slices.Equal(got, []int{other.ID}) {
+		t.Fatalf("comments by %d = %v", ada.ID, got)
+	}
+
+	// Editing re-resolves the mentions but keeps the author.
+	var edited models.Comment
+	s.expect(s.do("PUT", fmt.Sprintf("%s/%d", path, comment.ID), map[string]any{"body": "Only @ada now"}), http.StatusOK, &edited)
+	if !slices.Equal(edited.Mentions, []int{ada.ID}) || *edited.AuthorID != grace.ID || edited.Version != comment.Version+1 {
+		t.Fatalf("edited comment = %+v", edited)
+	}
+	if got := list[models.Comment](s, fmt.Sprintf("%s?mentions=%d", path, alan.ID)).Items; len(got) != 0 {
+		t.Fatalf("comments mentioning %d after edit = %v", alan.ID, got)
+	}
+
+	// Deleting an employee keeps their comments and mentions until the
+	// employee is purged, which drops the mentions.
+	s.expect(s.do("DELETE", fmt.Sprintf("/employees/%d", ada.ID), nil), http.StatusNoContent, nil)
+	var kept models.Comment
+	s.expect(s.do("GET", fmt.Sprintf("%s/%d", path, comment.ID), nil), http.StatusOK, &kept)
+	if !slices.Equal(kept.Mentions, []int{ada.ID}) {
+		t.Fatalf("mentions after deleting the mentioned employee = %v", kept.Mentions)
+	}
+	if _, err := s.repos.Employees.Purge(context.Background(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	s.expect(s.do("GET", fmt.Sprintf("%s/%d", path, comment.ID), nil), http.StatusOK, &kept)
+	if len(kept.Mentions) != 0 {
+		t.Fatalf("mentions after purging the mentioned employee = %v", kept.Mentions)
+	}
+	s.expect(s.do("GET", fmt.Sprintf("%s/%d", path, other.ID), nil), http.StatusOK, &kept)
+	if kept.AuthorID != nil || kept.Body != "Looks good to me" {
+		t.Fatalf("comment after purging its author = %+v", kept)
+	}
+}
+
+func TestCommentErrors(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	task := s.createTask("Write the parser", project.ID, lead.ID)
+	other := s.createTask("Write the linker", project.ID, lead.ID)
+	comment := s.createComment(task.ID, lead.ID, "First draft is up")
+
+	apiErr := s.expectError(s.do("POST", fmt.Sprintf("/tasks/%d/comments", task.ID), map[string]any{
+		"author_id": 999,
+		"body":      "   ",
+	}), http.StatusBadRequest, CodeValidation)
+	want := []FieldError{{Field: "body", Message: "is required"}, {Field: "author_id", Message: "does not exist"}}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+
+	s.expectError(s.do("GET", "/tasks/999/comments", nil), http.StatusNotFound, CodeNotFound)
+	s.expectError(s.do("POST", "/tasks/999/comments", map[string]any{"author_id": lead.ID, "body": "Hi"}), http.StatusNotFound, CodeNotFound)
+	// A comment is only found under its own task.
+	s.expectError(s.do("GET", fmt.Sprintf("/tasks/%d/comments/%d", other.ID, comment.ID), nil), http.StatusNotFound, CodeNotFound)
+	s.expectError(s.do("PUT", fmt.Sprintf("/tasks/%d/comments/%d", task.ID, comment.ID), map[string]any{"body": "Edited"},
+		"If-Match", `"9"`), http.StatusPreconditionFailed, CodePreconditionFailed)
+
+	path := fmt.Sprintf("/tasks/%d/comments/%d", task.ID, comment.ID)
+	s.expect(s.do("DELETE", path, nil), http.StatusOK, nil)
+	s.expectError(s.do("GET", path, nil), http.StatusNotFound, CodeNotFound)
+
+	// Deleting a task deletes its comments.
+	other = s.getTask(other.ID)
+	s.createComment(other.ID, lead.ID, "Soon")
+	s.expect(s.do("DELETE", fmt.Sprintf("/tasks/%d", other.ID), nil), http.StatusOK, nil)
+	s.expectError(s.do("GET", fmt.Sprintf("/tasks/%d/comments", other.ID), nil), http.StatusNotFound, CodeNotFound)
+}
+
+func TestTaskActivity(t *testing.T) {
+	s := newTestServer(t)
+	grace := s.createEmployee("Grace Hopper", "grace@example.com")
+	ada := s.createEmployee("Ada Lovelace", "ada@example.com")
+	project := s.createProject("Compiler", grace.ID)
+	s.expect(s.do("POST", fmt.Sprintf("/employees/%d/projects/%d", ada.ID, project.ID), nil), http.StatusCreated, nil)
+	task := s.createTask("Write the parser", project.ID, grace.ID)
+
+	comment := s.createComment(task.ID, grace.ID, "@ada can you take this?")
+	s.expect(s.do("PATCH", fmt.Sprintf("/tasks/%d", task.ID), map[string]any{"assigned_to": ada.ID}), http.StatusOK, nil)
+	s.transition(task, models.StatusInProgress, ada.ID)
+
+	var feed []models.Activity
+	s.expect(s.do("GET", fmt.Sprintf("/tasks/%d/activity", task.ID), nil), http.StatusOK, &feed)
+	kinds := make([]models.ActivityKind, len(feed))
+	for i, a := range feed {
+		kinds[i] = a.Kind
+	}
+	want := []models.ActivityKind{models.ActivityComment, models.ActivityAssigneeChange, models.ActivityStatusChange}
+	if !slices.Equal(kinds, want) {
+		t.Fatalf("feed kinds = %v, want %v", kinds, want)
+	}
+	if feed[0].Comment.ID != comment.ID || *feed[0].ActorID != grace.ID {
+		t.Errorf("comment entry = %+v", feed[0])
+	}
+	if change := feed[1].AssigneeChange; *change.From != grace.ID || *change.To != ada.ID {
+		t.Errorf("assignee change = %+v", change)
+	}
+	if feed[2].Transition.To != models.StatusInProgress || *feed[2].ActorID != ada.ID {
+		t.Errorf("status change entry = %+v", feed[2])
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"nstorm.com/main-backend/repository"
+)
+
+// requireAdmin rejects requests not made by an administrator.
+func requireAdmin(r *http.Request) error {
+	if !isAdmin(r) {
+		return forbidden("Administrator access required")
+	}
+	return nil
+}
+
+// getIncludingDeleted loads the row id with get and, if an administrator
+// asked for include_deleted, falls back to the deleted rows.
+func getIncludingDeleted[T any](r *http.Request, id int, get, getDeleted func(context.Context, int) (*T, error)) (*T, error) {
+	q := newListQuery(r)
+	include, err := q.includeDeleted(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.err(); err != nil {
+		return nil, err
+	}
+
+	row, err := get(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) && include {
+		return getDeleted(r.Context(), id)
+	}
+	return row, err
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+func TestSoftDeleteTasks(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	parser := s.createTask("Write the parser", project.ID, lead.ID)
+	grammar := s.createSubtask("Write the grammar", parser, 3)
+	lexer := s.createTask("Write the lexer", project.ID, lead.ID)
+
+	s.expect(s.do("DELETE", fmt.Sprintf("/tasks/%d?cascade=true", parser.ID), nil), http.StatusOK, nil)
+
+	// Deleting a task deletes its subtasks, and both disappear from reads.
+	for _, id := range []int{parser.ID, grammar.ID} {
+		s.expectError(s.do("GET", fmt.Sprintf("/tasks/%d", id), nil), http.StatusNotFound, CodeNotFound)
+	}
+	if got := ids(list[models.Task](s, "/tasks").Items, taskID); !slices.Equal(got, []int{lexer.ID}) {
+		t.Fatalf("tasks = %v", got)
+	}
+	s.expectError(s.do("PATCH", fmt.Sprintf("/tasks/%d", parser.ID), map[string]any{"title": "Write a parser"}), http.StatusNotFound, CodeNotFound)
+
+	// Only administrators see them.
+	s.expectError(s.do("GET", "/tasks?include_deleted=true", nil), http.StatusForbidden, CodeForbidden)
+	s.expectError(s.do("GET", fmt.Sprintf("/tasks/%d?include_deleted=true", parser.ID), nil), http.StatusForbidden, CodeForbidden)
+	var page repository.Page[models.Task]
+	s.expect(s.do("GET", "/tasks?include_deleted=true", nil, asAdmin...), http.StatusOK, &page)
+	if got := ids(page.Items, taskID); !slices.Equal(got, []int{parser.ID, grammar.ID, lexer.ID}) {
+		t.Fatalf("tasks including deleted = %v", got)
+	}
+	var deleted models.Task
+	s.expect(s.do("GET", fmt.Sprintf("/tasks/%d?include_deleted=true", parser.ID), nil, asAdmin...), http.StatusOK, &deleted)
+	if deleted.DeletedAt == nil || deleted.Version != parser.Version+1 {
+		t.Fatalf("deleted task = %+v", deleted)
+	}
+
+	// Restoring brings the subtasks deleted with the task back.
+	path := fmt.Sprintf("/tasks/%d/restore", parser.ID)
+	s.expectError(s.do("POST", path, nil), http.StatusForbidden, CodeForbidden)
+	s.expectError(s.do("POST", path, nil, "Authorization", "Bearer guess"), http.StatusForbidden, CodeForbidden)
+	s.expectError(s.do("POST", path, nil, append([]string{"If-Match", `"1"`}, asAdmin...)...), http.StatusPreconditionFailed, CodePreconditionFailed)
+	var restored models.Task
+	s.expect(s.do("POST", path, nil, asAdmin...), http.StatusOK, &restored)
+	if restored.DeletedAt != nil || restored.Version != deleted.Version+1 {
+		t.Fatalf("restored task = %+v", restored)
+	}
+	if got := s.getTask(grammar.ID); got.DeletedAt != nil {
+		t.Fatalf("restored subtask = %+v", got)
+	}
+
+	// A live task has nothing to restore.
+	apiErr := s.expectError(s.do("POST", fmt.Sprintf("/tasks/%d/restore", lexer.ID), nil, asAdmin...), http.StatusNotFound, CodeNotFound)
+	if apiErr.Message != "Deleted task not found" {
+		t.Fatalf("got %+v", apiErr)
+	}
+
+	// A subtask deleted on its own cannot come back without its parent.
+	s.expect(s.do("DELETE", fmt.Sprintf("/tasks/%d", grammar.ID), nil), http.StatusOK, nil)
+	s.expect(s.do("DELETE", fmt.Sprintf("/tasks/%d?cascade=true", parser.ID), nil), http.StatusOK, nil)
+	apiErr = s.expectError(s.do("POST", fmt.Sprintf("/tasks/%d/restore", grammar.ID), nil, asAdmin...), http.StatusConflict, CodeConflict)
+	if !slices.Equal(apiErr.Details, []FieldError{{Field: "parent_task_id", Message: "is deleted"}}) {
+		t.Fatalf("details = %+v", apiErr.Details)
+	}
+	s.expect(s.do("POST", path, nil, asAdmin...), http.StatusOK, nil)
+	s.expectError(s.do("GET", fmt.Sprintf("/tasks/%d", grammar.ID), nil), http.StatusNotFound, CodeNotFound)
+}
+
+func TestSoftDeleteProjectsAndEmployees(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	dev := s.createEmployee("Ada Lovelace", "ada@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	other := s.createProject("Debugger", lead.ID)
+	task := s.createTask("Write the parser", project.ID, dev.ID)
+
+	// An employee with open tasks cannot be deleted.
+	s.expectError(s.do("DELETE", fmt.Sprintf("/employees/%d", dev.ID), nil), http.StatusConflict, CodeConflict)
+
+	s.expect(s.do("DELETE", fmt.Sprintf("/projects/%d", project.ID), nil), http.StatusOK, nil)
+	s.expectError(s.do("GET", fmt.Sprintf("/projects/%d", project.ID), nil), http.StatusNotFound, CodeNotFound)
+	if got := ids(list[models.Project](s, "/projects").Items, projectID); !slices.Equal(got, []int{other.ID}) {
+		t.Fatalf("projects = %v", got)
+	}
+	s.expectError(s.do("GET", fmt.Sprintf("/tasks/%d", task.ID), nil), http.StatusNotFound, CodeNotFound)
+	var page repository.Page[models.Project]
+	s.expect(s.do("GET", "/projects?include_deleted=true", nil, asAdmin...), http.StatusOK, &page)
+	if got := ids(page.Items, projectID); !slices.Equal(got, []int{project.ID, other.ID}) {
+		t.Fatalf("projects including deleted = %v", got)
+	}
+
+	// With its tasks deleted, the developer can go too.
+	s.expect(s.do("DELETE", fmt.Sprintf("/employees/%d", dev.ID), nil), http.StatusNoContent, nil)
+	s.expectError(s.do("GET", fmt.Sprintf("/employees/%d", dev.ID), nil), http.StatusNotFound, CodeNotFound)
+	if got := ids(list[models.Employee](s, "/employees").Items, employeeID); !slices.Equal(got, []int{lead.ID}) {
+		t.Fatalf("employees = %v", got)
+	}
+
+	// A task comes back only after its project, and its open task only
+	// after its assignee.
+	apiErr := s.expectError(s.do("POST", fmt.Sprintf("/tasks/%d/restore", task.ID), nil, asAdmin...), http.StatusConflict, CodeConflict)
+	want := []FieldError{{Field: "project_id", Message: "is deleted"}, {Field: "assigned_to", Message: "is deleted"}}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+	s.expectError(s.do("POST", fmt.Sprintf("/employees/%d/restore", dev.ID), nil), http.StatusForbidden, CodeForbidden)
+	var employee models.Employee
+	s.expect(s.do("POST", fmt.Sprintf("/employees/%d/restore", dev.ID), nil, asAdmin...), http.StatusOK, &employee)
+	if employee.DeletedAt != nil || employee.Email != "ada@example.com" {
+		t.Fatalf("restored employee = %+v", employee)
+	}
+	s.expectError(s.do("POST", fmt.Sprintf("/employees/%d/restore", dev.ID), nil, asAdmin...), http.StatusNotFound, CodeNotFound)
+
+	var restored models.Project
+	s.expect(s.do("POST", fmt.Sprintf("/projects/%d/restore", project.ID), nil, asAdmin...), http.StatusOK, &restored)
+	if restored.DeletedAt != nil {
+		t.Fatalf("restored project = %+v", restored)
+	}
+	if got := s.getTask(task.ID); got.AssignedTo != dev.ID {
+		t.Fatalf("task of the restored project = %+v", got)
+	}
+	s.expectError(s.do("POST", fmt.Sprintf("/projects/%d/restore", other.ID), nil, asAdmin...), http.StatusNotFound, CodeNotFound)
+
+	// Nor does a project come back before its lead.
+	empty := s.createEmployee("Alan Turing", "alan@example.com")
+	led := s.createProject("Verifier", empty.ID)
+	s.expect(s.do("DELETE", fmt.Sprintf("/projects/%d", led.ID), nil), http.StatusOK, nil)
+	s.expect(s.do("DELETE", fmt.Sprintf("/employees/%d", empty.ID), nil), http.StatusNoContent, nil)
+	apiErr = s.expectError(s.do("POST", fmt.Sprintf("/projects/%d/restore", led.ID), nil, asAdmin...), http.StatusConflict, CodeConflict)
+	if !slices.Equal(apiErr.Details, []FieldError{{Field: "lead_id", Message: "does not exist"}}) {
+		t.Fatalf("details = %+v", apiErr.Details)
+	}
+}
+
+func TestArchiveProject(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	other := s.createProject("Debugger", lead.ID)
+	task := s.createTask("Write the parser", project.ID, lead.ID)
+	stepper := s.createTask("Write the stepper", other.ID, lead.ID)
+
+	s.expectError(s.do("POST", fmt.Sprintf("/projects/%d/archive", project.ID), nil, "If-Match", `"9"`), http.StatusPreconditionFailed, CodePreconditionFailed)
+	var archived models.Project
+	s.expect(s.do("POST", fmt.Sprintf("/projects/%d/archive", project.ID), nil), http.StatusOK, &archived)
+	if archived.ArchivedAt == nil || archived.Version != project.Version+1 {
+		t.Fatalf("archived project = %+v", archived)
+	}
+	apiErr := s.expectError(s.do("POST", fmt.Sprintf("/projects/%d/archive", project.ID), nil), http.StatusConflict, CodeConflict)
+	if apiErr.Message != "Project is already archived" {
+		t.Fatalf("got %+v", apiErr)
+	}
+
+	// It stays readable but leaves the project list.
+	s.expect(s.do("GET", fmt.Sprintf("/projects/%d", project.ID), nil), http.StatusOK, nil)
+	if got := ids(list[models.Project](s, "/projects").Items, projectID); !slices.Equal(got, []int{other.ID}) {
+		t.Fatalf("projects = %v", got)
+	}
+	if got := ids(list[models.Project](s, "/projects?include_archived=true").Items, projectID); !slices.Equal(got, []int{project.ID, other.ID}) {
+		t.Fatalf("projects including archived = %v", got)
+	}
+
+	// No task can be added or moved to it; its own tasks can still change.
+	archivedErr := []FieldError{{Field: "project_id", Message: "must not be an archived project"}}
+	apiErr = s.expectError(s.do("POST", "/tasks", map[string]any{
+		"title":       "Write the lexer",
+		"project_id":  project.ID,
+		"assigned_to": lead.ID,
+		"status":      models.StatusTodo,
+	}), http.StatusBadRequest, CodeValidation)
+	if !slices.Equal(apiErr.Details, archivedErr) {
+		t.Fatalf("details = %+v", apiErr.Details)
+	}
+	apiErr = s.expectError(s.do("PATCH", fmt.Sprintf("/tasks/%d", stepper.ID), map[string]any{"project_id": project.ID}), http.StatusBadRequest, CodeValidation)
+	if !slices.Equal(apiErr.Details, archivedErr) {
+		t.Fatalf("details = %+v", apiErr.Details)
+	}
+	s.expect(s.do("PATCH", fmt.Sprintf("/tasks/%d", task.ID), map[string]any{"title": "Write a parser"}), http.StatusOK, nil)
+
+	var unarchived models.Project
+	s.expect(s.do("POST", fmt.Sprintf("/projects/%d/unarchive", project.ID), nil), http.StatusOK, &unarchived)
+	if unarchived.ArchivedAt != nil {
+		t.Fatalf("unarchived project = %+v", unarchived)
+	}
+	apiErr = s.expectError(s.do("POST", fmt.Sprintf("/projects/%d/unarchive", project.ID), nil), http.StatusConflict, CodeConflict)
+	if apiErr.Message != "Project is not archived" {
+		t.Fatalf("got %+v", apiErr)
+	}
+	s.createTask("Write the lexer", project.ID, lead.ID)
+	s.expectError(s.do("POST", "/projects/999/archive", nil), http.StatusNotFound, CodeNotFound)
+}
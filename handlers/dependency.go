@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+// Notes recorded on the transitions the server makes on its own when a
+// task's dependencies open or close.
+const (
+	autoBlockNote   = "blocked by open dependencies"
+	autoUnblockNote = "dependencies finished"
+)
+
+// AddTaskDependency makes a task depend on another task of the same
+// project. Adding an existing dependency is a no-op.
+func (h *TaskHandler) AddTaskDependency(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	task, dependsOn, ok := h.dependencyPair(w, r)
+	if !ok {
+		return
+	}
+	if task.ProjectID != dependsOn.ProjectID {
+		writeError(w, r, newAPIError(http.StatusUnprocessableEntity, CodeInvalidReference,
+			"Tasks must belong to the same project",
+			FieldError{Field: "dependsOnId", Message: "belongs to a different project"}))
+		return
+	}
+
+	err := h.tasks.AddDependency(ctx, task.ID, dependsOn.ID)
+	if errors.Is(err, repository.ErrDependencyCycle) {
+		writeError(w, r, newAPIError(http.StatusConflict, CodeDependencyCycle,
+			"Dependency would create a cycle",
+			FieldError{Field: "dependsOnId", Message: "would create a dependency cycle"}))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.reconcile(ctx, task.ID)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// RemoveTaskDependency removes a dependency, unblocking the task if it was
+// blocked only because of its dependencies.
+func (h *TaskHandler) RemoveTaskDependency(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	taskID, err := pathID(r, "id", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	dependsOnID, err := pathID(r, "dependsOnId", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = h.tasks.RemoveDependency(ctx, taskID, dependsOnID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Dependency not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.reconcile(ctx, taskID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTaskDependencies lists the tasks a task is waiting for.
+func (h *TaskHandler) GetTaskDependencies(w http.ResponseWriter, r *http.Request) {
+	h.listRelated(w, r, h.tasks.ListDependencies)
+}
+
+// GetTaskDependents lists the tasks waiting for a task.
+func (h *TaskHandler) GetTaskDependents(w http.ResponseWriter, r *http.Request) {
+	h.listRelated(w, r, h.tasks.ListDependents)
+}
+
+func (h *TaskHandler) listRelated(w http.ResponseWriter, r *http.Request, list func(context.Context, int) ([]models.Task, error)) {
+	ctx := r.Context()
+	taskID, err := pathID(r, "id", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if _, err := h.tasks.GetByID(ctx, taskID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, notFound("Task not found"))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	tasks, err := list(ctx, taskID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+type topologicalTasks struct {
+	Tasks        []models.Task           `json:"tasks"`
+	Dependencies []models.TaskDependency `json:"dependencies"`
+}
+
+// GetProjectTasksTopological lists a project's tasks so that every task
+// comes after the tasks it depends on.
+func (h *TaskHandler) GetProjectTasksTopological(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID, err := pathID(r, "id", "project")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if _, err := h.projects.GetByID(ctx, projectID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, notFound("Project not found"))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	tasks, err := h.tasks.ListByProject(ctx, projectID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	dependencies, err := h.tasks.ProjectDependencies(ctx, projectID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	sorted, ok := models.SortByDependencies(tasks, dependencies)
+	if !ok {
+		// Inserts are checked for cycles, so this means the table was
+		// edited by hand.
+		writeError(w, r, errors.New("task dependencies contain a cycle"))
+		return
+	}
+	if dependencies == nil {
+		dependencies = []models.TaskDependency{}
+	}
+
+	writeJSON(w, http.StatusOK, topologicalTasks{Tasks: sorted, Dependencies: dependencies})
+}
+
+// checkProjectMove refuses to move a task to another project while it has
+// dependencies, which must stay within one project.
+func (h *TaskHandler) checkProjectMove(ctx context.Context, current, task *models.Task) error {
+	if task.ProjectID == current.ProjectID {
+		return nil
+	}
+	dependencies, err := h.tasks.ListDependencies(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	dependents, err := h.tasks.ListDependents(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	if len(dependencies) > 0 || len(dependents) > 0 {
+		return newAPIError(http.StatusConflict, CodeConflict, "Task has dependencies",
+			FieldError{Field: "project_id", Message: "cannot be changed while the task has dependencies; remove them first"})
+	}
+	return nil
+}
+
+// dependencyPair loads the two tasks named in a dependency route, writing
+// the error response and returning false if either is missing.
+func (h *TaskHandler) dependencyPair(w http.ResponseWriter, r *http.Request) (*models.Task, *models.Task, bool) {
+	ctx := r.Context()
+	taskID, err := pathID(r, "id", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return nil, nil, false
+	}
+	dependsOnID, err := pathID(r, "dependsOnId", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return nil, nil, false
+	}
+
+	task, err := h.tasks.GetByID(ctx, taskID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Task not found"))
+		return nil, nil, false
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return nil, nil, false
+	}
+
+	dependsOn, err := h.tasks.GetByID(ctx, dependsOnID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, newAPIError(http.StatusUnprocessableEntity, CodeInvalidReference,
+			"Referenced record does not exist",
+			FieldError{Field: "dependsOnId", Message: "task does not exist"}))
+		return nil, nil, false
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return nil, nil, false
+	}
+
+	return task, dependsOn, true
+}
+
+// allowedWhileBlocked reports whether a task with open dependencies may
+// move to status. It may only stay blocked or be dropped.
+func allowedWhileBlocked(status models.TaskStatus) bool {
+	return status == models.StatusBlocked || status == models.StatusCancelled
+}
+
+// openBlockers reports whether any task taskID depends on is still open.
+func (h *TaskHandler) openBlockers(ctx context.Context, taskID int) (bool, error) {
+	blockers, err := h.tasks.ListDependencies(ctx, taskID)
+	if err != nil {
+		return false, err
+	}
+	for _, blocker := range blockers {
+		if blocker.Status.Open() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// reconcile moves a task to BLOCKED while it has open dependencies, and
+// back to where it was once they are all finished. Only blocks the server
+// made itself are lifted; a task someone blocked by hand stays blocked.
+// Failures are logged rather than returned because the change that
+// triggered the check has already been made.
+func (h *TaskHandler) reconcile(ctx context.Context, taskID int) {
+	if err := h.reconcileTask(ctx, taskID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		slog.WarnContext(ctx, "updating blocked status failed", "task_id", taskID, "error", err)
+	}
+}
+
+func (h *TaskHandler) reconcileTask(ctx context.Context, taskID int) error {
+	task, err := h.tasks.GetByID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	blocked, err := h.openBlockers(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	transition := models.TaskTransition{TaskID: taskID, From: task.Status}
+	switch {
+	case blocked && task.Status.Open() && task.Status != models.StatusBlocked:
+		transition.To = models.StatusBlocked
+		transition.Note = autoBlockNote
+	case !blocked && task.Status == models.StatusBlocked:
+		history, err := h.tasks.Transitions(ctx, taskID)
+		if err != nil || len(history) == 0 {
+			return err
+		}
+		last := history[len(history)-1]
+		if last.To != models.StatusBlocked || last.ActorID != nil {
+			return nil
+		}
+		transition.To = last.From
+		if !h.workflow.Allows(models.StatusBlocked, transition.To) {
+			transition.To = models.StatusTodo
+		}
+		transition.Note = autoUnblockNote
+	default:
+		return nil
+	}
+	if !h.workflow.Allows(transition.From, transition.To) {
+		return nil
+	}
+
+	_, err = h.tasks.Transition(ctx, &transition, task.Version)
+	return err
+}
+
+// reconcileDependents re-checks each of the given tasks.
+func (h *TaskHandler) reconcileDependents(ctx context.Context, dependents []models.Task) {
+	for _, dependent := range dependents {
+		h.reconcile(ctx, dependent.ID)
+	}
+}
+
+// reconcileDependentsOf re-checks every task waiting for taskID, after its
+// status changed between open and finished.
+func (h *TaskHandler) reconcileDependentsOf(ctx context.Context, taskID int) {
+	dependents, err := h.tasks.ListDependents(ctx, taskID)
+	if err != nil {
+		slog.WarnContext(ctx, "listing dependent tasks failed", "task_id", taskID, "error", err)
+		return
+	}
+	h.reconcileDependents(ctx, dependents)
+}
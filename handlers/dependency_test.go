@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+)
+
+func TestDependencyBlocksAndUnblocks(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	parser := s.createTask("Write the parser", project.ID, lead.ID)
+	codegen := s.createTask("Write the code generator", project.ID, lead.ID)
+	codegen = s.transition(codegen, models.StatusInProgress, lead.ID)
+	dependency := fmt.Sprintf("/tasks/%d/dependencies/%d", codegen.ID, parser.ID)
+
+	s.expect(s.do("POST", dependency, nil), http.StatusCreated, nil)
+	// Adding it again changes nothing.
+	s.expect(s.do("POST", dependency, nil), http.StatusCreated, nil)
+	if got := s.getTask(codegen.ID).Status; got != models.StatusBlocked {
+		t.Fatalf("dependent status = %s, want BLOCKED", got)
+	}
+
+	var related []models.Task
+	s.expect(s.do("GET", fmt.Sprintf("/tasks/%d/dependencies", codegen.ID), nil), http.StatusOK, &related)
+	if got := ids(related, taskID); !slices.Equal(got, []int{parser.ID}) {
+		t.Fatalf("dependencies = %v, want [%d]", got, parser.ID)
+	}
+	s.expect(s.do("GET", fmt.Sprintf("/tasks/%d/dependents", parser.ID), nil), http.StatusOK, &related)
+	if got := ids(related, taskID); !slices.Equal(got, []int{codegen.ID}) {
+		t.Fatalf("dependents = %v, want [%d]", got, codegen.ID)
+	}
+
+	// A blocked task may only stay blocked or be dropped until its
+	// dependencies are finished.
+	apiErr := s.expectError(s.do("POST", fmt.Sprintf("/tasks/%d/transitions", codegen.ID),
+		map[string]any{"to": models.StatusInProgress, "actor_id": lead.ID}), http.StatusConflict, CodeInvalidTransition)
+	if apiErr.Message != "Task has open dependencies" {
+		t.Fatalf("got %+v", apiErr)
+	}
+
+	for _, to := range []models.TaskStatus{models.StatusInProgress, models.StatusInReview, models.StatusDone} {
+		s.transition(parser, to, lead.ID)
+	}
+	if got := s.getTask(codegen.ID).Status; got != models.StatusInProgress {
+		t.Fatalf("dependent status = %s after its dependency finished, want IN_PROGRESS", got)
+	}
+
+	var history []models.TaskTransition
+	s.expect(s.do("GET", fmt.Sprintf("/tasks/%d/transitions", codegen.ID), nil), http.StatusOK, &history)
+	if len(history) != 3 || history[1].Note != autoBlockNote || history[1].ActorID != nil || history[2].Note != autoUnblockNote {
+		t.Fatalf("history = %+v", history)
+	}
+
+	// Reopening the dependency blocks the dependent again.
+	s.transition(parser, models.StatusInProgress, lead.ID)
+	if got := s.getTask(codegen.ID).Status; got != models.StatusBlocked {
+		t.Fatalf("dependent status = %s after its dependency reopened, want BLOCKED", got)
+	}
+
+	s.expect(s.do("DELETE", dependency, nil), http.StatusNoContent, nil)
+	if got := s.getTask(codegen.ID).Status; got != models.StatusInProgress {
+		t.Fatalf("dependent status = %s after removing the dependency, want IN_PROGRESS", got)
+	}
+	s.expectError(s.do("DELETE", dependency, nil), http.StatusNotFound, CodeNotFound)
+}
+
+func TestDependencyManualBlockStays(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	parser := s.createTask("Write the parser", project.ID, lead.ID)
+	codegen := s.createTask("Write the code generator", project.ID, lead.ID)
+
+	s.transition(codegen, models.StatusBlocked, lead.ID)
+	s.expect(s.do("POST", fmt.Sprintf("/tasks/%d/dependencies/%d", codegen.ID, parser.ID), nil), http.StatusCreated, nil)
+	s.expect(s.do("DELETE", fmt.Sprintf("/tasks/%d/dependencies/%d", codegen.ID, parser.ID), nil), http.StatusNoContent, nil)
+	if got := s.getTask(codegen.ID).Status; got != models.StatusBlocked {
+		t.Fatalf("status = %s, want the manual block to stay", got)
+	}
+}
+
+func TestDependencyErrors(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	compiler := s.createProject("Compiler", lead.ID)
+	engine := s.createProject("Engine", lead.ID)
+	a := s.createTask("a", compiler.ID, lead.ID)
+	b := s.createTask("b", compiler.ID, lead.ID)
+	c := s.createTask("c", compiler.ID, lead.ID)
+	elsewhere := s.createTask("elsewhere", engine.ID, lead.ID)
+	add := func(task, dependsOn int) string { return fmt.Sprintf("/tasks/%d/dependencies/%d", task, dependsOn) }
+
+	s.expect(s.do("POST", add(b.ID, a.ID), nil), http.StatusCreated, nil)
+	s.expect(s.do("POST", add(c.ID, b.ID), nil), http.StatusCreated, nil)
+	apiErr := s.expectError(s.do("POST", add(a.ID, c.ID), nil), http.StatusConflict, CodeDependencyCycle)
+	if want := (FieldError{Field: "dependsOnId", Message: "would create a dependency cycle"}); len(apiErr.Details) != 1 || apiErr.Details[0] != want {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+	s.expectError(s.do("POST", add(a.ID, a.ID), nil), http.StatusConflict, CodeDependencyCycle)
+	s.expectError(s.do("POST", add(a.ID, elsewhere.ID), nil), http.StatusUnprocessableEntity, CodeInvalidReference)
+	s.expectError(s.do("POST", add(a.ID, 999), nil), http.StatusUnprocessableEntity, CodeInvalidReference)
+	s.expectError(s.do("POST", add(999, a.ID), nil), http.StatusNotFound, CodeNotFound)
+	s.expectError(s.do("GET", "/tasks/999/dependencies", nil), http.StatusNotFound, CodeNotFound)
+}
+
+func TestProjectTasksTopological(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	link := s.createTask("link", project.ID, lead.ID)
+	compile := s.createTask("compile", project.ID, lead.ID)
+	parse := s.createTask("parse", project.ID, lead.ID)
+	docs := s.createTask("docs", project.ID, lead.ID)
+	for _, pair := range [][2]int{{link.ID, compile.ID}, {compile.ID, parse.ID}} {
+		s.expect(s.do("POST", fmt.Sprintf("/tasks/%d/dependencies/%d", pair[0], pair[1]), nil), http.StatusCreated, nil)
+	}
+
+	var got topologicalTasks
+	s.expect(s.do("GET", fmt.Sprintf("/projects/%d/tasks/topological", project.ID), nil), http.StatusOK, &got)
+	order := ids(got.Tasks, taskID)
+	position := func(id int) int { return slices.Index(order, id) }
+	if len(order) != 4 || position(parse.ID) > position(compile.ID) || position(compile.ID) > position(link.ID) || position(docs.ID) < 0 {
+		t.Fatalf("order = %v", order)
+	}
+	if len(got.Dependencies) != 2 {
+		t.Fatalf("dependencies = %+v", got.Dependencies)
+	}
+	s.expectError(s.do("GET", "/projects/999/tasks/topological", nil), http.StatusNotFound, CodeNotFound)
+}
+
+func TestDeletingDependencyUnblocks(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	parser := s.createTask("Write the parser", project.ID, lead.ID)
+	codegen := s.createTask("Write the code generator", project.ID, lead.ID)
+	s.expect(s.do("POST", fmt.Sprintf("/tasks/%d/dependencies/%d", codegen.ID, parser.ID), nil), http.StatusCreated, nil)
+
+	s.expect(s.do("DELETE", fmt.Sprintf("/tasks/%d", parser.ID), nil), http.StatusOK, nil)
+	if got := s.getTask(codegen.ID).Status; got != models.StatusTodo {
+		t.Fatalf("status = %s after deleting the dependency, want TODO", got)
+	}
+}
+
+func TestMovingTaskWithDependencies(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	compiler := s.createProject("Compiler", lead.ID)
+	engine := s.createProject("Engine", lead.ID)
+	parser := s.createTask("Write the parser", compiler.ID, lead.ID)
+	codegen := s.createTask("Write the code generator", compiler.ID, lead.ID)
+	docs := s.createTask("Write the docs", compiler.ID, lead.ID)
+	dependency := fmt.Sprintf("/tasks/%d/dependencies/%d", codegen.ID, parser.ID)
+	s.expect(s.do("POST", dependency, nil), http.StatusCreated, nil)
+
+	// Neither end of a dependency can leave the project.
+	want := []FieldError{{Field: "project_id", Message: "cannot be changed while the task has dependencies; remove them first"}}
+	for _, task := range []models.Task{parser, codegen} {
+		apiErr := s.expectError(s.do("PATCH", fmt.Sprintf("/tasks/%d", task.ID), map[string]any{"project_id": engine.ID}), http.StatusConflict, CodeConflict)
+		if !slices.Equal(apiErr.Details, want) {
+			t.Fatalf("task %d: details = %+v", task.ID, apiErr.Details)
+		}
+	}
+	apiErr := s.expectError(s.do("PUT", fmt.Sprintf("/tasks/%d", parser.ID), map[string]any{
+		"title":       parser.Title,
+		"project_id":  engine.ID,
+		"assigned_to": lead.ID,
+	}), http.StatusConflict, CodeConflict)
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v", apiErr.Details)
+	}
+	if got := s.getTask(parser.ID); got.ProjectID != compiler.ID {
+		t.Fatalf("task after rejected move = %+v", got)
+	}
+
+	s.expect(s.do("PATCH", fmt.Sprintf("/tasks/%d", docs.ID), map[string]any{"project_id": engine.ID}), http.StatusOK, nil)
+	s.expect(s.do("DELETE", dependency, nil), http.StatusNoContent, nil)
+	s.expect(s.do("PATCH", fmt.Sprintf("/tasks/%d", parser.ID), map[string]any{"project_id": engine.ID}), http.StatusOK, nil)
+}
@@ -1,351 +1,368 @@
 package handlers
 
 import (
-	"context"
-	"encoding/json"
+	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5"
 	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
 )
 
 type EmployeeHandler struct {
-	db *pgx.Conn
+	employees repository.EmployeeRepository
+	projects  repository.ProjectRepository
+	tasks     repository.TaskRepository
 }
 
-func NewEmployeeHandler(db *pgx.Conn) *EmployeeHandler {
-	return &EmployeeHandler{db: db}
+func NewEmployeeHandler(repos *repository.Repositories) *EmployeeHandler {
+	return &EmployeeHandler{
+		employees: repos.Employees,
+		projects:  repos.Projects,
+		tasks:     repos.Tasks,
+	}
 }
 
 func (h *EmployeeHandler) CreateEmployee(w http.ResponseWriter, r *http.Request) {
 	var employee models.Employee
-	if err := json.NewDecoder(r.Body).Decode(&employee); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &employee); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := validateEmployee(&employee); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	query := `
-        INSERT INTO employees (name, email, role, skills)
-        VALUES ($1, $2, $3, $4)
-        RETURNING id, name, email, role, skills, created_at`
-
-	err := h.db.QueryRow(
-		context.Background(),
-		query,
-		employee.Name,
-		employee.Email,
-		employee.Role,
-		employee.Skills,
-	).Scan(
-		&employee.ID,
-		&employee.Name,
-		&employee.Email,
-		&employee.Role,
-		&employee.Skills,
-		&employee.CreatedAt,
-	)
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.employees.Create(r.Context(), &employee); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(employee)
+	setETag(w, employee.Version)
+	writeJSON(w, http.StatusOK, employee)
 }
 
+// GetEmployeeById returns an employee. Administrators can also fetch a
+// deleted one with include_deleted=true.
 func (h *EmployeeHandler) GetEmployeeById(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := pathID(r, "id", "employee")
 	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	var employee models.Employee
-	query := `
-        SELECT id, name, email, role, skills, created_at
-        FROM employees
-        WHERE id = $1`
-
-	err = h.db.QueryRow(context.Background(), query, id).Scan(
-		&employee.ID,
-		&employee.Name,
-		&employee.Email,
-		&employee.Role,
-		&employee.Skills,
-		&employee.CreatedAt,
-	)
-
-	if err == pgx.ErrNoRows {
-		http.Error(w, "Employee not found", http.StatusNotFound)
+	employee, err := getIncludingDeleted(r, id, h.employees.GetByID, h.employees.GetDeleted)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Employee not found"))
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(employee)
+	if notModified(w, r, employee.Version) {
+		return
+	}
+	writeJSON(w, http.StatusOK, employee)
 }
 
+// GetAllEmployees lists employees, optionally filtered by role and skill.
+// Deleted employees are only listed for administrators asking for
+// include_deleted=true.
 func (h *EmployeeHandler) GetAllEmployees(w http.ResponseWriter, r *http.Request) {
-	query := `
-        SELECT id, name, email, role, skills, created_at
-        FROM employees;`
-
-	rows, err := h.db.Query(context.Background(), query)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	var employees []models.Employee
-	for rows.Next() {
-		var emp models.Employee
-		err := rows.Scan(
-			&emp.ID,
-			&emp.Name,
-			&emp.Email,
-			&emp.Role,
-			&emp.Skills,
-			&emp.CreatedAt,
-		)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		employees = append(employees, emp)
+	q := newListQuery(r)
+	includeDeleted, err := q.includeDeleted(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	filter := repository.EmployeeFilter{
+		Role:           models.EmployeeRole(q.stringParam("role")),
+		Skill:          q.stringParam("skill"),
+		IncludeDeleted: includeDeleted,
+		Page:           page(q, repository.EmployeeSorts),
+	}
+	if filter.Role != "" && !filter.Role.Valid() {
+		q.errs.Add("role", "is not a valid role")
+	}
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	employees, err := h.employees.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	json.NewEncoder(w).Encode(employees)
+	writeJSON(w, http.StatusOK, employees)
 }
 
 func (h *EmployeeHandler) UpdateEmployee(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := pathID(r, "id", "employee")
 	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		writeError(w, r, err)
+		return
+	}
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	var employee models.Employee
-	if err := json.NewDecoder(r.Body).Decode(&employee); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &employee); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	employee.ID = id
+	employee.Version = version
+	if err := validateEmployee(&employee); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	query := `
-        UPDATE employees
-        SET name = $1, email = $2, role = $3, skills = $4
-        WHERE id = $5
-        RETURNING id, name, email, role, skills, created_at`
+	err = h.employees.Update(r.Context(), &employee)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Employee not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, employee.Version)
+	writeJSON(w, http.StatusOK, employee)
+}
+
+// PatchEmployee applies a JSON Merge Patch or JSON Patch to an employee, writing only
+// the fields it changes.
+func (h *EmployeeHandler) PatchEmployee(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := pathID(r, "id", "employee")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	current, err := h.employees.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Employee not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	err = h.db.QueryRow(
-		context.Background(),
-		query,
-		employee.Name,
-		employee.Email,
-		employee.Role,
-		employee.Skills,
-		id,
-	).Scan(
-		&employee.ID,
-		&employee.Name,
-		&employee.Email,
-		&employee.Role,
-		&employee.Skills,
-		&employee.CreatedAt,
-	)
+	employee, fields, err := applyPatch(r, current)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	employee.Version = version
+	if err := validateEmployee(employee); err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	if err == pgx.ErrNoRows {
-		http.Error(w, "Employee not found", http.StatusNotFound)
+	err = h.employees.Patch(ctx, employee, fields)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Employee not found"))
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(employee)
+	setETag(w, employee.Version)
+	writeJSON(w, http.StatusOK, employee)
 }
+
 func (h *EmployeeHandler) DeleteEmployee(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := pathID(r, "id", "employee")
 	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
-
-	query := `DELETE FROM employees WHERE id = $1`
-	result, err := h.db.Exec(context.Background(), query, id)
+	version, err := ifMatch(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	if result.RowsAffected() == 0 {
-		http.Error(w, "Employee not found", http.StatusNotFound)
+	err = h.employees.Delete(r.Context(), id, version)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Employee not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *EmployeeHandler) GetEmployeeTasks(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	employeeId, err := strconv.Atoi(vars["id"])
+// RestoreEmployee undoes the deletion of an employee. Only administrators
+// may restore.
+func (h *EmployeeHandler) RestoreEmployee(w http.ResponseWriter, r *http.Request) {
+	if err := requireAdmin(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	id, err := pathID(r, "id", "employee")
 	if err != nil {
-		http.Error(w, "Invalid employee ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	query := `
-        SELECT t.id, t.project_id, t.assigned_to, t.title, t.description, t.status, t.created_at
-        FROM tasks t
-        WHERE t.assigned_to = $1`
-
-	rows, err := h.db.Query(context.Background(), query, employeeId)
+	ctx := r.Context()
+	current, err := h.employees.GetDeleted(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Deleted employee not found"))
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
-	defer rows.Close()
 
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		err := rows.Scan(
-			&task.ID,
-			&task.ProjectID,
-			&task.AssignedTo,
-			&task.Title,
-			&task.Description,
-			&task.Status,
-			&task.CreatedAt,
-		)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		tasks = append(tasks, task)
+	employee, err := h.employees.Restore(ctx, id, current.Version)
+	if err != nil {
+		writeError(w, r, changeError(err, version, "Deleted employee"))
+		return
 	}
 
-	json.NewEncoder(w).Encode(tasks)
+	setETag(w, employee.Version)
+	writeJSON(w, http.StatusOK, employee)
 }
-func (h *EmployeeHandler) GetEmployeeTasksByStatus(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	employeeId, err := strconv.Atoi(vars["id"])
+
+func (h *EmployeeHandler) GetEmployeeTasks(w http.ResponseWriter, r *http.Request) {
+	employeeId, err := pathID(r, "id", "employee")
 	if err != nil {
-		http.Error(w, "Invalid employee ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	status := vars["status"]
-	if status == "" {
-		http.Error(w, "Status is required", http.StatusBadRequest)
+	tasks, err := h.tasks.ListByAssignee(r.Context(), employeeId)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	query := `
-        SELECT t.id, t.project_id, t.assigned_to, t.title, t.description, t.status, t.created_at
-        FROM tasks t
-        WHERE t.assigned_to = $1 AND t.status = $2`
+	writeJSON(w, http.StatusOK, tasks)
+}
 
-	rows, err := h.db.Query(context.Background(), query, employeeId, status)
+// GetEmployeeOverdueTasks lists the employee's open tasks that were due
+// before today, earliest due date first.
+func (h *EmployeeHandler) GetEmployeeOverdueTasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	employeeID, err := pathID(r, "id", "employee")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-	defer rows.Close()
 
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		err := rows.Scan(
-			&task.ID,
-			&task.ProjectID,
-			&task.AssignedTo,
-			&task.Title,
-			&task.Description,
-			&task.Status,
-			&task.CreatedAt,
-		)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+	q := newListQuery(r)
+	today := today()
+	filter := repository.TaskFilter{
+		AssignedTo: employeeID,
+		OverdueOn:  &today,
+		Page:       sortedPage(q, repository.TaskSorts, repository.Sort{Field: "due_date"}),
+	}
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if _, err := h.employees.GetByID(ctx, employeeID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, notFound("Employee not found"))
 			return
 		}
-		tasks = append(tasks, task)
+		writeError(w, r, err)
+		return
+	}
+
+	tasks, err := h.tasks.List(ctx, filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	json.NewEncoder(w).Encode(tasks)
+	writeJSON(w, http.StatusOK, tasks)
 }
 
-func (h *EmployeeHandler) GetEmployeeProjects(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	employeeId, err := strconv.Atoi(vars["id"])
+func (h *EmployeeHandler) GetEmployeeTasksByStatus(w http.ResponseWriter, r *http.Request) {
+	employeeId, err := pathID(r, "id", "employee")
 	if err != nil {
-		http.Error(w, "Invalid employee ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	query := `
-        SELECT p.id, p.name, p.description, p.lead_id, p.created_at
-        FROM projects p
-        JOIN employee_projects ep ON p.id = ep.project_id
-        WHERE ep.employee_id = $1`
+	status, ok := parseStatus(mux.Vars(r)["status"])
+	if !ok {
+		writeError(w, r, badRequest("Invalid status", FieldError{Field: "status", Message: statusMessage()}))
+		return
+	}
 
-	rows, err := h.db.Query(context.Background(), query, employeeId)
+	tasks, err := h.tasks.ListByAssigneeAndStatus(r.Context(), employeeId, status)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-	defer rows.Close()
 
-	var projects []models.Project
-	for rows.Next() {
-		var project models.Project
-		err := rows.Scan(
-			&project.ID,
-			&project.Name,
-			&project.Description,
-			&project.LeadID,
-			&project.CreatedAt,
-		)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		projects = append(projects, project)
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func (h *EmployeeHandler) GetEmployeeProjects(w http.ResponseWriter, r *http.Request) {
+	employeeId, err := pathID(r, "id", "employee")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	projects, err := h.projects.ListByEmployee(r.Context(), employeeId)
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	json.NewEncoder(w).Encode(projects)
+	writeJSON(w, http.StatusOK, projects)
 }
 
 func (h *EmployeeHandler) AssignEmployeeToProject(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	employeeId, err := strconv.Atoi(vars["employeeId"])
+	employeeId, err := pathID(r, "employeeId", "employee")
 	if err != nil {
-		http.Error(w, "Invalid employee ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	projectId, err := strconv.Atoi(vars["projectId"])
+	projectId, err := pathID(r, "projectId", "project")
 	if err != nil {
-		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	query := `
-        INSERT INTO employee_projects (employee_id, project_id)
-        VALUES ($1, $2)
-        ON CONFLICT (employee_id, project_id) DO NOTHING`
-
-	_, err = h.db.Exec(context.Background(), query, employeeId, projectId)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.employees.AssignToProject(r.Context(), employeeId, projectId); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -353,31 +370,25 @@ func (h *EmployeeHandler) AssignEmployeeToProject(w http.ResponseWriter, r *http
 }
 
 func (h *EmployeeHandler) RemoveEmployeeFromProject(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	employeeId, err := strconv.Atoi(vars["employeeId"])
+	employeeId, err := pathID(r, "employeeId", "employee")
 	if err != nil {
-		http.Error(w, "Invalid employee ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	projectId, err := strconv.Atoi(vars["projectId"])
+	projectId, err := pathID(r, "projectId", "project")
 	if err != nil {
-		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	query := `
-        DELETE FROM employee_projects
-        WHERE employee_id = $1 AND project_id = $2`
-
-	result, err := h.db.Exec(context.Background(), query, employeeId, projectId)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	err = h.employees.RemoveFromProject(r.Context(), employeeId, projectId)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Assignment not found"))
 		return
 	}
-
-	if result.RowsAffected() == 0 {
-		http.Error(w, "Assignment not found", http.StatusNotFound)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -385,42 +396,17 @@ func (h *EmployeeHandler) RemoveEmployeeFromProject(w http.ResponseWriter, r *ht
 }
 
 func (h *EmployeeHandler) GetEmployeesByProject(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	projectId, err := strconv.Atoi(vars["id"])
+	projectId, err := pathID(r, "id", "project")
 	if err != nil {
-		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	query := `
-        SELECT e.id, e.name, e.email, e.role, e.created_at
-        FROM employees e
-        JOIN employee_projects ep ON e.id = ep.employee_id
-        WHERE ep.project_id = $1`
-
-	rows, err := h.db.Query(context.Background(), query, projectId)
+	employees, err := h.employees.ListByProject(r.Context(), projectId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-	defer rows.Close()
-
-	var employees []models.Employee
-	for rows.Next() {
-		var emp models.Employee
-		err := rows.Scan(
-			&emp.ID,
-			&emp.Name,
-			&emp.Email,
-			&emp.Role,
-			&emp.CreatedAt,
-		)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		employees = append(employees, emp)
-	}
 
-	json.NewEncoder(w).Encode(employees)
+	writeJSON(w, http.StatusOK, employees)
 }
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+)
+
+func TestCreateAndGetEmployee(t *testing.T) {
+	s := newTestServer(t)
+
+	var created models.Employee
+	s.expect(s.do("POST", "/employees", map[string]any{
+		"name":   "Ada Lovelace",
+		"email":  "ada@example.com",
+		"role":   models.RoleDeveloper,
+		"skills": []string{"go", "sql"},
+	}), http.StatusOK, &created)
+	if created.ID == 0 {
+		t.Fatalf("created employee %+v has no ID", created)
+	}
+
+	var got models.Employee
+	s.expect(s.do("GET", fmt.Sprintf("/employees/%d", created.ID), nil), http.StatusOK, &got)
+	if got.Name != "Ada Lovelace" || got.Email != "ada@example.com" || !slices.Equal(got.Skills, []string{"go", "sql"}) {
+		t.Fatalf("got employee %+v", got)
+	}
+
+	s.createEmployee("Grace Hopper", "grace@example.com")
+	all := list[models.Employee](s, "/employees").Items
+	if len(all) != 2 || all[0].ID != created.ID {
+		t.Fatalf("listed %+v, want both employees in ID order", all)
+	}
+
+	s.expectError(s.do("GET", "/employees/999", nil), http.StatusNotFound, CodeNotFound)
+	s.expectError(s.do("GET", "/employees/abc", nil), http.StatusBadRequest, CodeBadRequest)
+}
+
+func TestUpdateEmployee(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.createEmployee("Ada Lovelace", "ada@example.com")
+	path := fmt.Sprintf("/employees/%d", employee.ID)
+
+	var updated models.Employee
+	s.expect(s.do("PUT", path, map[string]any{
+		"name":  "Ada King",
+		"email": "ada@example.com",
+		"role":  models.RoleProjectManager,
+	}), http.StatusOK, &updated)
+	if updated.Name != "Ada King" || updated.Role != models.RoleProjectManager {
+		t.Fatalf("updated employee %+v", updated)
+	}
+
+	var got models.Employee
+	s.expect(s.do("GET", path, nil), http.StatusOK, &got)
+	if got.Name != "Ada King" || !got.CreatedAt.Equal(employee.CreatedAt) {
+		t.Fatalf("got employee %+v after update", got)
+	}
+
+	s.expectError(s.do("PUT", "/employees/999", map[string]any{
+		"name":  "Nobody",
+		"email": "nobody@example.com",
+		"role":  models.RoleDeveloper,
+	}), http.StatusNotFound, CodeNotFound)
+}
+
+func TestDeleteEmployee(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.createEmployee("Ada Lovelace", "ada@example.com")
+	path := fmt.Sprintf("/employees/%d", employee.ID)
+
+	s.expect(s.do("DELETE", path, nil), http.StatusNoContent, nil)
+	s.expectError(s.do("GET", path, nil), http.StatusNotFound, CodeNotFound)
+	s.expectError(s.do("DELETE", path, nil), http.StatusNotFound, CodeNotFound)
+}
+
+func TestEmployeeProjects(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	employee := s.createEmployee("Ada Lovelace", "ada@example.com")
+	project := s.createProject("Analytical Engine", lead.ID)
+	other := s.createProject("Compiler", lead.ID)
+	assignment := fmt.Sprintf("/employees/%d/projects/%d", employee.ID, project.ID)
+
+	projectsOf := func(employeeID int) []int {
+		var projects []models.Project
+		s.expect(s.do("GET", fmt.Sprintf("/employees/%d/projects", employeeID), nil), http.StatusOK, &projects)
+		return ids(projects, projectID)
+	}
+	employeesOf := func(projectID int) []int {
+		var employees []models.Employee
+		s.expect(s.do("GET", fmt.Sprintf("/projects/%d/employees", projectID), nil), http.StatusOK, &employees)
+		return ids(employees, employeeID)
+	}
+
+	if got := projectsOf(employee.ID); len(got) != 0 {
+		t.Fatalf("projects before assignment = %v, want none", got)
+	}
+
+	s.expect(s.do("POST", assignment, nil), http.StatusCreated, nil)
+	// Assigning again changes nothing.
+	s.expect(s.do("POST", assignment, nil), http.StatusCreated, nil)
+	s.expect(s.do("POST", fmt.Sprintf("/employees/%d/projects/%d", lead.ID, project.ID), nil), http.StatusCreated, nil)
+
+	if got := projectsOf(employee.ID); !slices.Equal(got, []int{project.ID}) {
+		t.Fatalf("projects = %v, want [%d]", got, project.ID)
+	}
+	if got := employeesOf(project.ID); !slices.Equal(got, []int{lead.ID, employee.ID}) {
+		t.Fatalf("employees of project = %v, want [%d %d]", got, lead.ID, employee.ID)
+	}
+	if got := employeesOf(other.ID); len(got) != 0 {
+		t.Fatalf("employees of other project = %v, want none", got)
+	}
+
+	s.expect(s.do("DELETE", assignment, nil), http.StatusNoContent, nil)
+	s.expectError(s.do("DELETE", assignment, nil), http.StatusNotFound, CodeNotFound)
+	if got := projectsOf(employee.ID); len(got) != 0 {
+		t.Fatalf("projects after removal = %v, want none", got)
+	}
+	if got := employeesOf(project.ID); !slices.Equal(got, []int{lead.ID}) {
+		t.Fatalf("employees of project after removal = %v, want [%d]", got, lead.ID)
+	}
+}
+
+func TestEmployeeTasks(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	employee := s.createEmployee("Ada Lovelace", "ada@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	first := s.createTask("Write the parser", project.ID, employee.ID)
+	s.createTask("Write the linker", project.ID, lead.ID)
+	second := s.createTask("Write the loader", project.ID, employee.ID)
+
+	var tasks []models.Task
+	s.expect(s.do("GET", fmt.Sprintf("/employees/%d/tasks", employee.ID), nil), http.StatusOK, &tasks)
+	if got := ids(tasks, taskID); !slices.Equal(got, []int{first.ID, second.ID}) {
+		t.Fatalf("tasks = %v, want [%d %d]", got, first.ID, second.ID)
+	}
+	s.expect(s.do("GET", fmt.Sprintf("/employees/%d/tasks/TODO", employee.ID), nil), http.StatusOK, &tasks)
+	if len(tasks) != 2 {
+		t.Fatalf("to do tasks = %v, want 2", ids(tasks, taskID))
+	}
+	tasks = nil
+	s.expect(s.do("GET", fmt.Sprintf("/employees/%d/tasks/DONE", employee.ID), nil), http.StatusOK, &tasks)
+	if len(tasks) != 0 {
+		t.Fatalf("done tasks = %v, want none", ids(tasks, taskID))
+	}
+}
+
+func TestEmployeeConstraints(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	s.createProject("Compiler", lead.ID)
+
+	apiErr := s.expectError(s.do("POST", "/employees", map[string]any{
+		"name":  "Another Grace",
+		"email": "grace@example.com",
+		"role":  models.RoleDeveloper,
+	}), http.StatusConflict, CodeConflict)
+	if len(apiErr.Details) != 1 || apiErr.Details[0].Field != "email" {
+		t.Fatalf("duplicate email details = %+v", apiErr.Details)
+	}
+
+	s.expectError(s.do("POST", "/employees", map[string]any{
+		"name":  "Ada Lovelace",
+		"email": "ada@example.com",
+		"role":  "Wizard",
+	}), http.StatusBadRequest, CodeValidation)
+
+	s.expectError(s.do("POST", "/employees", "not an object"), http.StatusBadRequest, CodeBadRequest)
+
+	// A project lead cannot be deleted while the project exists.
+	s.expectError(s.do("DELETE", fmt.Sprintf("/employees/%d", lead.ID), nil), http.StatusConflict, CodeConflict)
+
+	s.expectError(s.do("POST", fmt.Sprintf("/employees/%d/projects/999", lead.ID), nil),
+		http.StatusUnprocessableEntity, CodeInvalidReference)
+}
+
+func TestEmployeeValidation(t *testing.T) {
+	s := newTestServer(t)
+
+	apiErr := s.expectError(s.do("POST", "/employees", map[string]any{
+		"name":   " ",
+		"email":  "Ada <ada@example.com>",
+		"role":   models.RoleDeveloper,
+		"skills": []string{"go", ""},
+	}), http.StatusBadRequest, CodeValidation)
+	want := []FieldError{
+		{Field: "name", Message: "is required"},
+		{Field: "email", Message: "must be a valid email address"},
+		{Field: "skills", Message: "must not contain empty values"},
+	}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+
+	apiErr = s.expectError(s.do("POST", "/employees", map[string]any{
+		"name":     "Ada Lovelace",
+		"email":    "ada@example.com",
+		"role":     models.RoleDeveloper,
+		"nickname": "Ada",
+	}), http.StatusBadRequest, CodeBadRequest)
+	if len(apiErr.Details) != 1 || apiErr.Details[0].Field != "body" {
+		t.Fatalf("unknown field details = %+v", apiErr.Details)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/employees", strings.NewReader(
+		`{"name": "Ada Lovelace", "email": "ada@example.com", "role": "Developer"} {}`))
+	s.router.ServeHTTP(rec, req)
+	s.expectError(rec, http.StatusBadRequest, CodeBadRequest)
+
+	// Nothing was written.
+	if employees := list[models.Employee](s, "/employees").Items; len(employees) != 0 {
+		t.Fatalf("invalid payloads created %v", ids(employees, employeeID))
+	}
+}
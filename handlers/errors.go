@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
+)
+
+// Error codes returned in APIError.Code.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeValidation           = "validation_failed"
+	CodeNotFound             = "not_found"
+	CodeForbidden            = "forbidden"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeConflict             = "conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodeInvalidTransition    = "invalid_transition"
+	CodeDependencyCycle      = "dependency_cycle"
+	CodeWorklogOverlap       = "worklog_overlap"
+	CodeInvalidReference     = "invalid_reference"
+	CodeUpstreamError        = "upstream_error"
+	CodeTimeout              = "timeout"
+	CodeClientClosed         = "client_closed_request"
+	CodeInternal             = "internal_error"
+)
+
+// FieldError describes a problem with one field of the request payload.
+type FieldError = validation.FieldError
+
+// APIError is the body of every error response, wrapped as {"error": ...}.
+type APIError struct {
+	Status    int          `json:"-"`
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+func newAPIError(status int, code, message string, details ...FieldError) *APIError {
+	return &APIError{Status: status, Code: code, Message: message, Details: details}
+}
+
+func badRequest(message string, details ...FieldError) *APIError {
+	return newAPIError(http.StatusBadRequest, CodeBadRequest, message, details...)
+}
+
+func notFound(message string) *APIError {
+	return newAPIError(http.StatusNotFound, CodeNotFound, message)
+}
+
+func forbidden(message string) *APIError {
+	return newAPIError(http.StatusForbidden, CodeForbidden, message)
+}
+
+// changeError maps the errors of writes that act on a row read just before,
+// such as transitions, starting a sprint or restoring. A row that changed
+// in between is a precondition failure only if the client sent If-Match,
+// otherwise a conflict it can simply retry. resource names the row in the
+// messages, e.g. "Task".
+func changeError(err error, version int, resource string) error {
+	if errors.Is(err, repository.ErrVersionConflict) && version == 0 {
+		return newAPIError(http.StatusConflict, CodeConflict, resource+" was modified concurrently; retry")
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFound(resource + " not found")
+	}
+	return err
+}
+
+// writeJSON writes v as the JSON response body with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError converts err into an APIError and writes it. Errors that are
+// not already an APIError are mapped by kind; anything unrecognised is
+// logged and reported as a generic 500 so internal details never reach the
+// client.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := toAPIError(r, err)
+	apiErr.RequestID = RequestIDFromContext(r.Context())
+	if apiErr.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "request failed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"request_id", apiErr.RequestID,
+			"error", err)
+	}
+	writeJSON(w, apiErr.Status, struct {
+		Error *APIError `json:"error"`
+	}{apiErr})
+}
+
+func toAPIError(r *http.Request, err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		copied := *apiErr
+		return &copied
+	}
+
+	ctxErr := r.Context().Err()
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctxErr, context.DeadlineExceeded):
+		return newAPIError(http.StatusGatewayTimeout, CodeTimeout, "Request timed out")
+	case errors.Is(err, context.Canceled) || errors.Is(ctxErr, context.Canceled):
+		return newAPIError(StatusClientClosedRequest, CodeClientClosed, "Client closed request")
+	case errors.Is(err, repository.ErrNotFound):
+		return notFound("Resource not found")
+	case errors.Is(err, repository.ErrVersionConflict):
+		return preconditionFailed()
+	case errors.Is(err, repository.ErrDependencyCycle):
+		return newAPIError(http.StatusConflict, CodeDependencyCycle, "Dependency would create a cycle")
+	case errors.Is(err, repository.ErrWorklogOverlap):
+		return newAPIError(http.StatusConflict, CodeWorklogOverlap, "Worklog overlaps time the employee has already logged")
+	}
+
+	var fieldErrs validation.Errors
+	if errors.As(err, &fieldErrs) {
+		return newAPIError(http.StatusBadRequest, CodeValidation, "Validation failed", fieldErrs...)
+	}
+
+	var constraint *repository.ConstraintError
+	if errors.As(err, &constraint) {
+		detail := FieldError{Field: constraint.Field, Message: constraint.Message}
+		switch constraint.Kind {
+		case repository.ConstraintUnique:
+			return newAPIError(http.StatusConflict, CodeConflict, "A record with this value already exists", detail)
+		case repository.ConstraintForeignKey:
+			return newAPIError(http.StatusUnprocessableEntity, CodeInvalidReference, "Referenced record does not exist", detail)
+		case repository.ConstraintCheck, repository.ConstraintNotNull:
+			return newAPIError(http.StatusBadRequest, CodeValidation, "Invalid field value", detail)
+		case repository.ConstraintInUse:
+			return newAPIError(http.StatusConflict, CodeConflict, "Record is still referenced by other records", detail)
+		}
+	}
+
+	return newAPIError(http.StatusInternalServerError, CodeInternal, "Internal server error")
+}
+
+// NotFound answers requests that match no route.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, notFound("Route not found"))
+}
+
+// MethodNotAllowed answers requests whose path matches a route but whose
+// method does not.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, newAPIError(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed"))
+}
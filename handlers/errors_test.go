@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nstorm.com/main-backend/repository"
+)
+
+func TestToAPIError(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	constraint := func(kind repository.ConstraintKind) error {
+		return fmt.Errorf("insert: %w", &repository.ConstraintError{Kind: kind, Field: "email", Message: "email already exists"})
+	}
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"api error", context.Background(), badRequest("nope"), http.StatusBadRequest, CodeBadRequest},
+		{"not found", context.Background(), fmt.Errorf("get: %w", repository.ErrNotFound), http.StatusNotFound, CodeNotFound},
+		{"unique", context.Background(), constraint(repository.ConstraintUnique), http.StatusConflict, CodeConflict},
+		{"foreign key", context.Background(), constraint(repository.ConstraintForeignKey), http.StatusUnprocessableEntity, CodeInvalidReference},
+		{"check", context.Background(), constraint(repository.ConstraintCheck), http.StatusBadRequest, CodeValidation},
+		{"not null", context.Background(), constraint(repository.ConstraintNotNull), http.StatusBadRequest, CodeValidation},
+		{"in use", context.Background(), constraint(repository.ConstraintInUse), http.StatusConflict, CodeConflict},
+		{"deadline error", context.Background(), context.DeadlineExceeded, http.StatusGatewayTimeout, CodeTimeout},
+		{"expired request", expired, errors.New("conn closed"), http.StatusGatewayTimeout, CodeTimeout},
+		{"cancel error", context.Background(), context.Canceled, StatusClientClosedRequest, CodeClientClosed},
+		{"cancelled request", cancelled, errors.New("conn closed"), StatusClientClosedRequest, CodeClientClosed},
+		{"anything else", context.Background(), errors.New("pq: secret detail"), http.StatusInternalServerError, CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil).WithContext(tt.ctx)
+			got := toAPIError(r, tt.err)
+			if got.Status != tt.wantStatus || got.Code != tt.wantCode {
+				t.Fatalf("got %d %s, want %d %s", got.Status, got.Code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestChangeError(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", nil)
+	tests := []struct {
+		name        string
+		err         error
+		version     int
+		wantStatus  int
+		wantMessage string
+	}{
+		{"changed meanwhile", repository.ErrVersionConflict, 0, http.StatusConflict, "Sprint was modified concurrently; retry"},
+		{"changed since If-Match", repository.ErrVersionConflict, 3, http.StatusPreconditionFailed, "Resource has been modified; fetch it again and retry"},
+		{"deleted meanwhile", fmt.Errorf("start: %w", repository.ErrNotFound), 0, http.StatusNotFound, "Sprint not found"},
+		{"anything else", errors.New("pq: secret detail"), 0, http.StatusInternalServerError, "Internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := toAPIError(r, changeError(tt.err, tt.version, "Sprint"))
+			if got.Status != tt.wantStatus || got.Message != tt.wantMessage {
+				t.Fatalf("got %d %q, want %d %q", got.Status, got.Message, tt.wantStatus, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	var body struct {
+		Error APIError `json:"error"`
+	}
+	serve := func(err error) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(RequestIDHeader, "req-1")
+		RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, r, err)
+		})).ServeHTTP(rec, req)
+		body.Error = APIError{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid response body %s: %v", rec.Body, err)
+		}
+		return rec
+	}
+
+	rec := serve(badRequest("Invalid task ID", FieldError{Field: "id", Message: "must be an integer"}))
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	want := APIError{
+		Code:      CodeBadRequest,
+		Message:   "Invalid task ID",
+		Details:   []FieldError{{Field: "id", Message: "must be an integer"}},
+		RequestID: "req-1",
+	}
+	if fmt.Sprint(body.Error) != fmt.Sprint(want) {
+		t.Fatalf("got %+v, want %+v", body.Error, want)
+	}
+
+	// Unrecognised errors never leak their text.
+	serve(errors.New("pq: secret detail"))
+	if body.Error.Message != "Internal server error" || body.Error.RequestID != "req-1" {
+		t.Fatalf("got %+v", body.Error)
+	}
+}
+
+func TestUnmatchedRoutes(t *testing.T) {
+	s := newTestServer(t)
+	s.expectError(s.do("GET", "/nowhere", nil), http.StatusNotFound, CodeNotFound)
+	s.expectError(s.do("PATCH", "/employees", nil), http.StatusMethodNotAllowed, CodeMethodNotAllowed)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag formats a row version as a strong entity tag.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", etag(version))
+}
+
+func preconditionFailed() *APIError {
+	return newAPIError(http.StatusPreconditionFailed, CodePreconditionFailed,
+		"Resource has been modified; fetch it again and retry")
+}
+
+// ifMatch returns the row version a write must still see according to the
+// If-Match header, or zero when the header is absent or "*". Weak tags never
+// match, as If-Match uses strong comparison.
+func ifMatch(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	if strings.Contains(header, ",") {
+		return 0, badRequest("Invalid If-Match header", FieldError{Field: "If-Match", Message: "must be a single entity tag"})
+	}
+	if strings.HasPrefix(header, "W/") {
+		return 0, preconditionFailed()
+	}
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version <= 0 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		return 0, preconditionFailed()
+	}
+	return version, nil
+}
+
+// checkIfMatch compares the If-Match header with the current version and
+// returns the version the write must still see, or zero if unconditional.
+func checkIfMatch(r *http.Request, current int) (int, error) {
+	version, err := ifMatch(r)
+	if err != nil {
+		return 0, err
+	}
+	if version != 0 && version != current {
+		return 0, preconditionFailed()
+	}
+	return version, nil
+}
+
+// notModified sets the ETag header for version and, if the If-None-Match
+// header already names it, answers 304 Not Modified and reports true.
+func notModified(w http.ResponseWriter, r *http.Request, version int) bool {
+	setETag(w, version)
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
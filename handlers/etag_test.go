@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    int
+		wantErr string
+	}{
+		{"", 0, ""},
+		{"*", 0, ""},
+		{`"3"`, 3, ""},
+		{` "12" `, 12, ""},
+		{`W/"3"`, 0, CodePreconditionFailed},
+		{`3`, 0, CodePreconditionFailed},
+		{`"0"`, 0, CodePreconditionFailed},
+		{`"abc"`, 0, CodePreconditionFailed},
+		{`"3", "4"`, 0, CodeBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/", nil)
+			r.Header.Set("If-Match", tt.header)
+			got, err := ifMatch(r)
+			var apiErr *APIError
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("ifMatch: %v", err)
+			case tt.wantErr != "" && (!errors.As(err, &apiErr) || apiErr.Code != tt.wantErr):
+				t.Fatalf("got error %v, want %s", err, tt.wantErr)
+			case got != tt.want:
+				t.Fatalf("got version %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{`"2"`, false},
+		{`"3"`, true},
+		{`W/"3"`, true},
+		{`"1", "3"`, true},
+		{"*", true},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("If-None-Match", tt.header)
+		if got := notModified(rec, r, 3); got != tt.want {
+			t.Errorf("If-None-Match %s: got %v, want %v", tt.header, got, tt.want)
+		}
+		if rec.Header().Get("ETag") != `"3"` {
+			t.Errorf("If-None-Match %s: ETag = %s", tt.header, rec.Header().Get("ETag"))
+		}
+		if tt.want && rec.Code != http.StatusNotModified {
+			t.Errorf("If-None-Match %s: status %d", tt.header, rec.Code)
+		}
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	path := fmt.Sprintf("/projects/%d", project.ID)
+
+	rec := s.do("GET", path, nil)
+	s.expect(rec, http.StatusOK, nil)
+	if rec.Header().Get("ETag") != etag(1) {
+		t.Fatalf("ETag = %s, want %s", rec.Header().Get("ETag"), etag(1))
+	}
+	if rec := s.do("GET", path, nil, "If-None-Match", etag(1)); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("got %d %s, want an empty 304", rec.Code, rec.Body)
+	}
+
+	s.expect(s.do("PATCH", path, map[string]any{"description": "A-0"}), http.StatusOK, nil)
+	s.expect(s.do("GET", path, nil, "If-None-Match", etag(1)), http.StatusOK, nil)
+}
+
+func TestUpdateEmployeeVersionConflict(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.createEmployee("Ada Lovelace", "ada@example.com")
+	path := fmt.Sprintf("/employees/%d", employee.ID)
+	body := func(name string) map[string]any {
+		return map[string]any{"name": name, "email": "ada@example.com", "role": models.RoleProjectManager}
+	}
+	if employee.Version != 1 {
+		t.Fatalf("new employee has version %d", employee.Version)
+	}
+
+	rec := s.do("PUT", path, body("Ada King"), "If-Match", etag(1))
+	var updated models.Employee
+	s.expect(rec, http.StatusOK, &updated)
+	if updated.Version != 2 || updated.Name != "Ada King" {
+		t.Fatalf("updated employee %+v, want version 2 named Ada King", updated)
+	}
+	if got := rec.Header().Get("ETag"); got != etag(2) {
+		t.Fatalf("ETag = %s, want %s", got, etag(2))
+	}
+
+	// A second writer still holding version 1 loses.
+	s.expectError(s.do("PUT", path, body("Countess of Lovelace"), "If-Match", etag(1)), http.StatusPreconditionFailed, CodePreconditionFailed)
+	s.expectError(s.do("PATCH", path, map[string]any{"name": "Countess of Lovelace"}, "If-Match", etag(1)), http.StatusPreconditionFailed, CodePreconditionFailed)
+	s.expectError(s.do("PUT", path, body("Countess of Lovelace"), "If-Match", "W/"+etag(2)), http.StatusPreconditionFailed, CodePreconditionFailed)
+
+	var got models.Employee
+	s.expect(s.do("GET", path, nil), http.StatusOK, &got)
+	if got.Name != "Ada King" || got.Version != 2 {
+		t.Fatalf("got employee %+v after failed writes", got)
+	}
+
+	s.expect(s.do("PATCH", path, map[string]any{"name": "Countess of Lovelace"}, "If-Match", etag(2)), http.StatusOK, &got)
+	if got.Name != "Countess of Lovelace" || got.Role != models.RoleProjectManager || got.Version != 3 {
+		t.Fatalf("patched employee %+v", got)
+	}
+
+	// Writes without If-Match are unconditional but still bump the version.
+	s.expect(s.do("PUT", path, body("Ada")), http.StatusOK, &got)
+	if got.Version != 4 {
+		t.Fatalf("version after unconditional write = %d, want 4", got.Version)
+	}
+
+	apiErr := s.expectError(s.do("PATCH", path, map[string]any{"version": 9}), http.StatusBadRequest, CodeBadRequest)
+	if len(apiErr.Details) != 1 || apiErr.Details[0].Field != "version" {
+		t.Fatalf("details = %+v, want version", apiErr.Details)
+	}
+
+	s.expectError(s.do("PUT", "/employees/999", body("Nobody"), "If-Match", etag(1)), http.StatusNotFound, CodeNotFound)
+}
+
+func TestDeleteTaskVersionConflict(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	task := s.createTask("Write the linker", project.ID, lead.ID)
+	path := fmt.Sprintf("/tasks/%d", task.ID)
+
+	s.expect(s.do("PATCH", path, map[string]any{"title": "Write the loader"}), http.StatusOK, nil)
+	s.expectError(s.do("DELETE", path, nil, "If-Match", etag(1)), http.StatusPreconditionFailed, CodePreconditionFailed)
+	s.expect(s.do("GET", path, nil), http.StatusOK, nil)
+
+	s.expect(s.do("DELETE", path, nil, "If-Match", etag(2)), http.StatusOK, nil)
+	s.expectError(s.do("GET", path, nil), http.StatusNotFound, CodeNotFound)
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"nstorm.com/main-backend/config"
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/repository/memory"
+)
+
+// testAdminToken is the administrator token of the test server. Requests
+// send it with asAdmin.
+const testAdminToken = "let-me-in"
+
+var asAdmin = []string{"Authorization", "Bearer " + testAdminToken}
+
+// testServer routes requests to the handlers as main.go does, backed by a
+// fresh in-memory store.
+type testServer struct {
+	t      *testing.T
+	repos  *repository.Repositories
+	router *mux.Router
+}
+
+func newTestServer(t *testing.T) *testServer {
+	repos := memory.NewRepositories()
+	employeeHandler := NewEmployeeHandler(repos)
+	projectHandler := NewProjectHandler(repos, config.Default().Chat)
+	taskHandler := NewTaskHandler(repos, models.DefaultTaskWorkflow())
+	commentHandler := NewCommentHandler(repos)
+	labelHandler := NewLabelHandler(repos)
+	worklogHandler := NewWorklogHandler(repos)
+	sprintHandler := NewSprintHandler(repos)
+	milestoneHandler := NewMilestoneHandler(repos)
+	templateHandler := NewTemplateHandler(repos)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/employees", employeeHandler.GetAllEmployees).Methods("GET")
+	router.HandleFunc("/employees", employeeHandler.CreateEmployee).Methods("POST")
+	router.HandleFunc("/employees/{id}", employeeHandler.GetEmployeeById).Methods("GET")
+	router.HandleFunc("/employees/{id}", employeeHandler.UpdateEmployee).Methods("PUT")
+	router.HandleFunc("/employees/{id}", employeeHandler.PatchEmployee).Methods("PATCH")
+	router.HandleFunc("/employees/{id}", employeeHandler.DeleteEmployee).Methods("DELETE")
+	router.HandleFunc("/employees/{id}/tasks", employeeHandler.GetEmployeeTasks).Methods("GET")
+	router.HandleFunc("/employees/{id}/tasks/overdue", employeeHandler.GetEmployeeOverdueTasks).Methods("GET")
+	router.HandleFunc("/employees/{id}/tasks/{status}", employeeHandler.GetEmployeeTasksByStatus).Methods("GET")
+	router.HandleFunc("/employees/{id}/projects", employeeHandler.GetEmployeeProjects).Methods("GET")
+	router.HandleFunc("/employees/{employeeId}/projects/{projectId}", employeeHandler.AssignEmployeeToProject).Methods("POST")
+	router.HandleFunc("/employees/{employeeId}/projects/{projectId}", employeeHandler.RemoveEmployeeFromProject).Methods("DELETE")
+	router.HandleFunc("/projects/{id}/employees", employeeHandler.GetEmployeesByProject).Methods("GET")
+
+	router.HandleFunc("/projects", projectHandler.GetAllProjects).Methods("GET")
+	router.HandleFunc("/projects", projectHandler.CreateProject).Methods("POST")
+	router.HandleFunc("/projects/{id}", projectHandler.GetProjectByID).Methods("GET")
+	router.HandleFunc("/projects/{id}", projectHandler.UpdateProject).Methods("PUT")
+	router.HandleFunc("/projects/{id}", projectHandler.PatchProject).Methods("PATCH")
+	router.HandleFunc("/projects/{id}", projectHandler.DeleteProject).Methods("DELETE")
+
+	router.HandleFunc("/tasks", taskHandler.GetAllTasks).Methods("GET")
+	router.HandleFunc("/tasks", taskHandler.CreateTask).Methods("POST")
+	router.HandleFunc("/tasks/{id}", taskHandler.GetTaskByID).Methods("GET")
+	router.HandleFunc("/tasks/{id}", taskHandler.UpdateTask).Methods("PUT")
+	router.HandleFunc("/tasks/{id}", taskHandler.PatchTask).Methods("PATCH")
+	router.HandleFunc("/tasks/{id}", taskHandler.DeleteTask).Methods("DELETE")
+	router.HandleFunc("/tasks/{id}/transitions", taskHandler.GetTaskTransitions).Methods("GET")
+	router.HandleFunc("/tasks/{id}/transitions", taskHandler.TransitionTask).Methods("POST")
+	router.HandleFunc("/tasks/{id}/move", taskHandler.MoveTask).Methods("POST")
+	router.HandleFunc("/tasks/{id}/subtree", taskHandler.GetTaskSubtree).Methods("GET")
+	router.HandleFunc("/tasks/{id}/dependencies", taskHandler.GetTaskDependencies).Methods("GET")
+	router.HandleFunc("/tasks/{id}/dependencies/{dependsOnId}", taskHandler.AddTaskDependency).Methods("POST")
+	router.HandleFunc("/tasks/{id}/dependencies/{dependsOnId}", taskHandler.RemoveTaskDependency).Methods("DELETE")
+	router.HandleFunc("/tasks/{id}/dependents", taskHandler.GetTaskDependents).Methods("GET")
+	router.HandleFunc("/projects/{id}/board", projectHandler.GetProjectBoard).Methods("GET")
+	router.HandleFunc("/projects/{id}/tasks/topological", taskHandler.GetProjectTasksTopological).Methods("GET")
+	router.HandleFunc("/tasks/{id}/comments", commentHandler.GetComments).Methods("GET")
+	router.HandleFunc("/tasks/{id}/comments", commentHandler.CreateComment).Methods("POST")
+	router.HandleFunc("/tasks/{id}/comments/{commentId}", commentHandler.GetComment).Methods("GET")
+	router.HandleFunc("/tasks/{id}/comments/{commentId}", commentHandler.UpdateComment).Methods("PUT")
+	router.HandleFunc("/tasks/{id}/comments/{commentId}", commentHandler.DeleteComment).Methods("DELETE")
+	router.HandleFunc("/tasks/{id}/activity", commentHandler.GetTaskActivity).Methods("GET")
+	router.HandleFunc("/labels", labelHandler.GetAllLabels).Methods("GET")
+	router.HandleFunc("/labels", labelHandler.CreateLabel).Methods("POST")
+	router.HandleFunc("/labels/stats", labelHandler.GetLabelStats).Methods("GET")
+	router.HandleFunc("/labels/{id}", labelHandler.GetLabelByID).Methods("GET")
+	router.HandleFunc("/labels/{id}", labelHandler.UpdateLabel).Methods("PUT")
+	router.HandleFunc("/labels/{id}", labelHandler.DeleteLabel).Methods("DELETE")
+	router.HandleFunc("/tasks/{id}/labels", labelHandler.GetTaskLabels).Methods("GET")
+	router.HandleFunc("/tasks/{id}/labels/{labelId}", labelHandler.AttachLabelToTask).Methods("POST")
+	router.HandleFunc("/tasks/{id}/labels/{labelId}", labelHandler.DetachLabelFromTask).Methods("DELETE")
+	router.HandleFunc("/projects/{id}/labels", labelHandler.GetProjectLabels).Methods("GET")
+	router.HandleFunc("/projects/{id}/labels/{labelId}", labelHandler.AttachLabelToProject).Methods("POST")
+	router.HandleFunc("/projects/{id}/labels/{labelId}", labelHandler.DetachLabelFromProject).Methods("DELETE")
+	router.HandleFunc("/worklogs", worklogHandler.GetWorklogs).Methods("GET")
+	router.HandleFunc("/worklogs", worklogHandler.CreateWorklog).Methods("POST")
+	router.HandleFunc("/worklogs/report", worklogHandler.GetWorklogReport).Methods("GET")
+	router.HandleFunc("/worklogs/{id}", worklogHandler.GetWorklog).Methods("GET")
+	router.HandleFunc("/worklogs/{id}", worklogHandler.UpdateWorklog).Methods("PUT")
+	router.HandleFunc("/worklogs/{id}", worklogHandler.DeleteWorklog).Methods("DELETE")
+	router.HandleFunc("/employees/{id}/timer", worklogHandler.GetTimer).Methods("GET")
+	router.HandleFunc("/employees/{id}/timer", worklogHandler.StartTimer).Methods("POST")
+	router.HandleFunc("/employees/{id}/timer", worklogHandler.StopTimer).Methods("DELETE")
+	router.HandleFunc("/sprints", sprintHandler.GetSprints).Methods("GET")
+	router.HandleFunc("/sprints", sprintHandler.CreateSprint).Methods("POST")
+	router.HandleFunc("/sprints/{id}", sprintHandler.GetSprint).Methods("GET")
+	router.HandleFunc("/sprints/{id}", sprintHandler.UpdateSprint).Methods("PUT")
+	router.HandleFunc("/sprints/{id}", sprintHandler.DeleteSprint).Methods("DELETE")
+	router.HandleFunc("/sprints/{id}/start", sprintHandler.StartSprint).Methods("POST")
+	router.HandleFunc("/sprints/{id}/close", sprintHandler.CloseSprint).Methods("POST")
+	router.HandleFunc("/sprints/{id}/tasks", sprintHandler.GetSprintTasks).Methods("GET")
+	router.HandleFunc("/projects/{id}/velocity", sprintHandler.GetProjectVelocity).Methods("GET")
+	router.HandleFunc("/milestones", milestoneHandler.GetMilestones).Methods("GET")
+	router.HandleFunc("/milestones", milestoneHandler.CreateMilestone).Methods("POST")
+	router.HandleFunc("/milestones/{id}", milestoneHandler.GetMilestone).Methods("GET")
+	router.HandleFunc("/milestones/{id}", milestoneHandler.UpdateMilestone).Methods("PUT")
+	router.HandleFunc("/milestones/{id}", milestoneHandler.DeleteMilestone).Methods("DELETE")
+	router.HandleFunc("/milestones/{id}/tasks", milestoneHandler.GetMilestoneTasks).Methods("GET")
+	router.HandleFunc("/milestones/{id}/progress", milestoneHandler.GetMilestoneProgress).Methods("GET")
+	router.HandleFunc("/projects/{id}/milestones", milestoneHandler.GetProjectMilestones).Methods("GET")
+	router.HandleFunc("/employees/{id}/restore", employeeHandler.RestoreEmployee).Methods("POST")
+	router.HandleFunc("/projects/{id}/restore", projectHandler.RestoreProject).Methods("POST")
+	router.HandleFunc("/projects/{id}/archive", projectHandler.ArchiveProject).Methods("POST")
+	router.HandleFunc("/projects/{id}/unarchive", projectHandler.UnarchiveProject).Methods("POST")
+	router.HandleFunc("/tasks/{id}/restore", taskHandler.RestoreTask).Methods("POST")
+	router.HandleFunc("/templates", templateHandler.GetTemplates).Methods("GET")
+	router.HandleFunc("/templates", templateHandler.CreateTemplate).Methods("POST")
+	router.HandleFunc("/templates/{id}", templateHandler.GetTemplate).Methods("GET")
+	router.HandleFunc("/templates/{id}", templateHandler.UpdateTemplate).Methods("PUT")
+	router.HandleFunc("/templates/{id}", templateHandler.DeleteTemplate).Methods("DELETE")
+	router.HandleFunc("/templates/{id}/instantiate", templateHandler.InstantiateTemplate).Methods("POST")
+	router.HandleFunc("/projects/{id}/clone", templateHandler.CloneProject).Methods("POST")
+
+	router.NotFoundHandler = http.HandlerFunc(NotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(MethodNotAllowed)
+	router.Use(Admin(testAdminToken))
+	return &testServer{t: t, repos: repos, router: router}
+}
+
+// do sends a request with body encoded as JSON, unless it is nil, and the
+// given headers as name, value pairs.
+func (s *testServer) do(method, path string, body any, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect fails unless the response has the given status and, when v is not
+// nil, decodes its body into v.
+func (s *testServer) expect(rec *httptest.ResponseRecorder, status int, v any) {
+	s.t.Helper()
+	if rec.Code != status {
+		s.t.Fatalf("got status %d, want %d: %s", rec.Code, status, rec.Body)
+	}
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			s.t.Fatalf("invalid response body %s: %v", rec.Body, err)
+		}
+	}
+}
+
+// expectError fails unless the response is an error envelope with the
+// given status and code, and returns the error.
+func (s *testServer) expectError(rec *httptest.ResponseRecorder, status int, code string) APIError {
+	s.t.Helper()
+	var body struct {
+		Error APIError `json:"error"`
+	}
+	s.expect(rec, status, &body)
+	if body.Error.Code != code {
+		s.t.Fatalf("got error code %q, want %q: %s", body.Error.Code, code, rec.Body)
+	}
+	return body.Error
+}
+
+func (s *testServer) createEmployee(name, email string) models.Employee {
+	s.t.Helper()
+	var employee models.Employee
+	s.expect(s.do("POST", "/employees", map[string]any{
+		"name":  name,
+		"email": email,
+		"role":  models.RoleDeveloper,
+	}), http.StatusOK, &employee)
+	return employee
+}
+
+func (s *testServer) createProject(name string, leadID int) models.Project {
+	s.t.Helper()
+	var project models.Project
+	s.expect(s.do("POST", "/projects", map[string]any{
+		"name":    name,
+		"lead_id": leadID,
+	}), http.StatusOK, &project)
+	return project
+}
+
+func (s *testServer) createTask(title string, projectID, assignedTo int) models.Task {
+	s.t.Helper()
+	var task models.Task
+	s.expect(s.do("POST", "/tasks", map[string]any{
+		"title":       title,
+		"project_id":  projectID,
+		"assigned_to": assignedTo,
+		"status":      "TODO",
+	}), http.StatusOK, &task)
+	return task
+}
+
+// transition moves a task to status on behalf of actor.
+func (s *testServer) transition(task models.Task, to models.TaskStatus, actorID int) models.Task {
+	s.t.Helper()
+	var moved models.Task
+	s.expect(s.do("POST", fmt.Sprintf("/tasks/%d/transitions", task.ID), map[string]any{
+		"to":       to,
+		"actor_id": actorID,
+	}), http.StatusOK, &moved)
+	return moved
+}
+
+// getTask fetches a task's current state.
+func (s *testServer) getTask(id int) models.Task {
+	s.t.Helper()
+	var task models.Task
+	s.expect(s.do("GET", fmt.Sprintf("/tasks/%d", id), nil), http.StatusOK, &task)
+	return task
+}
+
+// list fetches one page of a list endpoint.
+func list[T any](s *testServer, path string) repository.Page[T] {
+	s.t.Helper()
+	var page repository.Page[T]
+	s.expect(s.do("GET", path, nil), http.StatusOK, &page)
+	return page
+}
+
+// ids returns the IDs of items in order.
+func ids[T any](items []T, id func(T) int) []int {
+	out := []int{}
+	for _, item := range items {
+		out = append(out, id(item))
+	}
+	return out
+}
+
+func employeeID(e models.Employee) int   { return e.ID }
+func projectID(p models.Project) int     { return p.ID }
+func taskID(t models.Task) int           { return t.ID }
+func commentID(c models.Comment) int     { return c.ID }
+func labelID(l models.Label) int         { return l.ID }
+func worklogID(w models.Worklog) int     { return w.ID }
+func sprintID(s models.Sprint) int       { return s.ID }
+func milestoneID(m models.Milestone) int { return m.ID }
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DependencyCheck reports whether a dependency the server needs is usable.
+type DependencyCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+type HealthHandler struct {
+	checks        []DependencyCheck
+	timeout       time.Duration
+	build         BuildInfo
+	schemaVersion func(ctx context.Context) (int, error)
+	latestSchema  int
+}
+
+// NewHealthHandler builds the health endpoints. schemaVersion reports the
+// migration version applied to the database and latestSchema the newest
+// version the binary ships with.
+func NewHealthHandler(timeout time.Duration, build BuildInfo, schemaVersion func(ctx context.Context) (int, error), latestSchema int, checks ...DependencyCheck) *HealthHandler {
+	return &HealthHandler{
+		checks:        checks,
+		timeout:       timeout,
+		build:         build,
+		schemaVersion: schemaVersion,
+		latestSchema:  latestSchema,
+	}
+}
+
+// HTTPCheck returns a check that succeeds when url answers with any
+// non-5xx status.
+func HTTPCheck(client *http.Client, url string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+type dependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Healthz reports that the process is alive. It never touches dependencies.
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Readyz runs every dependency check concurrently under the configured
+// timeout and returns 503 if any of them fails.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	results := make(map[string]dependencyStatus, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check DependencyCheck) {
+			defer wg.Done()
+			start := time.Now()
+			err := check.Check(ctx)
+			result := dependencyStatus{Status: "ok", LatencyMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = "error"
+				result.Error = err.Error()
+			}
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	status, code := "ok", http.StatusOK
+	for _, result := range results {
+		if result.Status != "ok" {
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct {
+		Status string                      `json:"status"`
+		Checks map[string]dependencyStatus `json:"checks"`
+	}{status, results})
+}
+
+// Version reports the build and the schema migration version.
+func (h *HealthHandler) Version(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	response := struct {
+		BuildInfo
+		SchemaVersion int    `json:"schema_version"`
+		LatestSchema  int    `json:"latest_schema_version"`
+		SchemaError   string `json:"schema_error,omitempty"`
+	}{BuildInfo: h.build, LatestSchema: h.latestSchema}
+
+	version, err := h.schemaVersion(ctx)
+	if err != nil {
+		response.SchemaError = err.Error()
+	}
+	response.SchemaVersion = version
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type readyResponse struct {
+	Status string                      `json:"status"`
+	Checks map[string]dependencyStatus `json:"checks"`
+}
+
+func serveHealth(t *testing.T, handler http.HandlerFunc, v any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("GET", "/", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("invalid response body %s: %v", rec.Body, err)
+	}
+	return rec.Code
+}
+
+func schemaAt(version int, err error) func(context.Context) (int, error) {
+	return func(context.Context) (int, error) { return version, err }
+}
+
+func TestHealthz(t *testing.T) {
+	failing := DependencyCheck{"database", func(context.Context) error { return errors.New("down") }}
+	h := NewHealthHandler(time.Second, BuildInfo{}, schemaAt(1, nil), 1, failing)
+
+	var body map[string]string
+	if code := serveHealth(t, h.Healthz, &body); code != http.StatusOK || body["status"] != "ok" {
+		t.Fatalf("got %d %v, want 200 ok even with a failing dependency", code, body)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	ok := DependencyCheck{"database", func(context.Context) error { return nil }}
+	failing := DependencyCheck{"chat", func(context.Context) error { return errors.New("connection refused") }}
+	slow := DependencyCheck{"slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	t.Run("all ok", func(t *testing.T) {
+		h := NewHealthHandler(time.Second, BuildInfo{}, schemaAt(1, nil), 1, ok)
+		var body readyResponse
+		if code := serveHealth(t, h.Readyz, &body); code != http.StatusOK || body.Status != "ok" {
+			t.Fatalf("got %d %+v, want 200 ok", code, body)
+		}
+		if body.Checks["database"].Status != "ok" {
+			t.Fatalf("database check = %+v", body.Checks["database"])
+		}
+	})
+
+	t.Run("one failing", func(t *testing.T) {
+		h := NewHealthHandler(time.Second, BuildInfo{}, schemaAt(1, nil), 1, ok, failing)
+		var body readyResponse
+		if code := serveHealth(t, h.Readyz, &body); code != http.StatusServiceUnavailable || body.Status != "unavailable" {
+			t.Fatalf("got %d %+v, want 503 unavailable", code, body)
+		}
+		if got := body.Checks["chat"]; got.Status != "error" || got.Error != "connection refused" {
+			t.Fatalf("chat check = %+v", got)
+		}
+		if got := body.Checks["database"]; got.Status != "ok" {
+			t.Fatalf("database check = %+v", got)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		h := NewHealthHandler(20*time.Millisecond, BuildInfo{}, schemaAt(1, nil), 1, slow)
+		var body readyResponse
+		start := time.Now()
+		if code := serveHealth(t, h.Readyz, &body); code != http.StatusServiceUnavailable {
+			t.Fatalf("got %d %+v, want 503", code, body)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("readyz took %v despite a 20ms timeout", elapsed)
+		}
+		if got := body.Checks["slow"]; got.Error != context.DeadlineExceeded.Error() {
+			t.Fatalf("slow check = %+v", got)
+		}
+	})
+}
+
+func TestVersion(t *testing.T) {
+	build := BuildInfo{Commit: "abc123", GoVersion: "go1.23"}
+
+	var body map[string]any
+	h := NewHealthHandler(time.Second, build, schemaAt(3, nil), 4)
+	if code := serveHealth(t, h.Version, &body); code != http.StatusOK {
+		t.Fatalf("got %d", code)
+	}
+	if body["commit"] != "abc123" || body["schema_version"] != 3.0 || body["latest_schema_version"] != 4.0 {
+		t.Fatalf("got %v", body)
+	}
+	if _, ok := body["schema_error"]; ok {
+		t.Fatalf("unexpected schema error in %v", body)
+	}
+
+	body = nil
+	h = NewHealthHandler(time.Second, build, schemaAt(0, errors.New("no schema_migrations")), 4)
+	serveHealth(t, h.Version, &body)
+	if body["schema_error"] != "no schema_migrations" || body["schema_version"] != 0.0 {
+		t.Fatalf("got %v", body)
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	status := http.StatusMethodNotAllowed
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	check := HTTPCheck(server.Client(), server.URL)
+
+	// Any answer short of a server error means the service is up.
+	if err := check(context.Background()); err != nil {
+		t.Fatalf("check with status %d: %v", status, err)
+	}
+	status = http.StatusBadGateway
+	if err := check(context.Background()); err == nil {
+		t.Fatalf("check with status %d succeeded", status)
+	}
+	server.Close()
+	if err := check(context.Background()); err == nil {
+		t.Fatal("check against a closed server succeeded")
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+const cancelledWithParentNote = "parent task cancelled"
+
+// GetTaskSubtree returns a task with its subtasks nested to any depth, each
+// with the completion and hours rolled up from the tasks below it.
+func (h *TaskHandler) GetTaskSubtree(w http.ResponseWriter, r *http.Request) {
+	taskID, err := pathID(r, "id", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	tasks, err := h.tasks.Subtree(r.Context(), taskID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Task not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	tree, ok := models.BuildTaskTree(taskID, tasks)
+	if !ok {
+		writeError(w, r, notFound("Task not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, tree)
+}
+
+// openSubtasks counts the subtasks of taskID, at any depth, that are still
+// open.
+func (h *TaskHandler) openSubtasks(ctx context.Context, taskID int) (int, error) {
+	subtree, err := h.tasks.Subtree(ctx, taskID)
+	if err != nil {
+		return 0, err
+	}
+	open := 0
+	for _, task := range subtree {
+		if task.ID != taskID && task.Status.Open() {
+			open++
+		}
+	}
+	return open, nil
+}
+
+// cancelSubtasks cancels the open subtasks of a cancelled task, at any
+// depth, on behalf of the actor who cancelled it. Subtasks the workflow
+// cannot move to CANCELLED are left alone, and failures are logged because
+// the parent has already been cancelled.
+func (h *TaskHandler) cancelSubtasks(ctx context.Context, taskID, actorID int) {
+	subtree, err := h.tasks.Subtree(ctx, taskID)
+	if err != nil {
+		slog.WarnContext(ctx, "listing subtasks failed", "task_id", taskID, "error", err)
+		return
+	}
+	for _, task := range subtree {
+		if task.ID == taskID || !task.Status.Open() || !h.workflow.Allows(task.Status, models.StatusCancelled) {
+			continue
+		}
+		transition := models.TaskTransition{
+			TaskID:  task.ID,
+			From:    task.Status,
+			To:      models.StatusCancelled,
+			ActorID: &actorID,
+			Note:    cancelledWithParentNote,
+		}
+		if _, err := h.tasks.Transition(ctx, &transition, task.Version); err != nil {
+			slog.WarnContext(ctx, "cancelling subtask failed", "task_id", task.ID, "error", err)
+			continue
+		}
+		h.reconcileDependentsOf(ctx, task.ID)
+	}
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+)
+
+func (s *testServer) createSubtask(title string, parent models.Task, estimateHours float64) models.Task {
+	s.t.Helper()
+	var task models.Task
+	s.expect(s.do("POST", "/tasks", map[string]any{
+		"title":          title,
+		"project_id":     parent.ProjectID,
+		"assigned_to":    parent.AssignedTo,
+		"parent_task_id": parent.ID,
+		"status":         "TODO",
+		"estimate_hours": estimateHours,
+	}), http.StatusOK, &task)
+	return task
+}
+
+func TestTaskSubtree(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	compiler := s.createTask("Write the compiler", project.ID, lead.ID)
+	parser := s.createSubtask("Write the parser", compiler, 0)
+	lexer := s.createSubtask("Write the lexer", parser, 6)
+	grammar := s.createSubtask("Write the grammar", parser, 4)
+	codegen := s.createSubtask("Write the code generator", compiler, 10)
+	s.createTask("Write the linker", project.ID, lead.ID)
+
+	for _, to := range []models.TaskStatus{models.StatusInProgress, models.StatusInReview, models.StatusDone} {
+		s.transition(lexer, to, lead.ID)
+	}
+
+	var tree models.TaskNode
+	s.expect(s.do("GET", fmt.Sprintf("/tasks/%d/subtree", compiler.ID), nil), http.StatusOK, &tree)
+	want := models.TaskRollup{Tasks: 3, Done: 1, PercentComplete: 33.3, EstimateHours: 20, RemainingHours: 14}
+	if tree.ID != compiler.ID || tree.Rollup != want {
+		t.Fatalf("root %d rollup = %+v, want %+v", tree.ID, tree.Rollup, want)
+	}
+	if len(tree.Subtasks) != 2 || tree.Subtasks[0].ID != parser.ID || tree.Subtasks[1].ID != codegen.ID {
+		t.Fatalf("subtasks = %+v", tree.Subtasks)
+	}
+	nested := tree.Subtasks[0]
+	want = models.TaskRollup{Tasks: 2, Done: 1, PercentComplete: 50, EstimateHours: 10, RemainingHours: 4}
+	if len(nested.Subtasks) != 2 || nested.Subtasks[1].ID != grammar.ID || nested.Rollup != want {
+		t.Fatalf("nested subtree = %+v", nested)
+	}
+
+	page := list[models.Task](s, fmt.Sprintf("/tasks?parent_task_id=%d", parser.ID))
+	if got := ids(page.Items, taskID); !slices.Equal(got, []int{lexer.ID, grammar.ID}) {
+		t.Fatalf("subtasks of %d = %v", parser.ID, got)
+	}
+
+	s.expectError(s.do("GET", "/tasks/999/subtree", nil), http.StatusNotFound, CodeNotFound)
+}
+
+func TestTaskParentValidation(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	other := s.createProject("Debugger", lead.ID)
+	compiler := s.createTask("Write the compiler", project.ID, lead.ID)
+	parser := s.createSubtask("Write the parser", compiler, 4)
+	lexer := s.createSubtask("Write the lexer", parser, 2)
+	stepper := s.createTask("Write the stepper", other.ID, lead.ID)
+
+	tests := []struct {
+		name    string
+		task    models.Task
+		patch   map[string]any
+		field   string
+		message string
+	}{
+		{"missing parent", parser, map[string]any{"parent_task_id": 999}, "parent_task_id", "does not exist"},
+		{"parent in another project", parser, map[string]any{"parent_task_id": stepper.ID}, "parent_task_id", "must belong to the same project"},
+		{"itself", parser, map[string]any{"parent_task_id": parser.ID}, "parent_task_id", "must not be the task itself or one of its subtasks"},
+		{"own subtask", compiler, map[string]any{"parent_task_id": lexer.ID}, "parent_task_id", "must not be the task itself or one of its subtasks"},
+		{"away from its subtasks", parser, map[string]any{"project_id": other.ID, "parent_task_id": nil}, "project_id", "must be the project of the task's subtasks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := s.expectError(s.do("PATCH", fmt.Sprintf("/tasks/%d", tt.task.ID), tt.patch), http.StatusBadRequest, CodeValidation)
+			if !slices.Contains(apiErr.Details, FieldError{Field: tt.field, Message: tt.message}) {
+				t.Fatalf("details = %+v", apiErr.Details)
+			}
+		})
+	}
+
+	// Any depth is fine as long as the tree has no cycles.
+	var moved models.Task
+	s.expect(s.do("PATCH", fmt.Sprintf("/tasks/%d", stepper.ID), map[string]any{
+		"project_id":     project.ID,
+		"parent_task_id": lexer.ID,
+	}), http.StatusOK, &moved)
+	if moved.ParentTaskID == nil || *moved.ParentTaskID != lexer.ID {
+		t.Fatalf("moved task %+v", moved)
+	}
+	var tree models.TaskNode
+	s.expect(s.do("GET", fmt.Sprintf("/tasks/%d/subtree", compiler.ID), nil), http.StatusOK, &tree)
+	if depth := treeDepth(tree); depth != 4 {
+		t.Fatalf("tree depth = %d, want 4", depth)
+	}
+}
+
+func treeDepth(node models.TaskNode) int {
+	depth := 0
+	for _, sub := range node.Subtasks {
+		depth = max(depth, treeDepth(sub))
+	}
+	return depth + 1
+}
+
+func TestCompletingParent(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	compiler := s.createTask("Write the compiler", project.ID, lead.ID)
+	parser := s.createSubtask("Write the parser", compiler, 4)
+	lexer := s.createSubtask("Write the lexer", parser, 2)
+	for _, to := range []models.TaskStatus{models.StatusInProgress, models.StatusInReview} {
+		compiler = s.transition(compiler, to, lead.ID)
+	}
+
+	// An open subtask at any depth keeps the parent from being done.
+	parser = s.transition(parser, models.StatusInProgress, lead.ID)
+	apiErr := s.expectError(s.do("POST", fmt.Sprintf("/tasks/%d/transitions", compiler.ID),
+		map[string]any{"to": models.StatusDone, "actor_id": lead.ID}), http.StatusConflict, CodeInvalidTransition)
+	if apiErr.Message != "Task has open subtasks" {
+		t.Fatalf("got %+v", apiErr)
+	}
+
+	s.transition(lexer, models.StatusCancelled, lead.ID)
+	for _, to := range []models.TaskStatus{models.StatusInReview, models.StatusDone} {
+		parser = s.transition(parser, to, lead.ID)
+	}
+	if got := s.transition(compiler, models.StatusDone, lead.ID).Status; got != models.StatusDone {
+		t.Fatalf("parent status = %s, want DONE", got)
+	}
+}
+
+func TestCancellingParent(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	compiler := s.createTask("Write the compiler", project.ID, lead.ID)
+	parser := s.createSubtask("Write the parser", compiler, 4)
+	lexer := s.createSubtask("Write the lexer", parser, 2)
+	codegen := s.createSubtask("Write the code generator", compiler, 8)
+	for _, to := range []models.TaskStatus{models.StatusInProgress, models.StatusInReview, models.StatusDone} {
+		codegen = s.transition(codegen, to, lead.ID)
+	}
+
+	s.transition(compiler, models.StatusCancelled, lead.ID)
+
+	for _, task := range []models.Task{parser, lexer} {
+		if got := s.getTask(task.ID).Status; got != models.StatusCancelled {
+			t.Errorf("subtask %d status = %s, want CANCELLED", task.ID, got)
+		}
+		var history []models.TaskTransition
+		s.expect(s.do("GET", fmt.Sprintf("/tasks/%d/transitions", task.ID), nil), http.StatusOK, &history)
+		last := history[len(history)-1]
+		if last.Note != cancelledWithParentNote || last.ActorID == nil || *last.ActorID != lead.ID {
+			t.Errorf("subtask %d history = %+v", task.ID, history)
+		}
+	}
+	// Finished subtasks keep their status.
+	if got := s.getTask(codegen.ID).Status; got != models.StatusDone {
+		t.Errorf("finished subtask status = %s, want DONE", got)
+	}
+}
+
+func TestDeletingParent(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	compiler := s.createTask("Write the compiler", project.ID, lead.ID)
+	parser := s.createSubtask("Write the parser", compiler, 4)
+	lexer := s.createSubtask("Write the lexer", parser, 2)
+	linker := s.createTask("Write the linker", project.ID, lead.ID)
+
+	path := fmt.Sprintf("/tasks/%d", compiler.ID)
+	apiErr := s.expectError(s.do("DELETE", path, nil), http.StatusConflict, CodeConflict)
+	if len(apiErr.Details) != 1 || apiErr.Details[0].Field != "cascade" {
+		t.Fatalf("got %+v", apiErr)
+	}
+	s.getTask(parser.ID)
+
+	s.expect(s.do("DELETE", path+"?cascade=true", nil), http.StatusOK, nil)
+	for _, task := range []models.Task{compiler, parser, lexer} {
+		s.expectError(s.do("GET", fmt.Sprintf("/tasks/%d", task.ID), nil), http.StatusNotFound, CodeNotFound)
+	}
+	s.getTask(linker.ID)
+
+	// A leaf needs no cascade.
+	s.expect(s.do("DELETE", fmt.Sprintf("/tasks/%d", linker.ID), nil), http.StatusOK, nil)
+}
@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
+)
+
+type LabelHandler struct {
+	labels   repository.LabelRepository
+	tasks    repository.TaskRepository
+	projects repository.ProjectRepository
+}
+
+func NewLabelHandler(repos *repository.Repositories) *LabelHandler {
+	return &LabelHandler{
+		labels:   repos.Labels,
+		tasks:    repos.Tasks,
+		projects: repos.Projects,
+	}
+}
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// normalizeLabel trims the name, lower-cases the color and fills in the
+// default color.
+func normalizeLabel(label *models.Label) {
+	label.Name = strings.TrimSpace(label.Name)
+	label.Color = strings.ToLower(strings.TrimSpace(label.Color))
+	if label.Color == "" {
+		label.Color = models.DefaultLabelColor
+	}
+}
+
+func validateLabel(ctx context.Context, projects repository.ProjectRepository, label *models.Label) error {
+	errs := validation.Struct(label)
+	if !errs.Has("color") && !colorPattern.MatchString(label.Color) {
+		errs.Add("color", "must be a hex color like #1f883d")
+	}
+	if label.ProjectID != nil {
+		if err := checkExists(ctx, &errs, "project_id", *label.ProjectID, projects.GetByID); err != nil {
+			return err
+		}
+	}
+	return errs.Err()
+}
+
+// CreateLabel creates a global label, or one scoped to project_id.
+func (h *LabelHandler) CreateLabel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var label models.Label
+	if err := decodeJSON(r, &label); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	normalizeLabel(&label)
+	if err := validateLabel(ctx, h.projects, &label); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.labels.Create(ctx, &label); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, label.Version)
+	writeJSON(w, http.StatusOK, label)
+}
+
+func (h *LabelHandler) GetLabelByID(w http.ResponseWriter, r *http.Request) {
+	label, ok := h.label(w, r)
+	if !ok {
+		return
+	}
+
+	if notModified(w, r, label.Version) {
+		return
+	}
+	writeJSON(w, http.StatusOK, label)
+}
+
+// UpdateLabel replaces a label's name, color and description. The project
+// a label belongs to cannot be changed.
+func (h *LabelHandler) UpdateLabel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current, ok := h.label(w, r)
+	if !ok {
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var label models.Label
+	if err := decodeJSON(r, &label); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if label.ProjectID != nil && !sameProject(label.ProjectID, current.ProjectID) {
+		writeError(w, r, validation.Errors{{Field: "project_id", Message: "cannot be changed"}})
+		return
+	}
+	label.ID = current.ID
+	label.ProjectID = current.ProjectID
+	label.Version = version
+	normalizeLabel(&label)
+	if err := validateLabel(ctx, h.projects, &label); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = h.labels.Update(ctx, &label)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Label not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, label.Version)
+	writeJSON(w, http.StatusOK, label)
+}
+
+func sameProject(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// DeleteLabel deletes a label and detaches it from everything.
+func (h *LabelHandler) DeleteLabel(w http.ResponseWriter, r *http.Request) {
+	labelID, err := pathID(r, "id", "label")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = h.labels.Delete(r.Context(), labelID, version)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Label not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// labelFilter reads project_id, selecting the labels usable in a project,
+// and scope=global, selecting only global labels.
+func labelFilter(q *listQuery) repository.LabelFilter {
+	filter := repository.LabelFilter{ProjectID: q.idParam("project_id")}
+	switch scope := q.stringParam("scope"); scope {
+	case "", "all":
+	case "global":
+		filter.GlobalOnly = true
+	default:
+		q.errs.Add("scope", "must be one of all, global")
+	}
+	return filter
+}
+
+// GetAllLabels lists labels, optionally only those usable in a project or
+// only global ones.
+func (h *LabelHandler) GetAllLabels(w http.ResponseWriter, r *http.Request) {
+	q := newListQuery(r)
+	filter := labelFilter(q)
+	filter.Page = page(q, repository.LabelSorts)
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	labels, err := h.labels.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, labels)
+}
+
+// GetLabelStats counts, for every label, the tasks and projects it is
+// attached to. With project_id only that project's tasks are counted.
+func (h *LabelHandler) GetLabelStats(w http.ResponseWriter, r *http.Request) {
+	q := newListQuery(r)
+	filter := labelFilter(q)
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	stats, err := h.labels.Stats(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if stats == nil {
+		stats = []models.LabelStats{}
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// AttachLabelToTask labels a task. Labels scoped to another project are
+// rejected.
+func (h *LabelHandler) AttachLabelToTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	taskID, err := pathID(r, "id", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	task, err := h.tasks.GetByID(ctx, taskID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Task not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	label, ok := h.usableLabel(w, r, task.ProjectID)
+	if !ok {
+		return
+	}
+
+	if err := h.labels.AttachToTask(ctx, taskID, label.ID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *LabelHandler) DetachLabelFromTask(w http.ResponseWriter, r *http.Request) {
+	taskID, err := pathID(r, "id", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	labelID, err := pathID(r, "labelId", "label")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = h.labels.DetachFromTask(r.Context(), taskID, labelID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Label is not attached to the task"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *LabelHandler) GetTaskLabels(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	taskID, err := pathID(r, "id", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if _, err := h.tasks.GetByID(ctx, taskID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, notFound("Task not found"))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	labels, err := h.labels.TaskLabels(ctx, taskID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if labels == nil {
+		labels = []models.Label{}
+	}
+
+	writeJSON(w, http.StatusOK, labels)
+}
+
+// AttachLabelToProject labels a project. Labels scoped to another project
+// are rejected.
+func (h *LabelHandler) AttachLabelToProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID, ok := h.projectID(w, r)
+	if !ok {
+		return
+	}
+	label, ok := h.usableLabel(w, r, projectID)
+	if !ok {
+		return
+	}
+
+	if err := h.labels.AttachToProject(ctx, projectID, label.ID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *LabelHandler) DetachLabelFromProject(w http.ResponseWriter, r *http.Request) {
+	projectID, err := pathID(r, "id", "project")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	labelID, err := pathID(r, "labelId", "label")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = h.labels.DetachFromProject(r.Context(), projectID, labelID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Label is not attached to the project"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *LabelHandler) GetProjectLabels(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := h.projectID(w, r)
+	if !ok {
+		return
+	}
+
+	labels, err := h.labels.ProjectLabels(r.Context(), projectID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if labels == nil {
+		labels = []models.Label{}
+	}
+
+	writeJSON(w, http.StatusOK, labels)
+}
+
+// label loads the label named in the path, writing the error response and
+// returning false if it does not exist.
+func (h *LabelHandler) label(w http.ResponseWriter, r *http.Request) (*models.Label, bool) {
+	labelID, err := pathID(r, "id", "label")
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+
+	label, err := h.labels.GetByID(r.Context(), labelID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Label not found"))
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+	return label, true
+}
+
+// usableLabel loads the label named by labelId and checks that it can be
+// used in the project.
+func (h *LabelHandler) usableLabel(w http.ResponseWriter, r *http.Request, projectID int) (*models.Label, bool) {
+	labelID, err := pathID(r, "labelId", "label")
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+
+	label, err := h.labels.GetByID(r.Context(), labelID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, newAPIError(http.StatusUnprocessableEntity, CodeInvalidReference,
+			"Referenced record does not exist",
+			FieldError{Field: "labelId", Message: "label does not exist"}))
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+	if !label.UsableIn(projectID) {
+		writeError(w, r, newAPIError(http.StatusUnprocessableEntity, CodeInvalidReference,
+			"Label belongs to a different project",
+			FieldError{Field: "labelId", Message: "belongs to a different project"}))
+		return nil, false
+	}
+	return label, true
+}
+
+func (h *LabelHandler) projectID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	projectID, err := pathID(r, "id", "project")
+	if err != nil {
+		writeError(w, r, err)
+		return 0, false
+	}
+
+	if _, err := h.projects.GetByID(r.Context(), projectID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, notFound("Project not found"))
+			return 0, false
+		}
+		writeError(w, r, err)
+		return 0, false
+	}
+	return projectID, true
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+)
+
+func (s *testServer) createLabel(name string, projectID *int) models.Label {
+	s.t.Helper()
+	var label models.Label
+	s.expect(s.do("POST", "/labels", map[string]any{
+		"name":       name,
+		"project_id": projectID,
+	}), http.StatusOK, &label)
+	return label
+}
+
+func TestCreateLabel(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+
+	var label models.Label
+	s.expect(s.do("POST", "/labels", map[string]any{
+		"name":  "  backend ",
+		"color": "#1F883D",
+	}), http.StatusOK, &label)
+	if label.Name != "backend" || label.Color != "#1f883d" || label.ProjectID != nil {
+		t.Fatalf("label = %+v", label)
+	}
+	scoped := s.createLabel("parser", &project.ID)
+	if scoped.Color != models.DefaultLabelColor || *scoped.ProjectID != project.ID {
+		t.Fatalf("scoped label = %+v", scoped)
+	}
+
+	apiErr := s.expectError(s.do("POST", "/labels", map[string]any{
+		"name":       "",
+		"color":      "green",
+		"project_id": 999,
+	}), http.StatusBadRequest, CodeValidation)
+	want := []FieldError{
+		{Field: "name", Message: "is required"},
+		{Field: "color", Message: "must be a hex color like #1f883d"},
+		{Field: "project_id", Message: "does not exist"},
+	}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+
+	apiErr = s.expectError(s.do("PUT", fmt.Sprintf("/labels/%d", scoped.ID), map[string]any{
+		"name":       "parser",
+		"project_id": project.ID + 1,
+	}), http.StatusBadRequest, CodeValidation)
+	if apiErr.Details[0] != (FieldError{Field: "project_id", Message: "cannot be changed"}) {
+		t.Fatalf("details = %+v", apiErr.Details)
+	}
+
+	if got := ids(list[models.Label](s, "/labels?scope=global").Items, labelID); !slices.Equal(got, []int{label.ID}) {
+		t.Fatalf("global labels = %v", got)
+	}
+	if got := ids(list[models.Label](s, fmt.Sprintf("/labels?project_id=%d", project.ID)).Items, labelID); !slices.Equal(got, []int{label.ID, scoped.ID}) {
+		t.Fatalf("labels usable in %d = %v", project.ID, got)
+	}
+}
+
+func TestAttachLabel(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	other := s.createProject("Debugger", lead.ID)
+	task := s.createTask("Write the parser", project.ID, lead.ID)
+	global := s.createLabel("backend", nil)
+	scoped := s.createLabel("parser", &other.ID)
+
+	taskLabel := fmt.Sprintf("/tasks/%d/labels/%d", task.ID, global.ID)
+	projectLabel := fmt.Sprintf("/projects/%d/labels/%d", project.ID, global.ID)
+	// Attaching is idempotent.
+	for range 2 {
+		s.expect(s.do("POST", taskLabel, nil), http.StatusCreated, nil)
+		s.expect(s.do("POST", projectLabel, nil), http.StatusCreated, nil)
+	}
+	var labels []models.Label
+	s.expect(s.do("GET", fmt.Sprintf("/tasks/%d/labels", task.ID), nil), http.StatusOK, &labels)
+	if got := ids(labels, labelID); !slices.Equal(got, []int{global.ID}) {
+		t.Fatalf("task labels = %v", got)
+	}
+	s.expect(s.do("GET", fmt.Sprintf("/projects/%d/labels", project.ID), nil), http.StatusOK, &labels)
+	if got := ids(labels, labelID); !slices.Equal(got, []int{global.ID}) {
+		t.Fatalf("project labels = %v", got)
+	}
+
+	// Detaching once removes the label; again finds nothing to remove.
+	s.expect(s.do("DELETE", taskLabel, nil), http.StatusNoContent, nil)
+	s.expectError(s.do("DELETE", taskLabel, nil), http.StatusNotFound, CodeNotFound)
+	s.expect(s.do("DELETE", projectLabel, nil), http.StatusNoContent, nil)
+	s.expectError(s.do("DELETE", projectLabel, nil), http.StatusNotFound, CodeNotFound)
+	s.expect(s.do("GET", fmt.Sprintf("/tasks/%d/labels", task.ID), nil), http.StatusOK, &labels)
+	if len(labels) != 0 {
+		t.Fatalf("task labels after detaching = %v", labels)
+	}
+
+	apiErr := s.expectError(s.do("POST", fmt.Sprintf("/tasks/%d/labels/%d", task.ID, scoped.ID), nil),
+		http.StatusUnprocessableEntity, CodeInvalidReference)
+	if apiErr.Message != "Label belongs to a different project" {
+		t.Fatalf("got %+v", apiErr)
+	}
+	s.expectError(s.do("POST", fmt.Sprintf("/projects/%d/labels/%d", project.ID, scoped.ID), nil),
+		http.StatusUnprocessableEntity, CodeInvalidReference)
+	s.expectError(s.do("POST", fmt.Sprintf("/tasks/%d/labels/999", task.ID), nil), http.StatusUnprocessableEntity, CodeInvalidReference)
+	s.expectError(s.do("POST", fmt.Sprintf("/tasks/999/labels/%d", global.ID), nil), http.StatusNotFound, CodeNotFound)
+
+	// Deleting a label detaches it everywhere.
+	s.expect(s.do("POST", taskLabel, nil), http.StatusCreated, nil)
+	s.expect(s.do("DELETE", fmt.Sprintf("/labels/%d", global.ID), nil), http.StatusOK, nil)
+	s.expect(s.do("GET", fmt.Sprintf("/tasks/%d/labels", task.ID), nil), http.StatusOK, &labels)
+	if len(labels) != 0 {
+		t.Fatalf("task labels after deleting the label = %v", labels)
+	}
+}
+
+func TestLabelStats(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	compiler := s.createProject("Compiler", lead.ID)
+	debugger := s.createProject("Debugger", lead.ID)
+	backend := s.createLabel("backend", nil)
+	parser := s.createLabel("parser", &compiler.ID)
+	s.createLabel("stepper", &debugger.ID)
+
+	label := func(task models.Task, estimate, remaining any, l models.Label) {
+		t.Helper()
+		s.expect(s.do("PATCH", fmt.Sprintf("/tasks/%d", task.ID), map[string]any{
+			"estimate_hours":  estimate,
+			"remaining_hours": remaining,
+		}), http.StatusOK, nil)
+		s.expect(s.do("POST", fmt.Sprintf("/tasks/%d/labels/%d", task.ID, l.ID), nil), http.StatusCreated, nil)
+	}
+	lexer := s.createTask("Write the lexer", compiler.ID, lead.ID)
+	label(lexer, 8, 3, backend)
+	grammar := s.createTask("Write the grammar", compiler.ID, lead.ID)
+	label(grammar, 5, nil, backend)
+	codegen := s.createTask("Write the code generator", compiler.ID, lead.ID)
+	label(codegen, 13, nil, backend)
+	for _, to := range []models.TaskStatus{models.StatusInProgress, models.StatusInReview, models.StatusDone} {
+		s.transition(codegen, to, lead.ID)
+	}
+	dropped := s.createTask("Write a second parser", compiler.ID, lead.ID)
+	label(dropped, 40, nil, parser)
+	s.transition(dropped, models.StatusCancelled, lead.ID)
+	stepper := s.createTask("Write the stepper", debugger.ID, lead.ID)
+	label(stepper, 2, 1, backend)
+	s.expect(s.do("POST", fmt.Sprintf("/projects/%d/labels/%d", debugger.ID, backend.ID), nil), http.StatusCreated, nil)
+
+	var stats []models.LabelStats
+	s.expect(s.do("GET", fmt.Sprintf("/labels/stats?project_id=%d", compiler.ID), nil), http.StatusOK, &stats)
+	// Only the compiler's tasks count, and the debugger's own label is left
+	// out. Cancelled tasks count towards the total and the estimate only.
+	want := []models.LabelStats{
+		{LabelID: backend.ID, Name: "backend", Color: models.DefaultLabelColor,
+			Tasks: 3, OpenTasks: 2, DoneTasks: 1, Projects: 1, EstimateHours: 26, RemainingHours: 8},
+		{LabelID: parser.ID, ProjectID: &compiler.ID, Name: "parser", Color: models.DefaultLabelColor,
+			Tasks: 1, EstimateHours: 40},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+	for i := range want {
+		got := stats[i]
+		if !sameProject(got.ProjectID, want[i].ProjectID) {
+			t.Errorf("stats[%d] project = %v, want %v", i, got.ProjectID, want[i].ProjectID)
+		}
+		got.ProjectID = want[i].ProjectID
+		if got != want[i] {
+			t.Errorf("stats[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	s.expect(s.do("GET", "/labels/stats", nil), http.StatusOK, &stats)
+	if len(stats) != 3 || stats[0].Tasks != 4 || stats[0].RemainingHours != 9 || stats[2].Tasks != 0 {
+		t.Fatalf("stats across projects = %+v", stats)
+	}
+	s.expect(s.do("GET", "/labels/stats?scope=global", nil), http.StatusOK, &stats)
+	if len(stats) != 1 || stats[0].LabelID != backend.ID {
+		t.Fatalf("global stats = %+v", stats)
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
+)
+
+// listQuery reads list parameters from the query string, collecting every
+// problem so that they can be reported together.
+type listQuery struct {
+	values map[string][]string
+	errs   validation.Errors
+}
+
+func newListQuery(r *http.Request) *listQuery {
+	return &listQuery{values: r.URL.Query()}
+}
+
+func (q *listQuery) get(name string) string {
+	if v := q.values[name]; len(v) > 0 {
+		return strings.TrimSpace(v[0])
+	}
+	return ""
+}
+
+func (q *listQuery) stringParam(name string) string {
+	return q.get(name)
+}
+
+// statusParam reads a task status, accepting any letter case.
+func (q *listQuery) statusParam(name string) models.TaskStatus {
+	raw := q.get(name)
+	if raw == "" {
+		return ""
+	}
+	status, ok := parseStatus(raw)
+	if !ok {
+		q.errs.Add(name, statusMessage())
+	}
+	return status
+}
+
+// idParam reads a positive integer, returning 0 when the parameter is absent.
+func (q *listQuery) idParam(name string) int {
+	raw := q.get(name)
+	if raw == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		q.errs.Add(name, "must be a positive integer")
+		return 0
+	}
+	return n
+}
+
+// timeParam reads an RFC 3339 timestamp or a YYYY-MM-DD date.
+func (q *listQuery) timeParam(name string) *time.Time {
+	raw := q.get(name)
+	if raw == "" {
+		return nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return &t
+		}
+	}
+	q.errs.Add(name, "must be an RFC 3339 timestamp or YYYY-MM-DD date")
+	return nil
+}
+
+// dateParam reads a YYYY-MM-DD date.
+func (q *listQuery) dateParam(name string) *models.Date {
+	raw := q.get(name)
+	if raw == "" {
+		return nil
+	}
+	d, err := models.ParseDate(raw)
+	if err != nil {
+		q.errs.Add(name, "must be a YYYY-MM-DD date")
+		return nil
+	}
+	return &d
+}
+
+// today is the current date in UTC, against which due dates are judged.
+func today() models.Date {
+	return models.NewDate(time.Now().UTC())
+}
+
+// priorityParam reads a task priority, accepting any letter case.
+func (q *listQuery) priorityParam(name string) models.TaskPriority {
+	priority := models.TaskPriority(strings.ToUpper(q.get(name)))
+	if priority != "" && !priority.Valid() {
+		q.errs.Add(name, "must be one of P0, P1, P2, P3")
+	}
+	return priority
+}
+
+// boolParam reads true or false, returning false when absent.
+func (q *listQuery) boolParam(name string) bool {
+	raw := q.get(name)
+	if raw == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		q.errs.Add(name, "must be true or false")
+	}
+	return b
+}
+
+// includeDeleted reads include_deleted, which only administrators may set.
+func (q *listQuery) includeDeleted(r *http.Request) (bool, error) {
+	include := q.boolParam("include_deleted")
+	if include && !isAdmin(r) {
+		return false, forbidden("include_deleted requires administrator access")
+	}
+	return include, nil
+}
+
+// page reads limit, sort and cursor. sort names a field of sorts, prefixed
+// with - for descending order; the default is ascending by ID.
+func page[T any](q *listQuery, sorts map[string]repository.SortField[T]) repository.PageRequest {
+	return sortedPage(q, sorts, repository.Sort{Field: "id"})
+}
+
+// sortedPage is page with a different default sort order.
+func sortedPage[T any](q *listQuery, sorts map[string]repository.SortField[T], defaultSort repository.Sort) repository.PageRequest {
+	page := repository.PageRequest{Limit: repository.DefaultPageLimit, Sort: defaultSort}
+
+	if raw := q.get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > repository.MaxPageLimit {
+			q.errs.Add("limit", "must be between 1 and "+strconv.Itoa(repository.MaxPageLimit))
+		} else {
+			page.Limit = limit
+		}
+	}
+
+	if raw := q.get("sort"); raw != "" {
+		field, desc := strings.CutPrefix(raw, "-")
+		if _, ok := sorts[field]; ok {
+			page.Sort = repository.Sort{Field: field, Desc: desc}
+		} else {
+			fields := make([]string, 0, len(sorts))
+			for name := range sorts {
+				fields = append(fields, name)
+			}
+			sort.Strings(fields)
+			q.errs.Add("sort", "must be one of "+strings.Join(fields, ", ")+", optionally prefixed with -")
+		}
+	}
+
+	if raw := q.get("cursor"); raw != "" {
+		cursor, err := repository.DecodeCursor(raw)
+		if err == nil {
+			page.After = cursor
+			err = repository.CheckCursor(page, sorts)
+		}
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			q.errs.Add("cursor", "is invalid or does not match the requested sort")
+		}
+	}
+	return page
+}
+
+func (q *listQuery) err() error {
+	return q.errs.Err()
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"nstorm.com/main-backend/models"
+)
+
+func TestListPagination(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	var want []int
+	for _, title := range []string{"delta", "alpha", "charlie", "echo", "bravo"} {
+		want = append(want, s.createTask(title, project.ID, lead.ID).ID)
+	}
+	// want is now in ID order; byTitle is the same tasks sorted by title
+	// descending: echo, delta, charlie, bravo, alpha.
+	byTitle := []int{want[3], want[0], want[2], want[4], want[1]}
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"limit=2", want},
+		{"limit=2&sort=-title", byTitle},
+		{"limit=3&sort=id", want},
+		{"limit=200&sort=-created_at", []int{want[4], want[3], want[2], want[1], want[0]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got []int
+			path := "/tasks?" + tt.query
+			for pages := 0; ; pages++ {
+				if pages > len(tt.want) {
+					t.Fatal("pagination did not end")
+				}
+				page := list[models.Task](s, path)
+				if page.Total != len(tt.want) {
+					t.Fatalf("total = %d, want %d", page.Total, len(tt.want))
+				}
+				got = append(got, ids(page.Items, taskID)...)
+				if page.NextCursor == "" {
+					break
+				}
+				path = "/tasks?" + tt.query + "&cursor=" + url.QueryEscape(page.NextCursor)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	var dev models.Employee
+	s.expect(s.do("POST", "/employees", map[string]any{
+		"name":   "Ada Lovelace",
+		"email":  "ada@example.com",
+		"role":   models.RoleProjectManager,
+		"skills": []string{"go", "math"},
+	}), http.StatusOK, &dev)
+	compiler := s.createProject("Compiler", lead.ID)
+	engine := s.createProject("Engine", dev.ID)
+	parser := s.createTask("Write the parser", compiler.ID, lead.ID)
+	notes := s.createTask("Write the notes", engine.ID, dev.ID)
+	linker := s.createTask("Write the linker", compiler.ID, dev.ID)
+	for _, to := range []models.TaskStatus{models.StatusInProgress, models.StatusInReview, models.StatusDone} {
+		s.transition(linker, to, dev.ID)
+	}
+
+	employees := func(path string) []int { return ids(list[models.Employee](s, path).Items, employeeID) }
+	projects := func(path string) []int { return ids(list[models.Project](s, path).Items, projectID) }
+	tasks := func(path string) []int { return ids(list[models.Task](s, path).Items, taskID) }
+	future := url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339))
+	tests := []struct {
+		path string
+		list func(string) []int
+		want []int
+	}{
+		{"/employees?role=" + string(models.RoleProjectManager), employees, []int{dev.ID}},
+		{"/employees?skill=math", employees, []int{dev.ID}},
+		{"/employees?skill=cobol", employees, []int{}},
+		{fmt.Sprintf("/projects?lead_id=%d", dev.ID), projects, []int{engine.ID}},
+		{fmt.Sprintf("/tasks?project_id=%d", compiler.ID), tasks, []int{parser.ID, linker.ID}},
+		{fmt.Sprintf("/tasks?project_id=%d&assigned_to=%d", compiler.ID, dev.ID), tasks, []int{linker.ID}},
+		{"/tasks?status=TODO", tasks, []int{parser.ID, notes.ID}},
+		{"/tasks?created_before=" + future, tasks, []int{parser.ID, notes.ID, linker.ID}},
+		{"/tasks?created_after=" + future, tasks, []int{}},
+		{"/tasks?created_after=2000-01-01", tasks, []int{parser.ID, notes.ID, linker.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := tt.list(tt.path); !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListInvalidParameters(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	s.createTask("Write the parser", project.ID, lead.ID)
+	s.createTask("Write the linker", project.ID, lead.ID)
+	page := list[models.Task](s, "/tasks?limit=1&sort=title")
+
+	apiErr := s.expectError(s.do("GET", "/tasks?limit=0&sort=salary&project_id=x&created_after=yesterday&cursor=junk", nil),
+		http.StatusBadRequest, CodeValidation)
+	want := []FieldError{
+		{Field: "project_id", Message: "must be a positive integer"},
+		{Field: "created_after", Message: "must be an RFC 3339 timestamp or YYYY-MM-DD date"},
+		{Field: "limit", Message: "must be between 1 and 200"},
+		{Field: "sort", Message: "must be one of created_at, due_date, estimate_hours, id, priority, remaining_hours, start_date, status, title, optionally prefixed with -"},
+		{Field: "cursor", Message: "is invalid or does not match the requested sort"},
+	}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+
+	// A cursor only continues the sort it was issued for.
+	apiErr = s.expectError(s.do("GET", "/tasks?sort=-title&cursor="+url.QueryEscape(page.NextCursor), nil),
+		http.StatusBadRequest, CodeValidation)
+	if len(apiErr.Details) != 1 || apiErr.Details[0].Field != "cursor" {
+		t.Fatalf("details = %+v, want cursor", apiErr.Details)
+	}
+
+	s.expectError(s.do("GET", "/employees?role=Wizard", nil), http.StatusBadRequest, CodeValidation)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestTimeouts(t *testing.T) {
+	router := mux.NewRouter()
+	var remaining time.Duration
+	record := func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		if !ok {
+			t.Fatal("request has no deadline")
+		}
+		remaining = time.Until(deadline)
+	}
+	router.HandleFunc("/fast", record).Name("fast")
+	router.HandleFunc("/slow", record).Name("slow")
+	router.HandleFunc("/unnamed", record)
+	router.Use(Timeouts(time.Second, map[string]time.Duration{"slow": time.Minute}))
+
+	tests := []struct {
+		path string
+		want time.Duration
+	}{
+		{"/fast", time.Second},
+		{"/slow", time.Minute},
+		{"/unnamed", time.Second},
+	}
+	for _, tt := range tests {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.path, nil))
+		if remaining > tt.want || remaining < tt.want-100*time.Millisecond {
+			t.Errorf("%s: deadline in %v, want %v", tt.path, remaining, tt.want)
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "caller-id")
+	handler.ServeHTTP(rec, req)
+	if seen != "caller-id" || rec.Header().Get(RequestIDHeader) != "caller-id" {
+		t.Fatalf("context has %q, response has %q; want the caller's ID", seen, rec.Header().Get(RequestIDHeader))
+	}
+
+	for _, given := range []string{"", strings.Repeat("x", 129)} {
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(RequestIDHeader, given)
+		handler.ServeHTTP(rec, req)
+		if len(seen) != 32 || seen == given || rec.Header().Get(RequestIDHeader) != seen {
+			t.Fatalf("given %q: context has %q, response has %q; want a fresh ID in both", given, seen, rec.Header().Get(RequestIDHeader))
+		}
+	}
+}
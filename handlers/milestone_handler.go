@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
+)
+
+type MilestoneHandler struct {
+	milestones repository.MilestoneRepository
+	projects   repository.ProjectRepository
+	tasks      repository.TaskRepository
+}
+
+func NewMilestoneHandler(repos *repository.Repositories) *MilestoneHandler {
+	return &MilestoneHandler{
+		milestones: repos.Milestones,
+		projects:   repos.Projects,
+		tasks:      repos.Tasks,
+	}
+}
+
+func validateMilestone(ctx context.Context, projects repository.ProjectRepository, milestone *models.Milestone) error {
+	errs := validation.Struct(milestone)
+	if err := checkExists(ctx, &errs, "project_id", milestone.ProjectID, projects.GetByID); err != nil {
+		return err
+	}
+	return errs.Err()
+}
+
+// parseMilestoneStatus normalises a milestone status given in any letter
+// case.
+func parseMilestoneStatus(raw string) (models.MilestoneStatus, bool) {
+	status := models.MilestoneStatus(strings.ToUpper(strings.TrimSpace(raw)))
+	return status, status.Valid()
+}
+
+// CreateMilestone adds a milestone to a project. It is open unless a status
+// is given.
+func (h *MilestoneHandler) CreateMilestone(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var milestone models.Milestone
+	if err := decodeJSON(r, &milestone); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	milestone.Title = strings.TrimSpace(milestone.Title)
+	if milestone.Status == "" {
+		milestone.Status = models.MilestoneOpen
+	} else {
+		milestone.Status, _ = parseMilestoneStatus(string(milestone.Status))
+	}
+	if err := validateMilestone(ctx, h.projects, &milestone); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.milestones.Create(ctx, &milestone); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, milestone.Version)
+	writeJSON(w, http.StatusOK, milestone)
+}
+
+func (h *MilestoneHandler) GetMilestone(w http.ResponseWriter, r *http.Request) {
+	milestone, ok := h.milestone(w, r)
+	if !ok {
+		return
+	}
+
+	if notModified(w, r, milestone.Version) {
+		return
+	}
+	writeJSON(w, http.StatusOK, milestone)
+}
+
+// GetMilestones lists milestones, optionally only those of project_id or
+// with one status.
+func (h *MilestoneHandler) GetMilestones(w http.ResponseWriter, r *http.Request) {
+	q := newListQuery(r)
+	filter := repository.MilestoneFilter{
+		ProjectID: q.idParam("project_id"),
+		Status:    milestoneStatusParam(q),
+	}
+	filter.Page = page(q, repository.MilestoneSorts)
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	milestones, err := h.milestones.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, milestones)
+}
+
+// milestoneStatusParam reads the status parameter, accepting any letter
+// case.
+func milestoneStatusParam(q *listQuery) models.MilestoneStatus {
+	raw := q.get("status")
+	if raw == "" {
+		return ""
+	}
+	status, ok := parseMilestoneStatus(raw)
+	if !ok {
+		q.errs.Add("status", "must be one of OPEN, COMPLETED, CANCELLED")
+	}
+	return status
+}
+
+// UpdateMilestone replaces a milestone's title, description, target date
+// and status. The project cannot be changed; an empty status keeps the
+// current one.
+func (h *MilestoneHandler) UpdateMilestone(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current, ok := h.milestone(w, r)
+	if !ok {
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var milestone models.Milestone
+	if err := decodeJSON(r, &milestone); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if milestone.ProjectID != 0 && milestone.ProjectID != current.ProjectID {
+		writeError(w, r, validation.Errors{{Field: "project_id", Message: "cannot be changed"}})
+		return
+	}
+	milestone.ID = current.ID
+	milestone.ProjectID = current.ProjectID
+	milestone.Version = version
+	milestone.Title = strings.TrimSpace(milestone.Title)
+	if milestone.Status == "" {
+		milestone.Status = current.Status
+	} else {
+		milestone.Status, _ = parseMilestoneStatus(string(milestone.Status))
+	}
+	if err := validateMilestone(ctx, h.projects, &milestone); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = h.milestones.Update(ctx, &milestone)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Milestone not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, milestone.Version)
+	writeJSON(w, http.StatusOK, milestone)
+}
+
+// DeleteMilestone deletes a milestone and unlinks its tasks.
+func (h *MilestoneHandler) DeleteMilestone(w http.ResponseWriter, r *http.Request) {
+	milestoneID, err := pathID(r, "id", "milestone")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = h.milestones.Delete(r.Context(), milestoneID, version)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Milestone not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetMilestoneTasks lists the tasks linked to a milestone, ordered by ID.
+func (h *MilestoneHandler) GetMilestoneTasks(w http.ResponseWriter, r *http.Request) {
+	milestone, ok := h.milestone(w, r)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.milestones.Tasks(r.Context(), milestone.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// GetMilestoneProgress reports how far a milestone's tasks have got and
+// whether the work left fits before its target date.
+func (h *MilestoneHandler) GetMilestoneProgress(w http.ResponseWriter, r *http.Request) {
+	milestone, ok := h.milestone(w, r)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.milestones.Tasks(r.Context(), milestone.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.NewMilestoneProgress(*milestone, tasks, today()))
+}
+
+// GetProjectMilestones returns a project's milestones with their progress,
+// earliest target date first. They can be narrowed to one status, and with
+// at_risk=true to those at risk.
+func (h *MilestoneHandler) GetProjectMilestones(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID, err := pathID(r, "id", "project")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	q := newListQuery(r)
+	status := milestoneStatusParam(q)
+	atRisk := q.boolParam("at_risk")
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if _, err := h.projects.GetByID(ctx, projectID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, notFound("Project not found"))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	milestones, err := h.milestones.ListByProject(ctx, projectID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	tasks, err := h.tasks.ListByProject(ctx, projectID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	linked := make(map[int][]models.Task)
+	for _, task := range tasks {
+		if task.MilestoneID != nil {
+			linked[*task.MilestoneID] = append(linked[*task.MilestoneID], task)
+		}
+	}
+
+	date := today()
+	summaries := []models.MilestoneSummary{}
+	for _, milestone := range milestones {
+		if status != "" && milestone.Status != status {
+			continue
+		}
+		progress := models.NewMilestoneProgress(milestone, linked[milestone.ID], date)
+		if atRisk && !progress.AtRisk {
+			continue
+		}
+		summaries = append(summaries, models.MilestoneSummary{Milestone: milestone, Progress: progress})
+	}
+
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// milestone loads the milestone named in the path.
+func (h *MilestoneHandler) milestone(w http.ResponseWriter, r *http.Request) (*models.Milestone, bool) {
+	milestoneID, err := pathID(r, "id", "milestone")
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+
+	milestone, err := h.milestones.GetByID(r.Context(), milestoneID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Milestone not found"))
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+	return milestone, true
+}
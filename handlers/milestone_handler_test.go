@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+)
+
+func (s *testServer) createMilestone(title string, projectID int, targetDate models.Date) models.Milestone {
+	s.t.Helper()
+	var milestone models.Milestone
+	s.expect(s.do("POST", "/milestones", map[string]any{
+		"title":       title,
+		"project_id":  projectID,
+		"target_date": targetDate,
+	}), http.StatusOK, &milestone)
+	return milestone
+}
+
+func (s *testServer) linkMilestone(task models.Task, milestoneID int) models.Task {
+	s.t.Helper()
+	var linked models.Task
+	s.expect(s.do("PATCH", fmt.Sprintf("/tasks/%d", task.ID), map[string]any{"milestone_id": milestoneID}), http.StatusOK, &linked)
+	return linked
+}
+
+func TestMilestones(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	beta := s.createMilestone(" Beta ", project.ID, today().AddDays(365))
+	alpha := s.createMilestone("Alpha", project.ID, today().AddDays(-1))
+	if beta.Status != models.MilestoneOpen || beta.Title != "Beta" {
+		t.Fatalf("new milestone = %+v", beta)
+	}
+
+	var shipped models.Milestone
+	s.expect(s.do("POST", "/milestones", map[string]any{
+		"title":       "Preview",
+		"project_id":  project.ID,
+		"target_date": today().AddDays(-30),
+		"status":      "completed",
+	}), http.StatusOK, &shipped)
+	if shipped.Status != models.MilestoneCompleted {
+		t.Fatalf("status = %s, want COMPLETED", shipped.Status)
+	}
+
+	page := list[models.Milestone](s, fmt.Sprintf("/milestones?project_id=%d&status=open&sort=target_date", project.ID))
+	if got := ids(page.Items, milestoneID); !slices.Equal(got, []int{alpha.ID, beta.ID}) {
+		t.Fatalf("open milestones = %v", got)
+	}
+
+	lexer := s.linkMilestone(s.createTask("Write the lexer", project.ID, lead.ID), alpha.ID)
+	parser := s.linkMilestone(s.createTask("Write the parser", project.ID, lead.ID), beta.ID)
+	s.linkMilestone(s.createTask("Write the code generator", project.ID, lead.ID), beta.ID)
+	for _, to := range []models.TaskStatus{models.StatusInProgress, models.StatusInReview, models.StatusDone} {
+		s.transition(parser, to, lead.ID)
+	}
+
+	var tasks []models.Task
+	s.expect(s.do("GET", fmt.Sprintf("/milestones/%d/tasks", alpha.ID), nil), http.StatusOK, &tasks)
+	if got := ids(tasks, taskID); !slices.Equal(got, []int{lexer.ID}) {
+		t.Fatalf("milestone tasks = %v", got)
+	}
+
+	// Alpha's target date has passed with its task still open.
+	var progress models.MilestoneProgress
+	s.expect(s.do("GET", fmt.Sprintf("/milestones/%d/progress", alpha.ID), nil), http.StatusOK, &progress)
+	if !progress.AtRisk || progress.WorkingDaysLeft != 0 || !slices.Equal(progress.OverloadedAssignees, []int{lead.ID}) {
+		t.Fatalf("alpha progress = %+v", progress)
+	}
+	s.expect(s.do("GET", fmt.Sprintf("/milestones/%d/progress", beta.ID), nil), http.StatusOK, &progress)
+	if progress.AtRisk || progress.Tasks != 2 || progress.PercentComplete != 50 {
+		t.Fatalf("beta progress = %+v", progress)
+	}
+
+	var summaries []models.MilestoneSummary
+	s.expect(s.do("GET", fmt.Sprintf("/projects/%d/milestones", project.ID), nil), http.StatusOK, &summaries)
+	if got := ids(summaries, func(m models.MilestoneSummary) int { return m.ID }); !slices.Equal(got, []int{shipped.ID, alpha.ID, beta.ID}) {
+		t.Fatalf("project milestones = %v", got)
+	}
+	s.expect(s.do("GET", fmt.Sprintf("/projects/%d/milestones?at_risk=true", project.ID), nil), http.StatusOK, &summaries)
+	if len(summaries) != 1 || summaries[0].ID != alpha.ID {
+		t.Fatalf("at-risk milestones = %+v", summaries)
+	}
+	s.expectError(s.do("GET", "/projects/999/milestones", nil), http.StatusNotFound, CodeNotFound)
+
+	// Deleting a milestone unlinks its tasks.
+	s.expect(s.do("DELETE", fmt.Sprintf("/milestones/%d", alpha.ID), nil), http.StatusOK, nil)
+	if got := s.getTask(lexer.ID); got.MilestoneID != nil {
+		t.Fatalf("task of a deleted milestone = %+v", got)
+	}
+	s.expectError(s.do("GET", fmt.Sprintf("/milestones/%d", alpha.ID), nil), http.StatusNotFound, CodeNotFound)
+}
+
+func TestMilestoneValidation(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	other := s.createProject("Debugger", lead.ID)
+	milestone := s.createMilestone("Beta", project.ID, today().AddDays(30))
+	foreign := s.createMilestone("Beta", other.ID, today().AddDays(30))
+
+	apiErr := s.expectError(s.do("POST", "/milestones", map[string]any{
+		"title":      " ",
+		"project_id": 999,
+		"status":     "someday",
+	}), http.StatusBadRequest, CodeValidation)
+	want := []FieldError{
+		{Field: "title", Message: "is required"},
+		{Field: "target_date", Message: "is required"},
+		{Field: "status", Message: "SOMEDAY is not a valid value"},
+		{Field: "project_id", Message: "does not exist"},
+	}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+
+	apiErr = s.expectError(s.do("PUT", fmt.Sprintf("/milestones/%d", milestone.ID), map[string]any{
+		"title":       "Beta",
+		"project_id":  other.ID,
+		"target_date": milestone.TargetDate,
+	}), http.StatusBadRequest, CodeValidation)
+	if apiErr.Details[0] != (FieldError{Field: "project_id", Message: "cannot be changed"}) {
+		t.Fatalf("details = %+v", apiErr.Details)
+	}
+
+	// A linked task keeps its milestone once it is completed, but no task
+	// can join it after that.
+	linked := s.linkMilestone(s.createTask("Write the parser", project.ID, lead.ID), milestone.ID)
+	var completed models.Milestone
+	s.expect(s.do("PUT", fmt.Sprintf("/milestones/%d", milestone.ID), map[string]any{
+		"title":       "Beta",
+		"target_date": milestone.TargetDate,
+		"status":      models.MilestoneCompleted,
+	}), http.StatusOK, &completed)
+	if completed.Status != models.MilestoneCompleted || completed.Version != milestone.Version+1 {
+		t.Fatalf("updated milestone = %+v", completed)
+	}
+	s.expect(s.do("PATCH", fmt.Sprintf("/tasks/%d", linked.ID), map[string]any{"title": "Write the new parser"}), http.StatusOK, nil)
+
+	task := s.createTask("Write the lexer", project.ID, lead.ID)
+	tests := []struct {
+		milestoneID int
+		message     string
+	}{
+		{999, "does not exist"},
+		{foreign.ID, "must belong to the same project"},
+		{milestone.ID, "must be an open milestone"},
+	}
+	for _, tt := range tests {
+		apiErr := s.expectError(s.do("PATCH", fmt.Sprintf("/tasks/%d", task.ID), map[string]any{"milestone_id": tt.milestoneID}),
+			http.StatusBadRequest, CodeValidation)
+		if apiErr.Details[0] != (FieldError{Field: "milestone_id", Message: tt.message}) {
+			t.Fatalf("milestone %d: details = %+v", tt.milestoneID, apiErr.Details)
+		}
+	}
+
+	apiErr = s.expectError(s.do("GET", "/milestones?status=someday", nil), http.StatusBadRequest, CodeValidation)
+	if apiErr.Details[0].Field != "status" {
+		t.Fatalf("details = %+v", apiErr.Details)
+	}
+}
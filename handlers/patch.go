@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+
+	"nstorm.com/main-backend/patch"
+)
+
+// readOnlyFields may appear in a patched document but must keep their
+// current value.
+var readOnlyFields = []string{"id", "rank", "version", "created_at", "updated_at", "deleted_at", "archived_at", "projects", "tasks"}
+
+// applyPatch applies the request body to current as a JSON Merge Patch
+// (application/merge-patch+json or application/json) or a JSON Patch
+// (application/json-patch+json) and decodes the result into a new T. It also
+// returns the JSON names of the top-level fields whose value changed.
+func applyPatch[T any](r *http.Request, current *T) (*T, []string, error) {
+	var apply func(original, patch []byte) ([]byte, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case patch.MergePatchContentType, "application/json":
+		apply = patch.MergePatch
+	case patch.JSONPatchContentType:
+		apply = patch.JSONPatch
+	default:
+		return nil, nil, newAPIError(http.StatusUnsupportedMediaType, CodeUnsupportedMediaType,
+			"Unsupported patch format",
+			FieldError{Field: "Content-Type", Message: "must be " + patch.MergePatchContentType + " or " + patch.JSONPatchContentType})
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, badRequest("Invalid request body", FieldError{Field: "body", Message: err.Error()})
+	}
+	original, err := json.Marshal(current)
+	if err != nil {
+		return nil, nil, err
+	}
+	patched, err := apply(original, body)
+	if err != nil {
+		return nil, nil, badRequest("Invalid patch", FieldError{Field: "body", Message: err.Error()})
+	}
+
+	var before, after map[string]any
+	if err := json.Unmarshal(original, &before); err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(patched, &after); err != nil {
+		return nil, nil, badRequest("Invalid patch", FieldError{Field: "body", Message: "result must be a JSON object"})
+	}
+	var changed []string
+	for key, value := range after {
+		if old, ok := before[key]; !ok || !reflect.DeepEqual(old, value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+
+	var errs []FieldError
+	for _, field := range changed {
+		if slices.Contains(readOnlyFields, field) {
+			errs = append(errs, FieldError{Field: field, Message: "is read-only"})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, nil, badRequest("Invalid patch", errs...)
+	}
+
+	merged := new(T)
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(merged); err != nil {
+		return nil, nil, badRequest("Invalid patch", FieldError{Field: "body", Message: err.Error()})
+	}
+	return merged, changed, nil
+}
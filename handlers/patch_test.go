@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/patch"
+)
+
+func TestPatchMerge(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	task := s.createTask("Write the linker", project.ID, lead.ID)
+	path := fmt.Sprintf("/tasks/%d", task.ID)
+
+	for _, contentType := range []string{"application/json", patch.MergePatchContentType} {
+		var patched models.Task
+		s.expect(s.do("PATCH", path, map[string]any{"description": contentType}, "Content-Type", contentType), http.StatusOK, &patched)
+		if patched.Description != contentType || patched.Title != "Write the linker" || patched.Status != "TODO" {
+			t.Fatalf("patched task %+v", patched)
+		}
+	}
+
+	var got models.Task
+	s.expect(s.do("GET", path, nil), http.StatusOK, &got)
+	if got.Description != patch.MergePatchContentType || got.Title != "Write the linker" {
+		t.Fatalf("got task %+v after patch", got)
+	}
+}
+
+func TestPatchJSONPatch(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.createEmployee("Ada Lovelace", "ada@example.com")
+	path := fmt.Sprintf("/employees/%d", employee.ID)
+
+	var patched models.Employee
+	s.expect(s.do("PATCH", path, []map[string]any{
+		{"op": "test", "path": "/name", "value": "Ada Lovelace"},
+		{"op": "replace", "path": "/name", "value": "Ada King"},
+		{"op": "add", "path": "/skills", "value": []string{"math"}},
+		{"op": "add", "path": "/skills/-", "value": "poetry"},
+	}, "Content-Type", patch.JSONPatchContentType), http.StatusOK, &patched)
+	if patched.Name != "Ada King" || !slices.Equal(patched.Skills, []string{"math", "poetry"}) {
+		t.Fatalf("patched employee %+v", patched)
+	}
+
+	// A failing test operation leaves the employee untouched.
+	s.expectError(s.do("PATCH", path, []map[string]any{
+		{"op": "test", "path": "/name", "value": "Ada Lovelace"},
+		{"op": "replace", "path": "/name", "value": "Augusta"},
+	}, "Content-Type", patch.JSONPatchContentType), http.StatusBadRequest, CodeBadRequest)
+	var got models.Employee
+	s.expect(s.do("GET", path, nil), http.StatusOK, &got)
+	if got.Name != "Ada King" {
+		t.Fatalf("name = %q after failed patch", got.Name)
+	}
+}
+
+func TestPatchErrors(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	path := fmt.Sprintf("/projects/%d", project.ID)
+
+	s.expectError(s.do("PATCH", path, map[string]any{"name": "COBOL"}, "Content-Type", "text/plain"),
+		http.StatusUnsupportedMediaType, CodeUnsupportedMediaType)
+
+	apiErr := s.expectError(s.do("PATCH", path, map[string]any{"id": 99, "created_at": nil}), http.StatusBadRequest, CodeBadRequest)
+	want := []FieldError{
+		{Field: "created_at", Message: "is read-only"},
+		{Field: "id", Message: "is read-only"},
+	}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+
+	// Deleting and archiving have their own endpoints.
+	apiErr = s.expectError(s.do("PATCH", path, map[string]any{"deleted_at": "2024-03-01T00:00:00Z", "archived_at": "2024-03-01T00:00:00Z"}),
+		http.StatusBadRequest, CodeBadRequest)
+	want = []FieldError{
+		{Field: "archived_at", Message: "is read-only"},
+		{Field: "deleted_at", Message: "is read-only"},
+	}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+	task := s.createTask("Write the parser", project.ID, lead.ID)
+	apiErr = s.expectError(s.do("PATCH", fmt.Sprintf("/tasks/%d", task.ID), map[string]any{"deleted_at": "2024-03-01T00:00:00Z"}),
+		http.StatusBadRequest, CodeBadRequest)
+	if !slices.Equal(apiErr.Details, []FieldError{{Field: "deleted_at", Message: "is read-only"}}) {
+		t.Fatalf("details = %+v", apiErr.Details)
+	}
+
+	// Sending a read-only field unchanged is fine.
+	s.expect(s.do("PATCH", path, map[string]any{"id": project.ID, "description": "A-0"}), http.StatusOK, nil)
+
+	s.expectError(s.do("PATCH", path, map[string]any{"budget": 100}), http.StatusBadRequest, CodeBadRequest)
+	s.expectError(s.do("PATCH", path, []any{1, 2}), http.StatusBadRequest, CodeBadRequest)
+	s.expectError(s.do("PATCH", path, map[string]any{"name": nil, "lead_id": 999}), http.StatusBadRequest, CodeValidation)
+	s.expectError(s.do("PATCH", "/projects/999", map[string]any{"name": "COBOL"}), http.StatusNotFound, CodeNotFound)
+
+	var got models.Project
+	s.expect(s.do("GET", path, nil), http.StatusOK, &got)
+	if got.Name != "Compiler" || got.LeadID != lead.ID || got.Description != "A-0" {
+		t.Fatalf("got project %+v after rejected patches", got)
+	}
+}
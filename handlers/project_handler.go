@@ -2,188 +2,336 @@ package handlers
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
 
-	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5"
+	"nstorm.com/main-backend/config"
 	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
 )
 
 type ProjectHandler struct {
-	db *pgx.Conn
+	projects  repository.ProjectRepository
+	employees repository.EmployeeRepository
+	tasks     repository.TaskRepository
+
+	chatURL    string
+	chatClient *http.Client
 }
 
-func NewProjectHandler(db *pgx.Conn) *ProjectHandler {
-	return &ProjectHandler{db: db}
+func NewProjectHandler(repos *repository.Repositories, chat config.ChatConfig) *ProjectHandler {
+	return &ProjectHandler{
+		projects:   repos.Projects,
+		employees:  repos.Employees,
+		tasks:      repos.Tasks,
+		chatURL:    chat.URL,
+		chatClient: &http.Client{Timeout: chat.Timeout},
+	}
 }
 
 func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
 	var project models.Project
-	if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &project); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := validateProject(ctx, h.employees, &project); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	fmt.Println(project.LeadID)
-	query := `
-        INSERT INTO projects (name, description, lead_id)
-        VALUES ($1, $2, $3)
-        RETURNING id, created_at`
-
-	err := h.db.QueryRow(ctx, query,
-		project.Name,
-		project.Description,
-		project.LeadID,
-	).Scan(&project.ID, &project.CreatedAt)
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.projects.Create(ctx, &project); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(project)
+	setETag(w, project.Version)
+	writeJSON(w, http.StatusOK, project)
 }
 
 // GetProjectByID retrieves a project by its ID
 func (h *ProjectHandler) GetProjectByID(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	projectID, err := strconv.Atoi(vars["id"])
+	projectID, err := pathID(r, "id", "project")
 	if err != nil {
-		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	query := `
-        SELECT id, name, description, lead_id, created_at 
-        FROM projects 
-        WHERE id = $1`
-
-	var project models.Project
-	err = h.db.QueryRow(context.Background(), query, projectID).Scan(
-		&project.ID,
-		&project.Name,
-		&project.Description,
-		&project.LeadID,
-		&project.CreatedAt,
-	)
-
-	if err == pgx.ErrNoRows {
-		http.Error(w, "Project not found", http.StatusNotFound)
+	project, err := getIncludingDeleted(r, projectID, h.projects.GetByID, h.projects.GetDeleted)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Project not found"))
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(project)
+	if notModified(w, r, project.Version) {
+		return
+	}
+	writeJSON(w, http.StatusOK, project)
 }
 
 // UpdateProject updates an existing project
 func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	projectID, err := strconv.Atoi(vars["id"])
+	projectID, err := pathID(r, "id", "project")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := ifMatch(r)
 	if err != nil {
-		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
 	var project models.Project
-	if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &project); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	project.ID = projectID
+	project.Version = version
+	if err := validateProject(r.Context(), h.employees, &project); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	query := `
-        UPDATE projects 
-        SET name = $1, description = $2, lead_id = $3
-        WHERE id = $4
-        RETURNING id, name, description, lead_id, created_at`
+	if err := h.projects.Update(r.Context(), &project); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, notFound("Project not found"))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
 
-	err = h.db.QueryRow(context.Background(), query,
-		project.Name,
-		project.Description,
-		project.LeadID,
-		projectID,
-	).Scan(&project.ID, &project.Name, &project.Description, &project.LeadID, &project.CreatedAt)
+	setETag(w, project.Version)
+	writeJSON(w, http.StatusOK, project)
+}
 
+// PatchProject applies a JSON Merge Patch or JSON Patch to a project, writing only
+// the fields it changes.
+func (h *ProjectHandler) PatchProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID, err := pathID(r, "id", "project")
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			http.Error(w, "Project not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+
+	current, err := h.projects.GetByID(ctx, projectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Project not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(project)
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	project, fields, err := applyPatch(r, current)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	project.Version = version
+	if err := validateProject(ctx, h.employees, project); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = h.projects.Patch(ctx, project, fields)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Project not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, project.Version)
+	writeJSON(w, http.StatusOK, project)
 }
 
-// DeleteProject deletes a project by its ID
+// DeleteProject deletes a project by its ID, together with its tasks. It
+// can be restored until it is purged.
 func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	projectID, err := strconv.Atoi(vars["id"])
+	projectID, err := pathID(r, "id", "project")
 	if err != nil {
-		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
-
-	query := `DELETE FROM projects WHERE id = $1`
-	result, err := h.db.Exec(context.Background(), query, projectID)
+	version, err := ifMatch(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	if result.RowsAffected() == 0 {
-		http.Error(w, "Project not found", http.StatusNotFound)
+	err = h.projects.Delete(r.Context(), projectID, version)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Project not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
-// GetAllProjects retrieves all projects
+// GetAllProjects lists projects, optionally filtered by lead or label.
+// Archived projects are listed with include_archived=true, and deleted ones
+// for administrators asking for include_deleted=true.
 func (h *ProjectHandler) GetAllProjects(w http.ResponseWriter, r *http.Request) {
-	query := `
-        SELECT id, name, description, lead_id, created_at 
-        FROM projects`
+	q := newListQuery(r)
+	includeDeleted, err := q.includeDeleted(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	filter := repository.ProjectFilter{
+		LeadID:          q.idParam("lead_id"),
+		LabelID:         q.idParam("label_id"),
+		IncludeArchived: q.boolParam("include_archived"),
+		IncludeDeleted:  includeDeleted,
+		Page:            page(q, repository.ProjectSorts),
+	}
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	rows, err := h.db.Query(context.Background(), query)
+	projects, err := h.projects.List(r.Context(), filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	var projects []models.Project
-	for rows.Next() {
-		var project models.Project
-		err := rows.Scan(
-			&project.ID,
-			&project.Name,
-			&project.Description,
-			&project.LeadID,
-			&project.CreatedAt,
-		)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, projects)
+}
+
+// projectArchivedConflict rejects an operation the project's archived state
+// does not allow.
+func projectArchivedConflict(message string) *APIError {
+	return newAPIError(http.StatusConflict, CodeConflict, message)
+}
+
+// RestoreProject undoes the deletion of a project, bringing back the tasks
+// deleted with it. Its lead must not be deleted. Only administrators may
+// restore.
+func (h *ProjectHandler) RestoreProject(w http.ResponseWriter, r *http.Request) {
+	if err := requireAdmin(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	projectID, err := pathID(r, "id", "project")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	current, err := h.projects.GetDeleted(ctx, projectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Deleted project not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if _, err := h.employees.GetByID(ctx, current.LeadID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, newAPIError(http.StatusConflict, CodeConflict, "Project lead is deleted; restore them first",
+				FieldError{Field: "lead_id", Message: "does not exist"}))
 			return
 		}
-		projects = append(projects, project)
+		writeError(w, r, err)
+		return
+	}
+
+	project, err := h.projects.Restore(ctx, projectID, current.Version)
+	if err != nil {
+		writeError(w, r, changeError(err, version, "Deleted project"))
+		return
+	}
+
+	setETag(w, project.Version)
+	writeJSON(w, http.StatusOK, project)
+}
+
+// ArchiveProject archives a project. It stays readable, but is left out of
+// project lists by default and takes no new tasks.
+func (h *ProjectHandler) ArchiveProject(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true)
+}
+
+// UnarchiveProject returns an archived project to normal use.
+func (h *ProjectHandler) UnarchiveProject(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false)
+}
+
+func (h *ProjectHandler) setArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	projectID, err := pathID(r, "id", "project")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	current, err := h.projects.GetByID(ctx, projectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Project not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var project *models.Project
+	switch {
+	case archived && current.ArchivedAt != nil:
+		err = projectArchivedConflict("Project is already archived")
+	case !archived && current.ArchivedAt == nil:
+		err = projectArchivedConflict("Project is not archived")
+	case archived:
+		project, err = h.projects.Archive(ctx, projectID, current.Version)
+	default:
+		project, err = h.projects.Unarchive(ctx, projectID, current.Version)
+	}
+	if err != nil {
+		writeError(w, r, changeError(err, version, "Project"))
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(projects)
+	setETag(w, project.Version)
+	writeJSON(w, http.StatusOK, project)
 }
 
 type ChatResponse struct {
@@ -197,11 +345,19 @@ type TaskAssignment struct {
 	AssignedTo string `json:"assigned_to"`
 }
 
+// upstreamError reports a failure of the task generation service, unless
+// the request itself was cancelled or timed out while waiting for it.
+func upstreamError(r *http.Request, err error, message string) error {
+	if r.Context().Err() != nil {
+		return err
+	}
+	return newAPIError(http.StatusBadGateway, CodeUpstreamError, message)
+}
+
 func (h *ProjectHandler) GenerateAndAssignTasks(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	projectID, err := strconv.Atoi(vars["id"])
+	projectID, err := pathID(r, "id", "project")
 	if err != nil {
-		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
@@ -209,37 +365,41 @@ func (h *ProjectHandler) GenerateAndAssignTasks(w http.ResponseWriter, r *http.R
 	var req struct {
 		Requirements string `json:"requirements"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if strings.TrimSpace(req.Requirements) == "" {
+		writeError(w, r, validation.Errors{{Field: "requirements", Message: "is required"}})
 		return
 	}
 
-	// Query employees and their skills for the project
-	query := `
-        SELECT e.name, e.skills
-        FROM employees e
-        JOIN employee_projects ep ON e.id = ep.employee_id
-        WHERE ep.project_id = $1`
+	ctx := r.Context()
+	project, err := h.projects.GetByID(ctx, projectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Project not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if project.ArchivedAt != nil {
+		writeError(w, r, projectArchivedConflict("Project is archived; unarchive it first"))
+		return
+	}
 
-	rows, err := h.db.Query(context.Background(), query, projectID)
+	// Query employees and their skills for the project
+	members, err := h.employees.ListByProject(ctx, projectID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-	defer rows.Close()
 
 	// Build the prompt with employee skills
 	var employeeSkills []string
-	employeeNameMap := make(map[string]int)
-	for rows.Next() {
-		var name string
-		var skills []string
-		if err := rows.Scan(&name, &skills); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		employeeSkills = append(employeeSkills, fmt.Sprintf("%s: %v", name, skills))
-		employeeNameMap[name] = 1
+	for _, member := range members {
+		employeeSkills = append(employeeSkills, fmt.Sprintf("%s: %v", member.Name, member.Skills))
 	}
 
 	// Prepare the prompt
@@ -248,58 +408,45 @@ func (h *ProjectHandler) GenerateAndAssignTasks(w http.ResponseWriter, r *http.R
 		strings.Join(employeeSkills, "\n"))
 
 	// Send request to chat endpoint
-	chatReq, err := http.NewRequest("POST", "http://localhost:8000/chat",
+	chatReq, err := http.NewRequestWithContext(ctx, "POST", h.chatURL,
 		bytes.NewBufferString(fmt.Sprintf(`{"prompt": "%s"}`, prompt)))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 	chatReq.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(chatReq)
+	resp, err := h.chatClient.Do(chatReq)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, upstreamError(r, err, "Task generation service unavailable"))
 		return
 	}
 	defer resp.Body.Close()
 
 	var chatResponse ChatResponse
 	if err := json.NewDecoder(resp.Body).Decode(&chatResponse); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, upstreamError(r, err, "Invalid response from task generation service"))
 		return
 	}
 
 	// Insert tasks into database
-	ctx := context.Background()
-	tx, err := h.db.Begin(ctx)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	assignments := make([]repository.TaskAssignment, 0, len(chatResponse.Tasks))
+	for _, task := range chatResponse.Tasks {
+		assignments = append(assignments, repository.TaskAssignment{
+			Title:        task.Task,
+			AssigneeName: task.AssignedTo,
+		})
 	}
-	defer tx.Rollback(ctx)
 
-	insertQuery := `
-        INSERT INTO tasks (project_id, title, assigned_to, status)
-        SELECT $1, $2, e.id, 'TODO'
-        FROM employees e
-        WHERE e.name = $3
-        RETURNING id`
-
-	for _, task := range chatResponse.Tasks {
-		var taskID int
-		err := tx.QueryRow(ctx, insertQuery, projectID, task.Task, task.AssignedTo).Scan(&taskID)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to insert task: %v", err), http.StatusInternalServerError)
+	if _, err := h.tasks.CreateAssigned(ctx, projectID, assignments); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, newAPIError(http.StatusUnprocessableEntity, CodeInvalidReference,
+				"Failed to insert task", FieldError{Field: "assigned_to", Message: err.Error()}))
 			return
 		}
-	}
-
-	if err := tx.Commit(ctx); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(chatResponse)
+	writeJSON(w, http.StatusOK, chatResponse)
 }
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+)
+
+func TestCreateAndGetProject(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+
+	var created models.Project
+	s.expect(s.do("POST", "/projects", map[string]any{
+		"name":        "Compiler",
+		"description": "A-0",
+		"lead_id":     lead.ID,
+	}), http.StatusOK, &created)
+	if created.ID == 0 || created.LeadID != lead.ID {
+		t.Fatalf("created project %+v", created)
+	}
+
+	var got models.Project
+	s.expect(s.do("GET", fmt.Sprintf("/projects/%d", created.ID), nil), http.StatusOK, &got)
+	if got.Name != "Compiler" || got.Description != "A-0" || got.LeadID != lead.ID {
+		t.Fatalf("got project %+v", got)
+	}
+	s.expectError(s.do("GET", "/projects/999", nil), http.StatusNotFound, CodeNotFound)
+}
+
+func TestUpdateProject(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	other := s.createEmployee("Ada Lovelace", "ada@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	path := fmt.Sprintf("/projects/%d", project.ID)
+
+	var updated models.Project
+	s.expect(s.do("PUT", path, map[string]any{"name": "COBOL", "lead_id": other.ID}), http.StatusOK, &updated)
+	if updated.Name != "COBOL" || updated.LeadID != other.ID {
+		t.Fatalf("updated project %+v", updated)
+	}
+
+	var got models.Project
+	s.expect(s.do("GET", path, nil), http.StatusOK, &got)
+	if got.Name != "COBOL" || got.LeadID != other.ID {
+		t.Fatalf("got project %+v after update", got)
+	}
+	s.expectError(s.do("PUT", "/projects/999", map[string]any{"name": "COBOL", "lead_id": lead.ID}), http.StatusNotFound, CodeNotFound)
+}
+
+func TestDeleteProject(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	task := s.createTask("Write the linker", project.ID, lead.ID)
+	path := fmt.Sprintf("/projects/%d", project.ID)
+	s.expect(s.do("POST", fmt.Sprintf("/employees/%d/projects/%d", lead.ID, project.ID), nil), http.StatusCreated, nil)
+
+	s.expect(s.do("DELETE", path, nil), http.StatusOK, nil)
+	s.expectError(s.do("GET", path, nil), http.StatusNotFound, CodeNotFound)
+	s.expectError(s.do("DELETE", path, nil), http.StatusNotFound, CodeNotFound)
+
+	// The project's tasks and memberships go with it.
+	s.expectError(s.do("GET", fmt.Sprintf("/tasks/%d", task.ID), nil), http.StatusNotFound, CodeNotFound)
+	var projects []models.Project
+	s.expect(s.do("GET", fmt.Sprintf("/employees/%d/projects", lead.ID), nil), http.StatusOK, &projects)
+	if len(projects) != 0 {
+		t.Fatalf("lead still has projects %v", ids(projects, projectID))
+	}
+}
+
+func TestProjectValidation(t *testing.T) {
+	s := newTestServer(t)
+
+	apiErr := s.expectError(s.do("POST", "/projects", map[string]any{
+		"name":    "Compiler",
+		"lead_id": 999,
+	}), http.StatusBadRequest, CodeValidation)
+	want := []FieldError{{Field: "lead_id", Message: "does not exist"}}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+
+	apiErr = s.expectError(s.do("POST", "/projects", map[string]any{}), http.StatusBadRequest, CodeValidation)
+	want = []FieldError{
+		{Field: "name", Message: "is required"},
+		{Field: "lead_id", Message: "is required"},
+	}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+}
@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
+)
+
+// defaultOccurrencePreview is how many occurrences GetOccurrences returns
+// without a count.
+const defaultOccurrencePreview = 5
+
+type RecurringTaskHandler struct {
+	recurring repository.RecurringTaskRepository
+	projects  repository.ProjectRepository
+	employees repository.EmployeeRepository
+}
+
+func NewRecurringTaskHandler(repos *repository.Repositories) *RecurringTaskHandler {
+	return &RecurringTaskHandler{
+		recurring: repos.RecurringTasks,
+		projects:  repos.Projects,
+		employees: repos.Employees,
+	}
+}
+
+// normalizeRecurringTask trims the text fields and fills in the default
+// priority and time zone.
+func normalizeRecurringTask(recurring *models.RecurringTask) {
+	recurring.Title = strings.TrimSpace(recurring.Title)
+	recurring.Schedule = strings.Join(strings.Fields(recurring.Schedule), " ")
+	recurring.Timezone = strings.TrimSpace(recurring.Timezone)
+	if recurring.Priority == "" {
+		recurring.Priority = models.DefaultPriority
+	}
+	if recurring.Timezone == "" {
+		recurring.Timezone = "UTC"
+	}
+}
+
+func validateRecurringTask(ctx context.Context, projects repository.ProjectRepository, employees repository.EmployeeRepository, recurring *models.RecurringTask) error {
+	errs := validation.Struct(recurring)
+	if !errs.Has("schedule") {
+		if _, err := models.ParseSchedule(recurring.Schedule); err != nil {
+			errs.Add("schedule", err.Error())
+		}
+	}
+	if !errs.Has("timezone") {
+		if _, err := recurring.Location(); err != nil {
+			errs.Add("timezone", "must be an IANA time zone such as Europe/Berlin")
+		}
+	}
+	if !errs.Has("schedule") && !errs.Has("timezone") {
+		if _, err := recurring.NextRun(time.Now()); err != nil {
+			errs.Add("schedule", "is never due")
+		}
+	}
+	if err := checkExists(ctx, &errs, "project_id", recurring.ProjectID, projects.GetByID); err != nil {
+		return err
+	}
+	if err := checkExists(ctx, &errs, "assigned_to", recurring.AssignedTo, employees.GetByID); err != nil {
+		return err
+	}
+	return errs.Err()
+}
+
+// schedule sets NextRunAt to the first occurrence after now, or clears it
+// while the definition is paused.
+func schedule(recurring *models.RecurringTask) error {
+	recurring.NextRunAt = nil
+	if recurring.Paused {
+		return nil
+	}
+	next, err := recurring.NextRun(time.Now())
+	if err != nil {
+		return err
+	}
+	recurring.NextRunAt = &next
+	return nil
+}
+
+// CreateRecurringTask creates a definition whose schedule, a cron
+// expression read in timezone, says when to create its tasks.
+func (h *RecurringTaskHandler) CreateRecurringTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var recurring models.RecurringTask
+	if err := decodeJSON(r, &recurring); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	normalizeRecurringTask(&recurring)
+	if err := validateRecurringTask(ctx, h.projects, h.employees, &recurring); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := schedule(&recurring); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.recurring.Create(ctx, &recurring); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, recurring.Version)
+	writeJSON(w, http.StatusOK, recurring)
+}
+
+func (h *RecurringTaskHandler) GetRecurringTask(w http.ResponseWriter, r *http.Request) {
+	recurring, ok := h.recurringTask(w, r)
+	if !ok {
+		return
+	}
+
+	if notModified(w, r, recurring.Version) {
+		return
+	}
+	writeJSON(w, http.StatusOK, recurring)
+}
+
+// GetRecurringTasks lists definitions, optionally only those of project_id
+// or only paused or active ones.
+func (h *RecurringTaskHandler) GetRecurringTasks(w http.ResponseWriter, r *http.Request) {
+	q := newListQuery(r)
+	filter := repository.RecurringTaskFilter{ProjectID: q.idParam("project_id")}
+	if q.get("paused") != "" {
+		paused := q.boolParam("paused")
+		filter.Paused = &paused
+	}
+	filter.Page = page(q, repository.RecurringTaskSorts)
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	recurring, err := h.recurring.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, recurring)
+}
+
+// UpdateRecurringTask replaces a definition. The project cannot be changed.
+// Changing the schedule, time zone or paused state reschedules the next
+// run from now; tasks already created are left alone.
+func (h *RecurringTaskHandler) UpdateRecurringTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current, ok := h.recurringTask(w, r)
+	if !ok {
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var recurring models.RecurringTask
+	if err := decodeJSON(r, &recurring); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if recurring.ProjectID != 0 && recurring.ProjectID != current.ProjectID {
+		writeError(w, r, validation.Errors{{Field: "project_id", Message: "cannot be changed"}})
+		return
+	}
+	recurring.ID = current.ID
+	recurring.ProjectID = current.ProjectID
+	recurring.Version = version
+	normalizeRecurringTask(&recurring)
+	if err := validateRecurringTask(ctx, h.projects, h.employees, &recurring); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if recurring.Schedule != current.Schedule || recurring.Timezone != current.Timezone || recurring.Paused != current.Paused {
+		if err := schedule(&recurring); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	} else {
+		recurring.NextRunAt = current.NextRunAt
+	}
+
+	h.write(w, r, &recurring)
+}
+
+// DeleteRecurringTask deletes a definition. The tasks it created remain.
+func (h *RecurringTaskHandler) DeleteRecurringTask(w http.ResponseWriter, r *http.Request) {
+	recurringID, err := pathID(r, "id", "recurring task")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = h.recurring.Delete(r.Context(), recurringID, version)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Recurring task not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// PauseRecurringTask stops a definition from creating tasks until it is
+// resumed. Pausing a paused definition changes nothing.
+func (h *RecurringTaskHandler) PauseRecurringTask(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, true)
+}
+
+// ResumeRecurringTask restarts a paused definition from its next
+// occurrence after now; occurrences missed while paused are skipped.
+func (h *RecurringTaskHandler) ResumeRecurringTask(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, false)
+}
+
+func (h *RecurringTaskHandler) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	current, ok := h.recurringTask(w, r)
+	if !ok {
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if current.Paused == paused {
+		setETag(w, current.Version)
+		writeJSON(w, http.StatusOK, current)
+		return
+	}
+
+	current.Paused = paused
+	current.Version = version
+	if err := schedule(current); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.write(w, r, current)
+}
+
+// write stores an updated definition and writes it as the response.
+func (h *RecurringTaskHandler) write(w http.ResponseWriter, r *http.Request, recurring *models.RecurringTask) {
+	err := h.recurring.Update(r.Context(), recurring)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Recurring task not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, recurring.Version)
+	writeJSON(w, http.StatusOK, recurring)
+}
+
+type occurrencePreview struct {
+	RecurringTaskID int         `json:"recurring_task_id"`
+	Paused          bool        `json:"paused"`
+	Occurrences     []time.Time `json:"occurrences"`
+}
+
+// GetOccurrences previews the next count (default 5, at most 100) times a
+// definition is due after from (default now). For a paused definition
+// these are the times it would be due once resumed.
+func (h *RecurringTaskHandler) GetOccurrences(w http.ResponseWriter, r *http.Request) {
+	recurring, ok := h.recurringTask(w, r)
+	if !ok {
+		return
+	}
+
+	q := newListQuery(r)
+	count := q.idParam("count")
+	if count > models.MaxOccurrencePreview {
+		q.errs.Add("count", "must be at most 100")
+	}
+	if count == 0 {
+		count = defaultOccurrencePreview
+	}
+	from := time.Now()
+	if t := q.timeParam("from"); t != nil {
+		from = *t
+	}
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	occurrences, err := recurring.Occurrences(from, count)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, occurrencePreview{
+		RecurringTaskID: recurring.ID,
+		Paused:          recurring.Paused,
+		Occurrences:     occurrences,
+	})
+}
+
+// GetCreatedTasks lists the tasks a definition has created that still
+// exist, oldest occurrence first.
+func (h *RecurringTaskHandler) GetCreatedTasks(w http.ResponseWriter, r *http.Request) {
+	recurring, ok := h.recurringTask(w, r)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.recurring.Tasks(r.Context(), recurring.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// recurringTask loads the definition named in the path.
+func (h *RecurringTaskHandler) recurringTask(w http.ResponseWriter, r *http.Request) (*models.RecurringTask, bool) {
+	recurringID, err := pathID(r, "id", "recurring task")
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+
+	recurring, err := h.recurring.GetByID(r.Context(), recurringID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Recurring task not found"))
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+	return recurring, true
+}
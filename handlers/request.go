@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// pathID parses the named route variable as an integer ID. label is used in
+// the error message, e.g. "employee" gives "Invalid employee ID".
+func pathID(r *http.Request, name, label string) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)[name])
+	if err != nil {
+		return 0, badRequest("Invalid "+label+" ID", FieldError{Field: name, Message: "must be an integer"})
+	}
+	return id, nil
+}
+
+// decodeJSON decodes the request body into v, rejecting fields v does not
+// declare and anything after the first JSON value.
+func decodeJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return badRequest("Invalid request body", FieldError{Field: "body", Message: err.Error()})
+	}
+	if decoder.More() {
+		return badRequest("Invalid request body", FieldError{Field: "body", Message: "unexpected data after JSON object"})
+	}
+	return nil
+}
@@ -0,0 +1,419 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
+)
+
+const (
+	// defaultVelocitySprints is how many closed sprints GetProjectVelocity
+	// averages over without a sprints parameter.
+	defaultVelocitySprints = 5
+	maxVelocitySprints     = 50
+)
+
+type SprintHandler struct {
+	sprints  repository.SprintRepository
+	projects repository.ProjectRepository
+}
+
+func NewSprintHandler(repos *repository.Repositories) *SprintHandler {
+	return &SprintHandler{
+		sprints:  repos.Sprints,
+		projects: repos.Projects,
+	}
+}
+
+// parseSprintState normalises a sprint state given in any letter case.
+func parseSprintState(raw string) (models.SprintState, bool) {
+	state := models.SprintState(strings.ToUpper(strings.TrimSpace(raw)))
+	return state, state.Valid()
+}
+
+// sprintStateConflict rejects an operation the sprint's state does not
+// allow.
+func sprintStateConflict(message string, sprint *models.Sprint) *APIError {
+	return newAPIError(http.StatusConflict, CodeConflict, message,
+		FieldError{Field: "state", Message: "is " + string(sprint.State)})
+}
+
+// CreateSprint plans a new sprint. Sprints always start out planned.
+func (h *SprintHandler) CreateSprint(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var sprint models.Sprint
+	if err := decodeJSON(r, &sprint); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if sprint.State != "" && sprint.State != models.SprintPlanned {
+		writeError(w, r, validation.Errors{{Field: "state", Message: "must be PLANNED; sprints are started through POST /sprints/{id}/start"}})
+		return
+	}
+	sprint.Name = strings.TrimSpace(sprint.Name)
+	sprint.State = models.SprintPlanned
+	if err := validateSprint(ctx, h.projects, &sprint); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.sprints.Create(ctx, &sprint); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, sprint.Version)
+	writeJSON(w, http.StatusOK, sprint)
+}
+
+func (h *SprintHandler) GetSprint(w http.ResponseWriter, r *http.Request) {
+	sprint, ok := h.sprint(w, r)
+	if !ok {
+		return
+	}
+
+	if notModified(w, r, sprint.Version) {
+		return
+	}
+	writeJSON(w, http.StatusOK, sprint)
+}
+
+// GetSprints lists sprints, optionally only those of project_id or in one
+// state.
+func (h *SprintHandler) GetSprints(w http.ResponseWriter, r *http.Request) {
+	q := newListQuery(r)
+	filter := repository.SprintFilter{ProjectID: q.idParam("project_id")}
+	if raw := q.get("state"); raw != "" {
+		state, ok := parseSprintState(raw)
+		if !ok {
+			q.errs.Add("state", "must be one of PLANNED, ACTIVE, CLOSED")
+		}
+		filter.State = state
+	}
+	filter.Page = page(q, repository.SprintSorts)
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	sprints, err := h.sprints.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sprints)
+}
+
+// UpdateSprint replaces a sprint's name, goal and dates. The project cannot
+// be changed, and the state only through the start and close operations.
+func (h *SprintHandler) UpdateSprint(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current, ok := h.sprint(w, r)
+	if !ok {
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var sprint models.Sprint
+	if err := decodeJSON(r, &sprint); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if sprint.ProjectID != 0 && sprint.ProjectID != current.ProjectID {
+		writeError(w, r, validation.Errors{{Field: "project_id", Message: "cannot be changed"}})
+		return
+	}
+	if sprint.State != "" && sprint.State != current.State {
+		writeError(w, r, validation.Errors{{Field: "state", Message: "can only be changed by starting or closing the sprint"}})
+		return
+	}
+	sprint.ID = current.ID
+	sprint.ProjectID = current.ProjectID
+	sprint.State = current.State
+	sprint.Version = version
+	sprint.Name = strings.TrimSpace(sprint.Name)
+	if err := validateSprint(ctx, h.projects, &sprint); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = h.sprints.Update(ctx, &sprint)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Sprint not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, sprint.Version)
+	writeJSON(w, http.StatusOK, sprint)
+}
+
+// DeleteSprint deletes a sprint and returns its tasks to the backlog.
+func (h *SprintHandler) DeleteSprint(w http.ResponseWriter, r *http.Request) {
+	sprintID, err := pathID(r, "id", "sprint")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = h.sprints.Delete(r.Context(), sprintID, version)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Sprint not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// StartSprint makes a planned sprint the project's active one and records
+// the estimate of its tasks as the hours committed to.
+func (h *SprintHandler) StartSprint(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current, ok := h.sprint(w, r)
+	if !ok {
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if current.State != models.SprintPlanned {
+		writeError(w, r, sprintStateConflict("Only a planned sprint can be started", current))
+		return
+	}
+
+	active, err := h.sprints.List(ctx, repository.SprintFilter{
+		ProjectID: current.ProjectID,
+		State:     models.SprintActive,
+		Page:      repository.PageRequest{Limit: 1, Sort: repository.Sort{Field: "id"}},
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if len(active.Items) > 0 {
+		writeError(w, r, newAPIError(http.StatusConflict, CodeConflict,
+			"Project already has an active sprint; close it first",
+			FieldError{Field: "project_id", Message: "has active sprint " + active.Items[0].Name}))
+		return
+	}
+
+	sprint, err := h.sprints.Start(ctx, current.ID, current.Version)
+	if err != nil {
+		writeError(w, r, changeError(err, version, "Sprint"))
+		return
+	}
+
+	setETag(w, sprint.Version)
+	writeJSON(w, http.StatusOK, sprint)
+}
+
+// closeSprintRequest names where a closing sprint's open tasks go. With no
+// NextSprintID they go to the project's next planned sprint, or the
+// backlog if there is none; 0 sends them to the backlog.
+type closeSprintRequest struct {
+	NextSprintID *int `json:"next_sprint_id" validate:"min=0"`
+}
+
+type closeSprintResponse struct {
+	Sprint       *models.Sprint `json:"sprint"`
+	NextSprintID *int           `json:"next_sprint_id"`
+	RolledOver   []models.Task  `json:"rolled_over"`
+}
+
+// CloseSprint closes the active sprint, recording what it completed, and
+// rolls its unfinished tasks forward in the same transaction. Done and
+// cancelled tasks stay in the closed sprint.
+func (h *SprintHandler) CloseSprint(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current, ok := h.sprint(w, r)
+	if !ok {
+		return
+	}
+
+	var req closeSprintRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if err := validation.Struct(&req).Err(); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if current.State != models.SprintActive {
+		writeError(w, r, sprintStateConflict("Only an active sprint can be closed", current))
+		return
+	}
+
+	nextID, err := h.nextSprint(ctx, current, req.NextSprintID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	sprint, moved, err := h.sprints.Close(ctx, current.ID, nextID, current.Version)
+	if err != nil {
+		writeError(w, r, changeError(err, version, "Sprint"))
+		return
+	}
+
+	resp := closeSprintResponse{Sprint: sprint, RolledOver: moved}
+	if nextID != 0 {
+		resp.NextSprintID = &nextID
+	}
+	if resp.RolledOver == nil {
+		resp.RolledOver = []models.Task{}
+	}
+	setETag(w, sprint.Version)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// nextSprint resolves where a closing sprint's open tasks go: the sprint
+// requested, which must be a planned sprint of the same project, or by
+// default the planned sprint starting soonest. Zero means the backlog.
+func (h *SprintHandler) nextSprint(ctx context.Context, closing *models.Sprint, requested *int) (int, error) {
+	if requested != nil {
+		if *requested == 0 {
+			return 0, nil
+		}
+		next, err := h.sprints.GetByID(ctx, *requested)
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, validation.Errors{{Field: "next_sprint_id", Message: "does not exist"}}
+		}
+		if err != nil {
+			return 0, err
+		}
+		if next.ProjectID != closing.ProjectID || next.State != models.SprintPlanned {
+			return 0, validation.Errors{{Field: "next_sprint_id", Message: "must be a planned sprint of the same project"}}
+		}
+		return next.ID, nil
+	}
+
+	planned, err := h.sprints.List(ctx, repository.SprintFilter{
+		ProjectID: closing.ProjectID,
+		State:     models.SprintPlanned,
+		Page:      repository.PageRequest{Limit: 1, Sort: repository.Sort{Field: "start_date"}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(planned.Items) == 0 {
+		return 0, nil
+	}
+	return planned.Items[0].ID, nil
+}
+
+// GetSprintTasks lists the tasks in a sprint, ordered by ID.
+func (h *SprintHandler) GetSprintTasks(w http.ResponseWriter, r *http.Request) {
+	sprint, ok := h.sprint(w, r)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.sprints.Tasks(r.Context(), sprint.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// GetProjectVelocity reports the hours and tasks completed by a project's
+// most recently ended closed sprints, sprints of them (default 5, at most
+// 50), and their averages.
+func (h *SprintHandler) GetProjectVelocity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID, err := pathID(r, "id", "project")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	q := newListQuery(r)
+	count := q.idParam("sprints")
+	if count > maxVelocitySprints {
+		q.errs.Add("sprints", "must be at most 50")
+	}
+	if count == 0 {
+		count = defaultVelocitySprints
+	}
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if _, err := h.projects.GetByID(ctx, projectID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, notFound("Project not found"))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	closed, err := h.sprints.List(ctx, repository.SprintFilter{
+		ProjectID: projectID,
+		State:     models.SprintClosed,
+		Page:      repository.PageRequest{Limit: count, Sort: repository.Sort{Field: "end_date", Desc: true}},
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.NewVelocity(projectID, closed.Items))
+}
+
+// sprint loads the sprint named in the path.
+func (h *SprintHandler) sprint(w http.ResponseWriter, r *http.Request) (*models.Sprint, bool) {
+	sprintID, err := pathID(r, "id", "sprint")
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+
+	sprint, err := h.sprints.GetByID(r.Context(), sprintID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Sprint not found"))
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+	return sprint, true
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+)
+
+func (s *testServer) createSprint(name string, projectID int, startDate, endDate string) models.Sprint {
+	s.t.Helper()
+	var sprint models.Sprint
+	s.expect(s.do("POST", "/sprints", map[string]any{
+		"name":       name,
+		"project_id": projectID,
+		"start_date": startDate,
+		"end_date":   endDate,
+	}), http.StatusOK, &sprint)
+	return sprint
+}
+
+// planTask puts a task with an estimate into a sprint.
+func (s *testServer) planTask(task models.Task, sprintID int, estimateHours float64) models.Task {
+	s.t.Helper()
+	var planned models.Task
+	s.expect(s.do("PATCH", fmt.Sprintf("/tasks/%d", task.ID), map[string]any{
+		"sprint_id":      sprintID,
+		"estimate_hours": estimateHours,
+	}), http.StatusOK, &planned)
+	return planned
+}
+
+func (s *testServer) sprintAction(sprint models.Sprint, action string, body any) *models.Sprint {
+	s.t.Helper()
+	rec := s.do("POST", fmt.Sprintf("/sprints/%d/%s", sprint.ID, action), body)
+	if action == "close" {
+		var resp closeSprintResponse
+		s.expect(rec, http.StatusOK, &resp)
+		return resp.Sprint
+	}
+	var changed models.Sprint
+	s.expect(rec, http.StatusOK, &changed)
+	return &changed
+}
+
+func TestSprintStates(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	other := s.createProject("Debugger", lead.ID)
+	first := s.createSprint(" Sprint 1 ", project.ID, "2024-03-04", "2024-03-15")
+	second := s.createSprint("Sprint 2", project.ID, "2024-03-18", "2024-03-29")
+	elsewhere := s.createSprint("Sprint 1", other.ID, "2024-03-04", "2024-03-15")
+	if first.State != models.SprintPlanned || first.Name != "Sprint 1" || first.StartedAt != nil {
+		t.Fatalf("new sprint = %+v", first)
+	}
+
+	started := s.sprintAction(first, "start", nil)
+	if started.State != models.SprintActive || started.StartedAt == nil || *started.CommittedHours != 0 {
+		t.Fatalf("started sprint = %+v", started)
+	}
+
+	// Only one sprint of a project is active at a time.
+	apiErr := s.expectError(s.do("POST", fmt.Sprintf("/sprints/%d/start", second.ID), nil), http.StatusConflict, CodeConflict)
+	if apiErr.Details[0] != (FieldError{Field: "project_id", Message: "has active sprint Sprint 1"}) {
+		t.Fatalf("got %+v", apiErr)
+	}
+	s.sprintAction(elsewhere, "start", nil)
+
+	tests := []struct {
+		name    string
+		sprint  models.Sprint
+		action  string
+		message string
+		state   models.SprintState
+	}{
+		{"start active", first, "start", "Only a planned sprint can be started", models.SprintActive},
+		{"close planned", second, "close", "Only an active sprint can be closed", models.SprintPlanned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := s.expectError(s.do("POST", fmt.Sprintf("/sprints/%d/%s", tt.sprint.ID, tt.action), nil), http.StatusConflict, CodeConflict)
+			want := FieldError{Field: "state", Message: "is " + string(tt.state)}
+			if apiErr.Message != tt.message || len(apiErr.Details) != 1 || apiErr.Details[0] != want {
+				t.Fatalf("got %+v", apiErr)
+			}
+		})
+	}
+
+	closed := s.sprintAction(first, "close", nil)
+	if closed.State != models.SprintClosed || closed.ClosedAt == nil || *closed.CompletedTasks != 0 {
+		t.Fatalf("closed sprint = %+v", closed)
+	}
+	for _, action := range []string{"start", "close"} {
+		apiErr := s.expectError(s.do("POST", fmt.Sprintf("/sprints/%d/%s", first.ID, action), nil), http.StatusConflict, CodeConflict)
+		if apiErr.Details[0] != (FieldError{Field: "state", Message: "is CLOSED"}) {
+			t.Fatalf("%s closed sprint: got %+v", action, apiErr)
+		}
+	}
+	// With the first one closed, the next can start.
+	s.sprintAction(second, "start", nil)
+
+	page := list[models.Sprint](s, fmt.Sprintf("/sprints?project_id=%d&state=active", project.ID))
+	if got := ids(page.Items, sprintID); !slices.Equal(got, []int{second.ID}) {
+		t.Fatalf("active sprints = %v", got)
+	}
+	s.expectError(s.do("POST", fmt.Sprintf("/sprints/%d/start", first.ID), nil, "If-Match", `"1"`), http.StatusPreconditionFailed, CodePreconditionFailed)
+}
+
+func TestSprintValidation(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	sprint := s.createSprint("Sprint 1", project.ID, "2024-03-04", "2024-03-15")
+
+	apiErr := s.expectError(s.do("POST", "/sprints", map[string]any{
+		"name":       "",
+		"project_id": 999,
+		"start_date": "2024-03-15",
+		"end_date":   "2024-03-04",
+	}), http.StatusBadRequest, CodeValidation)
+	want := []FieldError{
+		{Field: "name", Message: "is required"},
+		{Field: "end_date", Message: "must not be before start_date"},
+		{Field: "project_id", Message: "does not exist"},
+	}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   map[string]any
+		field  string
+	}{
+		{"create active", "POST", "/sprints", map[string]any{"name": "Sprint 2", "project_id": project.ID, "start_date": "2024-03-18", "end_date": "2024-03-29", "state": "ACTIVE"}, "state"},
+		{"activate by update", "PUT", fmt.Sprintf("/sprints/%d", sprint.ID), map[string]any{"name": "Sprint 1", "start_date": "2024-03-04", "end_date": "2024-03-15", "state": "ACTIVE"}, "state"},
+		{"move project", "PUT", fmt.Sprintf("/sprints/%d", sprint.ID), map[string]any{"name": "Sprint 1", "project_id": project.ID + 1, "start_date": "2024-03-04", "end_date": "2024-03-15"}, "project_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := s.expectError(s.do(tt.method, tt.path, tt.body), http.StatusBadRequest, CodeValidation)
+			if len(apiErr.Details) != 1 || apiErr.Details[0].Field != tt.field {
+				t.Fatalf("details = %+v", apiErr.Details)
+			}
+		})
+	}
+
+	// Tasks can only join an open sprint of their own project.
+	other := s.createProject("Debugger", lead.ID)
+	foreign := s.createSprint("Sprint 1", other.ID, "2024-03-04", "2024-03-15")
+	task := s.createTask("Write the parser", project.ID, lead.ID)
+	s.planTask(task, sprint.ID, 5)
+	s.sprintAction(sprint, "start", nil)
+	s.sprintAction(sprint, "close", map[string]any{"next_sprint_id": 0})
+	late := s.createTask("Write the linker", project.ID, lead.ID)
+	for _, tt := range []struct {
+		sprintID int
+		message  string
+	}{
+		{999, "does not exist"},
+		{foreign.ID, "must belong to the same project"},
+		{sprint.ID, "must not be a closed sprint"},
+	} {
+		apiErr := s.expectError(s.do("PATCH", fmt.Sprintf("/tasks/%d", late.ID), map[string]any{"sprint_id": tt.sprintID}),
+			http.StatusBadRequest, CodeValidation)
+		if apiErr.Details[0] != (FieldError{Field: "sprint_id", Message: tt.message}) {
+			t.Fatalf("sprint %d: details = %+v", tt.sprintID, apiErr.Details)
+		}
+	}
+}
+
+func TestCloseSprintRollsOver(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	current := s.createSprint("Sprint 1", project.ID, "2024-03-04", "2024-03-15")
+	later := s.createSprint("Sprint 3", project.ID, "2024-04-01", "2024-04-12")
+	next := s.createSprint("Sprint 2", project.ID, "2024-03-18", "2024-03-29")
+
+	lexer := s.planTask(s.createTask("Write the lexer", project.ID, lead.ID), current.ID, 8)
+	parser := s.planTask(s.createTask("Write the parser", project.ID, lead.ID), current.ID, 13)
+	dropped := s.planTask(s.createTask("Write a second parser", project.ID, lead.ID), current.ID, 20)
+	codegen := s.planTask(s.createTask("Write the code generator", project.ID, lead.ID), current.ID, 5)
+	s.transition(dropped, models.StatusCancelled, lead.ID)
+
+	started := s.sprintAction(current, "start", nil)
+	if *started.CommittedHours != 26 {
+		t.Fatalf("committed hours = %v, want 26", *started.CommittedHours)
+	}
+	for _, to := range []models.TaskStatus{models.StatusInProgress, models.StatusInReview, models.StatusDone} {
+		s.transition(lexer, to, lead.ID)
+	}
+	s.transition(parser, models.StatusInProgress, lead.ID)
+
+	// Without a target the unfinished tasks go to the planned sprint that
+	// starts first.
+	var resp closeSprintResponse
+	s.expect(s.do("POST", fmt.Sprintf("/sprints/%d/close", current.ID), nil), http.StatusOK, &resp)
+	if resp.NextSprintID == nil || *resp.NextSprintID != next.ID {
+		t.Fatalf("rolled over to %v, want %d", resp.NextSprintID, next.ID)
+	}
+	if got := ids(resp.RolledOver, taskID); !slices.Equal(got, []int{parser.ID, codegen.ID}) {
+		t.Fatalf("rolled over %v, want [%d %d]", got, parser.ID, codegen.ID)
+	}
+	if *resp.Sprint.CompletedTasks != 1 || *resp.Sprint.CompletedHours != 8 {
+		t.Fatalf("closed sprint = %+v", resp.Sprint)
+	}
+	if got := s.getTask(parser.ID); got.SprintID == nil || *got.SprintID != next.ID || got.Status != models.StatusInProgress {
+		t.Fatalf("rolled over task = %+v", got)
+	}
+
+	// Finished tasks stay behind.
+	var tasks []models.Task
+	s.expect(s.do("GET", fmt.Sprintf("/sprints/%d/tasks", current.ID), nil), http.StatusOK, &tasks)
+	if got := ids(tasks, taskID); !slices.Equal(got, []int{lexer.ID, dropped.ID}) {
+		t.Fatalf("closed sprint tasks = %v", got)
+	}
+
+	// An explicit target must be a planned sprint of the project.
+	s.sprintAction(next, "start", nil)
+	apiErr := s.expectError(s.do("POST", fmt.Sprintf("/sprints/%d/close", next.ID), map[string]any{"next_sprint_id": current.ID}),
+		http.StatusBadRequest, CodeValidation)
+	if apiErr.Details[0] != (FieldError{Field: "next_sprint_id", Message: "must be a planned sprint of the same project"}) {
+		t.Fatalf("got %+v", apiErr)
+	}
+	// Zero sends them to the backlog even with a planned sprint left.
+	s.expect(s.do("POST", fmt.Sprintf("/sprints/%d/close", next.ID), map[string]any{"next_sprint_id": 0}), http.StatusOK, &resp)
+	if resp.NextSprintID != nil || len(resp.RolledOver) != 2 {
+		t.Fatalf("close response = %+v", resp)
+	}
+	if got := s.getTask(codegen.ID); got.SprintID != nil {
+		t.Fatalf("task after closing to the backlog = %+v", got)
+	}
+	s.expect(s.do("GET", fmt.Sprintf("/sprints/%d/tasks", later.ID), nil), http.StatusOK, &tasks)
+	if len(tasks) != 0 {
+		t.Fatalf("untouched sprint tasks = %v", ids(tasks, taskID))
+	}
+
+	var velocity models.Velocity
+	s.expect(s.do("GET", fmt.Sprintf("/projects/%d/velocity", project.ID), nil), http.StatusOK, &velocity)
+	if got := len(velocity.Sprints); got != 2 || velocity.Sprints[0].SprintID != next.ID || velocity.AverageHours != 4 || velocity.AverageTasks != 0.5 {
+		t.Fatalf("velocity = %+v", velocity)
+	}
+}
@@ -2,186 +2,531 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"net/http"
-	"strconv"
+	"slices"
 
-	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5"
 	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
 )
 
 type TaskHandler struct {
-	db *pgx.Conn
+	tasks      repository.TaskRepository
+	projects   repository.ProjectRepository
+	employees  repository.EmployeeRepository
+	sprints    repository.SprintRepository
+	milestones repository.MilestoneRepository
+
+	workflow models.TaskWorkflow
 }
 
-func NewTaskHandler(db *pgx.Conn) *TaskHandler {
-	return &TaskHandler{db: db}
+func NewTaskHandler(repos *repository.Repositories, workflow models.TaskWorkflow) *TaskHandler {
+	return &TaskHandler{
+		tasks:      repos.Tasks,
+		projects:   repos.Projects,
+		employees:  repos.Employees,
+		sprints:    repos.Sprints,
+		milestones: repos.Milestones,
+		workflow:   workflow,
+	}
 }
 
+// CreateTask adds a task. Tasks always start as TODO; any other status has
+// to be reached through the workflow so that the move is checked and
+// recorded.
 func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
 	var task models.Task
-	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &task); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if task.Status == "" {
+		task.Status = models.StatusTodo
+	}
+	if task.Status != models.StatusTodo {
+		writeError(w, r, validation.Errors{{Field: "status", Message: "must be TODO; change it with POST /tasks/{id}/transitions"}})
+		return
+	}
+	if task.Priority == "" {
+		task.Priority = models.DefaultPriority
+	}
+	if err := validateTask(ctx, h.projects, h.employees, h.tasks, h.sprints, h.milestones, &task); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.tasks.Create(ctx, &task); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	query := `
-        INSERT INTO tasks (project_id, assigned_to, title, description, status)
-        VALUES ($1, $2, $3, $4, $5)
-        RETURNING id, created_at`
+	setETag(w, task.Version)
+	writeJSON(w, http.StatusOK, task)
+}
 
-	err := h.db.QueryRow(ctx, query,
-		task.ProjectID,
-		task.AssignedTo,
-		task.Title,
-		task.Description,
-		task.Status,
-	).Scan(&task.ID, &task.CreatedAt)
+// GetTaskByID returns a task. Administrators can also fetch a deleted one
+// with include_deleted=true.
+func (h *TaskHandler) GetTaskByID(w http.ResponseWriter, r *http.Request) {
+	taskID, err := pathID(r, "id", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
+	task, err := getIncludingDeleted(r, taskID, h.tasks.GetByID, h.tasks.GetDeleted)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Task not found"))
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(task)
+	if notModified(w, r, task.Version) {
+		return
+	}
+	writeJSON(w, http.StatusOK, task)
 }
 
-func (h *TaskHandler) GetTaskByID(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	taskID, err := strconv.Atoi(vars["id"])
+func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
+	taskID, err := pathID(r, "id", "task")
 	if err != nil {
-		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		writeError(w, r, err)
+		return
+	}
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
-
-	query := `
-        SELECT id, project_id, assigned_to, title, description, status, created_at
-        FROM tasks 
-        WHERE id = $1`
 
 	var task models.Task
-	err = h.db.QueryRow(context.Background(), query, taskID).Scan(
-		&task.ID,
-		&task.ProjectID,
-		&task.AssignedTo,
-		&task.Title,
-		&task.Description,
-		&task.Status,
-		&task.CreatedAt,
-	)
+	if err := decodeJSON(r, &task); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	task.ID = taskID
+	task.Version = version
 
-	if err == pgx.ErrNoRows {
-		http.Error(w, "Task not found", http.StatusNotFound)
+	current, err := h.tasks.GetByID(r.Context(), taskID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Task not found"))
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+	if task.Status == "" {
+		task.Status = current.Status
+	}
+	if task.Status != current.Status {
+		writeError(w, r, statusChangeNotAllowed())
+		return
+	}
+	if task.Priority == "" {
+		task.Priority = models.DefaultPriority
+	}
+	if err := validateTask(r.Context(), h.projects, h.employees, h.tasks, h.sprints, h.milestones, &task); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := h.checkProjectMove(r.Context(), current, &task); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(task)
+	if err := h.tasks.Update(r.Context(), &task); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, notFound("Task not found"))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, task.Version)
+	writeJSON(w, http.StatusOK, task)
 }
 
-func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	taskID, err := strconv.Atoi(vars["id"])
+// PatchTask applies a JSON Merge Patch or JSON Patch to a task, writing only
+// the fields it changes.
+func (h *TaskHandler) PatchTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	taskID, err := pathID(r, "id", "task")
 	if err != nil {
-		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	var task models.Task
-	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	current, err := h.tasks.GetByID(ctx, taskID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Task not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	query := `
-        UPDATE tasks 
-        SET project_id = $1, assigned_to = $2, title = $3, description = $4, status = $5
-        WHERE id = $6
-        RETURNING id, project_id, assigned_to, title, description, status, created_at`
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	err = h.db.QueryRow(context.Background(), query,
-		task.ProjectID,
-		task.AssignedTo,
-		task.Title,
-		task.Description,
-		task.Status,
-		taskID,
-	).Scan(&task.ID, &task.ProjectID, &task.AssignedTo, &task.Title, &task.Description, &task.Status, &task.CreatedAt)
+	task, fields, err := applyPatch(r, current)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	task.Version = version
+	if slices.Contains(fields, "status") {
+		writeError(w, r, statusChangeNotAllowed())
+		return
+	}
+	if err := validateTask(ctx, h.projects, h.employees, h.tasks, h.sprints, h.milestones, task); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := h.checkProjectMove(ctx, current, task); err != nil {
+		writeError(w, r, err)
+		return
+	}
 
+	err = h.tasks.Patch(ctx, task, fields)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Task not found"))
+		return
+	}
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			http.Error(w, "Task not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(task)
+	setETag(w, task.Version)
+	writeJSON(w, http.StatusOK, task)
 }
 
 func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	taskID, err := strconv.Atoi(vars["id"])
+	taskID, err := pathID(r, "id", "task")
 	if err != nil {
-		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		writeError(w, r, err)
+		return
+	}
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	q := newListQuery(r)
+	cascade := q.boolParam("cascade")
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	query := `DELETE FROM tasks WHERE id = $1`
-	result, err := h.db.Exec(context.Background(), query, taskID)
+	ctx := r.Context()
+	subtree, err := h.tasks.Subtree(ctx, taskID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Task not found"))
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+	if len(subtree) > 1 && !cascade {
+		writeError(w, r, newAPIError(http.StatusConflict, CodeConflict, "Task has subtasks",
+			FieldError{Field: "cascade", Message: "must be true to delete the subtasks too"}))
 		return
 	}
 
-	if result.RowsAffected() == 0 {
-		http.Error(w, "Task not found", http.StatusNotFound)
+	var dependents []models.Task
+	for _, task := range subtree {
+		waiting, err := h.tasks.ListDependents(ctx, task.ID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		dependents = append(dependents, waiting...)
+	}
+
+	err = h.tasks.Delete(ctx, taskID, version)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Task not found"))
 		return
 	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.reconcileDependents(ctx, dependents)
 
 	w.WriteHeader(http.StatusOK)
 }
 
+// GetAllTasks lists tasks, optionally filtered by project, assignee,
+// status, priority, creation time, start and due dates, and overdue.
+// Deleted tasks are only listed for administrators asking for
+// include_deleted=true.
 func (h *TaskHandler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
-	query := `
-        SELECT id, project_id, assigned_to, title, description, status, created_at
-        FROM tasks`
+	q := newListQuery(r)
+	includeDeleted, err := q.includeDeleted(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	filter := repository.TaskFilter{
+		ProjectID:      q.idParam("project_id"),
+		AssignedTo:     q.idParam("assigned_to"),
+		ParentTaskID:   q.idParam("parent_task_id"),
+		LabelID:        q.idParam("label_id"),
+		SprintID:       q.idParam("sprint_id"),
+		MilestoneID:    q.idParam("milestone_id"),
+		Status:         q.statusParam("status"),
+		Priority:       q.priorityParam("priority"),
+		CreatedAfter:   q.timeParam("created_after"),
+		CreatedBefore:  q.timeParam("created_before"),
+		StartAfter:     q.dateParam("start_after"),
+		StartBefore:    q.dateParam("start_before"),
+		DueAfter:       q.dateParam("due_after"),
+		DueBefore:      q.dateParam("due_before"),
+		IncludeDeleted: includeDeleted,
+		Page:           page(q, repository.TaskSorts),
+	}
+	if q.boolParam("overdue") {
+		today := today()
+		filter.OverdueOn = &today
+	}
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	tasks, err := h.tasks.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// RestoreTask undoes the deletion of a task, bringing back the subtasks
+// deleted with it. Its project and parent must not be deleted, nor its
+// assignee while the task is open. Only administrators may restore.
+func (h *TaskHandler) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	if err := requireAdmin(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	taskID, err := pathID(r, "id", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	current, err := h.tasks.GetDeleted(ctx, taskID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Deleted task not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := h.checkRestore(ctx, current); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	task, err := h.tasks.Restore(ctx, taskID, current.Version)
+	if err != nil {
+		writeError(w, r, changeError(err, version, "Deleted task"))
+		return
+	}
+
+	setETag(w, task.Version)
+	writeJSON(w, http.StatusOK, task)
+}
+
+// checkRestore rejects restoring a task whose project, parent or, while
+// it is open, assignee is deleted.
+func (h *TaskHandler) checkRestore(ctx context.Context, task *models.Task) error {
+	var missing validation.Errors
+	if _, err := h.projects.GetByID(ctx, task.ProjectID); errors.Is(err, repository.ErrNotFound) {
+		missing.Add("project_id", "is deleted")
+	} else if err != nil {
+		return err
+	}
+	if task.ParentTaskID != nil {
+		if _, err := h.tasks.GetByID(ctx, *task.ParentTaskID); errors.Is(err, repository.ErrNotFound) {
+			missing.Add("parent_task_id", "is deleted")
+		} else if err != nil {
+			return err
+		}
+	}
+	if task.Status != models.StatusDone && task.Status != models.StatusCancelled {
+		if _, err := h.employees.GetByID(ctx, task.AssignedTo); errors.Is(err, repository.ErrNotFound) {
+			missing.Add("assigned_to", "is deleted")
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		return newAPIError(http.StatusConflict, CodeConflict, "Task refers to deleted records; restore them first", missing...)
+	}
+	return nil
+}
+
+type transitionRequest struct {
+	To      models.TaskStatus `json:"to" validate:"required,enum"`
+	ActorID int               `json:"actor_id" validate:"required,min=1"`
+	Note    string            `json:"note" validate:"max=1000"`
+}
+
+// TransitionTask moves a task to another status if the workflow allows it
+// and records the move in the task's history.
+func (h *TaskHandler) TransitionTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	taskID, err := pathID(r, "id", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req transitionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	req.To, _ = parseStatus(string(req.To))
+	errs := validation.Struct(&req)
+	if err := checkExists(ctx, &errs, "actor_id", req.ActorID, h.employees.GetByID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := errs.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	current, err := h.tasks.GetByID(ctx, taskID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Task not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := h.checkTransition(ctx, current, req.To); err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	rows, err := h.db.Query(context.Background(), query)
+	transition := models.TaskTransition{
+		TaskID:  taskID,
+		From:    current.Status,
+		To:      req.To,
+		ActorID: &req.ActorID,
+		Note:    req.Note,
+	}
+	task, err := h.tasks.Transition(ctx, &transition, current.Version)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, changeError(err, version, "Task"))
 		return
 	}
-	defer rows.Close()
+	h.afterTransition(ctx, current.Status, task, req.ActorID)
 
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		err := rows.Scan(
-			&task.ID,
-			&task.ProjectID,
-			&task.AssignedTo,
-			&task.Title,
-			&task.Description,
-			&task.Status,
-			&task.CreatedAt,
-		)
+	setETag(w, task.Version)
+	writeJSON(w, http.StatusOK, task)
+}
+
+// checkTransition explains why task may not move to status to, if it may
+// not.
+func (h *TaskHandler) checkTransition(ctx context.Context, task *models.Task, to models.TaskStatus) error {
+	if !h.workflow.Allows(task.Status, to) {
+		return invalidTransition(h.workflow, task.Status, to)
+	}
+	if to == models.StatusDone {
+		open, err := h.openSubtasks(ctx, task.ID)
+		if err != nil {
+			return err
+		}
+		if open > 0 {
+			return newAPIError(http.StatusConflict, CodeInvalidTransition,
+				"Task has open subtasks",
+				FieldError{Field: "to", Message: "finish or cancel the task's subtasks first"})
+		}
+	}
+	if !allowedWhileBlocked(to) {
+		blocked, err := h.openBlockers(ctx, task.ID)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return err
+		}
+		if blocked {
+			return newAPIError(http.StatusConflict, CodeInvalidTransition,
+				"Task has open dependencies",
+				FieldError{Field: "to", Message: "finish or remove the task's dependencies first"})
+		}
+	}
+	return nil
+}
+
+// afterTransition follows up a task's move from status from: its
+// dependents are re-checked and, if it was cancelled, so are its subtasks.
+func (h *TaskHandler) afterTransition(ctx context.Context, from models.TaskStatus, task *models.Task, actorID int) {
+	if task.Status.Open() != from.Open() {
+		h.reconcileDependentsOf(ctx, task.ID)
+	}
+	if task.Status == models.StatusCancelled {
+		h.cancelSubtasks(ctx, task.ID, actorID)
+	}
+}
+
+// GetTaskTransitions lists a task's status history, oldest first.
+func (h *TaskHandler) GetTaskTransitions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	taskID, err := pathID(r, "id", "task")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if _, err := h.tasks.GetByID(ctx, taskID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, notFound("Task not found"))
 			return
 		}
-		tasks = append(tasks, task)
+		writeError(w, r, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(tasks)
-}
\ No newline at end of file
+	transitions, err := h.tasks.Transitions(ctx, taskID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if transitions == nil {
+		transitions = []models.TaskTransition{}
+	}
+
+	writeJSON(w, http.StatusOK, transitions)
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+)
+
+func TestCreateAndGetTask(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+
+	var created models.Task
+	s.expect(s.do("POST", "/tasks", map[string]any{
+		"title":       "Write the linker",
+		"project_id":  project.ID,
+		"assigned_to": lead.ID,
+		"status":      "TODO",
+	}), http.StatusOK, &created)
+	if created.ID == 0 {
+		t.Fatalf("created task %+v has no ID", created)
+	}
+
+	var got models.Task
+	s.expect(s.do("GET", fmt.Sprintf("/tasks/%d", created.ID), nil), http.StatusOK, &got)
+	if got.Title != "Write the linker" || got.ProjectID != project.ID || got.AssignedTo != lead.ID {
+		t.Fatalf("got task %+v", got)
+	}
+	s.expectError(s.do("GET", "/tasks/999", nil), http.StatusNotFound, CodeNotFound)
+
+	second := s.createTask("Write the loader", project.ID, lead.ID)
+	tasks := list[models.Task](s, "/tasks").Items
+	if got := ids(tasks, taskID); !slices.Equal(got, []int{created.ID, second.ID}) {
+		t.Fatalf("tasks = %v, want [%d %d]", got, created.ID, second.ID)
+	}
+}
+
+func TestUpdateTask(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	task := s.createTask("Write the linker", project.ID, lead.ID)
+	path := fmt.Sprintf("/tasks/%d", task.ID)
+
+	var updated models.Task
+	s.expect(s.do("PUT", path, map[string]any{
+		"title":       "Write the loader",
+		"project_id":  project.ID,
+		"assigned_to": lead.ID,
+	}), http.StatusOK, &updated)
+	if updated.Title != "Write the loader" || updated.Status != models.StatusTodo {
+		t.Fatalf("updated task %+v", updated)
+	}
+
+	// The status only changes through a transition.
+	s.expectError(s.do("PUT", path, map[string]any{
+		"title":       "Write the loader",
+		"project_id":  project.ID,
+		"assigned_to": lead.ID,
+		"status":      models.StatusDone,
+	}), http.StatusConflict, CodeInvalidTransition)
+
+	var got models.Task
+	s.expect(s.do("GET", path, nil), http.StatusOK, &got)
+	if got.Title != "Write the loader" || got.Status != models.StatusTodo {
+		t.Fatalf("got task %+v after update", got)
+	}
+	s.expectError(s.do("PUT", "/tasks/999", map[string]any{
+		"title":       "Nothing",
+		"project_id":  project.ID,
+		"assigned_to": lead.ID,
+		"status":      "TODO",
+	}), http.StatusNotFound, CodeNotFound)
+}
+
+func TestDeleteTask(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	task := s.createTask("Write the linker", project.ID, lead.ID)
+	path := fmt.Sprintf("/tasks/%d", task.ID)
+
+	s.expect(s.do("DELETE", path, nil), http.StatusOK, nil)
+	s.expectError(s.do("GET", path, nil), http.StatusNotFound, CodeNotFound)
+	s.expectError(s.do("DELETE", path, nil), http.StatusNotFound, CodeNotFound)
+}
+
+func TestCreateTaskValidation(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+
+	apiErr := s.expectError(s.do("POST", "/tasks", map[string]any{
+		"title":       "Write the linker",
+		"project_id":  999,
+		"assigned_to": lead.ID,
+		"status":      "TODO",
+	}), http.StatusBadRequest, CodeValidation)
+	if len(apiErr.Details) != 1 || apiErr.Details[0].Field != "project_id" {
+		t.Fatalf("details = %+v, want project_id", apiErr.Details)
+	}
+
+	apiErr = s.expectError(s.do("POST", "/tasks", map[string]any{
+		"title":       "Write the linker",
+		"project_id":  project.ID,
+		"assigned_to": 999,
+		"status":      "TODO",
+	}), http.StatusBadRequest, CodeValidation)
+	if len(apiErr.Details) != 1 || apiErr.Details[0].Field != "assigned_to" {
+		t.Fatalf("details = %+v, want assigned_to", apiErr.Details)
+	}
+
+	// Other statuses are only reached through the workflow.
+	apiErr = s.expectError(s.do("POST", "/tasks", map[string]any{
+		"title":       "Write the linker",
+		"project_id":  project.ID,
+		"assigned_to": lead.ID,
+		"status":      "DONE",
+	}), http.StatusBadRequest, CodeValidation)
+	if !slices.Equal(apiErr.Details, []FieldError{{Field: "status", Message: "must be TODO; change it with POST /tasks/{id}/transitions"}}) {
+		t.Fatalf("details = %+v", apiErr.Details)
+	}
+
+	// Every problem is reported at once.
+	apiErr = s.expectError(s.do("POST", "/tasks", map[string]any{
+		"title":      strings.Repeat("x", 201),
+		"project_id": project.ID,
+	}), http.StatusBadRequest, CodeValidation)
+	want := []FieldError{
+		{Field: "assigned_to", Message: "is required"},
+		{Field: "title", Message: "must be at most 200 characters"},
+	}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+}
+
+func TestTaskPlanning(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+
+	task := s.createTask("Write the linker", project.ID, lead.ID)
+	if task.Priority != models.DefaultPriority || !task.DueDate.IsZero() || task.EstimateHours != nil {
+		t.Fatalf("new task %+v, want default priority and no plan", task)
+	}
+
+	var planned models.Task
+	s.expect(s.do("PATCH", fmt.Sprintf("/tasks/%d", task.ID), map[string]any{
+		"priority":       models.PriorityP0,
+		"start_date":     "2026-03-01",
+		"due_date":       "2026-03-05",
+		"estimate_hours": 6.5,
+	}), http.StatusOK, &planned)
+	if planned.Priority != models.PriorityP0 || planned.StartDate.String() != "2026-03-01" ||
+		planned.DueDate.String() != "2026-03-05" || *planned.EstimateHours != 6.5 {
+		t.Fatalf("planned task %+v", planned)
+	}
+
+	apiErr := s.expectError(s.do("PATCH", fmt.Sprintf("/tasks/%d", task.ID), map[string]any{
+		"priority":        "P9",
+		"due_date":        "2026-02-01",
+		"remaining_hours": -1,
+	}), http.StatusBadRequest, CodeValidation)
+	want := []FieldError{
+		{Field: "priority", Message: "P9 is not a valid value"},
+		{Field: "remaining_hours", Message: "must be at least 0"},
+		{Field: "due_date", Message: "must not be before start_date"},
+	}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+	s.expectError(s.do("PATCH", fmt.Sprintf("/tasks/%d", task.ID), map[string]any{"due_date": "soon"}),
+		http.StatusBadRequest, CodeBadRequest)
+}
+
+func TestListTasksByPlan(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	other := s.createEmployee("Ada Lovelace", "ada@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	yesterday := today().AddDays(-1).String()
+	plan := func(title string, assignee int, fields map[string]any) models.Task {
+		task := s.createTask(title, project.ID, assignee)
+		s.expect(s.do("PATCH", fmt.Sprintf("/tasks/%d", task.ID), fields), http.StatusOK, &task)
+		return task
+	}
+	late := plan("late", lead.ID, map[string]any{"priority": "P1", "due_date": yesterday, "estimate_hours": 8})
+	soon := plan("soon", lead.ID, map[string]any{"priority": "P0", "due_date": today().AddDays(3).String(), "estimate_hours": 2})
+	unplanned := plan("unplanned", lead.ID, map[string]any{})
+	othersLate := plan("other's", other.ID, map[string]any{"due_date": yesterday})
+	doneLate := plan("done late", lead.ID, map[string]any{"due_date": "2020-01-01"})
+	for _, to := range []models.TaskStatus{models.StatusInProgress, models.StatusInReview, models.StatusDone} {
+		s.expect(s.do("POST", fmt.Sprintf("/tasks/%d/transitions", doneLate.ID), map[string]any{"to": to, "actor_id": lead.ID}), http.StatusOK, nil)
+	}
+
+	tasks := func(path string) []int { return ids(list[models.Task](s, path).Items, taskID) }
+	tests := []struct {
+		path string
+		want []int
+	}{
+		{"/tasks?priority=p0", []int{soon.ID}},
+		{"/tasks?due_before=" + today().String(), []int{late.ID, othersLate.ID, doneLate.ID}},
+		{"/tasks?due_after=" + today().String(), []int{soon.ID}},
+		{"/tasks?overdue=true", []int{late.ID, othersLate.ID}},
+		{"/tasks?overdue=false", []int{late.ID, soon.ID, unplanned.ID, othersLate.ID, doneLate.ID}},
+		// Tasks without a due date or estimate sort last.
+		{"/tasks?sort=due_date", []int{doneLate.ID, late.ID, othersLate.ID, soon.ID, unplanned.ID}},
+		{"/tasks?sort=estimate_hours&limit=1", []int{soon.ID}},
+		// Ties break by ID in the same direction.
+		{"/tasks?sort=-priority&limit=2", []int{doneLate.ID, othersLate.ID}},
+		{fmt.Sprintf("/employees/%d/tasks/overdue", lead.ID), []int{late.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := tasks(tt.path); !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Paging through a nullable sort visits every task once.
+	var got []int
+	path := "/tasks?sort=estimate_hours&limit=2"
+	for path != "" {
+		page := list[models.Task](s, path)
+		got = append(got, ids(page.Items, taskID)...)
+		path = ""
+		if page.NextCursor != "" {
+			path = "/tasks?sort=estimate_hours&limit=2&cursor=" + page.NextCursor
+		}
+	}
+	if want := []int{soon.ID, late.ID, unplanned.ID, othersLate.ID, doneLate.ID}; !slices.Equal(got, want) {
+		t.Fatalf("paged by estimate = %v, want %v", got, want)
+	}
+
+	apiErr := s.expectError(s.do("GET", "/tasks?priority=urgent&due_before=tomorrow&overdue=yes", nil), http.StatusBadRequest, CodeValidation)
+	if len(apiErr.Details) != 3 {
+		t.Fatalf("details = %+v, want three", apiErr.Details)
+	}
+	s.expectError(s.do("GET", "/employees/999/tasks/overdue", nil), http.StatusNotFound, CodeNotFound)
+}
@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
+)
+
+type TemplateHandler struct {
+	templates  repository.TemplateRepository
+	projects   repository.ProjectRepository
+	employees  repository.EmployeeRepository
+	tasks      repository.TaskRepository
+	labels     repository.LabelRepository
+	milestones repository.MilestoneRepository
+}
+
+func NewTemplateHandler(repos *repository.Repositories) *TemplateHandler {
+	return &TemplateHandler{
+		templates:  repos.Templates,
+		projects:   repos.Projects,
+		employees:  repos.Employees,
+		tasks:      repos.Tasks,
+		labels:     repos.Labels,
+		milestones: repos.Milestones,
+	}
+}
+
+// createTemplateRequest names the project to save as a template. The name
+// and description default to the project's.
+type createTemplateRequest struct {
+	ProjectID   int     `json:"project_id" validate:"required,min=1"`
+	Name        string  `json:"name" validate:"max=200"`
+	Description *string `json:"description"`
+}
+
+// instantiateRequest describes the project to create from a template. The
+// name defaults to the template's and the lead to the template's lead.
+// Members maps employees of the template to those who take their place,
+// as the lead, as members and as assignees.
+type instantiateRequest struct {
+	Name        string      `json:"name" validate:"max=200"`
+	Description *string     `json:"description"`
+	LeadID      int         `json:"lead_id" validate:"min=1"`
+	StartDate   models.Date `json:"start_date"`
+	Members     map[int]int `json:"members"`
+}
+
+// CreateTemplate saves a project's plan as a template: its members, labels,
+// milestones and tasks with their subtasks, with dates kept relative to the
+// earliest of them.
+func (h *TemplateHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req createTemplateRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	errs := validation.Struct(&req)
+	if err := checkExists(ctx, &errs, "project_id", req.ProjectID, h.projects.GetByID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := errs.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	template, err := h.snapshot(ctx, req.ProjectID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if req.Name != "" {
+		template.Name = req.Name
+	}
+	if req.Description != nil {
+		template.Description = *req.Description
+	}
+
+	if err := h.templates.Create(ctx, template); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, template.Version)
+	writeJSON(w, http.StatusOK, template)
+}
+
+func (h *TemplateHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	template, ok := h.template(w, r)
+	if !ok {
+		return
+	}
+
+	if notModified(w, r, template.Version) {
+		return
+	}
+	writeJSON(w, http.StatusOK, template)
+}
+
+// GetTemplates lists templates, optionally only those saved from
+// project_id.
+func (h *TemplateHandler) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	q := newListQuery(r)
+	filter := repository.TemplateFilter{
+		SourceProjectID: q.idParam("project_id"),
+	}
+	filter.Page = page(q, repository.TemplateSorts)
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	templates, err := h.templates.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, templates)
+}
+
+// UpdateTemplate replaces a template's name and description. The plan it
+// holds cannot be edited; save the project again instead.
+func (h *TemplateHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current, ok := h.template(w, r)
+	if !ok {
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req struct {
+		Name        string `json:"name" validate:"required,max=200"`
+		Description string `json:"description"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validation.Struct(&req).Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	template := current
+	template.Name = req.Name
+	template.Description = req.Description
+	template.Version = version
+	err = h.templates.Update(ctx, template)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Template not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, template.Version)
+	writeJSON(w, http.StatusOK, template)
+}
+
+func (h *TemplateHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := pathID(r, "id", "template")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = h.templates.Delete(r.Context(), templateID, version)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Template not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// InstantiateTemplate creates a project from a template, laying its dates
+// out from start_date, today by default.
+func (h *TemplateHandler) InstantiateTemplate(w http.ResponseWriter, r *http.Request) {
+	template, ok := h.template(w, r)
+	if !ok {
+		return
+	}
+	h.instantiate(w, r, template, today())
+}
+
+// CloneProject creates a copy of a project as if it had been saved as a
+// template and instantiated straight away. Dates are kept unless a
+// start_date is given to move them to.
+func (h *TemplateHandler) CloneProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID, err := pathID(r, "id", "project")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	template, err := h.snapshot(ctx, projectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Project not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	start := template.StartDate
+	if start.IsZero() {
+		start = today()
+	}
+	h.instantiate(w, r, template, start)
+}
+
+// instantiate creates a project from template as the request describes,
+// starting on start unless the request gives a start date.
+func (h *TemplateHandler) instantiate(w http.ResponseWriter, r *http.Request, template *models.ProjectTemplate, start models.Date) {
+	ctx := r.Context()
+	var req instantiateRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err := h.validateInstantiate(ctx, &req, template); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	project := models.Project{
+		Name:        template.Name,
+		Description: template.Description,
+		LeadID:      template.LeadID,
+	}
+	if req.Name != "" {
+		project.Name = req.Name
+	}
+	if req.Description != nil {
+		project.Description = *req.Description
+	}
+	if req.LeadID != 0 {
+		project.LeadID = req.LeadID
+	}
+	if !req.StartDate.IsZero() {
+		start = req.StartDate
+	}
+
+	instance, err := h.templates.Instantiate(ctx, template, &project, start)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, instance)
+}
+
+// validateInstantiate checks an instantiate request and applies its member
+// mapping to template. Every employee the template then refers to has to
+// exist; those that have been deleted since it was saved must be mapped to
+// someone else.
+func (h *TemplateHandler) validateInstantiate(ctx context.Context, req *instantiateRequest, template *models.ProjectTemplate) error {
+	errs := validation.Struct(req)
+	for from, to := range req.Members {
+		if from <= 0 || to <= 0 {
+			errs.Add("members", "must map employee IDs to employee IDs")
+			return errs.Err()
+		}
+	}
+	template.MapMembers(req.Members)
+
+	leadID := template.LeadID
+	if req.LeadID != 0 {
+		leadID = req.LeadID
+	}
+	if err := checkExists(ctx, &errs, "lead_id", leadID, h.employees.GetByID); err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, id := range template.Employees() {
+		_, err := h.employees.GetByID(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			missing = append(missing, fmt.Sprint(id))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	switch len(missing) {
+	case 0:
+	case 1:
+		errs.Add("members", fmt.Sprintf("employee %s does not exist; map them to another employee", missing[0]))
+	default:
+		errs.Add("members", fmt.Sprintf("employees %s do not exist; map them to other employees", strings.Join(missing, ", ")))
+	}
+	return errs.Err()
+}
+
+// snapshot builds a template, not yet stored, from the current state of a
+// project.
+func (h *TemplateHandler) snapshot(ctx context.Context, projectID int) (*models.ProjectTemplate, error) {
+	project, err := h.projects.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := h.employees.ListByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	milestones, err := h.milestones.ListByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := h.tasks.ListByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	projectLabels, err := h.labels.ProjectLabels(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	taskLabels := make(map[int][]models.Label)
+	for _, task := range tasks {
+		if taskLabels[task.ID], err = h.labels.TaskLabels(ctx, task.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	template := models.NewProjectTemplate(*project, members, milestones, tasks, projectLabels, taskLabels)
+	return &template, nil
+}
+
+// template loads the template named in the path.
+func (h *TemplateHandler) template(w http.ResponseWriter, r *http.Request) (*models.ProjectTemplate, bool) {
+	templateID, err := pathID(r, "id", "template")
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+
+	template, err := h.templates.GetByID(r.Context(), templateID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Template not found"))
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+	return template, true
+}
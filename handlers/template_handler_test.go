@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+)
+
+// plannedProject sets up a project with a member, a milestone and dated
+// tasks for the developer, one of them labelled and with a subtask.
+func (s *testServer) plannedProject(lead, dev models.Employee) models.Project {
+	s.t.Helper()
+	project := s.createProject("Compiler", lead.ID)
+	s.expect(s.do("POST", fmt.Sprintf("/employees/%d/projects/%d", dev.ID, project.ID), nil), http.StatusCreated, nil)
+	s.expect(s.do("POST", "/milestones", map[string]any{
+		"title":       "Beta",
+		"project_id":  project.ID,
+		"target_date": "2024-03-29",
+	}), http.StatusOK, nil)
+	label := s.createLabel("parser", &project.ID)
+
+	parser := s.createTask("Write the parser", project.ID, dev.ID)
+	s.expect(s.do("PATCH", fmt.Sprintf("/tasks/%d", parser.ID), map[string]any{
+		"start_date": "2024-03-04",
+		"due_date":   "2024-03-08",
+	}), http.StatusOK, &parser)
+	s.expect(s.do("POST", fmt.Sprintf("/tasks/%d/labels/%d", parser.ID, label.ID), nil), http.StatusCreated, nil)
+	s.createSubtask("Write the grammar", parser, 3)
+	return project
+}
+
+func TestInstantiateTemplate(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	dev := s.createEmployee("Ada Lovelace", "ada@example.com")
+	project := s.plannedProject(lead, dev)
+
+	var template models.ProjectTemplate
+	s.expect(s.do("POST", "/templates", map[string]any{"project_id": project.ID, "name": " Compiler plan "}), http.StatusOK, &template)
+	if template.Name != "Compiler plan" || template.StartDate.String() != "2024-03-04" || len(template.Tasks) != 2 || len(template.Labels) != 1 {
+		t.Fatalf("template = %+v", template)
+	}
+	if got := ids(list[models.ProjectTemplate](s, fmt.Sprintf("/templates?project_id=%d", project.ID)).Items, func(t models.ProjectTemplate) int { return t.ID }); !slices.Equal(got, []int{template.ID}) {
+		t.Fatalf("templates = %v", got)
+	}
+
+	path := fmt.Sprintf("/templates/%d/instantiate", template.ID)
+	var instance models.ProjectInstance
+	s.expect(s.do("POST", path, map[string]any{"name": "Assembler", "start_date": "2024-06-03"}), http.StatusOK, &instance)
+	if instance.Project.ID == project.ID || instance.Project.Name != "Assembler" || instance.Project.LeadID != lead.ID {
+		t.Fatalf("project = %+v", instance.Project)
+	}
+	if len(instance.Milestones) != 1 || instance.Milestones[0].TargetDate.String() != "2024-06-28" {
+		t.Fatalf("milestones = %+v", instance.Milestones)
+	}
+	if len(instance.Tasks) != 2 || len(instance.Labels) != 1 {
+		t.Fatalf("instance = %+v", instance)
+	}
+	parser, grammar := instance.Tasks[0], instance.Tasks[1]
+	if parser.StartDate.String() != "2024-06-03" || parser.DueDate.String() != "2024-06-07" || parser.AssignedTo != dev.ID || parser.Status != models.StatusTodo {
+		t.Fatalf("parser = %+v", parser)
+	}
+	if *grammar.ParentTaskID != parser.ID || grammar.ProjectID != instance.Project.ID {
+		t.Fatalf("grammar = %+v", grammar)
+	}
+	var members []models.Employee
+	s.expect(s.do("GET", fmt.Sprintf("/projects/%d/employees", instance.Project.ID), nil), http.StatusOK, &members)
+	if got := ids(members, employeeID); !slices.Equal(got, []int{dev.ID}) {
+		t.Fatalf("members = %v", got)
+	}
+
+	// Once the developer is gone, someone has to take their place.
+	s.expect(s.do("DELETE", fmt.Sprintf("/projects/%d", project.ID), nil), http.StatusOK, nil)
+	s.expect(s.do("DELETE", fmt.Sprintf("/projects/%d", instance.Project.ID), nil), http.StatusOK, nil)
+	s.expect(s.do("DELETE", fmt.Sprintf("/employees/%d", dev.ID), nil), http.StatusNoContent, nil)
+	apiErr := s.expectError(s.do("POST", path, nil), http.StatusBadRequest, CodeValidation)
+	want := []FieldError{{Field: "members", Message: fmt.Sprintf("employee %d does not exist; map them to another employee", dev.ID)}}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+	s.expect(s.do("POST", path, map[string]any{"members": map[string]int{fmt.Sprint(dev.ID): lead.ID}}), http.StatusOK, &instance)
+	for _, task := range instance.Tasks {
+		if task.AssignedTo != lead.ID {
+			t.Fatalf("task = %+v, want it assigned to %d", task, lead.ID)
+		}
+	}
+	if instance.Tasks[0].StartDate != today() {
+		t.Fatalf("start date = %s, want today", instance.Tasks[0].StartDate)
+	}
+}
+
+func TestInstantiateValidation(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	dev := s.createEmployee("Ada Lovelace", "ada@example.com")
+	project := s.plannedProject(lead, dev)
+	var template models.ProjectTemplate
+	s.expect(s.do("POST", "/templates", map[string]any{"project_id": project.ID}), http.StatusOK, &template)
+	path := fmt.Sprintf("/templates/%d/instantiate", template.ID)
+
+	tests := []struct {
+		name string
+		req  map[string]any
+		want []FieldError
+	}{
+		{
+			name: "missing lead",
+			req:  map[string]any{"lead_id": 999},
+			want: []FieldError{{Field: "lead_id", Message: "does not exist"}},
+		},
+		{
+			name: "lead and member mapped to missing employees",
+			req:  map[string]any{"members": map[string]int{fmt.Sprint(dev.ID): 998, fmt.Sprint(lead.ID): 999}},
+			want: []FieldError{
+				{Field: "lead_id", Message: "does not exist"},
+				{Field: "members", Message: "employee 998 does not exist; map them to another employee"},
+			},
+		},
+		{
+			name: "not an ID",
+			req:  map[string]any{"members": map[string]int{"0": lead.ID}},
+			want: []FieldError{{Field: "members", Message: "must map employee IDs to employee IDs"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := s.expectError(s.do("POST", path, tt.req), http.StatusBadRequest, CodeValidation)
+			if !slices.Equal(apiErr.Details, tt.want) {
+				t.Fatalf("details = %+v, want %+v", apiErr.Details, tt.want)
+			}
+		})
+	}
+
+	s.expectError(s.do("POST", "/templates/999/instantiate", nil), http.StatusNotFound, CodeNotFound)
+	apiErr := s.expectError(s.do("POST", "/templates", map[string]any{"project_id": 999}), http.StatusBadRequest, CodeValidation)
+	if apiErr.Details[0] != (FieldError{Field: "project_id", Message: "does not exist"}) {
+		t.Fatalf("details = %+v", apiErr.Details)
+	}
+}
+
+func TestCloneProject(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	dev := s.createEmployee("Ada Lovelace", "ada@example.com")
+	project := s.plannedProject(lead, dev)
+	path := fmt.Sprintf("/projects/%d/clone", project.ID)
+
+	// Without a start date the copy keeps the project's dates.
+	var instance models.ProjectInstance
+	s.expect(s.do("POST", path, nil), http.StatusOK, &instance)
+	if instance.Project.Name != "Compiler" || instance.Project.ID == project.ID {
+		t.Fatalf("project = %+v", instance.Project)
+	}
+	if parser := instance.Tasks[0]; parser.StartDate.String() != "2024-03-04" || parser.DueDate.String() != "2024-03-08" {
+		t.Fatalf("parser dates = %s to %s", parser.StartDate, parser.DueDate)
+	}
+	if target := instance.Milestones[0].TargetDate.String(); target != "2024-03-29" {
+		t.Fatalf("milestone target = %s", target)
+	}
+
+	s.expect(s.do("POST", path, map[string]any{"name": "Compiler 2", "start_date": "2024-03-11"}), http.StatusOK, &instance)
+	if parser := instance.Tasks[0]; parser.StartDate.String() != "2024-03-11" || parser.DueDate.String() != "2024-03-15" {
+		t.Fatalf("moved parser dates = %s to %s", parser.StartDate, parser.DueDate)
+	}
+	if instance.Project.Name != "Compiler 2" {
+		t.Fatalf("project = %+v", instance.Project)
+	}
+
+	s.expectError(s.do("POST", "/projects/999/clone", nil), http.StatusNotFound, CodeNotFound)
+}
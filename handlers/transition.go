@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"nstorm.com/main-backend/models"
+)
+
+// parseStatus normalises a task status given in any letter case.
+func parseStatus(raw string) (models.TaskStatus, bool) {
+	status := models.TaskStatus(strings.ToUpper(strings.TrimSpace(raw)))
+	return status, status.Valid()
+}
+
+func statusMessage() string {
+	names := make([]string, len(models.TaskStatuses))
+	for i, status := range models.TaskStatuses {
+		names[i] = string(status)
+	}
+	return "must be one of " + strings.Join(names, ", ")
+}
+
+// invalidTransition reports a status change the workflow does not allow.
+func invalidTransition(workflow models.TaskWorkflow, from, to models.TaskStatus) *APIError {
+	allowed := make([]string, len(workflow[from]))
+	for i, status := range workflow[from] {
+		allowed[i] = string(status)
+	}
+	message := "no transitions allowed from " + string(from)
+	if len(allowed) > 0 {
+		message = "allowed from " + string(from) + ": " + strings.Join(allowed, ", ")
+	}
+	return newAPIError(http.StatusConflict, CodeInvalidTransition,
+		"Cannot move task from "+string(from)+" to "+string(to),
+		FieldError{Field: "to", Message: message})
+}
+
+// statusChangeNotAllowed rejects status changes made through PUT or PATCH.
+func statusChangeNotAllowed() *APIError {
+	return newAPIError(http.StatusConflict, CodeInvalidTransition,
+		"Task status can only be changed through POST /tasks/{id}/transitions",
+		FieldError{Field: "status", Message: "cannot be changed here"})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"nstorm.com/main-backend/models"
+)
+
+func TestTransitionTask(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	task := s.createTask("Write the linker", project.ID, lead.ID)
+	path := fmt.Sprintf("/tasks/%d/transitions", task.ID)
+
+	if task.Status != models.StatusTodo {
+		t.Fatalf("new task has status %s", task.Status)
+	}
+
+	var moved models.Task
+	rec := s.do("POST", path, map[string]any{"to": "in_progress", "actor_id": lead.ID, "note": "starting"})
+	s.expect(rec, http.StatusOK, &moved)
+	if moved.Status != models.StatusInProgress || moved.Version != 2 {
+		t.Fatalf("moved task %+v", moved)
+	}
+	if rec.Header().Get("ETag") != etag(2) {
+		t.Fatalf("ETag = %s", rec.Header().Get("ETag"))
+	}
+	s.expect(s.do("POST", path, map[string]any{"to": models.StatusInReview, "actor_id": lead.ID}, "If-Match", etag(2)), http.StatusOK, &moved)
+
+	var history []models.TaskTransition
+	s.expect(s.do("GET", path, nil), http.StatusOK, &history)
+	if len(history) != 2 {
+		t.Fatalf("history = %+v, want 2 entries", history)
+	}
+	first := history[0]
+	if first.From != models.StatusTodo || first.To != models.StatusInProgress || first.Note != "starting" ||
+		first.ActorID == nil || *first.ActorID != lead.ID || first.TaskID != task.ID {
+		t.Fatalf("first transition %+v", first)
+	}
+	if history[1].From != models.StatusInProgress || history[1].To != models.StatusInReview {
+		t.Fatalf("second transition %+v", history[1])
+	}
+}
+
+func TestTransitionTaskRejected(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	task := s.createTask("Write the linker", project.ID, lead.ID)
+	path := fmt.Sprintf("/tasks/%d/transitions", task.ID)
+
+	apiErr := s.expectError(s.do("POST", path, map[string]any{"to": models.StatusDone, "actor_id": lead.ID}),
+		http.StatusConflict, CodeInvalidTransition)
+	want := []FieldError{{Field: "to", Message: "allowed from TODO: IN_PROGRESS, BLOCKED, CANCELLED"}}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+
+	apiErr = s.expectError(s.do("POST", path, map[string]any{"to": "FINISHED", "actor_id": 999}),
+		http.StatusBadRequest, CodeValidation)
+	want = []FieldError{
+		{Field: "to", Message: "FINISHED is not a valid value"},
+		{Field: "actor_id", Message: "does not exist"},
+	}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+
+	s.expectError(s.do("POST", path, map[string]any{"to": models.StatusInProgress, "actor_id": lead.ID}, "If-Match", etag(5)),
+		http.StatusPreconditionFailed, CodePreconditionFailed)
+	s.expectError(s.do("POST", "/tasks/999/transitions", map[string]any{"to": models.StatusInProgress, "actor_id": lead.ID}),
+		http.StatusNotFound, CodeNotFound)
+	s.expectError(s.do("GET", "/tasks/999/transitions", nil), http.StatusNotFound, CodeNotFound)
+
+	// PATCH cannot sidestep the workflow either.
+	s.expectError(s.do("PATCH", fmt.Sprintf("/tasks/%d", task.ID), map[string]any{"status": models.StatusDone}),
+		http.StatusConflict, CodeInvalidTransition)
+
+	var history []models.TaskTransition
+	s.expect(s.do("GET", path, nil), http.StatusOK, &history)
+	if len(history) != 0 {
+		t.Fatalf("rejected transitions were recorded: %+v", history)
+	}
+}
+
+func TestTransitionTerminalStatus(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	task := s.createTask("Write the linker", project.ID, lead.ID)
+	path := fmt.Sprintf("/tasks/%d/transitions", task.ID)
+
+	// Route transitions through a handler with a configured workflow in
+	// which DONE is terminal.
+	handler := NewTaskHandler(s.repos, models.TaskWorkflow{models.StatusTodo: {models.StatusDone}})
+	s.router = mux.NewRouter()
+	s.router.HandleFunc("/tasks/{id}/transitions", handler.TransitionTask).Methods("POST")
+
+	s.expect(s.do("POST", path, map[string]any{"to": models.StatusDone, "actor_id": lead.ID}), http.StatusOK, nil)
+	apiErr := s.expectError(s.do("POST", path, map[string]any{"to": models.StatusTodo, "actor_id": lead.ID}),
+		http.StatusConflict, CodeInvalidTransition)
+	if apiErr.Details[0].Message != "no transitions allowed from DONE" {
+		t.Fatalf("details = %+v", apiErr.Details)
+	}
+}
+
+func TestListTasksByStatus(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	parser := s.createTask("Write the parser", project.ID, lead.ID)
+	linker := s.createTask("Write the linker", project.ID, lead.ID)
+	s.expect(s.do("POST", fmt.Sprintf("/tasks/%d/transitions", linker.ID),
+		map[string]any{"to": models.StatusBlocked, "actor_id": lead.ID}), http.StatusOK, nil)
+
+	if got := ids(list[models.Task](s, "/tasks?status=todo").Items, taskID); !slices.Equal(got, []int{parser.ID}) {
+		t.Fatalf("to do tasks = %v, want [%d]", got, parser.ID)
+	}
+	if got := ids(list[models.Task](s, "/tasks?status=BLOCKED").Items, taskID); !slices.Equal(got, []int{linker.ID}) {
+		t.Fatalf("blocked tasks = %v, want [%d]", got, linker.ID)
+	}
+	s.expectError(s.do("GET", "/tasks?status=stuck", nil), http.StatusBadRequest, CodeValidation)
+}
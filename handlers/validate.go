@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
+)
+
+// checkExists records a field error when id does not name an existing row.
+// It does nothing if the field already failed validation.
+func checkExists[T any](ctx context.Context, errs *validation.Errors, field string, id int, get func(context.Context, int) (T, error)) error {
+	if id <= 0 || errs.Has(field) {
+		return nil
+	}
+	_, err := get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		errs.Add(field, "does not exist")
+		return nil
+	}
+	return err
+}
+
+func validateEmployee(employee *models.Employee) error {
+	errs := validation.Struct(employee)
+	for _, skill := range employee.Skills {
+		if skill == "" {
+			errs.Add("skills", "must not contain empty values")
+			break
+		}
+	}
+	return errs.Err()
+}
+
+func validateProject(ctx context.Context, employees repository.EmployeeRepository, project *models.Project) error {
+	errs := validation.Struct(project)
+	if err := checkExists(ctx, &errs, "lead_id", project.LeadID, employees.GetByID); err != nil {
+		return err
+	}
+	return errs.Err()
+}
+
+func validateTask(ctx context.Context, projects repository.ProjectRepository, employees repository.EmployeeRepository, tasks repository.TaskRepository, sprints repository.SprintRepository, milestones repository.MilestoneRepository, task *models.Task) error {
+	errs := validation.Struct(task)
+	if !task.StartDate.IsZero() && !task.DueDate.IsZero() && task.DueDate.Before(task.StartDate.Time) {
+		errs.Add("due_date", "must not be before start_date")
+	}
+	if err := checkProject(ctx, &errs, projects, tasks, task); err != nil {
+		return err
+	}
+	if err := checkExists(ctx, &errs, "assigned_to", task.AssignedTo, employees.GetByID); err != nil {
+		return err
+	}
+	if err := checkHierarchy(ctx, &errs, tasks, task); err != nil {
+		return err
+	}
+	if err := checkSprint(ctx, &errs, tasks, sprints, task); err != nil {
+		return err
+	}
+	if err := checkMilestone(ctx, &errs, tasks, milestones, task); err != nil {
+		return err
+	}
+	return errs.Err()
+}
+
+// checkProject requires a task's project to exist and, unless the task is
+// already in it, not to be archived.
+func checkProject(ctx context.Context, errs *validation.Errors, projects repository.ProjectRepository, tasks repository.TaskRepository, task *models.Task) error {
+	if task.ProjectID <= 0 || errs.Has("project_id") {
+		return nil
+	}
+	project, err := projects.GetByID(ctx, task.ProjectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		errs.Add("project_id", "does not exist")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if project.ArchivedAt == nil {
+		return nil
+	}
+	if task.ID != 0 {
+		current, err := tasks.GetByID(ctx, task.ID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if current != nil && current.ProjectID == project.ID {
+			return nil
+		}
+	}
+	errs.Add("project_id", "must not be an archived project")
+	return nil
+}
+
+// checkSprint requires a task's sprint to exist in the same project and,
+// unless the task is already in it, not to be closed.
+func checkSprint(ctx context.Context, errs *validation.Errors, tasks repository.TaskRepository, sprints repository.SprintRepository, task *models.Task) error {
+	if task.SprintID == nil || errs.Has("sprint_id") {
+		return nil
+	}
+	sprint, err := sprints.GetByID(ctx, *task.SprintID)
+	if errors.Is(err, repository.ErrNotFound) {
+		errs.Add("sprint_id", "does not exist")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if sprint.ProjectID != task.ProjectID {
+		errs.Add("sprint_id", "must belong to the same project")
+		return nil
+	}
+	if sprint.State != models.SprintClosed {
+		return nil
+	}
+	if task.ID != 0 {
+		current, err := tasks.GetByID(ctx, task.ID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if current != nil && current.SprintID != nil && *current.SprintID == sprint.ID {
+			return nil
+		}
+	}
+	errs.Add("sprint_id", "must not be a closed sprint")
+	return nil
+}
+
+// checkMilestone requires a task's milestone to exist in the same project
+// and, unless the task is already linked to it, to be open.
+func checkMilestone(ctx context.Context, errs *validation.Errors, tasks repository.TaskRepository, milestones repository.MilestoneRepository, task *models.Task) error {
+	if task.MilestoneID == nil || errs.Has("milestone_id") {
+		return nil
+	}
+	milestone, err := milestones.GetByID(ctx, *task.MilestoneID)
+	if errors.Is(err, repository.ErrNotFound) {
+		errs.Add("milestone_id", "does not exist")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if milestone.ProjectID != task.ProjectID {
+		errs.Add("milestone_id", "must belong to the same project")
+		return nil
+	}
+	if milestone.Status == models.MilestoneOpen {
+		return nil
+	}
+	if task.ID != 0 {
+		current, err := tasks.GetByID(ctx, task.ID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if current != nil && current.MilestoneID != nil && *current.MilestoneID == milestone.ID {
+			return nil
+		}
+	}
+	errs.Add("milestone_id", "must be an open milestone")
+	return nil
+}
+
+// checkHierarchy requires a task's parent to exist in the same project and
+// not to be the task itself or one of its subtasks, and keeps an existing
+// task in the project of its subtasks.
+func checkHierarchy(ctx context.Context, errs *validation.Errors, tasks repository.TaskRepository, task *models.Task) error {
+	var subtree []models.Task
+	if task.ID != 0 {
+		var err error
+		subtree, err = tasks.Subtree(ctx, task.ID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+	}
+	for _, sub := range subtree {
+		if sub.ID != task.ID && sub.ProjectID != task.ProjectID && !errs.Has("project_id") {
+			errs.Add("project_id", "must be the project of the task's subtasks")
+		}
+	}
+
+	if task.ParentTaskID == nil || errs.Has("parent_task_id") {
+		return nil
+	}
+	parent, err := tasks.GetByID(ctx, *task.ParentTaskID)
+	if errors.Is(err, repository.ErrNotFound) {
+		errs.Add("parent_task_id", "does not exist")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if parent.ProjectID != task.ProjectID {
+		errs.Add("parent_task_id", "must belong to the same project")
+	}
+	for _, sub := range subtree {
+		if sub.ID == parent.ID {
+			errs.Add("parent_task_id", "must not be the task itself or one of its subtasks")
+			break
+		}
+	}
+	return nil
+}
+
+func validateSprint(ctx context.Context, projects repository.ProjectRepository, sprint *models.Sprint) error {
+	errs := validation.Struct(sprint)
+	if !sprint.StartDate.IsZero() && !sprint.EndDate.IsZero() && sprint.EndDate.Before(sprint.StartDate.Time) {
+		errs.Add("end_date", "must not be before start_date")
+	}
+	if err := checkExists(ctx, &errs, "project_id", sprint.ProjectID, projects.GetByID); err != nil {
+		return err
+	}
+	return errs.Err()
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
+)
+
+type WorklogHandler struct {
+	worklogs  repository.WorklogRepository
+	tasks     repository.TaskRepository
+	employees repository.EmployeeRepository
+}
+
+func NewWorklogHandler(repos *repository.Repositories) *WorklogHandler {
+	return &WorklogHandler{
+		worklogs:  repos.Worklogs,
+		tasks:     repos.Tasks,
+		employees: repos.Employees,
+	}
+}
+
+type worklogRequest struct {
+	TaskID          int       `json:"task_id" validate:"required,min=1"`
+	EmployeeID      int       `json:"employee_id" validate:"required,min=1"`
+	StartedAt       time.Time `json:"started_at" validate:"required"`
+	DurationSeconds *int      `json:"duration_seconds" validate:"required,min=0,max=86400"`
+	Note            string    `json:"note" validate:"max=1000"`
+}
+
+type worklogEditRequest struct {
+	StartedAt       time.Time `json:"started_at" validate:"required"`
+	DurationSeconds *int      `json:"duration_seconds" validate:"required,min=0,max=86400"`
+	Note            string    `json:"note" validate:"max=1000"`
+}
+
+type timerRequest struct {
+	TaskID int    `json:"task_id" validate:"required,min=1"`
+	Note   string `json:"note" validate:"max=1000"`
+}
+
+// CreateWorklog records time an employee has already spent on a task.
+func (h *WorklogHandler) CreateWorklog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req worklogRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	errs := validation.Struct(&req)
+	if err := checkExists(ctx, &errs, "task_id", req.TaskID, h.tasks.GetByID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := checkExists(ctx, &errs, "employee_id", req.EmployeeID, h.employees.GetByID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := errs.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	worklog := models.Worklog{
+		TaskID:          req.TaskID,
+		EmployeeID:      req.EmployeeID,
+		StartedAt:       req.StartedAt,
+		DurationSeconds: req.DurationSeconds,
+		Note:            req.Note,
+	}
+	if err := h.worklogs.Create(ctx, &worklog); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, worklog.Version)
+	writeJSON(w, http.StatusOK, worklog)
+}
+
+// GetWorklog retrieves a worklog by its ID
+func (h *WorklogHandler) GetWorklog(w http.ResponseWriter, r *http.Request) {
+	worklog, ok := h.worklog(w, r)
+	if !ok {
+		return
+	}
+
+	if notModified(w, r, worklog.Version) {
+		return
+	}
+	writeJSON(w, http.StatusOK, worklog)
+}
+
+// GetWorklogs lists worklogs, optionally filtered by task, employee or
+// project, by the dates from and to (inclusive) they started on, and by
+// whether they are running timers.
+func (h *WorklogHandler) GetWorklogs(w http.ResponseWriter, r *http.Request) {
+	q := newListQuery(r)
+	filter := worklogFilter(q)
+	if q.get("running") != "" {
+		running := q.boolParam("running")
+		filter.Running = &running
+	}
+	filter.Page = page(q, repository.WorklogSorts)
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	worklogs, err := h.worklogs.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, worklogs)
+}
+
+// UpdateWorklog replaces the start, duration and note of a worklog. The
+// task and employee cannot be changed.
+func (h *WorklogHandler) UpdateWorklog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current, ok := h.worklog(w, r)
+	if !ok {
+		return
+	}
+	version, err := checkIfMatch(r, current.Version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req worklogEditRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if err := validation.Struct(&req).Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if current.Running() {
+		writeError(w, r, newAPIError(http.StatusConflict, CodeConflict, "Worklog is a running timer; stop it first"))
+		return
+	}
+
+	worklog := models.Worklog{
+		ID:              current.ID,
+		StartedAt:       req.StartedAt,
+		DurationSeconds: req.DurationSeconds,
+		Note:            req.Note,
+		Version:         version,
+	}
+	err = h.worklogs.Update(ctx, &worklog)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Worklog not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, worklog.Version)
+	writeJSON(w, http.StatusOK, worklog)
+}
+
+// DeleteWorklog deletes a worklog, including a running timer.
+func (h *WorklogHandler) DeleteWorklog(w http.ResponseWriter, r *http.Request) {
+	worklogID, err := pathID(r, "id", "worklog")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = h.worklogs.Delete(r.Context(), worklogID, version)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Worklog not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetTimer returns the employee's running timer.
+func (h *WorklogHandler) GetTimer(w http.ResponseWriter, r *http.Request) {
+	employeeID, ok := h.employeeID(w, r)
+	if !ok {
+		return
+	}
+
+	worklog, err := h.worklogs.Running(r.Context(), employeeID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("No timer running"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, worklog)
+}
+
+// StartTimer starts a timer for the employee on a task. An employee has at
+// most one running timer, and it may not start before time they have
+// already logged ends.
+func (h *WorklogHandler) StartTimer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	employeeID, ok := h.employeeID(w, r)
+	if !ok {
+		return
+	}
+
+	var req timerRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	errs := validation.Struct(&req)
+	if err := checkExists(ctx, &errs, "task_id", req.TaskID, h.tasks.GetByID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := errs.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	worklog := models.Worklog{TaskID: req.TaskID, EmployeeID: employeeID, Note: req.Note}
+	err := h.worklogs.Start(ctx, &worklog)
+	if errors.Is(err, repository.ErrWorklogOverlap) {
+		if running, err := h.worklogs.Running(ctx, employeeID); err == nil {
+			writeError(w, r, newAPIError(http.StatusConflict, CodeWorklogOverlap, "A timer is already running for this employee",
+				FieldError{Field: "task_id", Message: "timer running on task " + strconv.Itoa(running.TaskID)}))
+			return
+		}
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, worklog.Version)
+	writeJSON(w, http.StatusOK, worklog)
+}
+
+// StopTimer stops the employee's running timer and returns the finished
+// worklog.
+func (h *WorklogHandler) StopTimer(w http.ResponseWriter, r *http.Request) {
+	employeeID, ok := h.employeeID(w, r)
+	if !ok {
+		return
+	}
+
+	worklog, err := h.worklogs.Stop(r.Context(), employeeID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("No timer running"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setETag(w, worklog.Version)
+	writeJSON(w, http.StatusOK, worklog)
+}
+
+type worklogReport struct {
+	GroupBy      models.WorklogGroup   `json:"group_by"`
+	From         *models.Date          `json:"from"`
+	To           *models.Date          `json:"to"`
+	Totals       []models.WorklogTotal `json:"totals"`
+	TotalSeconds int                   `json:"total_seconds"`
+	TotalHours   float64               `json:"total_hours"`
+}
+
+// GetWorklogReport totals finished worklogs per employee, task or project
+// (group_by), over the dates from and to, both inclusive. The filters of
+// GetWorklogs narrow the worklogs counted; running timers never count.
+func (h *WorklogHandler) GetWorklogReport(w http.ResponseWriter, r *http.Request) {
+	q := newListQuery(r)
+	filter := worklogFilter(q)
+	group := models.WorklogGroup(strings.ToLower(q.get("group_by")))
+	switch group {
+	case models.GroupByEmployee, models.GroupByTask, models.GroupByProject:
+	case "":
+		q.errs.Add("group_by", "is required")
+	default:
+		q.errs.Add("group_by", "must be one of employee, task, project")
+	}
+	if err := q.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	totals, err := h.worklogs.Report(r.Context(), group, filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	report := worklogReport{
+		GroupBy: group,
+		From:    q.dateParam("from"),
+		To:      q.dateParam("to"),
+		Totals:  totals,
+	}
+	for _, total := range totals {
+		report.TotalSeconds += total.Seconds
+	}
+	report.TotalHours = models.SecondsToHours(report.TotalSeconds)
+	writeJSON(w, http.StatusOK, report)
+}
+
+// worklogFilter reads the filters shared by the worklog list and report.
+// The dates from and to are inclusive and taken in UTC.
+func worklogFilter(q *listQuery) repository.WorklogFilter {
+	filter := repository.WorklogFilter{
+		TaskID:     q.idParam("task_id"),
+		EmployeeID: q.idParam("employee_id"),
+		ProjectID:  q.idParam("project_id"),
+	}
+	from, to := q.dateParam("from"), q.dateParam("to")
+	if from != nil {
+		start := from.Time
+		filter.From = &start
+	}
+	if to != nil {
+		end := to.AddDays(1).Time
+		filter.To = &end
+	}
+	if from != nil && to != nil && to.Before(from.Time) {
+		q.errs.Add("to", "must not be before from")
+	}
+	return filter
+}
+
+// employeeID reads the employee ID from the path and checks that the
+// employee exists, writing the error response and returning false if not.
+func (h *WorklogHandler) employeeID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	employeeID, err := pathID(r, "id", "employee")
+	if err != nil {
+		writeError(w, r, err)
+		return 0, false
+	}
+
+	if _, err := h.employees.GetByID(r.Context(), employeeID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, notFound("Employee not found"))
+			return 0, false
+		}
+		writeError(w, r, err)
+		return 0, false
+	}
+	return employeeID, true
+}
+
+// worklog loads the worklog named in the path.
+func (h *WorklogHandler) worklog(w http.ResponseWriter, r *http.Request) (*models.Worklog, bool) {
+	worklogID, err := pathID(r, "id", "worklog")
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+
+	worklog, err := h.worklogs.GetByID(r.Context(), worklogID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, notFound("Worklog not found"))
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+	return worklog, true
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"nstorm.com/main-backend/models"
+)
+
+func (s *testServer) logWork(taskID, employeeID int, startedAt string, minutes int) *httptest.ResponseRecorder {
+	s.t.Helper()
+	return s.do("POST", "/worklogs", map[string]any{
+		"task_id":          taskID,
+		"employee_id":      employeeID,
+		"started_at":       startedAt,
+		"duration_seconds": minutes * 60,
+	})
+}
+
+func TestWorklogOverlap(t *testing.T) {
+	s := newTestServer(t)
+	grace := s.createEmployee("Grace Hopper", "grace@example.com")
+	ada := s.createEmployee("Ada Lovelace", "ada@example.com")
+	project := s.createProject("Compiler", grace.ID)
+	task := s.createTask("Write the parser", project.ID, grace.ID)
+
+	var first models.Worklog
+	s.expect(s.logWork(task.ID, grace.ID, "2024-03-01T09:00:00Z", 60), http.StatusOK, &first)
+	if first.Running() || *first.DurationSeconds != 3600 || !first.StartedAt.Equal(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("worklog = %+v", first)
+	}
+
+	apiErr := s.expectError(s.logWork(task.ID, grace.ID, "2024-03-01T09:30:00Z", 60), http.StatusConflict, CodeWorklogOverlap)
+	if apiErr.Message != "Worklog overlaps time the employee has already logged" {
+		t.Fatalf("got %+v", apiErr)
+	}
+	// Time zones are compared as instants.
+	s.expectError(s.logWork(task.ID, grace.ID, "2024-03-01T10:59:00+02:00", 10), http.StatusConflict, CodeWorklogOverlap)
+
+	// Back to back entries and other employees' time don't overlap.
+	var second models.Worklog
+	s.expect(s.logWork(task.ID, grace.ID, "2024-03-01T10:00:00Z", 30), http.StatusOK, &second)
+	s.expect(s.logWork(task.ID, ada.ID, "2024-03-01T09:30:00Z", 60), http.StatusOK, nil)
+
+	// Editing an entry checks it against the others, but not itself.
+	path := fmt.Sprintf("/worklogs/%d", second.ID)
+	s.expectError(s.do("PUT", path, map[string]any{
+		"started_at":       "2024-03-01T09:45:00Z",
+		"duration_seconds": 1800,
+	}), http.StatusConflict, CodeWorklogOverlap)
+	var edited models.Worklog
+	s.expect(s.do("PUT", path, map[string]any{
+		"started_at":       "2024-03-01T10:15:00Z",
+		"duration_seconds": 1800,
+		"note":             " reviewed ",
+	}), http.StatusOK, &edited)
+	if edited.Note != "reviewed" || edited.TaskID != task.ID || edited.EmployeeID != grace.ID || edited.Version != 2 {
+		t.Fatalf("edited worklog = %+v", edited)
+	}
+
+	apiErr = s.expectError(s.logWork(task.ID, 999, "2024-03-02T09:00:00Z", 25*60), http.StatusBadRequest, CodeValidation)
+	want := []FieldError{
+		{Field: "duration_seconds", Message: "must be at most 86400"},
+		{Field: "employee_id", Message: "does not exist"},
+	}
+	if !slices.Equal(apiErr.Details, want) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, want)
+	}
+}
+
+func TestWorklogTimer(t *testing.T) {
+	s := newTestServer(t)
+	lead := s.createEmployee("Grace Hopper", "grace@example.com")
+	project := s.createProject("Compiler", lead.ID)
+	parser := s.createTask("Write the parser", project.ID, lead.ID)
+	linker := s.createTask("Write the linker", project.ID, lead.ID)
+	timer := fmt.Sprintf("/employees/%d/timer", lead.ID)
+
+	s.expectError(s.do("GET", timer, nil), http.StatusNotFound, CodeNotFound)
+	s.expectError(s.do("DELETE", timer, nil), http.StatusNotFound, CodeNotFound)
+
+	var started models.Worklog
+	s.expect(s.do("POST", timer, map[string]any{"task_id": parser.ID}), http.StatusOK, &started)
+	if !started.Running() || started.TaskID != parser.ID || time.Since(started.StartedAt) > time.Minute {
+		t.Fatalf("timer = %+v", started)
+	}
+	var running models.Worklog
+	s.expect(s.do("GET", timer, nil), http.StatusOK, &running)
+	if running.ID != started.ID {
+		t.Fatalf("running timer = %+v, want %d", running, started.ID)
+	}
+
+	// One timer at a time, and no logging time after it started.
+	apiErr := s.expectError(s.do("POST", timer, map[string]any{"task_id": linker.ID}), http.StatusConflict, CodeWorklogOverlap)
+	if len(apiErr.Details) != 1 || apiErr.Details[0].Message != fmt.Sprintf("timer running on task %d", parser.ID) {
+		t.Fatalf("got %+v", apiErr)
+	}
+	later := started.StartedAt.Add(time.Hour).Format(time.RFC3339)
+	s.expectError(s.logWork(linker.ID, lead.ID, later, 10), http.StatusConflict, CodeWorklogOverlap)
+	earlier := started.StartedAt.Add(-time.Hour).Format(time.RFC3339)
+	s.expect(s.logWork(linker.ID, lead.ID, earlier, 10), http.StatusOK, nil)
+	s.expectError(s.do("PUT", fmt.Sprintf("/worklogs/%d", started.ID), map[string]any{
+		"started_at":       earlier,
+		"duration_seconds": 60,
+	}), http.StatusConflict, CodeConflict)
+
+	page := list[models.Worklog](s, "/worklogs?running=true")
+	if got := ids(page.Items, worklogID); !slices.Equal(got, []int{started.ID}) {
+		t.Fatalf("running worklogs = %v", got)
+	}
+
+	var stopped models.Worklog
+	s.expect(s.do("DELETE", timer, nil), http.StatusOK, &stopped)
+	if stopped.ID != started.ID || stopped.Running() || *stopped.DurationSeconds > 60 {
+		t.Fatalf("stopped timer = %+v", stopped)
+	}
+	s.expectError(s.do("GET", timer, nil), http.StatusNotFound, CodeNotFound)
+	if page := list[models.Worklog](s, "/worklogs?running=true"); len(page.Items) != 0 {
+		t.Fatalf("running worklogs after stopping = %v", page.Items)
+	}
+}
+
+func TestWorklogReport(t *testing.T) {
+	s := newTestServer(t)
+	grace := s.createEmployee("Grace Hopper", "grace@example.com")
+	ada := s.createEmployee("Ada Lovelace", "ada@example.com")
+	compiler := s.createProject("Compiler", grace.ID)
+	debugger := s.createProject("Debugger", grace.ID)
+	parser := s.createTask("Write the parser", compiler.ID, grace.ID)
+	stepper := s.createTask("Write the stepper", debugger.ID, grace.ID)
+
+	s.expect(s.logWork(parser.ID, grace.ID, "2024-03-01T09:00:00Z", 90), http.StatusOK, nil)
+	s.expect(s.logWork(stepper.ID, grace.ID, "2024-03-02T09:00:00Z", 30), http.StatusOK, nil)
+	s.expect(s.logWork(parser.ID, ada.ID, "2024-03-02T23:30:00Z", 30), http.StatusOK, nil)
+	s.expect(s.logWork(parser.ID, ada.ID, "2024-03-03T00:00:00Z", 60), http.StatusOK, nil)
+	s.expect(s.do("POST", fmt.Sprintf("/employees/%d/timer", grace.ID), map[string]any{"task_id": parser.ID}), http.StatusOK, nil)
+
+	var report struct {
+		GroupBy      models.WorklogGroup   `json:"group_by"`
+		Totals       []models.WorklogTotal `json:"totals"`
+		TotalSeconds int                   `json:"total_seconds"`
+		TotalHours   float64               `json:"total_hours"`
+	}
+	// Running timers never count.
+	s.expect(s.do("GET", "/worklogs/report?group_by=employee", nil), http.StatusOK, &report)
+	want := []models.WorklogTotal{
+		{ID: grace.ID, Name: "Grace Hopper", Entries: 2, Seconds: 7200, Hours: 2},
+		{ID: ada.ID, Name: "Ada Lovelace", Entries: 2, Seconds: 5400, Hours: 1.5},
+	}
+	if !slices.Equal(report.Totals, want) || report.TotalSeconds != 12600 || report.TotalHours != 3.5 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	// The dates are inclusive and cut at midnight UTC.
+	s.expect(s.do("GET", "/worklogs/report?group_by=project&from=2024-03-02&to=2024-03-02", nil), http.StatusOK, &report)
+	want = []models.WorklogTotal{
+		{ID: compiler.ID, Name: "Compiler", Entries: 1, Seconds: 1800, Hours: 0.5},
+		{ID: debugger.ID, Name: "Debugger", Entries: 1, Seconds: 1800, Hours: 0.5},
+	}
+	if !slices.Equal(report.Totals, want) {
+		t.Fatalf("report = %+v, want %+v", report.Totals, want)
+	}
+
+	s.expect(s.do("GET", fmt.Sprintf("/worklogs/report?group_by=task&employee_id=%d", ada.ID), nil), http.StatusOK, &report)
+	want = []models.WorklogTotal{{ID: parser.ID, Name: "Write the parser", Entries: 2, Seconds: 5400, Hours: 1.5}}
+	if !slices.Equal(report.Totals, want) {
+		t.Fatalf("report = %+v, want %+v", report.Totals, want)
+	}
+
+	apiErr := s.expectError(s.do("GET", "/worklogs/report?group_by=week&from=2024-03-02&to=2024-03-01", nil), http.StatusBadRequest, CodeValidation)
+	wantErrs := []FieldError{
+		{Field: "to", Message: "must not be before from"},
+		{Field: "group_by", Message: "must be one of employee, task, project"},
+	}
+	if !slices.Equal(apiErr.Details, wantErrs) {
+		t.Fatalf("details = %+v, want %+v", apiErr.Details, wantErrs)
+	}
+}
@@ -2,73 +2,279 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"net/http"
-	"nstorm.com/main-backend/database"
 	"os"
+	"os/signal"
+	"slices"
+	"syscall"
 
 	"github.com/gorilla/mux"
+	"nstorm.com/main-backend/config"
+	"nstorm.com/main-backend/database"
 	"nstorm.com/main-backend/handlers"
+	"nstorm.com/main-backend/repository/postgres"
 )
 
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+func corsMiddleware(origins []string) func(http.Handler) http.Handler {
+	allowAll := slices.Contains(origins, "*")
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if allowAll {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Add("Vary", "Origin")
+				if origin := r.Header.Get("Origin"); slices.Contains(origins, origin) {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, If-Match, If-None-Match")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, ETag")
 
-		next.ServeHTTP(w, r)
-	})
+			if r.Method == "OPTIONS" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 func main() {
-	conn, err := database.NewConnection()
+	cfg, args, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	level, _ := config.ParseLogLevel(cfg.LogLevel)
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	if err := run(cfg, args); err != nil {
+		slog.Error("exiting", "error", err)
 		os.Exit(1)
 	}
-	defer conn.Close(context.Background())
+}
+
+func run(cfg *config.Config, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := database.NewPool(ctx, cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		pool.Close()
+		slog.Info("database pool closed")
+	}()
 
-	employeeHandler := handlers.NewEmployeeHandler(conn)
-	projectHandler := handlers.NewProjectHandler(conn)
-	taskHandler := handlers.NewTaskHandler(conn)
+	if len(args) > 0 && args[0] == "migrate" {
+		return runMigrate(ctx, pool, args[1:])
+	}
+
+	migrator, err := database.NewMigrator(pool)
+	if err != nil {
+		return err
+	}
+	if cfg.AutoMigrate {
+		if _, err := migrator.Up(ctx); err != nil {
+			return err
+		}
+	}
+
+	repos := postgres.NewRepositories(pool)
+
+	if cfg.Scheduler.Enabled {
+		schedulerCtx, stopScheduler := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			runScheduler(schedulerCtx, cfg.Scheduler.Interval, repos.RecurringTasks)
+		}()
+		// Wait for a run in progress before the pool closes.
+		defer func() {
+			stopScheduler()
+			<-done
+		}()
+	}
+	if cfg.Purge.Enabled {
+		purgeCtx, stopPurge := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			runPurge(purgeCtx, cfg.Purge, repos)
+		}()
+		defer func() {
+			stopPurge()
+			<-done
+		}()
+	}
+
+	checks := []handlers.DependencyCheck{{Name: "database", Check: pool.Ping}}
+	if cfg.Chat.ReadinessCheck {
+		checks = append(checks, handlers.DependencyCheck{
+			Name:  "chat",
+			Check: handlers.HTTPCheck(http.DefaultClient, cfg.Chat.URL),
+		})
+	}
+	healthHandler := handlers.NewHealthHandler(
+		cfg.ReadinessTimeout,
+		buildInfo(),
+		func(ctx context.Context) (int, error) { return database.Version(ctx, pool) },
+		migrator.Latest(),
+		checks...,
+	)
+
+	employeeHandler := handlers.NewEmployeeHandler(repos)
+	projectHandler := handlers.NewProjectHandler(repos, cfg.Chat)
+	workflow, err := cfg.Tasks.Workflow()
+	if err != nil {
+		return err
+	}
+	taskHandler := handlers.NewTaskHandler(repos, workflow)
+	commentHandler := handlers.NewCommentHandler(repos)
+	labelHandler := handlers.NewLabelHandler(repos)
+	worklogHandler := handlers.NewWorklogHandler(repos)
+	recurringHandler := handlers.NewRecurringTaskHandler(repos)
+	sprintHandler := handlers.NewSprintHandler(repos)
+	milestoneHandler := handlers.NewMilestoneHandler(repos)
+	templateHandler := handlers.NewTemplateHandler(repos)
 
 	router := mux.NewRouter()
 
-	router.HandleFunc("/employees", employeeHandler.GetAllEmployees).Methods("GET")
-	router.HandleFunc("/employees", employeeHandler.CreateEmployee).Methods("POST")
-	router.HandleFunc("/employees/{id}", employeeHandler.GetEmployeeById).Methods("GET")
-	router.HandleFunc("/employees/{id}", employeeHandler.UpdateEmployee).Methods("PUT")
-	router.HandleFunc("/employees/{id}", employeeHandler.DeleteEmployee).Methods("DELETE")
-
-	router.HandleFunc("/employees/{id}/tasks", employeeHandler.GetEmployeeTasks).Methods("GET")
-	router.HandleFunc("/employees/{id}/tasks/{status}", employeeHandler.GetEmployeeTasksByStatus).Methods("GET")
-	router.HandleFunc("/employees/{id}/projects", employeeHandler.GetEmployeeProjects).Methods("GET")
-	router.HandleFunc("/employees/{employeeId}/projects/{projectId}", employeeHandler.AssignEmployeeToProject).Methods("POST")
-	router.HandleFunc("/employees/{employeeId}/projects/{projectId}", employeeHandler.RemoveEmployeeFromProject).Methods("DELETE")
-	router.HandleFunc("/projects/{id}/employees", employeeHandler.GetEmployeesByProject).Methods("GET")
-
-	router.HandleFunc("/projects", projectHandler.GetAllProjects).Methods("GET")
-	router.HandleFunc("/projects", projectHandler.CreateProject).Methods("POST")
-	router.HandleFunc("/projects/{id}", projectHandler.GetProjectByID).Methods("GET")
-	router.HandleFunc("/projects/{id}", projectHandler.UpdateProject).Methods("PUT")
-	router.HandleFunc("/projects/{id}", projectHandler.DeleteProject).Methods("DELETE")
-
-	router.HandleFunc("/tasks", taskHandler.GetAllTasks).Methods("GET")
-	router.HandleFunc("/tasks", taskHandler.CreateTask).Methods("POST")
-	router.HandleFunc("/tasks/{id}", taskHandler.GetTaskByID).Methods("GET")
-	router.HandleFunc("/tasks/{id}", taskHandler.UpdateTask).Methods("PUT")
-	router.HandleFunc("/tasks/{id}", taskHandler.DeleteTask).Methods("DELETE")
-	router.HandleFunc("/projects/{id}/generate-tasks", projectHandler.GenerateAndAssignTasks).Methods("POST")
-
-	handler := corsMiddleware(router)
-
-	fmt.Println("Server starting on port 8888...")
-	http.ListenAndServe(":8888", handler)
+	router.HandleFunc("/healthz", healthHandler.Healthz).Methods("GET").Name("healthz")
+	router.HandleFunc("/readyz", healthHandler.Readyz).Methods("GET").Name("readyz")
+	router.HandleFunc("/version", healthHandler.Version).Methods("GET").Name("version")
+
+	router.HandleFunc("/employees", employeeHandler.GetAllEmployees).Methods("GET").Name("list-employees")
+	router.HandleFunc("/employees", employeeHandler.CreateEmployee).Methods("POST").Name("create-employee")
+	router.HandleFunc("/employees/{id}", employeeHandler.GetEmployeeById).Methods("GET").Name("get-employee")
+	router.HandleFunc("/employees/{id}", employeeHandler.UpdateEmployee).Methods("PUT").Name("update-employee")
+	router.HandleFunc("/employees/{id}", employeeHandler.PatchEmployee).Methods("PATCH").Name("patch-employee")
+	router.HandleFunc("/employees/{id}", employeeHandler.DeleteEmployee).Methods("DELETE").Name("delete-employee")
+
+	router.HandleFunc("/employees/{id}/tasks", employeeHandler.GetEmployeeTasks).Methods("GET").Name("list-employee-tasks")
+	router.HandleFunc("/employees/{id}/tasks/overdue", employeeHandler.GetEmployeeOverdueTasks).Methods("GET").Name("list-employee-overdue-tasks")
+	router.HandleFunc("/employees/{id}/tasks/{status}", employeeHandler.GetEmployeeTasksByStatus).Methods("GET").Name("list-employee-tasks-by-status")
+	router.HandleFunc("/employees/{id}/projects", employeeHandler.GetEmployeeProjects).Methods("GET").Name("list-employee-projects")
+	router.HandleFunc("/employees/{employeeId}/projects/{projectId}", employeeHandler.AssignEmployeeToProject).Methods("POST").Name("assign-employee-to-project")
+	router.HandleFunc("/employees/{employeeId}/projects/{projectId}", employeeHandler.RemoveEmployeeFromProject).Methods("DELETE").Name("remove-employee-from-project")
+	router.HandleFunc("/projects/{id}/employees", employeeHandler.GetEmployeesByProject).Methods("GET").Name("list-project-employees")
+
+	router.HandleFunc("/projects", projectHandler.GetAllProjects).Methods("GET").Name("list-projects")
+	router.HandleFunc("/projects", projectHandler.CreateProject).Methods("POST").Name("create-project")
+	router.HandleFunc("/projects/{id}", projectHandler.GetProjectByID).Methods("GET").Name("get-project")
+	router.HandleFunc("/projects/{id}", projectHandler.UpdateProject).Methods("PUT").Name("update-project")
+	router.HandleFunc("/projects/{id}", projectHandler.PatchProject).Methods("PATCH").Name("patch-project")
+	router.HandleFunc("/projects/{id}", projectHandler.DeleteProject).Methods("DELETE").Name("delete-project")
+
+	router.HandleFunc("/tasks", taskHandler.GetAllTasks).Methods("GET").Name("list-tasks")
+	router.HandleFunc("/tasks", taskHandler.CreateTask).Methods("POST").Name("create-task")
+	router.HandleFunc("/tasks/{id}", taskHandler.GetTaskByID).Methods("GET").Name("get-task")
+	router.HandleFunc("/tasks/{id}", taskHandler.UpdateTask).Methods("PUT").Name("update-task")
+	router.HandleFunc("/tasks/{id}", taskHandler.PatchTask).Methods("PATCH").Name("patch-task")
+	router.HandleFunc("/tasks/{id}", taskHandler.DeleteTask).Methods("DELETE").Name("delete-task")
+	router.HandleFunc("/tasks/{id}/transitions", taskHandler.GetTaskTransitions).Methods("GET").Name("list-task-transitions")
+	router.HandleFunc("/tasks/{id}/transitions", taskHandler.TransitionTask).Methods("POST").Name("transition-task")
+	router.HandleFunc("/tasks/{id}/move", taskHandler.MoveTask).Methods("POST").Name("move-task")
+	router.HandleFunc("/tasks/{id}/subtree", taskHandler.GetTaskSubtree).Methods("GET").Name("get-task-subtree")
+	router.HandleFunc("/tasks/{id}/dependencies", taskHandler.GetTaskDependencies).Methods("GET").Name("list-task-dependencies")
+	router.HandleFunc("/tasks/{id}/dependencies/{dependsOnId}", taskHandler.AddTaskDependency).Methods("POST").Name("add-task-dependency")
+	router.HandleFunc("/tasks/{id}/dependencies/{dependsOnId}", taskHandler.RemoveTaskDependency).Methods("DELETE").Name("remove-task-dependency")
+	router.HandleFunc("/tasks/{id}/dependents", taskHandler.GetTaskDependents).Methods("GET").Name("list-task-dependents")
+	router.HandleFunc("/projects/{id}/board", projectHandler.GetProjectBoard).Methods("GET").Name("get-project-board")
+	router.HandleFunc("/projects/{id}/tasks/topological", taskHandler.GetProjectTasksTopological).Methods("GET").Name("list-project-tasks-topological")
+	router.HandleFunc("/tasks/{id}/comments", commentHandler.GetComments).Methods("GET").Name("list-task-comments")
+	router.HandleFunc("/tasks/{id}/comments", commentHandler.CreateComment).Methods("POST").Name("create-task-comment")
+	router.HandleFunc("/tasks/{id}/comments/{commentId}", commentHandler.GetComment).Methods("GET").Name("get-task-comment")
+	router.HandleFunc("/tasks/{id}/comments/{commentId}", commentHandler.UpdateComment).Methods("PUT").Name("update-task-comment")
+	router.HandleFunc("/tasks/{id}/comments/{commentId}", commentHandler.DeleteComment).Methods("DELETE").Name("delete-task-comment")
+	router.HandleFunc("/tasks/{id}/activity", commentHandler.GetTaskActivity).Methods("GET").Name("get-task-activity")
+	router.HandleFunc("/labels", labelHandler.GetAllLabels).Methods("GET").Name("list-labels")
+	router.HandleFunc("/labels", labelHandler.CreateLabel).Methods("POST").Name("create-label")
+	router.HandleFunc("/labels/stats", labelHandler.GetLabelStats).Methods("GET").Name("label-stats")
+	router.HandleFunc("/labels/{id}", labelHandler.GetLabelByID).Methods("GET").Name("get-label")
+	router.HandleFunc("/labels/{id}", labelHandler.UpdateLabel).Methods("PUT").Name("update-label")
+	router.HandleFunc("/labels/{id}", labelHandler.DeleteLabel).Methods("DELETE").Name("delete-label")
+	router.HandleFunc("/tasks/{id}/labels", labelHandler.GetTaskLabels).Methods("GET").Name("list-task-labels")
+	router.HandleFunc("/tasks/{id}/labels/{labelId}", labelHandler.AttachLabelToTask).Methods("POST").Name("attach-task-label")
+	router.HandleFunc("/tasks/{id}/labels/{labelId}", labelHandler.DetachLabelFromTask).Methods("DELETE").Name("detach-task-label")
+	router.HandleFunc("/projects/{id}/labels", labelHandler.GetProjectLabels).Methods("GET").Name("list-project-labels")
+	router.HandleFunc("/projects/{id}/labels/{labelId}", labelHandler.AttachLabelToProject).Methods("POST").Name("attach-project-label")
+	router.HandleFunc("/projects/{id}/labels/{labelId}", labelHandler.DetachLabelFromProject).Methods("DELETE").Name("detach-project-label")
+	router.HandleFunc("/worklogs", worklogHandler.GetWorklogs).Methods("GET").Name("list-worklogs")
+	router.HandleFunc("/worklogs", worklogHandler.CreateWorklog).Methods("POST").Name("create-worklog")
+	router.HandleFunc("/worklogs/report", worklogHandler.GetWorklogReport).Methods("GET").Name("worklog-report")
+	router.HandleFunc("/worklogs/{id}", worklogHandler.GetWorklog).Methods("GET").Name("get-worklog")
+	router.HandleFunc("/worklogs/{id}", worklogHandler.UpdateWorklog).Methods("PUT").Name("update-worklog")
+	router.HandleFunc("/worklogs/{id}", worklogHandler.DeleteWorklog).Methods("DELETE").Name("delete-worklog")
+	router.HandleFunc("/employees/{id}/timer", worklogHandler.GetTimer).Methods("GET").Name("get-employee-timer")
+	router.HandleFunc("/employees/{id}/timer", worklogHandler.StartTimer).Methods("POST").Name("start-employee-timer")
+	router.HandleFunc("/employees/{id}/timer", worklogHandler.StopTimer).Methods("DELETE").Name("stop-employee-timer")
+	router.HandleFunc("/recurring-tasks", recurringHandler.GetRecurringTasks).Methods("GET").Name("list-recurring-tasks")
+	router.HandleFunc("/recurring-tasks", recurringHandler.CreateRecurringTask).Methods("POST").Name("create-recurring-task")
+	router.HandleFunc("/recurring-tasks/{id}", recurringHandler.GetRecurringTask).Methods("GET").Name("get-recurring-task")
+	router.HandleFunc("/recurring-tasks/{id}", recurringHandler.UpdateRecurringTask).Methods("PUT").Name("update-recurring-task")
+	router.HandleFunc("/recurring-tasks/{id}", recurringHandler.DeleteRecurringTask).Methods("DELETE").Name("delete-recurring-task")
+	router.HandleFunc("/recurring-tasks/{id}/pause", recurringHandler.PauseRecurringTask).Methods("POST").Name("pause-recurring-task")
+	router.HandleFunc("/recurring-tasks/{id}/resume", recurringHandler.ResumeRecurringTask).Methods("POST").Name("resume-recurring-task")
+	router.HandleFunc("/recurring-tasks/{id}/occurrences", recurringHandler.GetOccurrences).Methods("GET").Name("preview-recurring-task")
+	router.HandleFunc("/recurring-tasks/{id}/tasks", recurringHandler.GetCreatedTasks).Methods("GET").Name("list-recurring-task-tasks")
+	router.HandleFunc("/sprints", sprintHandler.GetSprints).Methods("GET").Name("list-sprints")
+	router.HandleFunc("/sprints", sprintHandler.CreateSprint).Methods("POST").Name("create-sprint")
+	router.HandleFunc("/sprints/{id}", sprintHandler.GetSprint).Methods("GET").Name("get-sprint")
+	router.HandleFunc("/sprints/{id}", sprintHandler.UpdateSprint).Methods("PUT").Name("update-sprint")
+	router.HandleFunc("/sprints/{id}", sprintHandler.DeleteSprint).Methods("DELETE").Name("delete-sprint")
+	router.HandleFunc("/sprints/{id}/start", sprintHandler.StartSprint).Methods("POST").Name("start-sprint")
+	router.HandleFunc("/sprints/{id}/close", sprintHandler.CloseSprint).Methods("POST").Name("close-sprint")
+	router.HandleFunc("/sprints/{id}/tasks", sprintHandler.GetSprintTasks).Methods("GET").Name("list-sprint-tasks")
+	router.HandleFunc("/projects/{id}/velocity", sprintHandler.GetProjectVelocity).Methods("GET").Name("get-project-velocity")
+	router.HandleFunc("/milestones", milestoneHandler.GetMilestones).Methods("GET").Name("list-milestones")
+	router.HandleFunc("/milestones", milestoneHandler.CreateMilestone).Methods("POST").Name("create-milestone")
+	router.HandleFunc("/milestones/{id}", milestoneHandler.GetMilestone).Methods("GET").Name("get-milestone")
+	router.HandleFunc("/milestones/{id}", milestoneHandler.UpdateMilestone).Methods("PUT").Name("update-milestone")
+	router.HandleFunc("/milestones/{id}", milestoneHandler.DeleteMilestone).Methods("DELETE").Name("delete-milestone")
+	router.HandleFunc("/milestones/{id}/tasks", milestoneHandler.GetMilestoneTasks).Methods("GET").Name("list-milestone-tasks")
+	router.HandleFunc("/milestones/{id}/progress", milestoneHandler.GetMilestoneProgress).Methods("GET").Name("get-milestone-progress")
+	router.HandleFunc("/projects/{id}/milestones", milestoneHandler.GetProjectMilestones).Methods("GET").Name("list-project-milestones")
+	router.HandleFunc("/employees/{id}/restore", employeeHandler.RestoreEmployee).Methods("POST").Name("restore-employee")
+	router.HandleFunc("/projects/{id}/restore", projectHandler.RestoreProject).Methods("POST").Name("restore-project")
+	router.HandleFunc("/projects/{id}/archive", projectHandler.ArchiveProject).Methods("POST").Name("archive-project")
+	router.HandleFunc("/projects/{id}/unarchive", projectHandler.UnarchiveProject).Methods("POST").Name("unarchive-project")
+	router.HandleFunc("/tasks/{id}/restore", taskHandler.RestoreTask).Methods("POST").Name("restore-task")
+	router.HandleFunc("/templates", templateHandler.GetTemplates).Methods("GET").Name("list-templates")
+	router.HandleFunc("/templates", templateHandler.CreateTemplate).Methods("POST").Name("create-template")
+	router.HandleFunc("/templates/{id}", templateHandler.GetTemplate).Methods("GET").Name("get-template")
+	router.HandleFunc("/templates/{id}", templateHandler.UpdateTemplate).Methods("PUT").Name("update-template")
+	router.HandleFunc("/templates/{id}", templateHandler.DeleteTemplate).Methods("DELETE").Name("delete-template")
+	router.HandleFunc("/templates/{id}/instantiate", templateHandler.InstantiateTemplate).Methods("POST").Name("instantiate-template")
+	router.HandleFunc("/projects/{id}/clone", templateHandler.CloneProject).Methods("POST").Name("clone-project")
+	router.HandleFunc("/projects/{id}/generate-tasks", projectHandler.GenerateAndAssignTasks).Methods("POST").Name("generate-tasks")
+
+	router.NotFoundHandler = http.HandlerFunc(handlers.NotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(handlers.MethodNotAllowed)
+	router.Use(handlers.Timeouts(cfg.Server.RequestTimeout, cfg.Server.RouteTimeouts))
+
+	var routes []string
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		routes = append(routes, route.GetName())
+		return nil
+	})
+	if err := cfg.Validate(routes...); err != nil {
+		return err
+	}
+
+	handler := handlers.RequestID(corsMiddleware(cfg.CORSOrigins)(handlers.Admin(cfg.AdminToken)(router)))
 
+	return serve(ctx, cfg.Server, cfg.Port, handler)
 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"nstorm.com/main-backend/database"
+)
+
+const migrateUsage = `usage: main-backend migrate <command>
+
+commands:
+  up          apply all pending migrations
+  down [n]    revert the last n migrations (default 1)
+  status      list applied and pending migrations`
+
+// runMigrate implements the migrate subcommand.
+func runMigrate(ctx context.Context, pool *pgxpool.Pool, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	migrator, err := database.NewMigrator(pool)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, version := range applied {
+			fmt.Printf("applied migration %d\n", version)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("database is up to date")
+		}
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, version := range reverted {
+			fmt.Printf("reverted migration %d\n", version)
+		}
+		return err
+
+	case "status":
+		applied, err := migrator.Applied(ctx)
+		if err != nil {
+			return err
+		}
+		appliedVersions := make(map[int]bool)
+		for _, migration := range applied {
+			appliedVersions[migration.Version] = true
+			fmt.Printf("%04d %-30s applied %s\n", migration.Version, migration.Name, migration.AppliedAt.Format("2006-01-02 15:04:05"))
+		}
+		migrations, err := database.LoadMigrations()
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrations {
+			if !appliedVersions[migration.Version] {
+				fmt.Printf("%04d %-30s pending\n", migration.Version, migration.Name)
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+}
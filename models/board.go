@@ -0,0 +1,31 @@
+package models
+
+// BoardColumn holds the tasks with one status, in rank order.
+type BoardColumn struct {
+	Status TaskStatus `json:"status"`
+	Tasks  []Task     `json:"tasks"`
+}
+
+// Board is a project's tasks grouped into one column per status, with the
+// columns in workflow order. Columns without tasks are included.
+type Board struct {
+	ProjectID int           `json:"project_id"`
+	Columns   []BoardColumn `json:"columns"`
+}
+
+// NewBoard groups tasks into the columns of a project's board, keeping
+// their order within each column.
+func NewBoard(projectID int, tasks []Task) Board {
+	board := Board{ProjectID: projectID, Columns: make([]BoardColumn, len(TaskStatuses))}
+	index := make(map[TaskStatus]int, len(TaskStatuses))
+	for i, status := range TaskStatuses {
+		board.Columns[i] = BoardColumn{Status: status, Tasks: []Task{}}
+		index[status] = i
+	}
+	for _, task := range tasks {
+		if i, ok := index[task.Status]; ok {
+			board.Columns[i].Tasks = append(board.Columns[i].Tasks, task)
+		}
+	}
+	return board
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Comment is a message on a task. AuthorID is nil once the author has been
+// deleted. Mentions holds the IDs of the employees @mentioned in Body.
+type Comment struct {
+	ID        int       `json:"id"`
+	TaskID    int       `json:"task_id"`
+	AuthorID  *int      `json:"author_id"`
+	Body      string    `json:"body" validate:"required,max=10000"`
+	Mentions  []int     `json:"mentions"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskAssigneeChange records a task being reassigned. From and To are nil
+// once the employee has been deleted.
+type TaskAssigneeChange struct {
+	ID        int       `json:"id"`
+	TaskID    int       `json:"task_id"`
+	From      *int      `json:"from"`
+	To        *int      `json:"to"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// A mention is @ followed by an email address or by the part of one before
+// the @, and must not be glued to a preceding word.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w.@])@([\w.+-]+(?:@[\w-]+(?:\.[\w-]+)+)?)`)
+
+// ParseMentions returns the distinct handles @mentioned in body, lower
+// cased, in order of first appearance.
+func ParseMentions(body string) []string {
+	var handles []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		handle := strings.ToLower(strings.TrimRight(match[1], "."))
+		if handle != "" && !seen[handle] {
+			seen[handle] = true
+			handles = append(handles, handle)
+		}
+	}
+	return handles
+}
+
+// ResolveMentions maps handles to the IDs of the employees they name. A
+// handle with an @ must equal an employee's email; one without must equal
+// the part of exactly one employee's email before the @. Handles that name
+// nobody, or more than one employee, are dropped. The IDs are sorted.
+func ResolveMentions(handles []string, employees []Employee) []int {
+	ids := []int{}
+	seen := make(map[int]bool)
+	for _, handle := range handles {
+		match := 0
+		for _, employee := range employees {
+			email := strings.ToLower(employee.Email)
+			local, _, _ := strings.Cut(email, "@")
+			if email == handle || (!strings.Contains(handle, "@") && local == handle) {
+				if match != 0 {
+					match = -1
+					break
+				}
+				match = employee.ID
+			}
+		}
+		if match > 0 && !seen[match] {
+			seen[match] = true
+			ids = append(ids, match)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+type ActivityKind string
+
+const (
+	ActivityComment        ActivityKind = "comment"
+	ActivityStatusChange   ActivityKind = "status_change"
+	ActivityAssigneeChange ActivityKind = "assignee_change"
+)
+
+// Activity is one entry of a task's activity feed. Exactly one of Comment,
+// Transition and AssigneeChange is set, according to Kind.
+type Activity struct {
+	Kind           ActivityKind        `json:"kind"`
+	At             time.Time           `json:"at"`
+	ActorID        *int                `json:"actor_id"`
+	Comment        *Comment            `json:"comment,omitempty"`
+	Transition     *TaskTransition     `json:"transition,omitempty"`
+	AssigneeChange *TaskAssigneeChange `json:"assignee_change,omitempty"`
+}
+
+// MergeActivity combines a task's comments, status changes and
+// reassignments into one feed, oldest first. Comments are placed by when
+// they were written, not when they were last edited.
+func MergeActivity(comments []Comment, transitions []TaskTransition, changes []TaskAssigneeChange) []Activity {
+	feed := make([]Activity, 0, len(comments)+len(transitions)+len(changes))
+	for i := range comments {
+		c := &comments[i]
+		feed = append(feed, Activity{Kind: ActivityComment, At: c.CreatedAt, ActorID: c.AuthorID, Comment: c})
+	}
+	for i := range transitions {
+		t := &transitions[i]
+		feed = append(feed, Activity{Kind: ActivityStatusChange, At: t.CreatedAt, ActorID: t.ActorID, Transition: t})
+	}
+	for i := range changes {
+		c := &changes[i]
+		feed = append(feed, Activity{Kind: ActivityAssigneeChange, At: c.CreatedAt, AssigneeChange: c})
+	}
+	sort.SliceStable(feed, func(i, j int) bool { return feed[i].At.Before(feed[j].At) })
+	return feed
+}
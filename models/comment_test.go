@@ -0,0 +1,101 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"no mentions here", nil},
+		{"@ada please review", []string{"ada"}},
+		{"thanks @Ada.Lovelace and @grace@Example.com.", []string{"ada.lovelace", "grace@example.com"}},
+		{"@ada, then @bob, then @ADA again", []string{"ada", "bob"}},
+		{"mail ada@example.com or see foo@bar", nil},
+		{"(@ada) [@bob]", []string{"ada", "bob"}},
+		{"end of sentence @ada.", []string{"ada"}},
+		{"a lone @ sign", nil},
+	}
+	for _, tt := range tests {
+		if got := ParseMentions(tt.body); !slices.Equal(got, tt.want) {
+			t.Errorf("ParseMentions(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestResolveMentions(t *testing.T) {
+	employees := []Employee{
+		{ID: 3, Email: "Ada@example.com"},
+		{ID: 1, Email: "grace@example.com"},
+		{ID: 2, Email: "grace@navy.mil"},
+	}
+	tests := []struct {
+		name    string
+		handles []string
+		want    []int
+	}{
+		{"none", nil, []int{}},
+		{"local part, case insensitive", []string{"ada"}, []int{3}},
+		{"ambiguous local part", []string{"grace"}, []int{}},
+		{"full email", []string{"grace@navy.mil"}, []int{2}},
+		{"nobody", []string{"alan", "alan@example.com"}, []int{}},
+		{"same employee twice, sorted", []string{"grace@navy.mil", "ada@example.com", "ada"}, []int{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveMentions(tt.handles, employees); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeActivity(t *testing.T) {
+	at := func(minute int) time.Time { return time.Date(2024, 3, 1, 9, minute, 0, 0, time.UTC) }
+	actor := 7
+
+	comments := []Comment{
+		{ID: 1, AuthorID: &actor, CreatedAt: at(1), UpdatedAt: at(9)},
+		{ID: 2, CreatedAt: at(5), UpdatedAt: at(5)},
+	}
+	transitions := []TaskTransition{
+		{ID: 1, To: StatusTodo, CreatedAt: at(0)},
+		{ID: 2, To: StatusInProgress, ActorID: &actor, CreatedAt: at(5)},
+	}
+	changes := []TaskAssigneeChange{{ID: 1, CreatedAt: at(3)}}
+
+	feed := MergeActivity(comments, transitions, changes)
+	type entry struct {
+		kind ActivityKind
+		id   int
+	}
+	var got []entry
+	for _, a := range feed {
+		switch a.Kind {
+		case ActivityComment:
+			got = append(got, entry{a.Kind, a.Comment.ID})
+		case ActivityStatusChange:
+			got = append(got, entry{a.Kind, a.Transition.ID})
+		case ActivityAssigneeChange:
+			got = append(got, entry{a.Kind, a.AssigneeChange.ID})
+		}
+	}
+	// Edited comments keep their place, and ties keep comments first.
+	want := []entry{
+		{ActivityStatusChange, 1},
+		{ActivityComment, 1},
+		{ActivityAssigneeChange, 1},
+		{ActivityComment, 2},
+		{ActivityStatusChange, 2},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("feed = %v, want %v", got, want)
+	}
+	if feed[1].ActorID == nil || *feed[1].ActorID != actor || feed[2].ActorID != nil {
+		t.Errorf("actors = %v, %v", feed[1].ActorID, feed[2].ActorID)
+	}
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDateJSON(t *testing.T) {
+	type wrapper struct {
+		Due Date `json:"due"`
+	}
+
+	var w wrapper
+	if err := json.Unmarshal([]byte(`{"due": "2026-02-28"}`), &w); err != nil {
+		t.Fatal(err)
+	}
+	if w.Due.String() != "2026-02-28" {
+		t.Fatalf("decoded %v", w.Due)
+	}
+	out, _ := json.Marshal(w)
+	if string(out) != `{"due":"2026-02-28"}` {
+		t.Fatalf("encoded %s", out)
+	}
+
+	if err := json.Unmarshal([]byte(`{"due": null}`), &w); err != nil || !w.Due.IsZero() {
+		t.Fatalf("null decoded to %v, %v", w.Due, err)
+	}
+	out, _ = json.Marshal(w)
+	if string(out) != `{"due":null}` {
+		t.Fatalf("zero date encoded as %s", out)
+	}
+
+	for _, bad := range []string{`"2026-02-30"`, `"28/02/2026"`, `"2026-02-28T10:00:00Z"`, `20260228`} {
+		if err := json.Unmarshal([]byte(`{"due": `+bad+`}`), &w); err == nil {
+			t.Errorf("decoding %s succeeded", bad)
+		}
+	}
+}
+
+func TestDateSQL(t *testing.T) {
+	var d Date
+	if err := d.Scan(time.Date(2026, 3, 1, 23, 30, 0, 0, time.FixedZone("", 5*3600))); err != nil || d.String() != "2026-03-01" {
+		t.Fatalf("scanned time as %v, %v", d, err)
+	}
+	if err := d.Scan("2026-03-02"); err != nil || d.String() != "2026-03-02" {
+		t.Fatalf("scanned string as %v, %v", d, err)
+	}
+	if v, err := d.Value(); err != nil || v.(time.Time).Format(time.DateOnly) != "2026-03-02" {
+		t.Fatalf("Value = %v, %v", v, err)
+	}
+	if err := d.Scan(nil); err != nil || !d.IsZero() {
+		t.Fatalf("scanned NULL as %v, %v", d, err)
+	}
+	if v, err := d.Value(); err != nil || v != nil {
+		t.Fatalf("zero date Value = %v, %v; want NULL", v, err)
+	}
+	if err := d.Scan(42); err == nil {
+		t.Fatal("scanning an int succeeded")
+	}
+}
+
+func TestDateAddDays(t *testing.T) {
+	d, _ := ParseDate("2026-02-27")
+	if got := d.AddDays(2).String(); got != "2026-03-01" {
+		t.Fatalf("AddDays(2) = %s", got)
+	}
+	if got := d.AddDays(-27).String(); got != "2026-01-31" {
+		t.Fatalf("AddDays(-27) = %s", got)
+	}
+}
+
+func TestTaskOverdue(t *testing.T) {
+	today, _ := ParseDate("2026-03-10")
+	tests := []struct {
+		name string
+		due  string
+		stat TaskStatus
+		want bool
+	}{
+		{"due yesterday", "2026-03-09", StatusInProgress, true},
+		{"due today", "2026-03-10", StatusTodo, false},
+		{"due tomorrow", "2026-03-11", StatusTodo, false},
+		{"no due date", "", StatusTodo, false},
+		{"done late", "2026-03-01", StatusDone, false},
+		{"cancelled late", "2026-03-01", StatusCancelled, false},
+		{"blocked late", "2026-03-01", StatusBlocked, true},
+	}
+	for _, tt := range tests {
+		task := Task{Status: tt.stat}
+		if tt.due != "" {
+			task.DueDate, _ = ParseDate(tt.due)
+		}
+		if got := task.Overdue(today); got != tt.want {
+			t.Errorf("%s: Overdue = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// TaskDependency says that TaskID cannot proceed until DependsOnID is
+// finished.
+type TaskDependency struct {
+	TaskID      int       `json:"task_id"`
+	DependsOnID int       `json:"depends_on_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SortByDependencies orders tasks so that every task comes after the tasks
+// it depends on, preferring more urgent and then older tasks among those
+// that are ready. Dependencies on tasks outside the list are ignored. It
+// reports false if the dependencies contain a cycle.
+func SortByDependencies(tasks []Task, dependencies []TaskDependency) ([]Task, bool) {
+	byID := make(map[int]Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	waiting := make(map[int]int, len(tasks))
+	dependents := make(map[int][]int)
+	for _, d := range dependencies {
+		if _, ok := byID[d.TaskID]; !ok {
+			continue
+		}
+		if _, ok := byID[d.DependsOnID]; !ok {
+			continue
+		}
+		waiting[d.TaskID]++
+		dependents[d.DependsOnID] = append(dependents[d.DependsOnID], d.TaskID)
+	}
+
+	var ready []Task
+	for _, task := range tasks {
+		if waiting[task.ID] == 0 {
+			ready = append(ready, task)
+		}
+	}
+	before := func(a, b Task) bool {
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.ID < b.ID
+	}
+
+	sorted := make([]Task, 0, len(tasks))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return before(ready[i], ready[j]) })
+		next := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, next)
+		for _, id := range dependents[next.ID] {
+			waiting[id]--
+			if waiting[id] == 0 {
+				ready = append(ready, byID[id])
+			}
+		}
+	}
+	return sorted, len(sorted) == len(tasks)
+}
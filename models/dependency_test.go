@@ -0,0 +1,103 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSortByDependencies(t *testing.T) {
+	task := func(id int, priority TaskPriority) Task {
+		return Task{ID: id, Priority: priority}
+	}
+	dependsOn := func(taskID, dependsOnID int) TaskDependency {
+		return TaskDependency{TaskID: taskID, DependsOnID: dependsOnID}
+	}
+
+	tests := []struct {
+		name         string
+		tasks        []Task
+		dependencies []TaskDependency
+		want         []int
+	}{
+		{
+			name:  "no tasks",
+			tasks: nil,
+			want:  []int{},
+		},
+		{
+			name:  "no dependencies, by priority then ID",
+			tasks: []Task{task(3, PriorityP2), task(1, PriorityP3), task(2, PriorityP2), task(4, PriorityP0)},
+			want:  []int{4, 2, 3, 1},
+		},
+		{
+			name:         "linear chain",
+			tasks:        []Task{task(1, PriorityP2), task(2, PriorityP2), task(3, PriorityP2), task(4, PriorityP2)},
+			dependencies: []TaskDependency{dependsOn(1, 2), dependsOn(2, 3), dependsOn(3, 4)},
+			want:         []int{4, 3, 2, 1},
+		},
+		{
+			name:         "chain ahead of a more urgent dependent",
+			tasks:        []Task{task(1, PriorityP0), task(2, PriorityP3)},
+			dependencies: []TaskDependency{dependsOn(1, 2)},
+			want:         []int{2, 1},
+		},
+		{
+			// 4 depends on 2 and 3, which both depend on 1.
+			name:         "diamond",
+			tasks:        []Task{task(4, PriorityP2), task(3, PriorityP2), task(2, PriorityP2), task(1, PriorityP2)},
+			dependencies: []TaskDependency{dependsOn(4, 2), dependsOn(4, 3), dependsOn(2, 1), dependsOn(3, 1)},
+			want:         []int{1, 2, 3, 4},
+		},
+		{
+			name:         "diamond with an urgent branch",
+			tasks:        []Task{task(1, PriorityP2), task(2, PriorityP2), task(3, PriorityP0), task(4, PriorityP2)},
+			dependencies: []TaskDependency{dependsOn(4, 2), dependsOn(4, 3), dependsOn(2, 1), dependsOn(3, 1)},
+			want:         []int{1, 3, 2, 4},
+		},
+		{
+			name:         "dependencies outside the list",
+			tasks:        []Task{task(1, PriorityP2), task(2, PriorityP2)},
+			dependencies: []TaskDependency{dependsOn(1, 99), dependsOn(99, 2), dependsOn(2, 1)},
+			want:         []int{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted, ok := SortByDependencies(tt.tasks, tt.dependencies)
+			if !ok {
+				t.Fatal("SortByDependencies reported a cycle")
+			}
+			got := []int{}
+			for _, task := range sorted {
+				got = append(got, task.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got order %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortByDependenciesCycle(t *testing.T) {
+	tasks := []Task{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	tests := []struct {
+		name         string
+		dependencies []TaskDependency
+	}{
+		{"self", []TaskDependency{{TaskID: 1, DependsOnID: 1}}},
+		{"two tasks", []TaskDependency{{TaskID: 1, DependsOnID: 2}, {TaskID: 2, DependsOnID: 1}}},
+		{"behind a chain", []TaskDependency{
+			{TaskID: 1, DependsOnID: 2},
+			{TaskID: 2, DependsOnID: 3},
+			{TaskID: 3, DependsOnID: 4},
+			{TaskID: 4, DependsOnID: 2},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if sorted, ok := SortByDependencies(tasks, tt.dependencies); ok {
+				t.Fatalf("SortByDependencies = %v, want a cycle reported", sorted)
+			}
+		})
+	}
+}
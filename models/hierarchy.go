@@ -0,0 +1,79 @@
+package models
+
+import "math"
+
+// TaskRollup summarises a task and its subtasks. Only leaf tasks are
+// counted, and cancelled ones not at all, so a parent's progress and hours
+// are exactly those of the work it was broken down into.
+type TaskRollup struct {
+	Tasks           int     `json:"tasks"`
+	Done            int     `json:"done"`
+	PercentComplete float64 `json:"percent_complete"`
+	EstimateHours   float64 `json:"estimate_hours"`
+	RemainingHours  float64 `json:"remaining_hours"`
+}
+
+// TaskNode is a task with its subtasks and their rollup.
+type TaskNode struct {
+	Task
+	Rollup   TaskRollup `json:"rollup"`
+	Subtasks []TaskNode `json:"subtasks"`
+}
+
+// BuildTaskTree arranges tasks into the tree rooted at rootID. Tasks that
+// are not descendants of the root are ignored. It reports false if the root
+// is not among the tasks.
+func BuildTaskTree(rootID int, tasks []Task) (*TaskNode, bool) {
+	var root *Task
+	children := make(map[int][]Task)
+	for i, task := range tasks {
+		if task.ID == rootID {
+			root = &tasks[i]
+		} else if task.ParentTaskID != nil {
+			children[*task.ParentTaskID] = append(children[*task.ParentTaskID], task)
+		}
+	}
+	if root == nil {
+		return nil, false
+	}
+	node := buildNode(*root, children)
+	return &node, true
+}
+
+func buildNode(task Task, children map[int][]Task) TaskNode {
+	node := TaskNode{Task: task, Subtasks: []TaskNode{}}
+	for _, child := range children[task.ID] {
+		sub := buildNode(child, children)
+		node.Subtasks = append(node.Subtasks, sub)
+		node.Rollup.Tasks += sub.Rollup.Tasks
+		node.Rollup.Done += sub.Rollup.Done
+		node.Rollup.EstimateHours += sub.Rollup.EstimateHours
+		node.Rollup.RemainingHours += sub.Rollup.RemainingHours
+	}
+	if len(node.Subtasks) == 0 && task.Status != StatusCancelled {
+		node.Rollup = leafRollup(task)
+	}
+	if node.Rollup.Tasks > 0 {
+		percent := 100 * float64(node.Rollup.Done) / float64(node.Rollup.Tasks)
+		node.Rollup.PercentComplete = math.Round(percent*10) / 10
+	}
+	return node
+}
+
+// leafRollup counts a task without subtasks. A task with no remaining
+// estimate has its whole estimate left until it is done.
+func leafRollup(task Task) TaskRollup {
+	rollup := TaskRollup{Tasks: 1}
+	if task.EstimateHours != nil {
+		rollup.EstimateHours = *task.EstimateHours
+	}
+	switch {
+	case task.Status == StatusDone:
+		rollup.Done = 1
+	case task.RemainingHours != nil:
+		rollup.RemainingHours = *task.RemainingHours
+	default:
+		rollup.RemainingHours = rollup.EstimateHours
+	}
+	return rollup
+}
@@ -0,0 +1,83 @@
+package models
+
+import "testing"
+
+func TestBuildTaskTree(t *testing.T) {
+	hours := func(h float64) *float64 { return &h }
+	task := func(id int, parent *int, status TaskStatus, estimate, remaining *float64) Task {
+		return Task{ID: id, ParentTaskID: parent, Status: status, EstimateHours: estimate, RemainingHours: remaining}
+	}
+	parent := func(id int) *int { return &id }
+
+	// 1 has subtasks 2 and 3; 3 has subtasks 4, 5 and 6. Task 7 belongs to
+	// another tree.
+	tasks := []Task{
+		task(1, nil, StatusInProgress, hours(100), nil),
+		task(2, parent(1), StatusDone, hours(8), hours(3)),
+		task(3, parent(1), StatusInProgress, nil, nil),
+		task(4, parent(3), StatusTodo, hours(5), nil),
+		task(5, parent(3), StatusInProgress, hours(10), hours(4)),
+		task(6, parent(3), StatusCancelled, hours(20), hours(20)),
+		task(7, nil, StatusTodo, hours(1), nil),
+	}
+
+	root, ok := BuildTaskTree(1, tasks)
+	if !ok {
+		t.Fatal("root not found")
+	}
+	// The parents' own estimates are ignored, done leaves have nothing
+	// left, and the cancelled leaf is not counted at all.
+	want := TaskRollup{Tasks: 3, Done: 1, PercentComplete: 33.3, EstimateHours: 23, RemainingHours: 9}
+	if root.Rollup != want {
+		t.Errorf("root rollup = %+v, want %+v", root.Rollup, want)
+	}
+	if len(root.Subtasks) != 2 || root.Subtasks[1].ID != 3 || len(root.Subtasks[1].Subtasks) != 3 {
+		t.Fatalf("tree = %+v", root)
+	}
+	want = TaskRollup{Tasks: 2, EstimateHours: 15, RemainingHours: 9}
+	if got := root.Subtasks[1].Rollup; got != want {
+		t.Errorf("subtask rollup = %+v, want %+v", got, want)
+	}
+	if got := root.Subtasks[1].Subtasks[2].Rollup; got != (TaskRollup{}) {
+		t.Errorf("cancelled leaf rollup = %+v, want zero", got)
+	}
+
+	leaf, ok := BuildTaskTree(4, tasks)
+	if !ok || len(leaf.Subtasks) != 0 {
+		t.Fatalf("leaf tree = %+v, %v", leaf, ok)
+	}
+	want = TaskRollup{Tasks: 1, EstimateHours: 5, RemainingHours: 5}
+	if leaf.Rollup != want {
+		t.Errorf("leaf rollup = %+v, want %+v", leaf.Rollup, want)
+	}
+
+	if _, ok := BuildTaskTree(99, tasks); ok {
+		t.Error("found a root that is not among the tasks")
+	}
+}
+
+func TestBuildTaskTreeDepth(t *testing.T) {
+	// A chain of subtasks rolls up through every level.
+	const depth = 50
+	tasks := []Task{{ID: 1, Status: StatusTodo}}
+	for id := 2; id <= depth; id++ {
+		parent := id - 1
+		tasks = append(tasks, Task{ID: id, ParentTaskID: &parent, Status: StatusTodo})
+	}
+	tasks[depth-1].Status = StatusDone
+
+	root, ok := BuildTaskTree(1, tasks)
+	if !ok {
+		t.Fatal("root not found")
+	}
+	levels := 1
+	for node := root; len(node.Subtasks) > 0; node = &node.Subtasks[0] {
+		if node.Rollup.Done != 1 || node.Rollup.PercentComplete != 100 {
+			t.Fatalf("level %d rollup = %+v", levels, node.Rollup)
+		}
+		levels++
+	}
+	if levels != depth {
+		t.Errorf("tree has %d levels, want %d", levels, depth)
+	}
+}
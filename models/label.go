@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// DefaultLabelColor is used for labels created without a color.
+const DefaultLabelColor = "#808080"
+
+// Label groups tasks and projects. ProjectID is nil for global labels,
+// which can be used anywhere; otherwise the label can only be attached to
+// that project and its tasks. Color is a lower-case #rrggbb value.
+type Label struct {
+	ID          int       `json:"id"`
+	ProjectID   *int      `json:"project_id" validate:"min=1"`
+	Name        string    `json:"name" validate:"required,max=50"`
+	Color       string    `json:"color" validate:"required,max=7"`
+	Description string    `json:"description" validate:"max=1000"`
+	Version     int       `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UsableIn reports whether the label can be attached within a project.
+func (l Label) UsableIn(projectID int) bool {
+	return l.ProjectID == nil || *l.ProjectID == projectID
+}
+
+// LabelStats counts what a label is attached to. OpenTasks and DoneTasks
+// break Tasks down by status. RemainingHours sums over the open tasks,
+// taking the estimate of those with no remaining hours set.
+type LabelStats struct {
+	LabelID        int     `json:"label_id"`
+	ProjectID      *int    `json:"project_id"`
+	Name           string  `json:"name"`
+	Color          string  `json:"color"`
+	Tasks          int     `json:"tasks"`
+	OpenTasks      int     `json:"open_tasks"`
+	DoneTasks      int     `json:"done_tasks"`
+	Projects       int     `json:"projects"`
+	EstimateHours  float64 `json:"estimate_hours"`
+	RemainingHours float64 `json:"remaining_hours"`
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+type MilestoneStatus string
+
+const (
+	MilestoneOpen      MilestoneStatus = "OPEN"
+	MilestoneCompleted MilestoneStatus = "COMPLETED"
+	MilestoneCancelled MilestoneStatus = "CANCELLED"
+)
+
+// Valid reports whether s is one of the statuses the milestones table
+// accepts.
+func (s MilestoneStatus) Valid() bool {
+	switch s {
+	case MilestoneOpen, MilestoneCompleted, MilestoneCancelled:
+		return true
+	}
+	return false
+}
+
+// WorkdayHours is the time an assignee is taken to have for a project's
+// work on each weekday when judging whether a milestone is at risk.
+const WorkdayHours = 8
+
+// Milestone is a target date within a project that tasks are linked to.
+type Milestone struct {
+	ID          int             `json:"id"`
+	ProjectID   int             `json:"project_id" validate:"required,min=1"`
+	Title       string          `json:"title" validate:"required,max=200"`
+	Description string          `json:"description"`
+	TargetDate  Date            `json:"target_date" validate:"required"`
+	Status      MilestoneStatus `json:"status" validate:"required,enum"`
+	Version     int             `json:"version"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// MilestoneProgress is how far the tasks of a milestone have got. Cancelled
+// tasks are not counted. RemainingHours is the work left on open tasks, and
+// CapacityHours what their assignees can do on the weekdays from today
+// through the target date. The milestone is at risk while it is open and
+// one of them has more work left on it than that, which includes any open
+// work once the target date has passed. OverloadedAssignees lists who.
+type MilestoneProgress struct {
+	Tasks               int     `json:"tasks"`
+	Done                int     `json:"done"`
+	Open                int     `json:"open"`
+	PercentComplete     float64 `json:"percent_complete"`
+	RemainingHours      float64 `json:"remaining_hours"`
+	WorkingDaysLeft     int     `json:"working_days_left"`
+	CapacityHours       float64 `json:"capacity_hours"`
+	AtRisk              bool    `json:"at_risk"`
+	OverloadedAssignees []int   `json:"overloaded_assignees"`
+}
+
+// MilestoneSummary is a milestone with its progress.
+type MilestoneSummary struct {
+	Milestone
+	Progress MilestoneProgress `json:"progress"`
+}
+
+// NewMilestoneProgress computes a milestone's progress from its tasks as of
+// today.
+func NewMilestoneProgress(milestone Milestone, tasks []Task, today Date) MilestoneProgress {
+	progress := MilestoneProgress{
+		WorkingDaysLeft:     WorkingDays(today, milestone.TargetDate),
+		OverloadedAssignees: []int{},
+	}
+	remaining := make(map[int]float64)
+	for _, task := range tasks {
+		if task.Status == StatusCancelled {
+			continue
+		}
+		progress.Tasks++
+		if task.Status == StatusDone {
+			progress.Done++
+			continue
+		}
+		progress.Open++
+		remaining[task.AssignedTo] += leafRollup(task).RemainingHours
+	}
+	if progress.Tasks > 0 {
+		percent := 100 * float64(progress.Done) / float64(progress.Tasks)
+		progress.PercentComplete = math.Round(percent*10) / 10
+	}
+
+	perAssignee := float64(progress.WorkingDaysLeft * WorkdayHours)
+	for assignee, hours := range remaining {
+		progress.RemainingHours += hours
+		progress.CapacityHours += perAssignee
+		if hours > perAssignee || progress.WorkingDaysLeft == 0 {
+			progress.OverloadedAssignees = append(progress.OverloadedAssignees, assignee)
+		}
+	}
+	slices.Sort(progress.OverloadedAssignees)
+	progress.RemainingHours = math.Round(progress.RemainingHours*100) / 100
+	progress.AtRisk = milestone.Status == MilestoneOpen && len(progress.OverloadedAssignees) > 0
+	return progress
+}
+
+// WorkingDays counts the weekdays from one date through another, or zero if
+// to is before from.
+func WorkingDays(from, to Date) int {
+	if to.Before(from.Time) {
+		return 0
+	}
+	days := int(to.Sub(from.Time).Hours()/24) + 1
+	weeks, rest := days/7, days%7
+	count := weeks * 5
+	weekday := from.Weekday()
+	for i := 0; i < rest; i++ {
+		if day := (weekday + time.Weekday(i)) % 7; day != time.Saturday && day != time.Sunday {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMilestoneProgress(t *testing.T) {
+	hours := func(h float64) *float64 { return &h }
+	monday := NewDate(time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC))
+	// Five working days left, 40 hours per assignee.
+	milestone := Milestone{Status: MilestoneOpen, TargetDate: monday.AddDays(4)}
+	tasks := []Task{
+		{AssignedTo: 1, Status: StatusDone, EstimateHours: hours(10)},
+		{AssignedTo: 1, Status: StatusInProgress, EstimateHours: hours(40), RemainingHours: hours(30)},
+		{AssignedTo: 2, Status: StatusTodo, EstimateHours: hours(25)},
+		{AssignedTo: 2, Status: StatusInReview, EstimateHours: hours(20), RemainingHours: hours(20.5)},
+		{AssignedTo: 3, Status: StatusCancelled, EstimateHours: hours(100)},
+	}
+
+	progress := NewMilestoneProgress(milestone, tasks, monday)
+	want := MilestoneProgress{
+		Tasks:               4,
+		Done:                1,
+		Open:                3,
+		PercentComplete:     25,
+		RemainingHours:      75.5,
+		WorkingDaysLeft:     5,
+		CapacityHours:       80,
+		AtRisk:              true,
+		OverloadedAssignees: []int{2},
+	}
+	if !reflect.DeepEqual(progress, want) {
+		t.Fatalf("progress = %+v, want %+v", progress, want)
+	}
+
+	// Only an open milestone is at risk.
+	completed := milestone
+	completed.Status = MilestoneCompleted
+	if progress := NewMilestoneProgress(completed, tasks, monday); progress.AtRisk || len(progress.OverloadedAssignees) != 1 {
+		t.Fatalf("completed milestone progress = %+v", progress)
+	}
+
+	tests := []struct {
+		name       string
+		tasks      []Task
+		today      Date
+		wantAtRisk bool
+	}{
+		{"no tasks", nil, monday, false},
+		{"all tasks cancelled", []Task{{AssignedTo: 1, Status: StatusCancelled, EstimateHours: hours(500)}}, monday, false},
+		{"past due with open work", []Task{{AssignedTo: 1, Status: StatusTodo}}, monday.AddDays(5), true},
+		{"past due with everything done", []Task{{AssignedTo: 1, Status: StatusDone, EstimateHours: hours(8)}}, monday.AddDays(5), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := NewMilestoneProgress(milestone, tt.tasks, tt.today)
+			if progress.AtRisk != tt.wantAtRisk {
+				t.Fatalf("at risk = %v, want %v (%+v)", progress.AtRisk, tt.wantAtRisk, progress)
+			}
+			if progress.OverloadedAssignees == nil {
+				t.Fatal("overloaded assignees are nil")
+			}
+		})
+	}
+	if progress := NewMilestoneProgress(milestone, tests[1].tasks, monday); progress.Tasks != 0 || progress.RemainingHours != 0 {
+		t.Fatalf("progress with only cancelled tasks = %+v", progress)
+	}
+	if progress := NewMilestoneProgress(milestone, tests[2].tasks, monday.AddDays(5)); progress.WorkingDaysLeft != 0 || progress.CapacityHours != 0 {
+		t.Fatalf("progress past the target date = %+v", progress)
+	}
+}
+
+func TestWorkingDays(t *testing.T) {
+	monday := NewDate(time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name     string
+		from, to Date
+		want     int
+	}{
+		{"same weekday", monday, monday, 1},
+		{"weekend", monday.AddDays(5), monday.AddDays(6), 0},
+		{"over a weekend", monday.AddDays(4), monday.AddDays(7), 2},
+		{"two weeks", monday, monday.AddDays(13), 10},
+		{"from a sunday", monday.AddDays(-1), monday.AddDays(9), 8},
+		{"backwards", monday.AddDays(1), monday, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WorkingDays(tt.from, tt.to); got != tt.want {
+				t.Fatalf("WorkingDays(%s, %s) = %d, want %d", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
@@ -9,32 +9,136 @@ const (
 	RoleDeveloper      EmployeeRole = "DEVELOPER"
 )
 
+// Valid reports whether r is one of the roles the employees table accepts.
+func (r EmployeeRole) Valid() bool {
+	switch r {
+	case RoleProjectManager, RoleDeveloper:
+		return true
+	}
+	return false
+}
+
+type TaskStatus string
+
+const (
+	StatusTodo       TaskStatus = "TODO"
+	StatusInProgress TaskStatus = "IN_PROGRESS"
+	StatusInReview   TaskStatus = "IN_REVIEW"
+	StatusBlocked    TaskStatus = "BLOCKED"
+	StatusDone       TaskStatus = "DONE"
+	StatusCancelled  TaskStatus = "CANCELLED"
+)
+
+// TaskStatuses lists every status in workflow order.
+var TaskStatuses = []TaskStatus{StatusTodo, StatusInProgress, StatusInReview, StatusBlocked, StatusDone, StatusCancelled}
+
+// Valid reports whether s is one of the statuses the tasks table accepts.
+func (s TaskStatus) Valid() bool {
+	switch s {
+	case StatusTodo, StatusInProgress, StatusInReview, StatusBlocked, StatusDone, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+type TaskPriority string
+
+const (
+	PriorityP0 TaskPriority = "P0"
+	PriorityP1 TaskPriority = "P1"
+	PriorityP2 TaskPriority = "P2"
+	PriorityP3 TaskPriority = "P3"
+
+	// DefaultPriority is given to tasks created without a priority.
+	DefaultPriority = PriorityP2
+)
+
+// Valid reports whether p is one of the priorities the tasks table accepts.
+// P0 is the most urgent.
+func (p TaskPriority) Valid() bool {
+	switch p {
+	case PriorityP0, PriorityP1, PriorityP2, PriorityP3:
+		return true
+	}
+	return false
+}
+
+// Open reports whether work on a task with status s is still outstanding.
+func (s TaskStatus) Open() bool {
+	return s != StatusDone && s != StatusCancelled
+}
+
+// Field lengths mirror the VARCHAR limits in the schema. DeletedAt is only
+// set on soft-deleted rows, which only administrators get to see.
 type Employee struct {
 	ID        int          `json:"id"`
-	Name      string       `json:"name"`
-	Email     string       `json:"email"`
-	Role      EmployeeRole `json:"role"`
+	Name      string       `json:"name" validate:"required,max=100"`
+	Email     string       `json:"email" validate:"required,max=255,email"`
+	Role      EmployeeRole `json:"role" validate:"required,enum"`
 	Skills    []string     `json:"skills,omitempty"`
+	Version   int          `json:"version"`
 	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	DeletedAt *time.Time   `json:"deleted_at,omitempty"`
 	Projects  []Project    `json:"projects,omitempty"`
 	Tasks     []Task       `json:"tasks,omitempty"`
 }
 
+// ArchivedAt is set while a project is archived: it stays readable but
+// takes no new tasks. DeletedAt is set as for employees.
 type Project struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	LeadID      int       `json:"lead_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	Tasks       []Task    `json:"tasks,omitempty"`
+	ID          int        `json:"id"`
+	Name        string     `json:"name" validate:"required,max=200"`
+	Description string     `json:"description"`
+	LeadID      int        `json:"lead_id" validate:"required,min=1"`
+	Version     int        `json:"version"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	Tasks       []Task     `json:"tasks,omitempty"`
 }
 
+// EstimateHours and RemainingHours are nil when no estimate has been made.
+// ParentTaskID is nil for top-level tasks, SprintID for tasks in the backlog
+// and MilestoneID for tasks not linked to a milestone. Rank orders the task
+// within its status column of the project board and is only changed by
+// moving the task. DeletedAt is set as for employees.
 type Task struct {
-	ID          int       `json:"id"`
-	ProjectID   int       `json:"project_id"`
-	AssignedTo  int       `json:"assigned_to"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID             int          `json:"id"`
+	ProjectID      int          `json:"project_id" validate:"required,min=1"`
+	AssignedTo     int          `json:"assigned_to" validate:"required,min=1"`
+	ParentTaskID   *int         `json:"parent_task_id" validate:"min=1"`
+	SprintID       *int         `json:"sprint_id" validate:"min=1"`
+	MilestoneID    *int         `json:"milestone_id" validate:"min=1"`
+	Title          string       `json:"title" validate:"required,max=200"`
+	Description    string       `json:"description"`
+	Status         TaskStatus   `json:"status" validate:"required,enum"`
+	Priority       TaskPriority `json:"priority" validate:"required,enum"`
+	Rank           string       `json:"rank"`
+	StartDate      Date         `json:"start_date"`
+	DueDate        Date         `json:"due_date"`
+	EstimateHours  *float64     `json:"estimate_hours" validate:"min=0,max=99999"`
+	RemainingHours *float64     `json:"remaining_hours" validate:"min=0,max=99999"`
+	Version        int          `json:"version"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	DeletedAt      *time.Time   `json:"deleted_at,omitempty"`
+}
+
+// TaskTransition records one status change of a task. ActorID is nil when
+// the change was made by the system or the actor has since been deleted.
+type TaskTransition struct {
+	ID        int        `json:"id"`
+	TaskID    int        `json:"task_id"`
+	From      TaskStatus `json:"from"`
+	To        TaskStatus `json:"to"`
+	ActorID   *int       `json:"actor_id"`
+	Note      string     `json:"note,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Overdue reports whether the task was due before today and is still open.
+func (t Task) Overdue(today Date) bool {
+	return !t.DueDate.IsZero() && t.DueDate.Before(today.Time) && t.Status.Open()
 }
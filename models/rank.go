@@ -0,0 +1,187 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A rank orders tasks within a board column. Ranks are compared as strings
+// and read as base-36 fractions between 0 and 1, so there is always room
+// for another rank between two others and moving a task only rewrites that
+// task's row. Ranks never end in "0", which would make them equal in value
+// to a shorter rank.
+//
+// rankDigits are the characters ranks are written in, in ascending order.
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// rankPrecision is the number of digits in which ranks step away from the
+// ends of a column. Adding tasks one after another at the bottom (or top)
+// of a column then keeps ranks this short for the first few hundred
+// thousand tasks, rather than halving the space left each time.
+const rankPrecision = 4
+
+// MaxRankLength bounds the length of a rank. Ranks grow when tasks are
+// repeatedly dropped into the same gap; a column whose next rank would be
+// longer is given fresh ranks with RankSequence instead.
+const MaxRankLength = 64
+
+// ValidRank reports whether rank is a non-empty string of rank digits that
+// does not end in "0".
+func ValidRank(rank string) bool {
+	if rank == "" || strings.HasSuffix(rank, "0") {
+		return false
+	}
+	for i := 0; i < len(rank); i++ {
+		if strings.IndexByte(rankDigits, rank[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// RankBetween returns a rank that sorts after before and ahead of after.
+// An empty before stands for the start of the column and an empty after
+// for its end, so RankBetween("", "") is the first rank of an empty column.
+// It fails unless before sorts ahead of after and both are valid ranks.
+func RankBetween(before, after string) (string, error) {
+	if before != "" && !ValidRank(before) {
+		return "", fmt.Errorf("invalid rank %q", before)
+	}
+	if after != "" && !ValidRank(after) {
+		return "", fmt.Errorf("invalid rank %q", after)
+	}
+	if before != "" && after != "" && before >= after {
+		return "", fmt.Errorf("rank %q does not sort ahead of %q", before, after)
+	}
+	switch {
+	case after == "":
+		return rankIncrement(before), nil
+	case before == "":
+		return rankDecrement(after), nil
+	}
+	return rankMidpoint(before, after), nil
+}
+
+// RankAfter returns a rank that sorts after last, or the first rank of an
+// empty column when last is empty. last must be a valid rank or empty.
+func RankAfter(last string) string {
+	rank, err := RankBetween(last, "")
+	if err != nil {
+		panic(err)
+	}
+	return rank
+}
+
+// RankSequence returns n ranks in ascending order, spread evenly over the
+// whole column so there is room before, between and after each of them.
+func RankSequence(n int) []string {
+	width, span := rankPrecision, 1
+	for range width {
+		span *= len(rankDigits)
+	}
+	for span/(n+1) < len(rankDigits) {
+		width++
+		span *= len(rankDigits)
+	}
+	step := span / (n + 1)
+
+	ranks := make([]string, n)
+	digits := make([]int, width)
+	for k := range ranks {
+		value := (k + 1) * step
+		for i := width - 1; i >= 0; i-- {
+			digits[i] = value % len(rankDigits)
+			value /= len(rankDigits)
+		}
+		ranks[k] = rankString(digits)
+	}
+	return ranks
+}
+
+// rankMidpoint returns a rank roughly half way between a and b, reading
+// missing digits of a as 0 and, when b is empty, every digit of b as one
+// past the largest.
+func rankMidpoint(a, b string) string {
+	var prefix strings.Builder
+	for i := 0; ; i++ {
+		lo := rankDigit(a, i, 0)
+		hi := rankDigit(b, i, len(rankDigits))
+		if lo == hi {
+			prefix.WriteByte(rankDigits[lo])
+			continue
+		}
+		if hi-lo > 1 {
+			prefix.WriteByte(rankDigits[(lo+hi)/2])
+			return prefix.String()
+		}
+		// The digits are adjacent: keep a's digit and go half way between
+		// the rest of a and the end of the column.
+		prefix.WriteByte(rankDigits[lo])
+		rest := ""
+		if i+1 < len(a) {
+			rest = a[i+1:]
+		}
+		return prefix.String() + rankMidpoint(rest, "")
+	}
+}
+
+// rankIncrement returns the smallest step after a at rankPrecision digits,
+// or half way to the end of the column once a is too close to it.
+func rankIncrement(a string) string {
+	if a == "" {
+		return rankMidpoint("", "")
+	}
+	digits := rankValues(a)
+	for i := len(digits) - 1; i >= 0; i-- {
+		if digits[i]++; digits[i] < len(rankDigits) {
+			return rankString(digits)
+		}
+		digits[i] = 0
+	}
+	return rankMidpoint(a, "")
+}
+
+// rankDecrement returns the smallest step ahead of b at rankPrecision
+// digits, or half way to the start of the column once b is too close to it.
+func rankDecrement(b string) string {
+	digits := rankValues(b)
+	for i := len(digits) - 1; i >= 0; i-- {
+		if digits[i]--; digits[i] >= 0 {
+			break
+		}
+		digits[i] = len(rankDigits) - 1
+	}
+	if rank := rankString(digits); rank != "" {
+		return rank
+	}
+	return rankMidpoint("", b)
+}
+
+// rankValues returns the digit values of rank, padded with zeros to at
+// least rankPrecision digits.
+func rankValues(rank string) []int {
+	digits := make([]int, max(len(rank), rankPrecision))
+	for i := range rank {
+		digits[i] = rankDigit(rank, i, 0)
+	}
+	return digits
+}
+
+// rankString writes digits as a rank, dropping trailing zeros.
+func rankString(digits []int) string {
+	for len(digits) > 0 && digits[len(digits)-1] == 0 {
+		digits = digits[:len(digits)-1]
+	}
+	b := make([]byte, len(digits))
+	for i, d := range digits {
+		b[i] = rankDigits[d]
+	}
+	return string(b)
+}
+
+func rankDigit(rank string, i, missing int) int {
+	if i >= len(rank) {
+		return missing
+	}
+	return strings.IndexByte(rankDigits, rank[i])
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+// checkBetween fails unless rank is a valid rank that sorts strictly
+// between before and after, empty ones standing for the ends of the column.
+func checkBetween(t *testing.T, rank, before, after string) {
+	t.Helper()
+	if !ValidRank(rank) {
+		t.Fatalf("rank %q between %q and %q is not valid", rank, before, after)
+	}
+	if before != "" && rank <= before {
+		t.Fatalf("rank %q does not sort after %q", rank, before)
+	}
+	if after != "" && rank >= after {
+		t.Fatalf("rank %q does not sort ahead of %q", rank, after)
+	}
+}
+
+func TestRankBetween(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+	}{
+		{"empty column", "", ""},
+		{"after last", "i", ""},
+		{"ahead of first", "", "i"},
+		{"wide gap", "1", "z"},
+		{"adjacent digits", "i", "j"},
+		{"longer after", "i", "i1"},
+		{"longer before", "i1", "i2"},
+		{"before is a prefix", "i", "i0001"},
+		{"after is a prefix", "hzzz", "i"},
+		{"near the start", "", "0001"},
+		{"near the end", "zzzz", ""},
+		{"next to the end", "zzzzz", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rank, err := RankBetween(tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("RankBetween(%q, %q): %v", tt.before, tt.after, err)
+			}
+			checkBetween(t, rank, tt.before, tt.after)
+		})
+	}
+}
+
+func TestRankBetweenInvalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+	}{
+		{"trailing zero", "i", "i0"},
+		{"trailing zero before", "i0", ""},
+		{"not a rank digit", "", "I"},
+		{"equal", "i", "i"},
+		{"reversed", "j", "i"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rank, err := RankBetween(tt.before, tt.after); err == nil {
+				t.Fatalf("RankBetween(%q, %q) = %q, want an error", tt.before, tt.after, rank)
+			}
+		})
+	}
+}
+
+func TestRankBetweenRepeated(t *testing.T) {
+	tests := []struct {
+		name string
+		next func(ranks []string) (before, after string, at int)
+	}{
+		{"tail", func(ranks []string) (string, string, int) {
+			return ranks[len(ranks)-1], "", len(ranks)
+		}},
+		{"head", func(ranks []string) (string, string, int) {
+			return "", ranks[0], 0
+		}},
+		{"same gap", func(ranks []string) (string, string, int) {
+			return ranks[0], ranks[1], 1
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, _ := RankBetween("", "")
+			second, _ := RankBetween(first, "")
+			ranks := []string{first, second}
+			for range 200 {
+				before, after, at := tt.next(ranks)
+				rank, err := RankBetween(before, after)
+				if err != nil {
+					t.Fatalf("RankBetween(%q, %q): %v", before, after, err)
+				}
+				checkBetween(t, rank, before, after)
+				ranks = slices.Insert(ranks, at, rank)
+			}
+			if !slices.IsSorted(ranks) {
+				t.Fatalf("ranks out of order: %q", ranks)
+			}
+		})
+	}
+}
+
+func TestRankBetweenStaysShortAtTheEnds(t *testing.T) {
+	rank := ""
+	for range 10000 {
+		rank = RankAfter(rank)
+	}
+	if len(rank) > rankPrecision {
+		t.Fatalf("rank %q after 10000 appends is longer than %d digits", rank, rankPrecision)
+	}
+}
+
+func TestRankSequence(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 35, 36, 1000, 100000} {
+		ranks := RankSequence(n)
+		if len(ranks) != n {
+			t.Fatalf("RankSequence(%d) returned %d ranks", n, len(ranks))
+		}
+		for i, rank := range ranks {
+			before, after := "", ""
+			if i > 0 {
+				before = ranks[i-1]
+			}
+			if i+1 < n {
+				after = ranks[i+1]
+			}
+			checkBetween(t, rank, before, after)
+			if len(rank) > MaxRankLength {
+				t.Fatalf("RankSequence(%d) rank %q is longer than %d", n, rank, MaxRankLength)
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		// There must be room ahead of the first rank and after the last.
+		if _, err := RankBetween("", ranks[0]); err != nil {
+			t.Fatalf("RankSequence(%d): no room ahead of %q: %v", n, ranks[0], err)
+		}
+		if _, err := RankBetween(ranks[n-1], ""); err != nil {
+			t.Fatalf("RankSequence(%d): no room after %q: %v", n, ranks[n-1], err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxOccurrencePreview caps how many upcoming occurrences can be previewed
+// at once.
+const MaxOccurrencePreview = 100
+
+// errNeverDue is returned for schedules that cannot fire, such as one for
+// February 30th.
+var errNeverDue = errors.New("schedule is never due")
+
+// RecurringTask is a definition from which a task is created every time
+// its cron Schedule comes due in Timezone. Each task starts on the date it
+// was due and, with DueInDays, is due that many days later.
+//
+// NextRunAt is when the next task will be created; it is nil while the
+// definition is paused or once its schedule has no further occurrences.
+// LastRunAt is the occurrence the last task was created for.
+type RecurringTask struct {
+	ID            int          `json:"id"`
+	ProjectID     int          `json:"project_id" validate:"required,min=1"`
+	AssignedTo    int          `json:"assigned_to" validate:"required,min=1"`
+	Title         string       `json:"title" validate:"required,max=200"`
+	Description   string       `json:"description"`
+	Priority      TaskPriority `json:"priority" validate:"enum"`
+	EstimateHours *float64     `json:"estimate_hours" validate:"min=0,max=99999"`
+	DueInDays     *int         `json:"due_in_days" validate:"min=0,max=366"`
+	Schedule      string       `json:"schedule" validate:"required,max=100"`
+	Timezone      string       `json:"timezone" validate:"max=64"`
+	Paused        bool         `json:"paused"`
+	NextRunAt     *time.Time   `json:"next_run_at"`
+	LastRunAt     *time.Time   `json:"last_run_at"`
+	Version       int          `json:"version"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// Location returns the time zone the schedule is read in, UTC by default.
+func (rt RecurringTask) Location() (*time.Location, error) {
+	if rt.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(rt.Timezone)
+}
+
+// Occurrences returns up to n times after t at which the definition is
+// due, stopping early if the schedule runs out.
+func (rt RecurringTask) Occurrences(t time.Time, n int) ([]time.Time, error) {
+	schedule, err := ParseSchedule(rt.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := rt.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences := []time.Time{}
+	t = t.In(loc)
+	for len(occurrences) < n {
+		if t = schedule.Next(t); t.IsZero() {
+			break
+		}
+		occurrences = append(occurrences, t)
+	}
+	return occurrences, nil
+}
+
+// NextRun returns the first time after t at which the definition is due.
+func (rt RecurringTask) NextRun(t time.Time) (time.Time, error) {
+	occurrences, err := rt.Occurrences(t, 1)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(occurrences) == 0 {
+		return time.Time{}, errNeverDue
+	}
+	return occurrences[0], nil
+}
+
+// Catchup picks the occurrence to create a task for once NextRunAt has
+// passed: the latest one not after now, so that occurrences missed while
+// the server was down produce one task rather than a backlog. next is the
+// first occurrence after now, or the zero time if there is none.
+func (rt RecurringTask) Catchup(now time.Time) (occurrence, next time.Time, err error) {
+	schedule, err := ParseSchedule(rt.Schedule)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	loc, err := rt.Location()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	occurrence = rt.NextRunAt.In(loc)
+	for {
+		next = schedule.Next(occurrence)
+		if next.IsZero() || next.After(now) {
+			return occurrence, next, nil
+		}
+		occurrence = next
+	}
+}
+
+// NewTask returns the task to create for an occurrence.
+func (rt RecurringTask) NewTask(occurrence time.Time) Task {
+	if loc, err := rt.Location(); err == nil {
+		occurrence = occurrence.In(loc)
+	}
+	priority := rt.Priority
+	if priority == "" {
+		priority = DefaultPriority
+	}
+	task := Task{
+		ProjectID:   rt.ProjectID,
+		AssignedTo:  rt.AssignedTo,
+		Title:       rt.Title,
+		Description: rt.Description,
+		Status:      StatusTodo,
+		Priority:    priority,
+		StartDate:   NewDate(occurrence),
+	}
+	if rt.EstimateHours != nil {
+		estimate := *rt.EstimateHours
+		remaining := estimate
+		task.EstimateHours = &estimate
+		task.RemainingHours = &remaining
+	}
+	if rt.DueInDays != nil {
+		task.DueDate = task.StartDate.AddDays(*rt.DueInDays)
+	}
+	return task
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRecurringOccurrences(t *testing.T) {
+	rt := RecurringTask{Schedule: "0 9 * * mon", Timezone: "Europe/Berlin"}
+	from := time.Date(2026, time.March, 20, 12, 0, 0, 0, time.UTC)
+
+	got, err := rt.Occurrences(from, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Berlin moves to summer time on March 29th.
+	want := []time.Time{
+		time.Date(2026, time.March, 23, 8, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 30, 7, 0, 0, 0, time.UTC),
+		time.Date(2026, time.April, 6, 7, 0, 0, 0, time.UTC),
+	}
+	if !slices.EqualFunc(got, want, time.Time.Equal) {
+		t.Fatalf("occurrences = %v, want %v", got, want)
+	}
+
+	never := RecurringTask{Schedule: "0 0 30 2 *"}
+	if got, err := never.Occurrences(from, 3); err != nil || len(got) != 0 {
+		t.Fatalf("occurrences of a schedule that is never due = %v, %v", got, err)
+	}
+	if _, err := never.NextRun(from); err != errNeverDue {
+		t.Fatalf("NextRun error = %v, want %v", err, errNeverDue)
+	}
+	if _, err := (RecurringTask{Schedule: "@daily", Timezone: "Mars/Olympus"}).NextRun(from); err == nil {
+		t.Fatal("NextRun succeeded with an unknown time zone")
+	}
+}
+
+func TestRecurringCatchup(t *testing.T) {
+	at := func(day, hour int) time.Time { return time.Date(2026, time.March, day, hour, 0, 0, 0, time.UTC) }
+	due := at(2, 9)
+	rt := RecurringTask{Schedule: "0 9 * * *", NextRunAt: &due}
+
+	tests := []struct {
+		name                 string
+		now                  time.Time
+		wantOccurrence, next time.Time
+	}{
+		{"just due", at(2, 9), at(2, 9), at(3, 9)},
+		{"due earlier today", at(2, 18), at(2, 9), at(3, 9)},
+		{"missed days collapse into the latest", at(5, 10), at(5, 9), at(6, 9)},
+		{"latest not yet due today", at(5, 8), at(4, 9), at(5, 9)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			occurrence, next, err := rt.Catchup(tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !occurrence.Equal(tt.wantOccurrence) || !next.Equal(tt.next) {
+				t.Fatalf("got %s, next %s; want %s, next %s", occurrence, next, tt.wantOccurrence, tt.next)
+			}
+		})
+	}
+}
+
+func TestRecurringNewTask(t *testing.T) {
+	estimate, dueIn := 2.5, 3
+	rt := RecurringTask{
+		ProjectID:     1,
+		AssignedTo:    2,
+		Title:         "Rotate the keys",
+		Schedule:      "0 23 * * *",
+		Timezone:      "America/New_York",
+		EstimateHours: &estimate,
+		DueInDays:     &dueIn,
+	}
+	// 04:00 UTC is still the previous evening in New York.
+	task := rt.NewTask(time.Date(2026, time.March, 2, 4, 0, 0, 0, time.UTC))
+	if task.Status != StatusTodo || task.Priority != DefaultPriority || task.Title != rt.Title {
+		t.Fatalf("task = %+v", task)
+	}
+	if task.StartDate.String() != "2026-03-01" || task.DueDate.String() != "2026-03-04" {
+		t.Fatalf("task dates = %s to %s", task.StartDate, task.DueDate)
+	}
+	if *task.EstimateHours != estimate || *task.RemainingHours != estimate {
+		t.Fatalf("task hours = %v, %v", *task.EstimateHours, *task.RemainingHours)
+	}
+	// The task does not share the definition's estimate.
+	*task.EstimateHours = 1
+	if *rt.EstimateHours != estimate {
+		t.Fatal("changing the task's estimate changed the definition's")
+	}
+
+	rt.EstimateHours, rt.DueInDays, rt.Priority = nil, nil, PriorityP0
+	task = rt.NewTask(time.Date(2026, time.March, 2, 4, 0, 0, 0, time.UTC))
+	if task.Priority != PriorityP0 || task.EstimateHours != nil || !task.DueDate.IsZero() {
+		t.Fatalf("task = %+v", task)
+	}
+}
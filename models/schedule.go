@@ -0,0 +1,174 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed five-field cron expression: minute, hour, day of
+// month, month and day of week. Fields accept *, values, ranges a-b, steps
+// */n or a-b/n, and comma-separated lists of these; months and weekdays
+// may be given by their three-letter English names, and both 0 and 7 mean
+// Sunday. As in cron, when both day fields are restricted a day matching
+// either one is due; a day field starting with *, such as */2, does not
+// count as restricted.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	anyDOM, anyDOW                bool
+}
+
+// scheduleMacros are the shorthands accepted in place of five fields.
+var scheduleMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type scheduleField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var scheduleFields = [5]scheduleField{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{"day of week", 0, 7, []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// ParseSchedule parses a cron expression or macro such as @weekly.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if expanded, ok := scheduleMacros[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != len(scheduleFields) {
+		return Schedule{}, fmt.Errorf("schedule must have 5 fields or be one of @hourly, @daily, @weekly, @monthly, @yearly")
+	}
+
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseScheduleField(part, scheduleFields[i])
+		if err != nil {
+			return Schedule{}, err
+		}
+		bits[i] = b
+	}
+	// Fold Sunday written as 7 onto 0.
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+	return Schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		anyDOM: strings.HasPrefix(parts[2], "*"),
+		anyDOW: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+func parseScheduleField(s string, field scheduleField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(s, ",") {
+		span, stepText, hasStep := strings.Cut(item, "/")
+		lo, hi := field.min, field.max
+		if span != "*" {
+			first, last, isRange := strings.Cut(span, "-")
+			var err error
+			if lo, err = parseScheduleValue(first, field); err != nil {
+				return 0, err
+			}
+			switch {
+			case isRange:
+				if hi, err = parseScheduleValue(last, field); err != nil {
+					return 0, err
+				}
+			case !hasStep:
+				hi = lo
+			}
+		}
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("%s step %q must be a positive integer", field.name, stepText)
+			}
+			step = n
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("%s range %q is empty", field.name, span)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func parseScheduleValue(s string, field scheduleField) (int, error) {
+	for i, name := range field.names {
+		if name != "" && strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < field.min || n > field.max {
+		return 0, fmt.Errorf("%s %q must be between %d and %d", field.name, s, field.min, field.max)
+	}
+	return n, nil
+}
+
+// Next returns the first time strictly after t, to the minute, at which
+// the schedule is due, reading the fields in t's location. It returns the
+// zero time if the schedule is not due within five years, as for February
+// 30th.
+func (s Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	// advance moves t forward to next, or by a minute where a daylight
+	// saving change would otherwise make next repeat an earlier wall time.
+	advance := func(next time.Time) {
+		if !next.After(t) {
+			next = t.Add(time.Minute)
+		}
+		t = next
+	}
+	for t.Before(limit) {
+		year, month, day := t.Date()
+		switch {
+		case s.month&(1<<uint(month)) == 0:
+			advance(time.Date(year, month+1, 1, 0, 0, 0, 0, loc))
+		case !s.dayMatches(t):
+			advance(time.Date(year, month, day+1, 0, 0, 0, 0, loc))
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			advance(time.Date(year, month, day, t.Hour()+1, 0, 0, 0, loc))
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			advance(time.Date(year, month, day, t.Hour(), t.Minute()+1, 0, 0, loc))
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.anyDOM || s.anyDOW {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	// A Friday.
+	friday := at(2026, time.January, 30, 10, 7)
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", friday, at(2026, time.January, 30, 10, 8)},
+		{"seconds are dropped", "* * * * *", friday.Add(59 * time.Second), at(2026, time.January, 30, 10, 8)},
+		{"minute step", "*/15 * * * *", friday, at(2026, time.January, 30, 10, 15)},
+		{"hour step", "0 */6 * * *", friday, at(2026, time.January, 30, 12, 0)},
+		{"range step", "0 1-23/4 * * *", friday, at(2026, time.January, 30, 13, 0)},
+		{"value step", "10/20 * * * *", friday, at(2026, time.January, 30, 10, 10)},
+		{"hour range", "30 9-17 * * *", friday, at(2026, time.January, 30, 10, 30)},
+		{"hour range ended", "30 9-17 * * *", at(2026, time.January, 30, 17, 45), at(2026, time.January, 31, 9, 30)},
+		{"list", "0,30 8-10/2,20 * * *", friday, at(2026, time.January, 30, 10, 30)},
+		{"list next item", "0,30 8-10/2,20 * * *", at(2026, time.January, 30, 10, 45), at(2026, time.January, 30, 20, 0)},
+		{"weekday range", "0 9 * * mon-fri", friday, at(2026, time.February, 2, 9, 0)},
+		{"sunday as 7", "0 0 * * 7", friday, at(2026, time.February, 1, 0, 0)},
+		{"sunday as 0", "0 0 * * 0", friday, at(2026, time.February, 1, 0, 0)},
+		{"day of month list", "0 0 1,15 * *", friday, at(2026, time.February, 1, 0, 0)},
+		{"month names", "0 0 1 jan,JUL *", friday, at(2026, time.July, 1, 0, 0)},
+		{"day of month only", "0 0 13 * *", friday, at(2026, time.February, 13, 0, 0)},
+		{"day of week only", "0 0 * * 5", friday, at(2026, time.February, 6, 0, 0)},
+		{"either day, weekday first", "0 0 13 * 5", friday, at(2026, time.February, 6, 0, 0)},
+		{"either day, date first", "0 0 13 * 1", at(2026, time.February, 9, 10, 0), at(2026, time.February, 13, 0, 0)},
+		{"stepped day of month is not restricted", "0 0 */2 * 1", friday, at(2026, time.February, 9, 0, 0)},
+		{"31st skips short months", "0 0 31 * *", at(2026, time.January, 31, 10, 0), at(2026, time.March, 31, 0, 0)},
+		{"leap day", "0 0 29 2 *", at(2026, time.March, 1, 0, 0), at(2028, time.February, 29, 0, 0)},
+		{"end of month into next", "0 0 * * *", at(2026, time.April, 30, 23, 30), at(2026, time.May, 1, 0, 0)},
+		{"end of year into next", "59 23 31 12 *", at(2026, time.December, 31, 23, 59), at(2027, time.December, 31, 23, 59)},
+		{"last minute of the year", "* * * * *", at(2026, time.December, 31, 23, 59), at(2027, time.January, 1, 0, 0)},
+		{"weekly macro", "@weekly", friday, at(2026, time.February, 1, 0, 0)},
+		{"monthly macro", "@Monthly", friday, at(2026, time.February, 1, 0, 0)},
+		{"never due", "0 0 30 2 *", friday, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q): %v", tt.spec, err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Fatalf("%q after %s: got %s, want %s", tt.spec, tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleNextKeepsLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	s, err := ParseSchedule("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2026, time.January, 30, 8, 0, 0, 0, time.UTC)
+	want := time.Date(2026, time.January, 31, 9, 0, 0, 0, loc)
+	if got := s.Next(from.In(loc)); !got.Equal(want) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@often",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"-1 * * * *",
+		"*/0 * * * *",
+		"*/-5 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1,,2 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"* * * * monday",
+	} {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := ParseSchedule(spec); err == nil {
+				t.Fatalf("ParseSchedule(%q) succeeded, want an error", spec)
+			}
+		})
+	}
+}
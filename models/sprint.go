@@ -0,0 +1,103 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+type SprintState string
+
+const (
+	SprintPlanned SprintState = "PLANNED"
+	SprintActive  SprintState = "ACTIVE"
+	SprintClosed  SprintState = "CLOSED"
+)
+
+// Valid reports whether s is one of the states the sprints table accepts.
+func (s SprintState) Valid() bool {
+	switch s {
+	case SprintPlanned, SprintActive, SprintClosed:
+		return true
+	}
+	return false
+}
+
+// Sprint is a timebox of a project. It is planned, then started and
+// finally closed; a project has at most one active sprint. CommittedHours
+// is the estimate of the sprint's tasks when it started; CompletedHours
+// and CompletedTasks count its done tasks when it closed. They are nil
+// until then.
+type Sprint struct {
+	ID             int         `json:"id"`
+	ProjectID      int         `json:"project_id" validate:"required,min=1"`
+	Name           string      `json:"name" validate:"required,max=200"`
+	Goal           string      `json:"goal"`
+	StartDate      Date        `json:"start_date" validate:"required"`
+	EndDate        Date        `json:"end_date" validate:"required"`
+	State          SprintState `json:"state"`
+	StartedAt      *time.Time  `json:"started_at"`
+	ClosedAt       *time.Time  `json:"closed_at"`
+	CommittedHours *float64    `json:"committed_hours"`
+	CompletedHours *float64    `json:"completed_hours"`
+	CompletedTasks *int        `json:"completed_tasks"`
+	Version        int         `json:"version"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// SprintVelocity is what one closed sprint committed to and completed.
+type SprintVelocity struct {
+	SprintID       int     `json:"sprint_id"`
+	Name           string  `json:"name"`
+	StartDate      Date    `json:"start_date"`
+	EndDate        Date    `json:"end_date"`
+	CommittedHours float64 `json:"committed_hours"`
+	CompletedHours float64 `json:"completed_hours"`
+	CompletedTasks int     `json:"completed_tasks"`
+}
+
+// Velocity summarises a project's recent closed sprints, most recent
+// first. AverageHours is the mean of their completed hours and
+// AverageTasks of their completed tasks.
+type Velocity struct {
+	ProjectID    int              `json:"project_id"`
+	Sprints      []SprintVelocity `json:"sprints"`
+	AverageHours float64          `json:"average_hours"`
+	AverageTasks float64          `json:"average_tasks"`
+}
+
+// NewVelocity computes the velocity over closed sprints, keeping their
+// order. Sprints that are not closed are skipped.
+func NewVelocity(projectID int, sprints []Sprint) Velocity {
+	velocity := Velocity{ProjectID: projectID, Sprints: []SprintVelocity{}}
+	var hours float64
+	var tasks int
+	for _, sprint := range sprints {
+		if sprint.State != SprintClosed {
+			continue
+		}
+		v := SprintVelocity{
+			SprintID:  sprint.ID,
+			Name:      sprint.Name,
+			StartDate: sprint.StartDate,
+			EndDate:   sprint.EndDate,
+		}
+		if sprint.CommittedHours != nil {
+			v.CommittedHours = *sprint.CommittedHours
+		}
+		if sprint.CompletedHours != nil {
+			v.CompletedHours = *sprint.CompletedHours
+		}
+		if sprint.CompletedTasks != nil {
+			v.CompletedTasks = *sprint.CompletedTasks
+		}
+		velocity.Sprints = append(velocity.Sprints, v)
+		hours += v.CompletedHours
+		tasks += v.CompletedTasks
+	}
+	if n := float64(len(velocity.Sprints)); n > 0 {
+		velocity.AverageHours = math.Round(hours/n*100) / 100
+		velocity.AverageTasks = math.Round(float64(tasks)/n*10) / 10
+	}
+	return velocity
+}
@@ -0,0 +1,43 @@
+package models
+
+import "testing"
+
+func TestNewVelocity(t *testing.T) {
+	hours := func(h float64) *float64 { return &h }
+	count := func(n int) *int { return &n }
+	closed := func(id int, committed, completed float64, tasks int) Sprint {
+		return Sprint{ID: id, State: SprintClosed, CommittedHours: hours(committed), CompletedHours: hours(completed), CompletedTasks: count(tasks)}
+	}
+
+	velocity := NewVelocity(7, []Sprint{
+		closed(3, 40, 30, 4),
+		{ID: 4, State: SprintActive, CommittedHours: hours(50)},
+		closed(2, 35, 25.5, 3),
+		// Closed before anything was estimated.
+		{ID: 1, State: SprintClosed, CompletedTasks: count(3)},
+	})
+	if velocity.ProjectID != 7 || len(velocity.Sprints) != 3 {
+		t.Fatalf("velocity = %+v", velocity)
+	}
+	for i, id := range []int{3, 2, 1} {
+		if velocity.Sprints[i].SprintID != id {
+			t.Fatalf("sprint %d is %d, want %d", i, velocity.Sprints[i].SprintID, id)
+		}
+	}
+	want := SprintVelocity{SprintID: 2, CommittedHours: 35, CompletedHours: 25.5, CompletedTasks: 3}
+	if velocity.Sprints[1] != want {
+		t.Errorf("sprint velocity = %+v, want %+v", velocity.Sprints[1], want)
+	}
+	if got := velocity.Sprints[2]; got.CommittedHours != 0 || got.CompletedHours != 0 || got.CompletedTasks != 3 {
+		t.Errorf("unestimated sprint velocity = %+v", got)
+	}
+	// 55.5 / 3 and 10 / 3, rounded.
+	if velocity.AverageHours != 18.5 || velocity.AverageTasks != 3.3 {
+		t.Errorf("averages = %v hours, %v tasks", velocity.AverageHours, velocity.AverageTasks)
+	}
+
+	empty := NewVelocity(7, nil)
+	if empty.Sprints == nil || len(empty.Sprints) != 0 || empty.AverageHours != 0 || empty.AverageTasks != 0 {
+		t.Errorf("velocity without sprints = %+v", empty)
+	}
+}
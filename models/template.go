@@ -0,0 +1,299 @@
+package models
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// ProjectTemplate is a snapshot of a project's plan from which new projects
+// are created. Its labels, milestones and tasks are keyed by the IDs they
+// had in the source project, and tasks refer to each other, to milestones
+// and to labels by those keys. Tasks are ordered so that every parent comes
+// before its subtasks.
+//
+// Dates are kept as offsets in days from StartDate, the earliest date in
+// the source project, so that they can be laid out again from any other
+// start. StartDate is zero when the source had no dates at all.
+// SourceProjectID is nil once the source project has been purged.
+type ProjectTemplate struct {
+	ID              int                 `json:"id"`
+	Name            string              `json:"name" validate:"required,max=200"`
+	Description     string              `json:"description"`
+	SourceProjectID *int                `json:"source_project_id"`
+	StartDate       Date                `json:"start_date"`
+	LeadID          int                 `json:"lead_id"`
+	Members         []int               `json:"members"`
+	Labels          []TemplateLabel     `json:"labels"`
+	Milestones      []TemplateMilestone `json:"milestones"`
+	Tasks           []TemplateTask      `json:"tasks"`
+	Version         int                 `json:"version"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+}
+
+// TemplateLabel is a label used by the source project. Global labels are
+// reused by name when the template is instantiated and recreated as project
+// labels if they no longer exist. Attached is set for labels attached to
+// the project itself rather than only to its tasks.
+type TemplateLabel struct {
+	Key         int    `json:"key"`
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+	Global      bool   `json:"global"`
+	Attached    bool   `json:"attached"`
+}
+
+type TemplateMilestone struct {
+	Key          int    `json:"key"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	TargetOffset int    `json:"target_offset_days"`
+}
+
+// TemplateTask is a task of the source project. StartOffset and DueOffset
+// are nil for tasks without those dates.
+type TemplateTask struct {
+	Key           int          `json:"key"`
+	ParentKey     *int         `json:"parent_key"`
+	MilestoneKey  *int         `json:"milestone_key"`
+	Title         string       `json:"title"`
+	Description   string       `json:"description"`
+	Priority      TaskPriority `json:"priority"`
+	EstimateHours *float64     `json:"estimate_hours"`
+	AssignedTo    int          `json:"assigned_to"`
+	StartOffset   *int         `json:"start_offset_days"`
+	DueOffset     *int         `json:"due_offset_days"`
+	LabelKeys     []int        `json:"label_keys"`
+}
+
+// ProjectInstance is a project created from a template together with what
+// was created in it.
+type ProjectInstance struct {
+	Project    Project     `json:"project"`
+	Labels     []Label     `json:"labels"`
+	Milestones []Milestone `json:"milestones"`
+	Tasks      []Task      `json:"tasks"`
+}
+
+// NewProjectTemplate takes a snapshot of a project given its members,
+// milestones and tasks, the labels attached to it and those attached to
+// each of its tasks, keyed by task ID.
+//
+// Cancelled tasks and their subtasks are left out, as they are no longer
+// part of the plan. Done tasks are kept: a template repeats all the work of
+// its source, and every task starts over as to do in the projects created
+// from it.
+func NewProjectTemplate(project Project, members []Employee, milestones []Milestone, tasks []Task, projectLabels []Label, taskLabels map[int][]Label) ProjectTemplate {
+	tasks = withoutCancelled(tasks)
+	template := ProjectTemplate{
+		Name:            project.Name,
+		Description:     project.Description,
+		SourceProjectID: &project.ID,
+		LeadID:          project.LeadID,
+		Members:         []int{},
+		Labels:          []TemplateLabel{},
+		Milestones:      []TemplateMilestone{},
+		Tasks:           []TemplateTask{},
+	}
+	for _, member := range members {
+		template.Members = append(template.Members, member.ID)
+	}
+	slices.Sort(template.Members)
+
+	for _, milestone := range milestones {
+		template.StartDate = earliest(template.StartDate, milestone.TargetDate)
+	}
+	for _, task := range tasks {
+		template.StartDate = earliest(template.StartDate, task.StartDate)
+		template.StartDate = earliest(template.StartDate, task.DueDate)
+	}
+
+	labels := make(map[int]*TemplateLabel)
+	addLabel := func(label Label, attached bool) {
+		if l, ok := labels[label.ID]; ok {
+			l.Attached = l.Attached || attached
+			return
+		}
+		labels[label.ID] = &TemplateLabel{
+			Key:         label.ID,
+			Name:        label.Name,
+			Color:       label.Color,
+			Description: label.Description,
+			Global:      label.ProjectID == nil,
+			Attached:    attached,
+		}
+	}
+	for _, label := range projectLabels {
+		addLabel(label, true)
+	}
+
+	for _, milestone := range milestones {
+		template.Milestones = append(template.Milestones, TemplateMilestone{
+			Key:          milestone.ID,
+			Title:        milestone.Title,
+			Description:  milestone.Description,
+			TargetOffset: dayOffset(template.StartDate, milestone.TargetDate),
+		})
+	}
+
+	for _, task := range tasks {
+		t := TemplateTask{
+			Key:           task.ID,
+			ParentKey:     task.ParentTaskID,
+			MilestoneKey:  task.MilestoneID,
+			Title:         task.Title,
+			Description:   task.Description,
+			Priority:      task.Priority,
+			EstimateHours: task.EstimateHours,
+			AssignedTo:    task.AssignedTo,
+			LabelKeys:     []int{},
+		}
+		if !task.StartDate.IsZero() {
+			offset := dayOffset(template.StartDate, task.StartDate)
+			t.StartOffset = &offset
+		}
+		if !task.DueDate.IsZero() {
+			offset := dayOffset(template.StartDate, task.DueDate)
+			t.DueOffset = &offset
+		}
+		for _, label := range taskLabels[task.ID] {
+			addLabel(label, false)
+			t.LabelKeys = append(t.LabelKeys, label.ID)
+		}
+		template.Tasks = append(template.Tasks, t)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		template.Labels = append(template.Labels, *labels[key])
+	}
+	return template
+}
+
+// withoutCancelled orders tasks as parentsFirst does, leaving out cancelled
+// tasks together with all of their subtasks.
+func withoutCancelled(tasks []Task) []Task {
+	dropped := make(map[int]bool)
+	kept := make([]Task, 0, len(tasks))
+	for _, task := range parentsFirst(tasks) {
+		if task.Status == StatusCancelled || task.ParentTaskID != nil && dropped[*task.ParentTaskID] {
+			dropped[task.ID] = true
+			continue
+		}
+		kept = append(kept, task)
+	}
+	return kept
+}
+
+// parentsFirst orders tasks depth first, subtasks by ID after their parent.
+// Tasks whose parent is not among them are treated as top-level.
+func parentsFirst(tasks []Task) []Task {
+	byID := make(map[int]bool, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = true
+	}
+	var roots []Task
+	children := make(map[int][]Task)
+	for _, task := range tasks {
+		if task.ParentTaskID != nil && byID[*task.ParentTaskID] {
+			children[*task.ParentTaskID] = append(children[*task.ParentTaskID], task)
+		} else {
+			roots = append(roots, task)
+		}
+	}
+
+	ordered := make([]Task, 0, len(tasks))
+	var visit func([]Task)
+	visit = func(level []Task) {
+		slices.SortFunc(level, func(a, b Task) int { return a.ID - b.ID })
+		for _, task := range level {
+			ordered = append(ordered, task)
+			visit(children[task.ID])
+		}
+	}
+	visit(roots)
+	return ordered
+}
+
+// earliest returns the earlier of two dates, ignoring zero ones.
+func earliest(a, b Date) Date {
+	if a.IsZero() || !b.IsZero() && b.Before(a.Time) {
+		return b
+	}
+	return a
+}
+
+// dayOffset returns the number of days from start to d.
+func dayOffset(start, d Date) int {
+	return int(d.Sub(start.Time).Hours() / 24)
+}
+
+// MapMembers replaces employees throughout the template: the lead, the
+// members and the assignees of tasks. Employees not in mapping are kept.
+func (t *ProjectTemplate) MapMembers(mapping map[int]int) {
+	mapped := func(id int) int {
+		if to, ok := mapping[id]; ok {
+			return to
+		}
+		return id
+	}
+	t.LeadID = mapped(t.LeadID)
+	members := make([]int, 0, len(t.Members))
+	for _, id := range t.Members {
+		members = append(members, mapped(id))
+	}
+	slices.Sort(members)
+	t.Members = slices.Compact(members)
+	for i := range t.Tasks {
+		t.Tasks[i].AssignedTo = mapped(t.Tasks[i].AssignedTo)
+	}
+}
+
+// Employees returns the members and task assignees of the template, in
+// ascending order and without duplicates.
+func (t ProjectTemplate) Employees() []int {
+	ids := slices.Clone(t.Members)
+	for _, task := range t.Tasks {
+		ids = append(ids, task.AssignedTo)
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// Milestone returns the open milestone of projectID the template milestone
+// becomes when the template is laid out from start.
+func (m TemplateMilestone) Milestone(projectID int, start Date) Milestone {
+	return Milestone{
+		ProjectID:   projectID,
+		Title:       m.Title,
+		Description: m.Description,
+		TargetDate:  start.AddDays(m.TargetOffset),
+		Status:      MilestoneOpen,
+	}
+}
+
+// Task returns the task of projectID the template task becomes when the
+// template is laid out from start. It is to do; its parent and milestone
+// are left for the caller to fill in.
+func (t TemplateTask) Task(projectID int, start Date) Task {
+	task := Task{
+		ProjectID:     projectID,
+		AssignedTo:    t.AssignedTo,
+		Title:         t.Title,
+		Description:   t.Description,
+		Status:        StatusTodo,
+		Priority:      t.Priority,
+		EstimateHours: t.EstimateHours,
+	}
+	if !task.Priority.Valid() {
+		task.Priority = DefaultPriority
+	}
+	if t.StartOffset != nil {
+		task.StartDate = start.AddDays(*t.StartOffset)
+	}
+	if t.DueOffset != nil {
+		task.DueDate = start.AddDays(*t.DueOffset)
+	}
+	return task
+}
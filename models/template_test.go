@@ -0,0 +1,130 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNewProjectTemplate(t *testing.T) {
+	date := func(day int) Date { return NewDate(time.Date(2024, time.March, day, 0, 0, 0, 0, time.UTC)) }
+	estimate := 5.0
+	parentID, milestoneID, projectID := 10, 4, 7
+	project := Project{ID: 7, Name: "Compiler", Description: "A C compiler", LeadID: 1}
+	members := []Employee{{ID: 3}, {ID: 1}}
+	milestones := []Milestone{{ID: milestoneID, Title: "Beta", TargetDate: date(20)}}
+	tasks := []Task{
+		{ID: 11, ParentTaskID: &parentID, AssignedTo: 3, Title: "Write the grammar", Priority: PriorityP1},
+		{ID: 10, MilestoneID: &milestoneID, AssignedTo: 3, Title: "Write the parser", StartDate: date(4), DueDate: date(8), EstimateHours: &estimate},
+		{ID: 5, AssignedTo: 1, Title: "Write the lexer", Status: StatusDone, DueDate: date(1)},
+	}
+	shared := Label{ID: 2, Name: "backend", Color: "#00ff00"}
+	local := Label{ID: 1, Name: "parser", ProjectID: &projectID}
+
+	template := NewProjectTemplate(project, members, milestones, tasks, []Label{shared}, map[int][]Label{
+		5:  {shared},
+		10: {local, shared},
+	})
+	if template.Name != "Compiler" || *template.SourceProjectID != 7 || template.LeadID != 1 || !slices.Equal(template.Members, []int{1, 3}) {
+		t.Fatalf("template = %+v", template)
+	}
+
+	// Dates are counted from the earliest one, here a due date.
+	if template.StartDate != date(1) || template.Milestones[0].TargetOffset != 19 {
+		t.Fatalf("start date %s, milestone offset %d", template.StartDate, template.Milestones[0].TargetOffset)
+	}
+	var keys []int
+	for _, task := range template.Tasks {
+		keys = append(keys, task.Key)
+	}
+	if !slices.Equal(keys, []int{5, 10, 11}) {
+		t.Fatalf("task keys = %v, want parents before subtasks", keys)
+	}
+	parser := template.Tasks[1]
+	if *parser.StartOffset != 3 || *parser.DueOffset != 7 || *parser.MilestoneKey != milestoneID || !slices.Equal(parser.LabelKeys, []int{1, 2}) {
+		t.Fatalf("parser = %+v", parser)
+	}
+	if grammar := template.Tasks[2]; *grammar.ParentKey != parentID || grammar.StartOffset != nil || grammar.DueOffset != nil {
+		t.Fatalf("grammar = %+v", grammar)
+	}
+	wantLabels := []TemplateLabel{
+		{Key: 1, Name: "parser"},
+		{Key: 2, Name: "backend", Color: "#00ff00", Global: true, Attached: true},
+	}
+	if !slices.Equal(template.Labels, wantLabels) {
+		t.Fatalf("labels = %+v, want %+v", template.Labels, wantLabels)
+	}
+
+	// Laid out again from another start, the offsets are kept and the work
+	// starts over.
+	start := date(25)
+	task := parser.Task(9, start)
+	if task.ProjectID != 9 || task.Status != StatusTodo || task.Priority != DefaultPriority {
+		t.Fatalf("task = %+v", task)
+	}
+	if task.StartDate != date(28) || task.DueDate != NewDate(time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("task dates = %s to %s", task.StartDate, task.DueDate)
+	}
+	if milestone := template.Milestones[0].Milestone(9, start); milestone.TargetDate != date(25).AddDays(19) || milestone.Status != MilestoneOpen {
+		t.Fatalf("milestone = %+v", milestone)
+	}
+
+	undated := NewProjectTemplate(project, nil, nil, tasks[:1], nil, nil)
+	if !undated.StartDate.IsZero() || undated.Members == nil || undated.Labels == nil || undated.Milestones == nil {
+		t.Fatalf("template without dates = %+v", undated)
+	}
+}
+
+func TestMapMembers(t *testing.T) {
+	template := ProjectTemplate{
+		LeadID:  1,
+		Members: []int{1, 2, 3},
+		Tasks:   []TemplateTask{{Key: 1, AssignedTo: 2}, {Key: 2, AssignedTo: 3}, {Key: 3, AssignedTo: 5}},
+	}
+	if got := template.Employees(); !slices.Equal(got, []int{1, 2, 3, 5}) {
+		t.Fatalf("employees = %v", got)
+	}
+
+	// 4 takes over from 2 and 1 from 3; whoever is not mapped stays.
+	template.MapMembers(map[int]int{2: 4, 3: 1})
+	var assignees []int
+	for _, task := range template.Tasks {
+		assignees = append(assignees, task.AssignedTo)
+	}
+	if template.LeadID != 1 || !slices.Equal(template.Members, []int{1, 4}) || !slices.Equal(assignees, []int{4, 1, 5}) {
+		t.Fatalf("lead %d, members %v, assignees %v", template.LeadID, template.Members, assignees)
+	}
+	if got := template.Employees(); !slices.Equal(got, []int{1, 4, 5}) {
+		t.Fatalf("employees = %v", got)
+	}
+
+	template.MapMembers(map[int]int{1: 6})
+	if template.LeadID != 6 || !slices.Equal(template.Members, []int{4, 6}) {
+		t.Fatalf("lead %d, members %v", template.LeadID, template.Members)
+	}
+}
+
+func TestNewProjectTemplateLeavesOutCancelled(t *testing.T) {
+	date := func(day int) Date { return NewDate(time.Date(2024, time.March, day, 0, 0, 0, 0, time.UTC)) }
+	droppedID, keptID := 1, 4
+	tasks := []Task{
+		{ID: 1, Title: "Write a second parser", Status: StatusCancelled, StartDate: date(1)},
+		{ID: 2, ParentTaskID: &droppedID, Title: "Write its grammar", Status: StatusInProgress},
+		{ID: 3, ParentTaskID: &keptID, Title: "Write the tokens", Status: StatusCancelled},
+		{ID: 4, Title: "Write the lexer", Status: StatusDone, StartDate: date(4)},
+		{ID: 5, ParentTaskID: &keptID, Title: "Write the scanner", Status: StatusTodo},
+	}
+
+	template := NewProjectTemplate(Project{ID: 7}, nil, nil, tasks, nil, nil)
+	var keys []int
+	for _, task := range template.Tasks {
+		keys = append(keys, task.Key)
+	}
+	if !slices.Equal(keys, []int{4, 5}) {
+		t.Fatalf("task keys = %v, want [4 5]", keys)
+	}
+	// The dropped tasks' dates do not count either.
+	if template.StartDate != date(4) {
+		t.Fatalf("start date = %s, want %s", template.StartDate, date(4))
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+)
+
+// TaskWorkflow maps each status to the statuses a task may move to from it.
+// Statuses without an entry are terminal.
+type TaskWorkflow map[TaskStatus][]TaskStatus
+
+// DefaultTaskWorkflow is the transition graph used unless configured
+// otherwise.
+func DefaultTaskWorkflow() TaskWorkflow {
+	return TaskWorkflow{
+		StatusTodo:       {StatusInProgress, StatusBlocked, StatusCancelled},
+		StatusInProgress: {StatusInReview, StatusBlocked, StatusTodo, StatusCancelled},
+		StatusInReview:   {StatusDone, StatusInProgress, StatusBlocked},
+		StatusBlocked:    {StatusTodo, StatusInProgress, StatusCancelled},
+		StatusDone:       {StatusInProgress},
+		StatusCancelled:  {StatusTodo},
+	}
+}
+
+// ParseTaskWorkflow builds a workflow from status names, rejecting names
+// that are not valid statuses.
+func ParseTaskWorkflow(graph map[string][]string) (TaskWorkflow, error) {
+	workflow := make(TaskWorkflow, len(graph))
+	for from, targets := range graph {
+		if !TaskStatus(from).Valid() {
+			return nil, fmt.Errorf("unknown task status %q", from)
+		}
+		for _, to := range targets {
+			if !TaskStatus(to).Valid() {
+				return nil, fmt.Errorf("unknown task status %q", to)
+			}
+			workflow[TaskStatus(from)] = append(workflow[TaskStatus(from)], TaskStatus(to))
+		}
+	}
+	return workflow, nil
+}
+
+// Allows reports whether a task may move from one status to another.
+func (w TaskWorkflow) Allows(from, to TaskStatus) bool {
+	return slices.Contains(w[from], to)
+}
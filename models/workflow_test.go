@@ -0,0 +1,67 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestDefaultTaskWorkflow(t *testing.T) {
+	workflow := DefaultTaskWorkflow()
+	for from, targets := range workflow {
+		if !from.Valid() {
+			t.Errorf("unknown status %q", from)
+		}
+		for _, to := range targets {
+			if !to.Valid() || to == from {
+				t.Errorf("bad transition %s -> %s", from, to)
+			}
+		}
+	}
+	for _, status := range TaskStatuses {
+		if len(workflow[status]) == 0 {
+			t.Errorf("%s is a dead end", status)
+		}
+	}
+
+	tests := []struct {
+		from, to TaskStatus
+		want     bool
+	}{
+		{StatusTodo, StatusInProgress, true},
+		{StatusTodo, StatusDone, false},
+		{StatusInReview, StatusDone, true},
+		{StatusDone, StatusInProgress, true},
+		{StatusDone, StatusCancelled, false},
+		{StatusCancelled, StatusTodo, true},
+	}
+	for _, tt := range tests {
+		if got := workflow.Allows(tt.from, tt.to); got != tt.want {
+			t.Errorf("Allows(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestParseTaskWorkflow(t *testing.T) {
+	workflow, err := ParseTaskWorkflow(map[string][]string{
+		"TODO":        {"IN_PROGRESS", "CANCELLED"},
+		"IN_PROGRESS": {"DONE"},
+		"DONE":        {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !workflow.Allows(StatusTodo, StatusCancelled) || !workflow.Allows(StatusInProgress, StatusDone) {
+		t.Fatalf("workflow %v lost a transition", workflow)
+	}
+	if workflow.Allows(StatusDone, StatusTodo) || workflow.Allows(StatusBlocked, StatusTodo) {
+		t.Fatalf("workflow %v allows too much", workflow)
+	}
+
+	for _, graph := range []map[string][]string{
+		{"todo": {"DONE"}},
+		{"TODO": {"FINISHED"}},
+	} {
+		if _, err := ParseTaskWorkflow(graph); err == nil {
+			t.Errorf("ParseTaskWorkflow(%v) succeeded", graph)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// MaxWorklogSeconds caps a single worklog entry at one day.
+const MaxWorklogSeconds = 24 * 60 * 60
+
+// Worklog is time an employee spent on a task. DurationSeconds is nil while
+// the entry is a running timer.
+type Worklog struct {
+	ID              int       `json:"id"`
+	TaskID          int       `json:"task_id" validate:"required,min=1"`
+	EmployeeID      int       `json:"employee_id" validate:"required,min=1"`
+	StartedAt       time.Time `json:"started_at"`
+	DurationSeconds *int      `json:"duration_seconds" validate:"min=0,max=86400"`
+	Note            string    `json:"note" validate:"max=1000"`
+	Version         int       `json:"version"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Running reports whether the worklog is a timer that has not been stopped.
+func (w Worklog) Running() bool {
+	return w.DurationSeconds == nil
+}
+
+// Overlaps reports whether two worklogs share any time. A running timer
+// extends indefinitely.
+func (w Worklog) Overlaps(other Worklog) bool {
+	return startsBeforeEnd(w, other) && startsBeforeEnd(other, w)
+}
+
+// startsBeforeEnd reports whether a starts before b ends.
+func startsBeforeEnd(a, b Worklog) bool {
+	if b.Running() {
+		return true
+	}
+	return a.StartedAt.Before(b.StartedAt.Add(time.Duration(*b.DurationSeconds) * time.Second))
+}
+
+// WorklogGroup says what a worklog report totals time by.
+type WorklogGroup string
+
+const (
+	GroupByEmployee WorklogGroup = "employee"
+	GroupByTask     WorklogGroup = "task"
+	GroupByProject  WorklogGroup = "project"
+)
+
+// WorklogTotal is the time logged against one employee, task or project.
+// Name is the employee or project name, or the task title.
+type WorklogTotal struct {
+	ID      int     `json:"id"`
+	Name    string  `json:"name"`
+	Entries int     `json:"entries"`
+	Seconds int     `json:"seconds"`
+	Hours   float64 `json:"hours"`
+}
+
+// SecondsToHours converts logged seconds to hours rounded to two decimals,
+// the precision tasks are estimated in.
+func SecondsToHours(seconds int) float64 {
+	return math.Round(float64(seconds)/36) / 100
+}
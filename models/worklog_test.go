@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWorklogOverlaps(t *testing.T) {
+	at := func(hour, minute int) time.Time { return time.Date(2024, 3, 1, hour, minute, 0, 0, time.UTC) }
+	logged := func(start time.Time, minutes int) Worklog {
+		seconds := minutes * 60
+		return Worklog{StartedAt: start, DurationSeconds: &seconds}
+	}
+	running := func(start time.Time) Worklog { return Worklog{StartedAt: start} }
+
+	tests := []struct {
+		name string
+		a, b Worklog
+		want bool
+	}{
+		{"disjoint", logged(at(9, 0), 60), logged(at(11, 0), 30), false},
+		{"back to back", logged(at(9, 0), 60), logged(at(10, 0), 30), false},
+		{"one minute over", logged(at(9, 0), 61), logged(at(10, 0), 30), true},
+		{"contained", logged(at(9, 0), 180), logged(at(10, 0), 30), true},
+		{"same start", logged(at(9, 0), 10), logged(at(9, 0), 20), true},
+		{"empty entry inside another", logged(at(9, 0), 60), logged(at(9, 30), 0), true},
+		{"empty entry at the end of another", logged(at(9, 0), 60), logged(at(10, 0), 0), false},
+		{"timer after logged time", logged(at(9, 0), 60), running(at(10, 0)), false},
+		{"timer during logged time", logged(at(9, 0), 60), running(at(9, 59)), true},
+		{"logged time after a timer started", running(at(9, 0)), logged(at(15, 0), 10), true},
+		{"two timers", running(at(9, 0)), running(at(15, 0)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Overlaps(tt.b); got != tt.want {
+				t.Errorf("a.Overlaps(b) = %v, want %v", got, tt.want)
+			}
+			if got := tt.b.Overlaps(tt.a); got != tt.want {
+				t.Errorf("b.Overlaps(a) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecondsToHours(t *testing.T) {
+	tests := []struct {
+		seconds int
+		want    float64
+	}{
+		{0, 0},
+		{3600, 1},
+		{5400, 1.5},
+		{60, 0.02},
+		{17, 0},
+		{18, 0.01},
+		{MaxWorklogSeconds, 24},
+	}
+	for _, tt := range tests {
+		if got := SecondsToHours(tt.seconds); got != tt.want {
+			t.Errorf("SecondsToHours(%d) = %v, want %v", tt.seconds, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package patch
+
+import "testing"
+
+// The examples of RFC 6902, appendix A, followed by cases the appendix
+// does not cover.
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		original, patch string
+		want            string
+	}{
+		{
+			"A.1 adding an object member",
+			`{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux"}]`,
+			`{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			"A.2 adding an array element",
+			`{"foo":["bar","baz"]}`,
+			`[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			`{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			"A.3 removing an object member",
+			`{"baz":"qux","foo":"bar"}`,
+			`[{"op":"remove","path":"/baz"}]`,
+			`{"foo":"bar"}`,
+		},
+		{
+			"A.4 removing an array element",
+			`{"foo":["bar","qux","baz"]}`,
+			`[{"op":"remove","path":"/foo/1"}]`,
+			`{"foo":["bar","baz"]}`,
+		},
+		{
+			"A.5 replacing a value",
+			`{"baz":"qux","foo":"bar"}`,
+			`[{"op":"replace","path":"/baz","value":"boo"}]`,
+			`{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			"A.6 moving a value",
+			`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			"A.7 moving an array element",
+			`{"foo":["all","grass","cows","eat"]}`,
+			`[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			`{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			"A.8 testing a value",
+			`{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		{
+			"A.10 adding a nested member object",
+			`{"foo":"bar"}`,
+			`[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+			`{"foo":"bar","child":{"grandchild":{}}}`,
+		},
+		{
+			"A.11 ignoring unrecognized elements",
+			`{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux","xyz":123}]`,
+			`{"foo":"bar","baz":"qux"}`,
+		},
+		{
+			"A.14 escape ordering",
+			`{"/":9,"~1":10}`,
+			`[{"op":"test","path":"/~01","value":10}]`,
+			`{"/":9,"~1":10}`,
+		},
+		{
+			"A.16 adding an array value",
+			`{"foo":["bar"]}`,
+			`[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			`{"foo":["bar",["abc","def"]]}`,
+		},
+		{
+			"adding to the end of an empty array",
+			`{"foo":[]}`,
+			`[{"op":"add","path":"/foo/-","value":1},{"op":"add","path":"/foo/-","value":2}]`,
+			`{"foo":[1,2]}`,
+		},
+		{
+			"adding at the length of an array",
+			`{"foo":[1]}`,
+			`[{"op":"add","path":"/foo/1","value":2}]`,
+			`{"foo":[1,2]}`,
+		},
+		{
+			"adding a member that exists replaces it",
+			`{"foo":"bar"}`,
+			`[{"op":"add","path":"/foo","value":"baz"}]`,
+			`{"foo":"baz"}`,
+		},
+		{
+			"replacing with null",
+			`{"foo":"bar"}`,
+			`[{"op":"replace","path":"/foo","value":null}]`,
+			`{"foo":null}`,
+		},
+		{
+			"replacing the whole document",
+			`{"foo":"bar"}`,
+			`[{"op":"replace","path":"","value":{"baz":1}}]`,
+			`{"baz":1}`,
+		},
+		{
+			"escaped slash",
+			`{"a/b":1}`,
+			`[{"op":"replace","path":"/a~1b","value":2}]`,
+			`{"a/b":2}`,
+		},
+		{
+			"moving to the end of an array",
+			`{"foo":["a","b"],"bar":"c"}`,
+			`[{"op":"move","from":"/bar","path":"/foo/-"}]`,
+			`{"foo":["a","b","c"]}`,
+		},
+		{
+			"moving a value onto itself",
+			`{"foo":{"bar":1}}`,
+			`[{"op":"move","from":"/foo","path":"/foo"}]`,
+			`{"foo":{"bar":1}}`,
+		},
+		{
+			"copying a value",
+			`{"foo":{"bar":1}}`,
+			`[{"op":"copy","from":"/foo","path":"/baz"}]`,
+			`{"foo":{"bar":1},"baz":{"bar":1}}`,
+		},
+		{
+			"copying an array element to the end",
+			`{"foo":["a","b"]}`,
+			`[{"op":"copy","from":"/foo/0","path":"/foo/-"}]`,
+			`{"foo":["a","b","a"]}`,
+		},
+		{
+			"changing a copy leaves the original",
+			`{"foo":{"bar":1}}`,
+			`[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`,
+			`{"foo":{"bar":1},"baz":{"bar":2}}`,
+		},
+		{
+			"testing null",
+			`{"foo":null}`,
+			`[{"op":"test","path":"/foo","value":null}]`,
+			`{"foo":null}`,
+		},
+		{
+			"testing an object",
+			`{"foo":{"a":1,"b":[2]}}`,
+			`[{"op":"test","path":"/foo","value":{"b":[2],"a":1}}]`,
+			`{"foo":{"a":1,"b":[2]}}`,
+		},
+		{
+			"empty patch",
+			`{"foo":"bar"}`,
+			`[]`,
+			`{"foo":"bar"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONPatch([]byte(tt.original), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("JSONPatch: %v", err)
+			}
+			if !equalJSON(t, got, []byte(tt.want)) {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPatchInvalid(t *testing.T) {
+	tests := []struct {
+		name            string
+		original, patch string
+	}{
+		{"A.9 testing a value that differs", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`},
+		{"A.12 adding to a nonexistent target", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`},
+		{"A.15 comparing strings and numbers", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":"10"}]`},
+		{"invalid document", `{"foo":`, `[]`},
+		{"patch is not an array", `{}`, `{"op":"add","path":"/foo","value":1}`},
+		{"unknown operation", `{}`, `[{"op":"merge","path":"/foo","value":1}]`},
+		{"missing value", `{}`, `[{"op":"add","path":"/foo"}]`},
+		{"pointer without a slash", `{"foo":1}`, `[{"op":"remove","path":"foo"}]`},
+		{"removing a missing member", `{"foo":1}`, `[{"op":"remove","path":"/bar"}]`},
+		{"replacing a missing member", `{"foo":1}`, `[{"op":"replace","path":"/bar","value":2}]`},
+		{"removing the end of an array", `{"foo":[1]}`, `[{"op":"remove","path":"/foo/-"}]`},
+		{"testing the end of an array", `{"foo":[1]}`, `[{"op":"test","path":"/foo/-","value":1}]`},
+		{"adding past the end of an array", `{"foo":[1]}`, `[{"op":"add","path":"/foo/2","value":2}]`},
+		{"index with a leading zero", `{"foo":[1,2]}`, `[{"op":"replace","path":"/foo/01","value":3}]`},
+		{"negative index", `{"foo":[1,2]}`, `[{"op":"remove","path":"/foo/-1"}]`},
+		{"adding below a scalar", `{"foo":1}`, `[{"op":"add","path":"/foo/bar","value":2}]`},
+		{"moving a value into itself", `{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`},
+		{"moving a missing value", `{"foo":1}`, `[{"op":"move","from":"/bar","path":"/baz"}]`},
+		{"copying a missing value", `{"foo":1}`, `[{"op":"copy","from":"/bar","path":"/baz"}]`},
+		{"failing after a successful operation", `{"foo":1}`, `[{"op":"add","path":"/bar","value":2},{"op":"test","path":"/foo","value":2}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := JSONPatch([]byte(tt.original), []byte(tt.patch)); err == nil {
+				t.Fatalf("JSONPatch succeeded with %s, want an error", got)
+			}
+		})
+	}
+}
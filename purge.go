@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"nstorm.com/main-backend/config"
+	"nstorm.com/main-backend/repository"
+)
+
+// runPurge permanently removes the records deleted more than the retention
+// ago, now and then every interval until ctx is cancelled.
+func runPurge(ctx context.Context, cfg config.PurgeConfig, repos *repository.Repositories) {
+	slog.Info("purge starting", "interval", cfg.Interval, "retention", cfg.Retention)
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		purgeDeleted(ctx, cfg, repos)
+		select {
+		case <-ctx.Done():
+			slog.Info("purge stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeDeleted removes tasks before projects and projects before employees,
+// since purged tasks and projects may be what still refers to an employee.
+// Like runDue it gives up after the interval.
+func purgeDeleted(ctx context.Context, cfg config.PurgeConfig, repos *repository.Repositories) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Interval)
+	defer cancel()
+
+	cutoff := time.Now().Add(-cfg.Retention)
+	for _, table := range []struct {
+		name  string
+		purge func(context.Context, time.Time) (int, error)
+	}{
+		{"tasks", repos.Tasks.Purge},
+		{"projects", repos.Projects.Purge},
+		{"employees", repos.Employees.Purge},
+	} {
+		n, err := table.purge(ctx, cutoff)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("purging deleted records failed", "table", table.name, "error", err)
+			}
+			return
+		}
+		if n > 0 {
+			slog.Info("deleted records purged", "table", table.name, "count", n)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"nstorm.com/main-backend/models"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// ErrInvalidCursor is returned for cursors that cannot be decoded or that
+// were issued for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Sort orders a list by one field, breaking ties by ID in the same direction.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// Cursor marks the last item of a page: its sort value and its ID.
+type Cursor struct {
+	Sort  Sort
+	Value string
+	ID    int
+}
+
+type cursorPayload struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// Encode returns the opaque form of c handed to clients.
+func (c Cursor) Encode() string {
+	payload, _ := json.Marshal(cursorPayload{Sort: c.Sort.String(), Value: c.Value, ID: c.ID})
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// DecodeCursor parses a cursor produced by Cursor.Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil || payload.ID <= 0 || payload.Sort == "" {
+		return nil, ErrInvalidCursor
+	}
+	field, desc := strings.CutPrefix(payload.Sort, "-")
+	return &Cursor{Sort: Sort{Field: field, Desc: desc}, Value: payload.Value, ID: payload.ID}, nil
+}
+
+// PageRequest selects one page of a sorted list. After is nil for the
+// first page.
+type PageRequest struct {
+	Limit int
+	Sort  Sort
+	After *Cursor
+}
+
+// Page is one page of a list together with the total number of items that
+// match the filter and, when more remain, the cursor for the next page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ValueKind says how a sort field's values are compared.
+type ValueKind int
+
+const (
+	KindInt ValueKind = iota
+	KindString
+	KindTime
+	KindDate
+	KindFloat
+)
+
+// SortField describes a field a list can be ordered by: the column that
+// holds it and how to read it from an item for building cursors.
+type SortField[T any] struct {
+	Column string
+	Kind   ValueKind
+	Value  func(T) string
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// Nullable columns are sorted through these stand-ins so that missing
+// values come last in ascending order and keyset comparisons never see
+// NULL. The columns' own checks keep real values below them.
+const (
+	noDate  = "9999-12-31"
+	noHours = "1000000"
+)
+
+func dateValue(d models.Date) string {
+	if d.IsZero() {
+		return noDate
+	}
+	return d.String()
+}
+
+func hoursValue(h *float64) string {
+	if h == nil {
+		return noHours
+	}
+	return strconv.FormatFloat(*h, 'f', -1, 64)
+}
+
+var EmployeeSorts = map[string]SortField[models.Employee]{
+	"id":         {"id", KindInt, func(e models.Employee) string { return strconv.Itoa(e.ID) }},
+	"name":       {"name", KindString, func(e models.Employee) string { return e.Name }},
+	"email":      {"email", KindString, func(e models.Employee) string { return e.Email }},
+	"created_at": {"created_at", KindTime, func(e models.Employee) string { return formatTime(e.CreatedAt) }},
+}
+
+var ProjectSorts = map[string]SortField[models.Project]{
+	"id":         {"id", KindInt, func(p models.Project) string { return strconv.Itoa(p.ID) }},
+	"name":       {"name", KindString, func(p models.Project) string { return p.Name }},
+	"created_at": {"created_at", KindTime, func(p models.Project) string { return formatTime(p.CreatedAt) }},
+}
+
+var TaskSorts = map[string]SortField[models.Task]{
+	"id":              {"id", KindInt, func(t models.Task) string { return strconv.Itoa(t.ID) }},
+	"title":           {"title", KindString, func(t models.Task) string { return t.Title }},
+	"status":          {"status", KindString, func(t models.Task) string { return string(t.Status) }},
+	"priority":        {"priority", KindString, func(t models.Task) string { return string(t.Priority) }},
+	"start_date":      {"COALESCE(start_date, DATE '" + noDate + "')", KindDate, func(t models.Task) string { return dateValue(t.StartDate) }},
+	"due_date":        {"COALESCE(due_date, DATE '" + noDate + "')", KindDate, func(t models.Task) string { return dateValue(t.DueDate) }},
+	"estimate_hours":  {"COALESCE(estimate_hours, " + noHours + ")", KindFloat, func(t models.Task) string { return hoursValue(t.EstimateHours) }},
+	"remaining_hours": {"COALESCE(remaining_hours, " + noHours + ")", KindFloat, func(t models.Task) string { return hoursValue(t.RemainingHours) }},
+	"created_at":      {"created_at", KindTime, func(t models.Task) string { return formatTime(t.CreatedAt) }},
+}
+
+var LabelSorts = map[string]SortField[models.Label]{
+	"id":         {"id", KindInt, func(l models.Label) string { return strconv.Itoa(l.ID) }},
+	"name":       {"name", KindString, func(l models.Label) string { return l.Name }},
+	"created_at": {"created_at", KindTime, func(l models.Label) string { return formatTime(l.CreatedAt) }},
+}
+
+var CommentSorts = map[string]SortField[models.Comment]{
+	"id":         {"id", KindInt, func(c models.Comment) string { return strconv.Itoa(c.ID) }},
+	"created_at": {"created_at", KindTime, func(c models.Comment) string { return formatTime(c.CreatedAt) }},
+	"updated_at": {"updated_at", KindTime, func(c models.Comment) string { return formatTime(c.UpdatedAt) }},
+}
+
+var WorklogSorts = map[string]SortField[models.Worklog]{
+	"id":         {"id", KindInt, func(w models.Worklog) string { return strconv.Itoa(w.ID) }},
+	"started_at": {"started_at", KindTime, func(w models.Worklog) string { return formatTime(w.StartedAt) }},
+}
+
+var RecurringTaskSorts = map[string]SortField[models.RecurringTask]{
+	"id":         {"id", KindInt, func(r models.RecurringTask) string { return strconv.Itoa(r.ID) }},
+	"title":      {"title", KindString, func(r models.RecurringTask) string { return r.Title }},
+	"created_at": {"created_at", KindTime, func(r models.RecurringTask) string { return formatTime(r.CreatedAt) }},
+}
+
+var SprintSorts = map[string]SortField[models.Sprint]{
+	"id":         {"id", KindInt, func(s models.Sprint) string { return strconv.Itoa(s.ID) }},
+	"name":       {"name", KindString, func(s models.Sprint) string { return s.Name }},
+	"start_date": {"start_date", KindDate, func(s models.Sprint) string { return dateValue(s.StartDate) }},
+	"end_date":   {"end_date", KindDate, func(s models.Sprint) string { return dateValue(s.EndDate) }},
+	"created_at": {"created_at", KindTime, func(s models.Sprint) string { return formatTime(s.CreatedAt) }},
+}
+
+var MilestoneSorts = map[string]SortField[models.Milestone]{
+	"id":          {"id", KindInt, func(m models.Milestone) string { return strconv.Itoa(m.ID) }},
+	"title":       {"title", KindString, func(m models.Milestone) string { return m.Title }},
+	"target_date": {"target_date", KindDate, func(m models.Milestone) string { return dateValue(m.TargetDate) }},
+	"created_at":  {"created_at", KindTime, func(m models.Milestone) string { return formatTime(m.CreatedAt) }},
+}
+
+var TemplateSorts = map[string]SortField[models.ProjectTemplate]{
+	"id":         {"id", KindInt, func(t models.ProjectTemplate) string { return strconv.Itoa(t.ID) }},
+	"name":       {"name", KindString, func(t models.ProjectTemplate) string { return t.Name }},
+	"created_at": {"created_at", KindTime, func(t models.ProjectTemplate) string { return formatTime(t.CreatedAt) }},
+}
+
+// ParseValue converts a cursor value to the Go type of its field.
+func ParseValue(kind ValueKind, s string) (any, error) {
+	switch kind {
+	case KindInt:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return n, nil
+	case KindTime:
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return t, nil
+	case KindDate:
+		t, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return t, nil
+	case KindFloat:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return f, nil
+	}
+	return s, nil
+}
+
+// CompareValues orders two values of the given kind the way Postgres would.
+func CompareValues(kind ValueKind, a, b string) int {
+	switch kind {
+	case KindInt:
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return x - y
+	case KindTime:
+		x, _ := time.Parse(time.RFC3339Nano, a)
+		y, _ := time.Parse(time.RFC3339Nano, b)
+		return x.Compare(y)
+	case KindFloat:
+		x, _ := strconv.ParseFloat(a, 64)
+		y, _ := strconv.ParseFloat(b, 64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// IncludeDeleted adds soft-deleted rows to the employees, projects and
+// tasks listed. Archived projects are only listed with IncludeArchived.
+type EmployeeFilter struct {
+	Role           models.EmployeeRole
+	Skill          string
+	IncludeDeleted bool
+	Page           PageRequest
+}
+
+type ProjectFilter struct {
+	LeadID          int
+	LabelID         int
+	IncludeArchived bool
+	IncludeDeleted  bool
+	Page            PageRequest
+}
+
+// TaskFilter date bounds are inclusive for After and exclusive for Before.
+// OverdueOn selects open tasks due before that date.
+type TaskFilter struct {
+	ProjectID      int
+	AssignedTo     int
+	ParentTaskID   int
+	LabelID        int
+	SprintID       int
+	MilestoneID    int
+	Status         models.TaskStatus
+	Priority       models.TaskPriority
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	StartAfter     *models.Date
+	StartBefore    *models.Date
+	DueAfter       *models.Date
+	DueBefore      *models.Date
+	OverdueOn      *models.Date
+	IncludeDeleted bool
+	Page           PageRequest
+}
+
+// CommentFilter lists the comments on one task, optionally only those by
+// an author or mentioning an employee.
+type CommentFilter struct {
+	TaskID      int
+	AuthorID    int
+	MentionedID int
+	Page        PageRequest
+}
+
+// LabelFilter with a ProjectID selects the labels usable in that project:
+// its own and the global ones. GlobalOnly selects only global labels.
+type LabelFilter struct {
+	ProjectID  int
+	GlobalOnly bool
+	Page       PageRequest
+}
+
+// WorklogFilter bounds apply to started_at and are inclusive for From and
+// exclusive for To. Running selects only running timers when true and
+// only finished worklogs when false.
+type WorklogFilter struct {
+	TaskID     int
+	EmployeeID int
+	ProjectID  int
+	From       *time.Time
+	To         *time.Time
+	Running    *bool
+	Page       PageRequest
+}
+
+// RecurringTaskFilter selects definitions by project and, when Paused is
+// set, by whether they are paused.
+type RecurringTaskFilter struct {
+	ProjectID int
+	Paused    *bool
+	Page      PageRequest
+}
+
+// SprintFilter selects sprints by project and state.
+type SprintFilter struct {
+	ProjectID int
+	State     models.SprintState
+	Page      PageRequest
+}
+
+// MilestoneFilter selects milestones by project and status.
+type MilestoneFilter struct {
+	ProjectID int
+	Status    models.MilestoneStatus
+	Page      PageRequest
+}
+
+// TemplateFilter selects templates by the project they were saved from.
+type TemplateFilter struct {
+	SourceProjectID int
+	Page            PageRequest
+}
+
+// CheckCursor verifies that a cursor belongs to the requested sort order
+// and that its value parses for the sort field.
+func CheckCursor[T any](page PageRequest, sorts map[string]SortField[T]) error {
+	if page.After == nil {
+		return nil
+	}
+	if page.After.Sort != page.Sort {
+		return fmt.Errorf("%w: cursor was issued for sort %s", ErrInvalidCursor, page.After.Sort)
+	}
+	field, ok := sorts[page.Sort.Field]
+	if !ok {
+		return fmt.Errorf("unknown sort field %q", page.Sort.Field)
+	}
+	_, err := ParseValue(field.Kind, page.After.Value)
+	return err
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"nstorm.com/main-backend/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []Cursor{
+		{Sort: Sort{Field: "id"}, Value: "42", ID: 42},
+		{Sort: Sort{Field: "name", Desc: true}, Value: "Ada Lovelace", ID: 7},
+		{Sort: Sort{Field: "title"}, Value: "", ID: 1},
+		{Sort: Sort{Field: "title"}, Value: "commas, \"quotes\" and ünïcode / slashes", ID: 3},
+		{Sort: Sort{Field: "created_at", Desc: true}, Value: formatTime(time.Date(2026, 1, 30, 10, 7, 0, 123456789, time.UTC)), ID: 9},
+		{Sort: Sort{Field: "due_date"}, Value: noDate, ID: 12},
+	}
+	for _, want := range tests {
+		t.Run(want.Sort.String(), func(t *testing.T) {
+			encoded := want.Encode()
+			if _, err := base64.RawURLEncoding.DecodeString(encoded); err != nil {
+				t.Fatalf("cursor %q is not URL-safe base64: %v", encoded, err)
+			}
+			got, err := DecodeCursor(encoded)
+			if err != nil {
+				t.Fatalf("DecodeCursor(%q): %v", encoded, err)
+			}
+			if *got != want {
+				t.Fatalf("got %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	tests := []struct {
+		name, cursor string
+	}{
+		{"empty", ""},
+		{"not base64", "not a cursor!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"id","v":"1","id":1}`))},
+		{"not JSON", encode("id=1")},
+		{"no ID", encode(`{"s":"id","v":"1"}`)},
+		{"negative ID", encode(`{"s":"id","v":"1","id":-1}`)},
+		{"no sort", encode(`{"v":"1","id":1}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("DecodeCursor(%q) = %v, want ErrInvalidCursor", tt.cursor, err)
+			}
+		})
+	}
+}
+
+func TestCheckCursor(t *testing.T) {
+	task := models.Task{ID: 5, Title: "Write tests", CreatedAt: time.Date(2026, 1, 30, 10, 7, 0, 0, time.UTC)}
+	cursor := func(sort Sort) *Cursor {
+		return &Cursor{Sort: sort, Value: TaskSorts[sort.Field].Value(task), ID: task.ID}
+	}
+	// replay passes a cursor through its encoded form, as clients do.
+	replay := func(c *Cursor) *Cursor {
+		decoded, err := DecodeCursor(c.Encode())
+		if err != nil {
+			t.Fatalf("DecodeCursor: %v", err)
+		}
+		return decoded
+	}
+	byTitle := Sort{Field: "title"}
+	byCreated := Sort{Field: "created_at", Desc: true}
+
+	tests := []struct {
+		name    string
+		page    PageRequest
+		invalid bool
+	}{
+		{"first page", PageRequest{Sort: byTitle}, false},
+		{"same sort", PageRequest{Sort: byTitle, After: replay(cursor(byTitle))}, false},
+		{"same descending sort", PageRequest{Sort: byCreated, After: replay(cursor(byCreated))}, false},
+		{"different field", PageRequest{Sort: byCreated, After: replay(cursor(byTitle))}, true},
+		{"different direction", PageRequest{Sort: Sort{Field: "title", Desc: true}, After: replay(cursor(byTitle))}, true},
+		{"value of another kind", PageRequest{Sort: Sort{Field: "created_at"}, After: &Cursor{Sort: Sort{Field: "created_at"}, Value: "yesterday", ID: 5}}, true},
+		{"id value that is not a number", PageRequest{Sort: Sort{Field: "id"}, After: &Cursor{Sort: Sort{Field: "id"}, Value: "five", ID: 5}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckCursor(tt.page, TaskSorts)
+			if tt.invalid && !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("CheckCursor = %v, want ErrInvalidCursor", err)
+			}
+			if !tt.invalid && err != nil {
+				t.Fatalf("CheckCursor: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckCursorUnknownField(t *testing.T) {
+	sort := Sort{Field: "salary"}
+	page := PageRequest{Sort: sort, After: &Cursor{Sort: sort, Value: "1", ID: 1}}
+	if err := CheckCursor(page, EmployeeSorts); err == nil {
+		t.Fatal("CheckCursor accepted an unknown sort field")
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	tests := []struct {
+		kind ValueKind
+		a, b string
+		want int
+	}{
+		{KindInt, "9", "10", -1},
+		{KindInt, "10", "10", 0},
+		{KindString, "9", "10", 1},
+		{KindFloat, "2.5", "10", -1},
+		{KindFloat, noHours, "999999.5", 1},
+		{KindDate, "2026-01-30", noDate, -1},
+		{KindTime, "2026-01-30T10:07:00.5Z", "2026-01-30T10:07:00Z", 1},
+		{KindTime, "2026-01-30T12:07:00+02:00", "2026-01-30T10:07:00Z", 0},
+	}
+	sign := func(n int) int { return min(max(n, -1), 1) }
+	for _, tt := range tests {
+		if got := sign(CompareValues(tt.kind, tt.a, tt.b)); got != tt.want {
+			t.Errorf("CompareValues(%d, %q, %q) = %d, want %d", tt.kind, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
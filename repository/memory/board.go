@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+func (r *TaskRepository) Board(ctx context.Context, projectID int) ([]models.Task, error) {
+	tasks := r.filter(func(task models.Task) bool {
+		return task.ProjectID == projectID
+	})
+	slices.SortStableFunc(tasks, func(a, b models.Task) int {
+		return cmp.Or(cmp.Compare(a.Status, b.Status), compareRank(a, b))
+	})
+	return tasks, nil
+}
+
+func (r *TaskRepository) Move(ctx context.Context, transition *models.TaskTransition, position repository.TaskPosition, version int) (*models.Task, error) {
+	return r.move(transition, position, version, transition.From != transition.To)
+}
+
+// move re-ranks a task and changes its status, recording the transition if
+// record is set.
+func (r *TaskRepository) move(transition *models.TaskTransition, position repository.TaskPosition, version int, record bool) (*models.Task, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[transition.TaskID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if err := checkVersion(task.Version, version); err != nil {
+		return nil, err
+	}
+	if task.Status != transition.From {
+		return nil, repository.ErrVersionConflict
+	}
+	if !transition.To.Valid() {
+		return nil, constraintError(repository.ConstraintCheck, "tasks", "status", "status has an invalid value")
+	}
+	if record && transition.ActorID != nil {
+		if _, ok := s.employees[*transition.ActorID]; !ok {
+			return nil, constraintError(repository.ConstraintForeignKey, "task_transitions", "actor_id", "actor_id refers to a row that does not exist")
+		}
+	}
+	rank, err := s.boardRank(task.ProjectID, task.ID, transition.To, position)
+	if err != nil {
+		return nil, err
+	}
+
+	task.Status = transition.To
+	task.Rank = rank
+	task.Version++
+	task.UpdatedAt = s.now()
+	s.tasks[task.ID] = task
+
+	if record {
+		s.nextTransitionID++
+		transition.ID = s.nextTransitionID
+		transition.CreatedAt = task.UpdatedAt
+		s.transitions = append(s.transitions, cloneTransition(*transition))
+	}
+	return &task, nil
+}
+
+// compareRank orders tasks of one board column.
+func compareRank(a, b models.Task) int {
+	return cmp.Or(cmp.Compare(a.Rank, b.Rank), cmp.Compare(a.ID, b.ID))
+}
+
+// column returns the tasks of a project's board column in rank order,
+// leaving out excludeID. The caller must hold the store lock.
+func (s *Store) column(projectID int, status models.TaskStatus, excludeID int) []models.Task {
+	var tasks []models.Task
+	for id, task := range s.tasks {
+		if id != excludeID && task.ProjectID == projectID && task.Status == status {
+			tasks = append(tasks, task)
+		}
+	}
+	slices.SortFunc(tasks, compareRank)
+	return tasks
+}
+
+// lastRank returns the rank at the bottom of a board column, or "" if the
+// column is empty. The caller must hold the store lock.
+func (s *Store) lastRank(projectID int, status models.TaskStatus) string {
+	column := s.column(projectID, status, 0)
+	if len(column) == 0 {
+		return ""
+	}
+	return column[len(column)-1].Rank
+}
+
+// boardRank returns the rank that puts taskID at position in the project's
+// column for status, first giving the column fresh ranks if there is no
+// room left at that spot. The caller must hold the store lock.
+func (s *Store) boardRank(projectID, taskID int, status models.TaskStatus, position repository.TaskPosition) (string, error) {
+	column := s.column(projectID, status, taskID)
+	at := len(column)
+	if neighbour := max(position.AfterID, position.BeforeID); neighbour != 0 {
+		i := slices.IndexFunc(column, func(t models.Task) bool { return t.ID == neighbour })
+		if i < 0 {
+			return "", repository.ErrVersionConflict
+		}
+		at = i
+		if position.AfterID != 0 {
+			at = i + 1
+		}
+	}
+
+	var before, after string
+	if at > 0 {
+		before = column[at-1].Rank
+	}
+	if at < len(column) {
+		after = column[at].Rank
+	}
+	rank, err := models.RankBetween(before, after)
+	if err == nil && len(rank) <= models.MaxRankLength {
+		return rank, nil
+	}
+
+	// Spread the column's ranks evenly, leaving room for the task. The
+	// other tasks keep their version, since their order does not change.
+	ranks := models.RankSequence(len(column) + 1)
+	for i, task := range column {
+		if i >= at {
+			i++
+		}
+		task.Rank = ranks[i]
+		s.tasks[task.ID] = task
+	}
+	return ranks[at], nil
+}
@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type CommentRepository struct {
+	store *Store
+}
+
+func NewCommentRepository(store *Store) *CommentRepository {
+	return &CommentRepository{store: store}
+}
+
+// checkComment enforces the constraints the task_comments and
+// comment_mentions tables declare. The caller must hold the store lock.
+func (s *Store) checkComment(comment *models.Comment) error {
+	if comment.Body == "" {
+		return constraintError(repository.ConstraintCheck, "task_comments", "body", "body has an invalid value")
+	}
+	if _, ok := s.tasks[comment.TaskID]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "task_comments", "task_id", "task_id refers to a row that does not exist")
+	}
+	if comment.AuthorID != nil {
+		if _, ok := s.employees[*comment.AuthorID]; !ok {
+			return constraintError(repository.ConstraintForeignKey, "task_comments", "author_id", "author_id refers to a row that does not exist")
+		}
+	}
+	for _, id := range comment.Mentions {
+		if _, ok := s.employees[id]; !ok {
+			return constraintError(repository.ConstraintForeignKey, "comment_mentions", "employee_id", "employee_id refers to a row that does not exist")
+		}
+	}
+	return nil
+}
+
+func (r *CommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkComment(comment); err != nil {
+		return err
+	}
+
+	s.nextCommentID++
+	comment.ID = s.nextCommentID
+	comment.Mentions = sortedMentions(comment.Mentions)
+	comment.Version = 1
+	comment.CreatedAt = s.now()
+	comment.UpdatedAt = comment.CreatedAt
+	s.comments[comment.ID] = cloneComment(*comment)
+	return nil
+}
+
+func (r *CommentRepository) GetByID(ctx context.Context, id int) (*models.Comment, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comment, ok := s.comments[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	comment = cloneComment(comment)
+	return &comment, nil
+}
+
+func (r *CommentRepository) filter(match func(models.Comment) bool) []models.Comment {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var comments []models.Comment
+	for _, id := range sortedKeys(s.comments) {
+		if comment := s.comments[id]; match(comment) {
+			comments = append(comments, cloneComment(comment))
+		}
+	}
+	return comments
+}
+
+func (r *CommentRepository) List(ctx context.Context, filter repository.CommentFilter) (*repository.Page[models.Comment], error) {
+	comments := r.filter(func(comment models.Comment) bool {
+		switch {
+		case comment.TaskID != filter.TaskID,
+			filter.AuthorID != 0 && (comment.AuthorID == nil || *comment.AuthorID != filter.AuthorID),
+			filter.MentionedID != 0 && !slices.Contains(comment.Mentions, filter.MentionedID):
+			return false
+		}
+		return true
+	})
+	return paginate(comments, filter.Page, repository.CommentSorts, func(c models.Comment) int { return c.ID }), nil
+}
+
+func (r *CommentRepository) ListByTask(ctx context.Context, taskID int) ([]models.Comment, error) {
+	return r.filter(func(comment models.Comment) bool {
+		return comment.TaskID == taskID
+	}), nil
+}
+
+func (r *CommentRepository) Update(ctx context.Context, comment *models.Comment) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.comments[comment.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, comment.Version); err != nil {
+		return err
+	}
+	existing.Body = comment.Body
+	existing.Mentions = sortedMentions(comment.Mentions)
+	if err := s.checkComment(&existing); err != nil {
+		return err
+	}
+
+	existing.Version++
+	existing.UpdatedAt = s.now()
+	s.comments[comment.ID] = cloneComment(existing)
+	*comment = existing
+	return nil
+}
+
+func (r *CommentRepository) Delete(ctx context.Context, id, version int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.comments[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return err
+	}
+	delete(s.comments, id)
+	return nil
+}
+
+// sortedMentions returns the distinct mentioned IDs in ascending order,
+// the way the Postgres repository reads them back.
+func sortedMentions(ids []int) []int {
+	sorted := append([]int{}, ids...)
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
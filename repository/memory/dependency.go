@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type dependency struct {
+	taskID      int
+	dependsOnID int
+}
+
+// upstream reports whether target is among the tasks id depends on,
+// directly or transitively. The caller must hold the store lock.
+func (s *Store) upstream(id, target int) bool {
+	seen := map[int]bool{id: true}
+	queue := []int{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for d := range s.dependencies {
+			if d.taskID != current || seen[d.dependsOnID] {
+				continue
+			}
+			if d.dependsOnID == target {
+				return true
+			}
+			seen[d.dependsOnID] = true
+			queue = append(queue, d.dependsOnID)
+		}
+	}
+	return false
+}
+
+func (r *TaskRepository) AddDependency(ctx context.Context, taskID, dependsOnID int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if taskID == dependsOnID || s.upstream(dependsOnID, taskID) {
+		return repository.ErrDependencyCycle
+	}
+	if _, ok := s.tasks[taskID]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "task_dependencies", "task_id", "task_id refers to a row that does not exist")
+	}
+	if _, ok := s.tasks[dependsOnID]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "task_dependencies", "depends_on_id", "depends_on_id refers to a row that does not exist")
+	}
+
+	d := dependency{taskID: taskID, dependsOnID: dependsOnID}
+	if _, ok := s.dependencies[d]; !ok {
+		s.dependencies[d] = s.now()
+	}
+	return nil
+}
+
+func (r *TaskRepository) RemoveDependency(ctx context.Context, taskID, dependsOnID int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := dependency{taskID: taskID, dependsOnID: dependsOnID}
+	if _, ok := s.dependencies[d]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.dependencies, d)
+	return nil
+}
+
+func (r *TaskRepository) ListDependencies(ctx context.Context, taskID int) ([]models.Task, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tasks []models.Task
+	for _, id := range sortedKeys(s.tasks) {
+		if _, ok := s.dependencies[dependency{taskID: taskID, dependsOnID: id}]; ok {
+			tasks = append(tasks, s.tasks[id])
+		}
+	}
+	return tasks, nil
+}
+
+func (r *TaskRepository) ListDependents(ctx context.Context, taskID int) ([]models.Task, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tasks []models.Task
+	for _, id := range sortedKeys(s.tasks) {
+		if _, ok := s.dependencies[dependency{taskID: id, dependsOnID: taskID}]; ok {
+			tasks = append(tasks, s.tasks[id])
+		}
+	}
+	return tasks, nil
+}
+
+func (r *TaskRepository) ProjectDependencies(ctx context.Context, projectID int) ([]models.TaskDependency, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var dependencies []models.TaskDependency
+	for d, createdAt := range s.dependencies {
+		task, ok := s.tasks[d.taskID]
+		if _, live := s.tasks[d.dependsOnID]; !ok || !live {
+			continue
+		}
+		if task.ProjectID == projectID {
+			dependencies = append(dependencies, models.TaskDependency{
+				TaskID:      d.taskID,
+				DependsOnID: d.dependsOnID,
+				CreatedAt:   createdAt,
+			})
+		}
+	}
+	sort.Slice(dependencies, func(i, j int) bool {
+		a, b := dependencies[i], dependencies[j]
+		if a.TaskID != b.TaskID {
+			return a.TaskID < b.TaskID
+		}
+		return a.DependsOnID < b.DependsOnID
+	})
+	return dependencies, nil
+}
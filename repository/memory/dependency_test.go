@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+// newTasks creates a project with n tasks and returns their IDs in order.
+func newTasks(t *testing.T, repos *repository.Repositories, n int) []int {
+	t.Helper()
+	ctx := context.Background()
+	lead := models.Employee{Name: "Lead", Email: "lead@example.com", Role: models.RoleProjectManager}
+	if err := repos.Employees.Create(ctx, &lead); err != nil {
+		t.Fatal(err)
+	}
+	project := models.Project{Name: "Project", LeadID: lead.ID}
+	if err := repos.Projects.Create(ctx, &project); err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int, n)
+	for i := range ids {
+		task := models.Task{
+			ProjectID:  project.ID,
+			AssignedTo: lead.ID,
+			Title:      fmt.Sprintf("Task %d", i+1),
+			Status:     models.StatusTodo,
+			Priority:   models.DefaultPriority,
+		}
+		if err := repos.Tasks.Create(ctx, &task); err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = task.ID
+	}
+	return ids
+}
+
+func TestAddDependencyCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges [][2]int
+		cycle [2]int
+	}{
+		{"self", nil, [2]int{0, 0}},
+		{"two tasks", [][2]int{{0, 1}}, [2]int{1, 0}},
+		{"linear chain", [][2]int{{0, 1}, {1, 2}, {2, 3}}, [2]int{3, 0}},
+		{"diamond", [][2]int{{3, 1}, {3, 2}, {1, 0}, {2, 0}}, [2]int{0, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := NewRepositories()
+			ids := newTasks(t, repos, 4)
+			for _, e := range tt.edges {
+				if err := repos.Tasks.AddDependency(ctx, ids[e[0]], ids[e[1]]); err != nil {
+					t.Fatalf("AddDependency(%d, %d): %v", ids[e[0]], ids[e[1]], err)
+				}
+			}
+			err := repos.Tasks.AddDependency(ctx, ids[tt.cycle[0]], ids[tt.cycle[1]])
+			if !errors.Is(err, repository.ErrDependencyCycle) {
+				t.Fatalf("AddDependency(%d, %d) = %v, want ErrDependencyCycle", ids[tt.cycle[0]], ids[tt.cycle[1]], err)
+			}
+		})
+	}
+}
+
+func TestAddDependencyDiamond(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepositories()
+	ids := newTasks(t, repos, 4)
+	// The last task depends on the middle two, which both depend on the
+	// first: two paths to the same task are not a cycle.
+	for _, e := range [][2]int{{3, 1}, {3, 2}, {1, 0}, {2, 0}} {
+		if err := repos.Tasks.AddDependency(ctx, ids[e[0]], ids[e[1]]); err != nil {
+			t.Fatalf("AddDependency(%d, %d): %v", ids[e[0]], ids[e[1]], err)
+		}
+	}
+
+	task, err := repos.Tasks.GetByID(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := repos.Tasks.ListByProject(ctx, task.ProjectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dependencies, err := repos.Tasks.ProjectDependencies(ctx, task.ProjectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sorted, ok := models.SortByDependencies(tasks, dependencies)
+	if !ok {
+		t.Fatal("SortByDependencies reported a cycle")
+	}
+	for i, task := range sorted {
+		if task.ID != ids[i] {
+			t.Fatalf("task %d sorted at %d, want %d", task.ID, i, ids[i])
+		}
+	}
+}
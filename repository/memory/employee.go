@@ -0,0 +1,372 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
+)
+
+type EmployeeRepository struct {
+	store *Store
+}
+
+func NewEmployeeRepository(store *Store) *EmployeeRepository {
+	return &EmployeeRepository{store: store}
+}
+
+// checkEmployee enforces the constraints the employees table declares.
+// The caller must hold the store lock.
+func (s *Store) checkEmployee(employee *models.Employee) error {
+	switch employee.Role {
+	case models.RoleProjectManager, models.RoleDeveloper:
+	default:
+		return constraintError(repository.ConstraintCheck, "employees", "role", "role has an invalid value")
+	}
+	for id, existing := range s.employees {
+		if id != employee.ID && existing.Email == employee.Email {
+			return constraintError(repository.ConstraintUnique, "employees", "email", "email already exists")
+		}
+	}
+	return nil
+}
+
+func (r *EmployeeRepository) Create(ctx context.Context, employee *models.Employee) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	employee.ID = 0
+	if err := s.checkEmployee(employee); err != nil {
+		return err
+	}
+
+	s.nextEmployeeID++
+	employee.ID = s.nextEmployeeID
+	employee.Version = 1
+	employee.CreatedAt = s.now()
+	employee.UpdatedAt = employee.CreatedAt
+	if employee.Skills == nil {
+		employee.Skills = []string{}
+	}
+	s.employees[employee.ID] = cloneEmployee(*employee)
+	return nil
+}
+
+func (r *EmployeeRepository) GetByID(ctx context.Context, id int) (*models.Employee, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	employee, ok := s.employees[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	employee = cloneEmployee(employee)
+	return &employee, nil
+}
+
+func (r *EmployeeRepository) List(ctx context.Context, filter repository.EmployeeFilter) (*repository.Page[models.Employee], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var employees []models.Employee
+	for _, employee := range s.listedEmployees(filter.IncludeDeleted) {
+		if filter.Role != "" && employee.Role != filter.Role {
+			continue
+		}
+		if filter.Skill != "" && !slices.Contains(employee.Skills, filter.Skill) {
+			continue
+		}
+		employees = append(employees, cloneEmployee(employee))
+	}
+	return paginate(employees, filter.Page, repository.EmployeeSorts, func(e models.Employee) int { return e.ID }), nil
+}
+
+func (r *EmployeeRepository) Update(ctx context.Context, employee *models.Employee) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.employees[employee.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, employee.Version); err != nil {
+		return err
+	}
+	if err := s.checkEmployee(employee); err != nil {
+		return err
+	}
+
+	existing.Name = employee.Name
+	existing.Email = employee.Email
+	existing.Role = employee.Role
+	existing.Skills = employee.Skills
+	existing.Version++
+	existing.UpdatedAt = s.now()
+	s.employees[employee.ID] = cloneEmployee(existing)
+	*employee = cloneEmployee(existing)
+	return nil
+}
+
+func (r *EmployeeRepository) Patch(ctx context.Context, employee *models.Employee, fields []string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.employees[employee.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, employee.Version); err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		switch field {
+		case "name":
+			existing.Name = employee.Name
+		case "email":
+			existing.Email = employee.Email
+		case "role":
+			existing.Role = employee.Role
+		case "skills":
+			existing.Skills = employee.Skills
+		default:
+			return validation.Errors{{Field: field, Message: "cannot be patched"}}
+		}
+	}
+	if err := s.checkEmployee(&existing); err != nil {
+		return err
+	}
+
+	if len(fields) > 0 {
+		existing.Version++
+		existing.UpdatedAt = s.now()
+	}
+	s.employees[employee.ID] = cloneEmployee(existing)
+	*employee = cloneEmployee(existing)
+	return nil
+}
+
+// listedEmployees returns the live employees, followed by the deleted ones
+// if includeDeleted is set. The caller must hold the store lock.
+func (s *Store) listedEmployees(includeDeleted bool) []models.Employee {
+	var employees []models.Employee
+	for _, employee := range s.employees {
+		employees = append(employees, employee)
+	}
+	if includeDeleted {
+		for _, employee := range s.deletedEmployees {
+			employees = append(employees, employee)
+		}
+	}
+	return employees
+}
+
+func (r *EmployeeRepository) Delete(ctx context.Context, id, version int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.employees[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return err
+	}
+	for _, project := range s.projects {
+		if project.LeadID == id {
+			return constraintError(repository.ConstraintInUse, "projects", "lead_id", "still referenced by projects")
+		}
+	}
+	for _, task := range s.tasks {
+		if task.AssignedTo == id && task.Status != models.StatusDone && task.Status != models.StatusCancelled {
+			return constraintError(repository.ConstraintInUse, "tasks", "assigned_to", "still referenced by tasks")
+		}
+	}
+	for _, recurring := range s.recurringTasks {
+		if recurring.AssignedTo == id {
+			return constraintError(repository.ConstraintInUse, "recurring_tasks", "assigned_to", "still referenced by recurring_tasks")
+		}
+	}
+
+	now := s.now()
+	existing.DeletedAt = &now
+	existing.Version++
+	existing.UpdatedAt = now
+	delete(s.employees, id)
+	s.deletedEmployees[id] = existing
+	return nil
+}
+
+func (r *EmployeeRepository) GetDeleted(ctx context.Context, id int) (*models.Employee, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	employee, ok := s.deletedEmployees[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	employee = cloneEmployee(employee)
+	return &employee, nil
+}
+
+func (r *EmployeeRepository) Restore(ctx context.Context, id, version int) (*models.Employee, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.deletedEmployees[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return nil, err
+	}
+	if err := s.checkEmployee(&existing); err != nil {
+		return nil, err
+	}
+
+	existing.DeletedAt = nil
+	existing.Version++
+	existing.UpdatedAt = s.now()
+	delete(s.deletedEmployees, id)
+	s.employees[id] = existing
+	employee := cloneEmployee(existing)
+	return &employee, nil
+}
+
+// Purge removes the employees deleted before cutoff that no project, task,
+// worklog or recurring task refers to, along with the rows that reference
+// them, mirroring the ON DELETE rules of the schema.
+func (r *EmployeeRepository) Purge(ctx context.Context, cutoff time.Time) (int, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	referenced := make(map[int]bool)
+	for _, project := range s.listedProjects(true, true) {
+		referenced[project.LeadID] = true
+	}
+	for _, task := range s.listedTasks(true) {
+		referenced[task.AssignedTo] = true
+	}
+	for _, worklog := range s.worklogs {
+		referenced[worklog.EmployeeID] = true
+	}
+	for _, recurring := range s.recurringTasks {
+		referenced[recurring.AssignedTo] = true
+	}
+
+	purged := 0
+	for id, employee := range s.deletedEmployees {
+		if referenced[id] || !employee.DeletedAt.Before(cutoff) {
+			continue
+		}
+		s.purgeEmployee(id)
+		purged++
+	}
+	return purged, nil
+}
+
+// purgeEmployee removes a deleted employee and the rows that reference
+// them. The caller must hold the store lock.
+func (s *Store) purgeEmployee(id int) {
+	delete(s.deletedEmployees, id)
+	for m := range s.memberships {
+		if m.employeeID == id {
+			delete(s.memberships, m)
+		}
+	}
+	for i, t := range s.transitions {
+		if t.ActorID != nil && *t.ActorID == id {
+			s.transitions[i].ActorID = nil
+		}
+	}
+	for commentID, comment := range s.comments {
+		if comment.AuthorID != nil && *comment.AuthorID == id {
+			comment.AuthorID = nil
+		}
+		comment.Mentions = slices.DeleteFunc(comment.Mentions, func(m int) bool { return m == id })
+		s.comments[commentID] = comment
+	}
+	for i, c := range s.assigneeChanges {
+		if c.From != nil && *c.From == id {
+			s.assigneeChanges[i].From = nil
+		}
+		if c.To != nil && *c.To == id {
+			s.assigneeChanges[i].To = nil
+		}
+	}
+}
+
+func (r *EmployeeRepository) ListByHandles(ctx context.Context, handles []string) ([]models.Employee, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var employees []models.Employee
+	for _, id := range sortedKeys(s.employees) {
+		email := strings.ToLower(s.employees[id].Email)
+		local, _, _ := strings.Cut(email, "@")
+		if slices.Contains(handles, email) || slices.Contains(handles, local) {
+			employees = append(employees, cloneEmployee(s.employees[id]))
+		}
+	}
+	return employees, nil
+}
+
+func (r *EmployeeRepository) ListByProject(ctx context.Context, projectID int) ([]models.Employee, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var employees []models.Employee
+	for _, id := range sortedKeys(s.employees) {
+		if _, ok := s.memberships[membership{employeeID: id, projectID: projectID}]; ok {
+			employees = append(employees, cloneEmployee(s.employees[id]))
+		}
+	}
+	return employees, nil
+}
+
+func (r *EmployeeRepository) AssignToProject(ctx context.Context, employeeID, projectID int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.employees[employeeID]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "employee_projects", "employee_id", "employee_id refers to a row that does not exist")
+	}
+	if _, ok := s.projects[projectID]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "employee_projects", "project_id", "project_id refers to a row that does not exist")
+	}
+
+	m := membership{employeeID: employeeID, projectID: projectID}
+	if _, ok := s.memberships[m]; !ok {
+		s.memberships[m] = s.now()
+	}
+	return nil
+}
+
+func (r *EmployeeRepository) RemoveFromProject(ctx context.Context, employeeID, projectID int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := membership{employeeID: employeeID, projectID: projectID}
+	if _, ok := s.memberships[m]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.memberships, m)
+	return nil
+}
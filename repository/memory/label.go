@@ -0,0 +1,324 @@
+package memory
+
+import (
+	"context"
+	"strings"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type taskLabel struct {
+	taskID  int
+	labelID int
+}
+
+type projectLabel struct {
+	projectID int
+	labelID   int
+}
+
+type LabelRepository struct {
+	store *Store
+}
+
+func NewLabelRepository(store *Store) *LabelRepository {
+	return &LabelRepository{store: store}
+}
+
+// checkLabel enforces the constraints the labels table declares.
+// The caller must hold the store lock.
+func (s *Store) checkLabel(label *models.Label) error {
+	if label.Name == "" {
+		return constraintError(repository.ConstraintCheck, "labels", "name", "name has an invalid value")
+	}
+	if !validColor(label.Color) {
+		return constraintError(repository.ConstraintCheck, "labels", "color", "color has an invalid value")
+	}
+	if label.ProjectID != nil {
+		if _, ok := s.projects[*label.ProjectID]; !ok {
+			return constraintError(repository.ConstraintForeignKey, "labels", "project_id", "project_id refers to a row that does not exist")
+		}
+	}
+	for _, other := range s.labels {
+		if other.ID != label.ID && sameScope(other.ProjectID, label.ProjectID) && strings.EqualFold(other.Name, label.Name) {
+			return constraintError(repository.ConstraintUnique, "labels", "name", "name already exists")
+		}
+	}
+	return nil
+}
+
+func validColor(color string) bool {
+	if len(color) != 7 || color[0] != '#' {
+		return false
+	}
+	for _, c := range color[1:] {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func sameScope(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func (r *LabelRepository) Create(ctx context.Context, label *models.Label) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	label.ID = 0
+	if err := s.checkLabel(label); err != nil {
+		return err
+	}
+
+	s.nextLabelID++
+	label.ID = s.nextLabelID
+	label.Version = 1
+	label.CreatedAt = s.now()
+	label.UpdatedAt = label.CreatedAt
+	s.labels[label.ID] = cloneLabel(*label)
+	return nil
+}
+
+func (r *LabelRepository) GetByID(ctx context.Context, id int) (*models.Label, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	label, ok := s.labels[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	label = cloneLabel(label)
+	return &label, nil
+}
+
+// selectLabels returns the labels matching filter, ordered by ID.
+// The caller must hold the store lock.
+func (s *Store) selectLabels(filter repository.LabelFilter) []models.Label {
+	var labels []models.Label
+	for _, id := range sortedKeys(s.labels) {
+		label := s.labels[id]
+		switch {
+		case filter.ProjectID != 0 && !label.UsableIn(filter.ProjectID),
+			filter.GlobalOnly && label.ProjectID != nil:
+			continue
+		}
+		labels = append(labels, cloneLabel(label))
+	}
+	return labels
+}
+
+func (r *LabelRepository) List(ctx context.Context, filter repository.LabelFilter) (*repository.Page[models.Label], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	labels := s.selectLabels(filter)
+	return paginate(labels, filter.Page, repository.LabelSorts, func(l models.Label) int { return l.ID }), nil
+}
+
+func (r *LabelRepository) Update(ctx context.Context, label *models.Label) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.labels[label.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, label.Version); err != nil {
+		return err
+	}
+	existing.Name = label.Name
+	existing.Color = label.Color
+	existing.Description = label.Description
+	if err := s.checkLabel(&existing); err != nil {
+		return err
+	}
+
+	existing.Version++
+	existing.UpdatedAt = s.now()
+	s.labels[label.ID] = cloneLabel(existing)
+	*label = existing
+	return nil
+}
+
+func (r *LabelRepository) Delete(ctx context.Context, id, version int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.labels[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return err
+	}
+	s.deleteLabel(id)
+	return nil
+}
+
+// deleteLabel removes a label and its attachments. The caller must hold
+// the store lock.
+func (s *Store) deleteLabel(id int) {
+	delete(s.labels, id)
+	for l := range s.taskLabels {
+		if l.labelID == id {
+			delete(s.taskLabels, l)
+		}
+	}
+	for l := range s.projectLabels {
+		if l.labelID == id {
+			delete(s.projectLabels, l)
+		}
+	}
+}
+
+// hasTaskLabel reports whether a label is attached to a task. The caller
+// must hold the store lock.
+func (s *Store) hasTaskLabel(taskID, labelID int) bool {
+	_, ok := s.taskLabels[taskLabel{taskID: taskID, labelID: labelID}]
+	return ok
+}
+
+func (r *LabelRepository) AttachToTask(ctx context.Context, taskID, labelID int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[taskID]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "task_labels", "task_id", "task_id refers to a row that does not exist")
+	}
+	if _, ok := s.labels[labelID]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "task_labels", "label_id", "label_id refers to a row that does not exist")
+	}
+	l := taskLabel{taskID: taskID, labelID: labelID}
+	if _, ok := s.taskLabels[l]; !ok {
+		s.taskLabels[l] = s.now()
+	}
+	return nil
+}
+
+func (r *LabelRepository) DetachFromTask(ctx context.Context, taskID, labelID int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := taskLabel{taskID: taskID, labelID: labelID}
+	if _, ok := s.taskLabels[l]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.taskLabels, l)
+	return nil
+}
+
+func (r *LabelRepository) TaskLabels(ctx context.Context, taskID int) ([]models.Label, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var labels []models.Label
+	for _, id := range sortedKeys(s.labels) {
+		if _, ok := s.taskLabels[taskLabel{taskID: taskID, labelID: id}]; ok {
+			labels = append(labels, cloneLabel(s.labels[id]))
+		}
+	}
+	return labels, nil
+}
+
+func (r *LabelRepository) AttachToProject(ctx context.Context, projectID, labelID int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.projects[projectID]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "project_labels", "project_id", "project_id refers to a row that does not exist")
+	}
+	if _, ok := s.labels[labelID]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "project_labels", "label_id", "label_id refers to a row that does not exist")
+	}
+	l := projectLabel{projectID: projectID, labelID: labelID}
+	if _, ok := s.projectLabels[l]; !ok {
+		s.projectLabels[l] = s.now()
+	}
+	return nil
+}
+
+func (r *LabelRepository) DetachFromProject(ctx context.Context, projectID, labelID int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := projectLabel{projectID: projectID, labelID: labelID}
+	if _, ok := s.projectLabels[l]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.projectLabels, l)
+	return nil
+}
+
+func (r *LabelRepository) ProjectLabels(ctx context.Context, projectID int) ([]models.Label, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var labels []models.Label
+	for _, id := range sortedKeys(s.labels) {
+		if _, ok := s.projectLabels[projectLabel{projectID: projectID, labelID: id}]; ok {
+			labels = append(labels, cloneLabel(s.labels[id]))
+		}
+	}
+	return labels, nil
+}
+
+func (r *LabelRepository) Stats(ctx context.Context, filter repository.LabelFilter) ([]models.LabelStats, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var stats []models.LabelStats
+	for _, label := range s.selectLabels(filter) {
+		st := models.LabelStats{
+			LabelID:   label.ID,
+			ProjectID: label.ProjectID,
+			Name:      label.Name,
+			Color:     label.Color,
+		}
+		for l := range s.taskLabels {
+			task, ok := s.tasks[l.taskID]
+			if !ok || l.labelID != label.ID || (filter.ProjectID != 0 && task.ProjectID != filter.ProjectID) {
+				continue
+			}
+			st.Tasks++
+			if task.EstimateHours != nil {
+				st.EstimateHours += *task.EstimateHours
+			}
+			switch {
+			case task.Status == models.StatusDone:
+				st.DoneTasks++
+			case task.Status.Open():
+				st.OpenTasks++
+				if task.RemainingHours != nil {
+					st.RemainingHours += *task.RemainingHours
+				} else if task.EstimateHours != nil {
+					st.RemainingHours += *task.EstimateHours
+				}
+			}
+		}
+		for l := range s.projectLabels {
+			if l.labelID == label.ID {
+				st.Projects++
+			}
+		}
+		stats = append(stats, st)
+	}
+	return stats, nil
+}
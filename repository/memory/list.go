@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"sort"
+
+	"nstorm.com/main-backend/repository"
+)
+
+// paginate sorts the already-filtered items and cuts out the requested
+// page, producing the same cursors as the Postgres implementation.
+func paginate[T any](items []T, page repository.PageRequest, sorts map[string]repository.SortField[T], id func(T) int) *repository.Page[T] {
+	field := sorts[page.Sort.Field]
+	compare := func(a, b T) int {
+		c := repository.CompareValues(field.Kind, field.Value(a), field.Value(b))
+		if c == 0 {
+			c = id(a) - id(b)
+		}
+		if page.Sort.Desc {
+			return -c
+		}
+		return c
+	}
+	sort.SliceStable(items, func(i, j int) bool { return compare(items[i], items[j]) < 0 })
+
+	result := &repository.Page[T]{Items: []T{}, Total: len(items)}
+	start := 0
+	if page.After != nil {
+		start = sort.Search(len(items), func(i int) bool {
+			c := repository.CompareValues(field.Kind, field.Value(items[i]), page.After.Value)
+			if c == 0 {
+				c = id(items[i]) - page.After.ID
+			}
+			if page.Sort.Desc {
+				c = -c
+			}
+			return c > 0
+		})
+	}
+
+	end := start + page.Limit
+	if end < len(items) {
+		last := items[end-1]
+		result.NextCursor = repository.Cursor{Sort: page.Sort, Value: field.Value(last), ID: id(last)}.Encode()
+	} else {
+		end = len(items)
+	}
+	result.Items = append(result.Items, items[start:end]...)
+	return result
+}
@@ -0,0 +1,168 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type MilestoneRepository struct {
+	store *Store
+}
+
+func NewMilestoneRepository(store *Store) *MilestoneRepository {
+	return &MilestoneRepository{store: store}
+}
+
+// checkMilestone enforces the constraints the milestones table declares.
+// The caller must hold the store lock.
+func (s *Store) checkMilestone(milestone *models.Milestone) error {
+	if !milestone.Status.Valid() {
+		return constraintError(repository.ConstraintCheck, "milestones", "status", "status has an invalid value")
+	}
+	if _, ok := s.projects[milestone.ProjectID]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "milestones", "project_id", "project_id refers to a row that does not exist")
+	}
+	return nil
+}
+
+func (r *MilestoneRepository) Create(ctx context.Context, milestone *models.Milestone) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := models.Milestone{
+		ProjectID:   milestone.ProjectID,
+		Title:       milestone.Title,
+		Description: milestone.Description,
+		TargetDate:  milestone.TargetDate,
+		Status:      milestone.Status,
+	}
+	if err := s.checkMilestone(&created); err != nil {
+		return err
+	}
+
+	s.nextMilestoneID++
+	created.ID = s.nextMilestoneID
+	created.Version = 1
+	created.CreatedAt = s.now()
+	created.UpdatedAt = created.CreatedAt
+	s.milestones[created.ID] = created
+	*milestone = created
+	return nil
+}
+
+func (r *MilestoneRepository) GetByID(ctx context.Context, id int) (*models.Milestone, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	milestone, ok := s.milestones[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &milestone, nil
+}
+
+func (r *MilestoneRepository) List(ctx context.Context, filter repository.MilestoneFilter) (*repository.Page[models.Milestone], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []models.Milestone
+	for _, id := range sortedKeys(s.milestones) {
+		milestone := s.milestones[id]
+		switch {
+		case filter.ProjectID != 0 && milestone.ProjectID != filter.ProjectID,
+			filter.Status != "" && milestone.Status != filter.Status:
+			continue
+		}
+		matched = append(matched, milestone)
+	}
+	return paginate(matched, filter.Page, repository.MilestoneSorts, func(m models.Milestone) int { return m.ID }), nil
+}
+
+func (r *MilestoneRepository) ListByProject(ctx context.Context, projectID int) ([]models.Milestone, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var milestones []models.Milestone
+	for _, milestone := range s.milestones {
+		if milestone.ProjectID == projectID {
+			milestones = append(milestones, milestone)
+		}
+	}
+	slices.SortFunc(milestones, func(a, b models.Milestone) int {
+		return cmp.Or(a.TargetDate.Compare(b.TargetDate.Time), cmp.Compare(a.ID, b.ID))
+	})
+	return milestones, nil
+}
+
+func (r *MilestoneRepository) Update(ctx context.Context, milestone *models.Milestone) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.milestones[milestone.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, milestone.Version); err != nil {
+		return err
+	}
+	updated := existing
+	updated.Title = milestone.Title
+	updated.Description = milestone.Description
+	updated.TargetDate = milestone.TargetDate
+	updated.Status = milestone.Status
+	if err := s.checkMilestone(&updated); err != nil {
+		return err
+	}
+
+	updated.Version++
+	updated.UpdatedAt = s.now()
+	s.milestones[milestone.ID] = updated
+	*milestone = updated
+	return nil
+}
+
+func (r *MilestoneRepository) Delete(ctx context.Context, id, version int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.milestones[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return err
+	}
+
+	delete(s.milestones, id)
+	for taskID, task := range s.tasks {
+		if task.MilestoneID != nil && *task.MilestoneID == id {
+			task.MilestoneID = nil
+			s.tasks[taskID] = task
+		}
+	}
+	return nil
+}
+
+func (r *MilestoneRepository) Tasks(ctx context.Context, id int) ([]models.Task, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tasks []models.Task
+	for _, taskID := range sortedKeys(s.tasks) {
+		if task := s.tasks[taskID]; task.MilestoneID != nil && *task.MilestoneID == id {
+			tasks = append(tasks, cloneTask(task))
+		}
+	}
+	return tasks, nil
+}
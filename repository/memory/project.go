@@ -0,0 +1,339 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
+)
+
+type ProjectRepository struct {
+	store *Store
+}
+
+func NewProjectRepository(store *Store) *ProjectRepository {
+	return &ProjectRepository{store: store}
+}
+
+// checkProject enforces the foreign keys the projects table declares.
+// The caller must hold the store lock.
+func (s *Store) checkProject(project *models.Project) error {
+	if _, ok := s.employees[project.LeadID]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "projects", "lead_id", "lead_id refers to a row that does not exist")
+	}
+	return nil
+}
+
+func (r *ProjectRepository) Create(ctx context.Context, project *models.Project) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkProject(project); err != nil {
+		return err
+	}
+
+	s.nextProjectID++
+	project.ID = s.nextProjectID
+	project.Version = 1
+	project.CreatedAt = s.now()
+	project.UpdatedAt = project.CreatedAt
+	project.Tasks = nil
+	s.projects[project.ID] = *project
+	return nil
+}
+
+func (r *ProjectRepository) GetByID(ctx context.Context, id int) (*models.Project, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	project, ok := s.projects[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &project, nil
+}
+
+func (r *ProjectRepository) List(ctx context.Context, filter repository.ProjectFilter) (*repository.Page[models.Project], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var projects []models.Project
+	for _, project := range s.listedProjects(filter.IncludeArchived, filter.IncludeDeleted) {
+		if filter.LeadID != 0 && project.LeadID != filter.LeadID {
+			continue
+		}
+		if _, ok := s.projectLabels[projectLabel{projectID: project.ID, labelID: filter.LabelID}]; filter.LabelID != 0 && !ok {
+			continue
+		}
+		projects = append(projects, project)
+	}
+	return paginate(projects, filter.Page, repository.ProjectSorts, func(p models.Project) int { return p.ID }), nil
+}
+
+func (r *ProjectRepository) Update(ctx context.Context, project *models.Project) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.projects[project.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, project.Version); err != nil {
+		return err
+	}
+	if err := s.checkProject(project); err != nil {
+		return err
+	}
+
+	existing.Name = project.Name
+	existing.Description = project.Description
+	existing.LeadID = project.LeadID
+	existing.Version++
+	existing.UpdatedAt = s.now()
+	s.projects[project.ID] = existing
+	*project = existing
+	return nil
+}
+
+func (r *ProjectRepository) Patch(ctx context.Context, project *models.Project, fields []string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.projects[project.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, project.Version); err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		switch field {
+		case "name":
+			existing.Name = project.Name
+		case "description":
+			existing.Description = project.Description
+		case "lead_id":
+			existing.LeadID = project.LeadID
+		default:
+			return validation.Errors{{Field: field, Message: "cannot be patched"}}
+		}
+	}
+	if err := s.checkProject(&existing); err != nil {
+		return err
+	}
+
+	if len(fields) > 0 {
+		existing.Version++
+		existing.UpdatedAt = s.now()
+	}
+	s.projects[project.ID] = existing
+	*project = existing
+	return nil
+}
+
+// listedProjects returns the live projects, leaving out archived ones
+// unless includeArchived is set, followed by the deleted ones if
+// includeDeleted is set. The caller must hold the store lock.
+func (s *Store) listedProjects(includeArchived, includeDeleted bool) []models.Project {
+	var projects []models.Project
+	for _, project := range s.projects {
+		if project.ArchivedAt == nil || includeArchived {
+			projects = append(projects, project)
+		}
+	}
+	if includeDeleted {
+		for _, project := range s.deletedProjects {
+			if project.ArchivedAt == nil || includeArchived {
+				projects = append(projects, project)
+			}
+		}
+	}
+	return projects
+}
+
+// Delete soft-deletes the project and its tasks, stamping them all with the
+// same time so that Restore can tell them from tasks deleted before.
+func (r *ProjectRepository) Delete(ctx context.Context, id, version int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.projects[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return err
+	}
+
+	now := s.now()
+	existing.DeletedAt = &now
+	existing.Version++
+	existing.UpdatedAt = now
+	delete(s.projects, id)
+	s.deletedProjects[id] = existing
+	for taskID, task := range s.tasks {
+		if task.ProjectID == id {
+			s.softDeleteTask(taskID, now)
+		}
+	}
+	return nil
+}
+
+func (r *ProjectRepository) GetDeleted(ctx context.Context, id int) (*models.Project, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	project, ok := s.deletedProjects[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &project, nil
+}
+
+func (r *ProjectRepository) Restore(ctx context.Context, id, version int) (*models.Project, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.deletedProjects[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return nil, err
+	}
+
+	for taskID, task := range s.deletedTasks {
+		if task.ProjectID == id && task.DeletedAt.Equal(*existing.DeletedAt) {
+			s.restoreTask(taskID)
+		}
+	}
+	existing.DeletedAt = nil
+	existing.Version++
+	existing.UpdatedAt = s.now()
+	delete(s.deletedProjects, id)
+	s.projects[id] = existing
+	return &existing, nil
+}
+
+func (r *ProjectRepository) Archive(ctx context.Context, id, version int) (*models.Project, error) {
+	return r.setArchived(id, version, true)
+}
+
+func (r *ProjectRepository) Unarchive(ctx context.Context, id, version int) (*models.Project, error) {
+	return r.setArchived(id, version, false)
+}
+
+func (r *ProjectRepository) setArchived(id, version int, archived bool) (*models.Project, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.projects[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return nil, err
+	}
+	if (existing.ArchivedAt != nil) == archived {
+		return nil, repository.ErrVersionConflict
+	}
+
+	now := s.now()
+	existing.ArchivedAt = nil
+	if archived {
+		existing.ArchivedAt = &now
+	}
+	existing.Version++
+	existing.UpdatedAt = now
+	s.projects[id] = existing
+	return &existing, nil
+}
+
+func (r *ProjectRepository) Purge(ctx context.Context, cutoff time.Time) (int, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for id, project := range s.deletedProjects {
+		if project.DeletedAt.Before(cutoff) {
+			s.purgeProject(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// purgeProject removes a deleted project along with its tasks and
+// memberships, mirroring the ON DELETE CASCADE rules of the schema. The
+// caller must hold the store lock.
+func (s *Store) purgeProject(id int) {
+	delete(s.deletedProjects, id)
+	for _, task := range s.listedTasks(true) {
+		if task.ProjectID == id {
+			s.deleteTask(task.ID)
+		}
+	}
+	for m := range s.memberships {
+		if m.projectID == id {
+			delete(s.memberships, m)
+		}
+	}
+	for labelID, label := range s.labels {
+		if label.ProjectID != nil && *label.ProjectID == id {
+			s.deleteLabel(labelID)
+		}
+	}
+	for l := range s.projectLabels {
+		if l.projectID == id {
+			delete(s.projectLabels, l)
+		}
+	}
+	for recurringID, recurring := range s.recurringTasks {
+		if recurring.ProjectID == id {
+			s.deleteRecurringTask(recurringID)
+		}
+	}
+	for sprintID, sprint := range s.sprints {
+		if sprint.ProjectID == id {
+			delete(s.sprints, sprintID)
+		}
+	}
+	for milestoneID, milestone := range s.milestones {
+		if milestone.ProjectID == id {
+			delete(s.milestones, milestoneID)
+		}
+	}
+	for templateID, template := range s.templates {
+		if template.SourceProjectID != nil && *template.SourceProjectID == id {
+			template.SourceProjectID = nil
+			s.templates[templateID] = template
+		}
+	}
+}
+
+func (r *ProjectRepository) ListByEmployee(ctx context.Context, employeeID int) ([]models.Project, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var projects []models.Project
+	for _, id := range sortedKeys(s.projects) {
+		if _, ok := s.memberships[membership{employeeID: employeeID, projectID: id}]; ok {
+			projects = append(projects, s.projects[id])
+		}
+	}
+	return projects, nil
+}
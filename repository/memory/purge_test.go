@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	day := func(n int) time.Time { return time.Date(2026, time.March, n, 12, 0, 0, 0, time.UTC) }
+	now := day(1)
+	store.now = func() time.Time { return now }
+	employees, projects, tasks := NewEmployeeRepository(store), NewProjectRepository(store), NewTaskRepository(store)
+
+	lead := models.Employee{Name: "Lead", Email: "lead@example.com", Role: models.RoleProjectManager}
+	dev := models.Employee{Name: "Dev", Email: "dev@example.com", Role: models.RoleDeveloper}
+	temp := models.Employee{Name: "Temp", Email: "temp@example.com", Role: models.RoleDeveloper}
+	for _, employee := range []*models.Employee{&lead, &dev, &temp} {
+		if err := employees.Create(ctx, employee); err != nil {
+			t.Fatal(err)
+		}
+	}
+	project := models.Project{Name: "Project", LeadID: lead.ID}
+	if err := projects.Create(ctx, &project); err != nil {
+		t.Fatal(err)
+	}
+	newTask := func(title string, status models.TaskStatus, parentID *int) models.Task {
+		t.Helper()
+		task := models.Task{ProjectID: project.ID, AssignedTo: dev.ID, ParentTaskID: parentID, Title: title, Status: status, Priority: models.DefaultPriority}
+		if err := tasks.Create(ctx, &task); err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+	stale := newTask("Stale", models.StatusDone, nil)
+	parent := newTask("Parent", models.StatusDone, nil)
+	child := newTask("Child", models.StatusDone, &parent.ID)
+
+	// Day 1: a task and an employee nothing refers to. Day 10: the project,
+	// its remaining tasks, and the developer their tasks still refer to.
+	if err := tasks.Delete(ctx, stale.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := employees.Delete(ctx, temp.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	now = day(10)
+	if err := projects.Delete(ctx, project.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := employees.Delete(ctx, dev.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	purge := func(name string, purge func(context.Context, time.Time) (int, error), cutoff time.Time, want int) {
+		t.Helper()
+		n, err := purge(ctx, cutoff)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Fatalf("purging %s deleted before %s removed %d, want %d", name, cutoff, n, want)
+		}
+	}
+	gone := func(id int, getDeleted func(context.Context, int) (*models.Task, error)) bool {
+		_, err := getDeleted(ctx, id)
+		return errors.Is(err, repository.ErrNotFound)
+	}
+
+	// Rows deleted at the cutoff itself are kept.
+	purge("tasks", tasks.Purge, day(1), 0)
+	purge("tasks", tasks.Purge, day(5), 1)
+	if !gone(stale.ID, tasks.GetDeleted) || gone(parent.ID, tasks.GetDeleted) || gone(child.ID, tasks.GetDeleted) {
+		t.Fatal("purge removed the wrong tasks")
+	}
+	purge("projects", projects.Purge, day(5), 0)
+	// The developer is still assigned the deleted tasks.
+	purge("employees", employees.Purge, day(11), 1)
+	if _, err := employees.GetDeleted(ctx, dev.ID); err != nil {
+		t.Fatalf("developer purged while deleted tasks refer to them: %v", err)
+	}
+
+	// Purging the project takes its tasks along, which frees the developer.
+	purge("projects", projects.Purge, day(11), 1)
+	if !gone(parent.ID, tasks.GetDeleted) || !gone(child.ID, tasks.GetDeleted) {
+		t.Fatal("tasks of a purged project are still there")
+	}
+	purge("employees", employees.Purge, day(11), 1)
+	if _, err := employees.GetByID(ctx, lead.ID); err != nil {
+		t.Fatalf("live employee: %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+// recurringRun is one occurrence a task was created for.
+type recurringRun struct {
+	recurringTaskID int
+	occurrenceAt    time.Time
+}
+
+type RecurringTaskRepository struct {
+	store *Store
+}
+
+func NewRecurringTaskRepository(store *Store) *RecurringTaskRepository {
+	return &RecurringTaskRepository{store: store}
+}
+
+// checkRecurringTask enforces the constraints the recurring_tasks table
+// declares. The caller must hold the store lock.
+func (s *Store) checkRecurringTask(recurring *models.RecurringTask) error {
+	if !recurring.Priority.Valid() {
+		return constraintError(repository.ConstraintCheck, "recurring_tasks", "priority", "priority has an invalid value")
+	}
+	if recurring.DueInDays != nil && *recurring.DueInDays < 0 {
+		return constraintError(repository.ConstraintCheck, "recurring_tasks", "due_in_days", "due_in_days has an invalid value")
+	}
+	if _, ok := s.projects[recurring.ProjectID]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "recurring_tasks", "project_id", "project_id refers to a row that does not exist")
+	}
+	if _, ok := s.employees[recurring.AssignedTo]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "recurring_tasks", "assigned_to", "assigned_to refers to a row that does not exist")
+	}
+	return nil
+}
+
+func (r *RecurringTaskRepository) Create(ctx context.Context, recurring *models.RecurringTask) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRecurringTask(recurring); err != nil {
+		return err
+	}
+
+	s.nextRecurringTaskID++
+	recurring.ID = s.nextRecurringTaskID
+	recurring.LastRunAt = nil
+	recurring.Version = 1
+	recurring.CreatedAt = s.now()
+	recurring.UpdatedAt = recurring.CreatedAt
+	s.recurringTasks[recurring.ID] = cloneRecurringTask(*recurring)
+	return nil
+}
+
+func (r *RecurringTaskRepository) GetByID(ctx context.Context, id int) (*models.RecurringTask, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	recurring, ok := s.recurringTasks[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	recurring = cloneRecurringTask(recurring)
+	return &recurring, nil
+}
+
+func (r *RecurringTaskRepository) List(ctx context.Context, filter repository.RecurringTaskFilter) (*repository.Page[models.RecurringTask], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []models.RecurringTask
+	for _, id := range sortedKeys(s.recurringTasks) {
+		recurring := s.recurringTasks[id]
+		switch {
+		case filter.ProjectID != 0 && recurring.ProjectID != filter.ProjectID,
+			filter.Paused != nil && recurring.Paused != *filter.Paused:
+			continue
+		}
+		matched = append(matched, cloneRecurringTask(recurring))
+	}
+	return paginate(matched, filter.Page, repository.RecurringTaskSorts, func(r models.RecurringTask) int { return r.ID }), nil
+}
+
+func (r *RecurringTaskRepository) Update(ctx context.Context, recurring *models.RecurringTask) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.recurringTasks[recurring.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, recurring.Version); err != nil {
+		return err
+	}
+	updated := *recurring
+	updated.ProjectID = existing.ProjectID
+	updated.LastRunAt = existing.LastRunAt
+	updated.CreatedAt = existing.CreatedAt
+	if err := s.checkRecurringTask(&updated); err != nil {
+		return err
+	}
+
+	updated.Version = existing.Version + 1
+	updated.UpdatedAt = s.now()
+	s.recurringTasks[recurring.ID] = cloneRecurringTask(updated)
+	*recurring = cloneRecurringTask(updated)
+	return nil
+}
+
+func (r *RecurringTaskRepository) Delete(ctx context.Context, id, version int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.recurringTasks[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return err
+	}
+	s.deleteRecurringTask(id)
+	return nil
+}
+
+// deleteRecurringTask removes a definition and its runs.
+// The caller must hold the store lock.
+func (s *Store) deleteRecurringTask(id int) {
+	delete(s.recurringTasks, id)
+	for run := range s.recurringRuns {
+		if run.recurringTaskID == id {
+			delete(s.recurringRuns, run)
+		}
+	}
+}
+
+func (r *RecurringTaskRepository) RunDue(ctx context.Context, now time.Time) ([]models.Task, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var created []models.Task
+	var errs []error
+	for _, id := range sortedKeys(s.recurringTasks) {
+		recurring := s.recurringTasks[id]
+		if recurring.Paused || recurring.NextRunAt == nil || recurring.NextRunAt.After(now) {
+			continue
+		}
+		// Deleted and archived projects take no new tasks.
+		if project, ok := s.projects[recurring.ProjectID]; !ok || project.ArchivedAt != nil {
+			continue
+		}
+		occurrence, next, err := recurring.Catchup(now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recurring task %d: %w", id, err))
+			continue
+		}
+
+		run := recurringRun{recurringTaskID: id, occurrenceAt: occurrence.UTC()}
+		if _, done := s.recurringRuns[run]; !done {
+			task := recurring.NewTask(occurrence)
+			if err := s.checkTask(&task); err != nil {
+				errs = append(errs, fmt.Errorf("recurring task %d: %w", id, err))
+				continue
+			}
+			s.insertTask(&task)
+			s.recurringRuns[run] = task.ID
+			created = append(created, task)
+		}
+
+		recurring.NextRunAt = nil
+		if !next.IsZero() {
+			recurring.NextRunAt = &next
+		}
+		recurring.LastRunAt = &occurrence
+		recurring.Version++
+		recurring.UpdatedAt = s.now()
+		s.recurringTasks[id] = cloneRecurringTask(recurring)
+	}
+	return created, errors.Join(errs...)
+}
+
+func (r *RecurringTaskRepository) Tasks(ctx context.Context, id int) ([]models.Task, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var runs []recurringRun
+	for run, taskID := range s.recurringRuns {
+		if run.recurringTaskID == id && taskID != 0 {
+			runs = append(runs, run)
+		}
+	}
+	slices.SortFunc(runs, func(a, b recurringRun) int {
+		return a.occurrenceAt.Compare(b.occurrenceAt)
+	})
+
+	var tasks []models.Task
+	for _, run := range runs {
+		if task, ok := s.tasks[s.recurringRuns[run]]; ok {
+			tasks = append(tasks, cloneTask(task))
+		}
+	}
+	return tasks, nil
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"nstorm.com/main-backend/models"
+)
+
+func TestRunDue(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepositories()
+	taskIDs := newTasks(t, repos, 1)
+	task, err := repos.Tasks.GetByID(ctx, taskIDs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(day, hour int) time.Time { return time.Date(2026, time.March, day, hour, 0, 0, 0, time.UTC) }
+	first := at(2, 9)
+	daily := models.RecurringTask{
+		ProjectID:  task.ProjectID,
+		AssignedTo: task.AssignedTo,
+		Title:      "Check the backups",
+		Priority:   models.DefaultPriority,
+		Schedule:   "0 9 * * *",
+		NextRunAt:  &first,
+	}
+	paused := daily
+	paused.Title, paused.Paused, paused.NextRunAt = "Paused", true, nil
+	for _, recurring := range []*models.RecurringTask{&daily, &paused} {
+		if err := repos.RecurringTasks.Create(ctx, recurring); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(now time.Time, wantStarts ...string) {
+		t.Helper()
+		created, err := repos.RecurringTasks.RunDue(ctx, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var starts []string
+		for _, task := range created {
+			starts = append(starts, task.StartDate.String())
+		}
+		if !slices.Equal(starts, wantStarts) {
+			t.Fatalf("RunDue(%s) created tasks starting %v, want %v", now, starts, wantStarts)
+		}
+	}
+
+	run(at(2, 8))
+	run(at(2, 9), "2026-03-02")
+	run(at(2, 12))
+	// Days missed while down produce one task, for the latest.
+	run(at(5, 10), "2026-03-05")
+
+	got, err := repos.RecurringTasks.GetByID(ctx, daily.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.NextRunAt.Equal(at(6, 9)) || !got.LastRunAt.Equal(at(5, 9)) {
+		t.Fatalf("next run %s, last run %s", got.NextRunAt, got.LastRunAt)
+	}
+
+	// Rewinding the schedule does not create an occurrence twice.
+	rewound := at(5, 9)
+	got.NextRunAt = &rewound
+	if err := repos.RecurringTasks.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	run(at(5, 10))
+
+	tasks, err := repos.RecurringTasks.Tasks(ctx, daily.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 || tasks[0].StartDate.String() != "2026-03-02" || tasks[1].StartDate.String() != "2026-03-05" {
+		t.Fatalf("created tasks = %+v", tasks)
+	}
+}
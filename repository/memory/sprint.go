@@ -0,0 +1,261 @@
+package memory
+
+import (
+	"context"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type SprintRepository struct {
+	store *Store
+}
+
+func NewSprintRepository(store *Store) *SprintRepository {
+	return &SprintRepository{store: store}
+}
+
+// checkSprint enforces the constraints the sprints table declares.
+// The caller must hold the store lock.
+func (s *Store) checkSprint(sprint *models.Sprint) error {
+	if !sprint.State.Valid() {
+		return constraintError(repository.ConstraintCheck, "sprints", "state", "state has an invalid value")
+	}
+	if sprint.EndDate.Before(sprint.StartDate.Time) {
+		return constraintError(repository.ConstraintCheck, "sprints", "end_date", "end_date has an invalid value")
+	}
+	if _, ok := s.projects[sprint.ProjectID]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "sprints", "project_id", "project_id refers to a row that does not exist")
+	}
+	if sprint.State == models.SprintActive {
+		for id, other := range s.sprints {
+			if id != sprint.ID && other.ProjectID == sprint.ProjectID && other.State == models.SprintActive {
+				return constraintError(repository.ConstraintUnique, "sprints", "state", "state already exists")
+			}
+		}
+	}
+	return nil
+}
+
+func (r *SprintRepository) Create(ctx context.Context, sprint *models.Sprint) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := models.Sprint{
+		ProjectID: sprint.ProjectID,
+		Name:      sprint.Name,
+		Goal:      sprint.Goal,
+		StartDate: sprint.StartDate,
+		EndDate:   sprint.EndDate,
+		State:     models.SprintPlanned,
+	}
+	if err := s.checkSprint(&created); err != nil {
+		return err
+	}
+
+	s.nextSprintID++
+	created.ID = s.nextSprintID
+	created.Version = 1
+	created.CreatedAt = s.now()
+	created.UpdatedAt = created.CreatedAt
+	s.sprints[created.ID] = created
+	*sprint = cloneSprint(created)
+	return nil
+}
+
+func (r *SprintRepository) GetByID(ctx context.Context, id int) (*models.Sprint, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sprint, ok := s.sprints[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	sprint = cloneSprint(sprint)
+	return &sprint, nil
+}
+
+func (r *SprintRepository) List(ctx context.Context, filter repository.SprintFilter) (*repository.Page[models.Sprint], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []models.Sprint
+	for _, id := range sortedKeys(s.sprints) {
+		sprint := s.sprints[id]
+		switch {
+		case filter.ProjectID != 0 && sprint.ProjectID != filter.ProjectID,
+			filter.State != "" && sprint.State != filter.State:
+			continue
+		}
+		matched = append(matched, cloneSprint(sprint))
+	}
+	return paginate(matched, filter.Page, repository.SprintSorts, func(s models.Sprint) int { return s.ID }), nil
+}
+
+func (r *SprintRepository) Update(ctx context.Context, sprint *models.Sprint) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.sprints[sprint.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, sprint.Version); err != nil {
+		return err
+	}
+	updated := existing
+	updated.Name = sprint.Name
+	updated.Goal = sprint.Goal
+	updated.StartDate = sprint.StartDate
+	updated.EndDate = sprint.EndDate
+	if err := s.checkSprint(&updated); err != nil {
+		return err
+	}
+
+	updated.Version++
+	updated.UpdatedAt = s.now()
+	s.sprints[sprint.ID] = updated
+	*sprint = cloneSprint(updated)
+	return nil
+}
+
+func (r *SprintRepository) Delete(ctx context.Context, id, version int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.sprints[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return err
+	}
+
+	delete(s.sprints, id)
+	for taskID, task := range s.tasks {
+		if task.SprintID != nil && *task.SprintID == id {
+			task.SprintID = nil
+			s.tasks[taskID] = task
+		}
+	}
+	return nil
+}
+
+// sprintTasks returns the tasks in a sprint, ordered by ID.
+// The caller must hold the store lock.
+func (s *Store) sprintTasks(id int) []models.Task {
+	var tasks []models.Task
+	for _, taskID := range sortedKeys(s.tasks) {
+		if task := s.tasks[taskID]; task.SprintID != nil && *task.SprintID == id {
+			tasks = append(tasks, cloneTask(task))
+		}
+	}
+	return tasks
+}
+
+// changeSprintState moves a sprint from one state to another, failing the
+// way the conditional update in Postgres does. The caller must hold the
+// store lock.
+func (s *Store) changeSprintState(id, version int, from models.SprintState) (models.Sprint, error) {
+	sprint, ok := s.sprints[id]
+	if !ok {
+		return models.Sprint{}, repository.ErrNotFound
+	}
+	if err := checkVersion(sprint.Version, version); err != nil {
+		return models.Sprint{}, err
+	}
+	if sprint.State != from {
+		return models.Sprint{}, repository.ErrVersionConflict
+	}
+	return sprint, nil
+}
+
+func (r *SprintRepository) Start(ctx context.Context, id, version int) (*models.Sprint, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sprint, err := s.changeSprintState(id, version, models.SprintPlanned)
+	if err != nil {
+		return nil, err
+	}
+	sprint.State = models.SprintActive
+	if err := s.checkSprint(&sprint); err != nil {
+		return nil, err
+	}
+
+	var committed float64
+	for _, task := range s.sprintTasks(id) {
+		if task.Status != models.StatusCancelled && task.EstimateHours != nil {
+			committed += *task.EstimateHours
+		}
+	}
+	now := s.now()
+	sprint.StartedAt = &now
+	sprint.CommittedHours = &committed
+	sprint.Version++
+	sprint.UpdatedAt = now
+	s.sprints[id] = cloneSprint(sprint)
+	return &sprint, nil
+}
+
+func (r *SprintRepository) Close(ctx context.Context, id, nextID, version int) (*models.Sprint, []models.Task, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sprint, err := s.changeSprintState(id, version, models.SprintActive)
+	if err != nil {
+		return nil, nil, err
+	}
+	var next *int
+	if nextID != 0 {
+		target, ok := s.sprints[nextID]
+		if !ok || target.ProjectID != sprint.ProjectID || target.State != models.SprintPlanned {
+			return nil, nil, repository.ErrVersionConflict
+		}
+		next = &nextID
+	}
+
+	now := s.now()
+	var completed float64
+	var done int
+	var moved []models.Task
+	for _, task := range s.sprintTasks(id) {
+		if task.Status == models.StatusDone {
+			done++
+			if task.EstimateHours != nil {
+				completed += *task.EstimateHours
+			}
+		}
+		if task.Status.Open() {
+			task.SprintID = cloneInt(next)
+			task.Version++
+			task.UpdatedAt = now
+			s.tasks[task.ID] = cloneTask(task)
+			moved = append(moved, task)
+		}
+	}
+
+	sprint.State = models.SprintClosed
+	sprint.ClosedAt = &now
+	sprint.CompletedHours = &completed
+	sprint.CompletedTasks = &done
+	sprint.Version++
+	sprint.UpdatedAt = now
+	s.sprints[id] = cloneSprint(sprint)
+	return &sprint, moved, nil
+}
+
+func (r *SprintRepository) Tasks(ctx context.Context, id int) ([]models.Task, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sprintTasks(id), nil
+}
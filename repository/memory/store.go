@@ -0,0 +1,252 @@
+package memory
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type membership struct {
+	employeeID int
+	projectID  int
+}
+
+// Store holds every in-memory table behind a single lock so that the
+// repositories sharing it observe a consistent view, the same way they
+// would against one Postgres database.
+type Store struct {
+	mu sync.RWMutex
+
+	now func() time.Time
+
+	nextEmployeeID int
+	nextProjectID  int
+	nextTaskID     int
+
+	nextTransitionID     int
+	nextCommentID        int
+	nextAssigneeChangeID int
+	nextLabelID          int
+	nextWorklogID        int
+	nextRecurringTaskID  int
+	nextSprintID         int
+	nextMilestoneID      int
+	nextTemplateID       int
+
+	employees       map[int]models.Employee
+	projects        map[int]models.Project
+	tasks           map[int]models.Task
+	memberships     map[membership]time.Time
+	transitions     []models.TaskTransition
+	dependencies    map[dependency]time.Time
+	comments        map[int]models.Comment
+	assigneeChanges []models.TaskAssigneeChange
+	labels          map[int]models.Label
+	taskLabels      map[taskLabel]time.Time
+	projectLabels   map[projectLabel]time.Time
+	worklogs        map[int]models.Worklog
+	recurringTasks  map[int]models.RecurringTask
+	recurringRuns   map[recurringRun]int
+	sprints         map[int]models.Sprint
+	milestones      map[int]models.Milestone
+	templates       map[int]models.ProjectTemplate
+
+	// Soft-deleted rows are kept apart from the live ones, so that reads
+	// which only see live rows need not skip them.
+	deletedEmployees map[int]models.Employee
+	deletedProjects  map[int]models.Project
+	deletedTasks     map[int]models.Task
+}
+
+func NewStore() *Store {
+	return &Store{
+		now:         time.Now,
+		employees:   make(map[int]models.Employee),
+		projects:    make(map[int]models.Project),
+		tasks:       make(map[int]models.Task),
+		memberships: make(map[membership]time.Time),
+
+		dependencies: make(map[dependency]time.Time),
+		comments:     make(map[int]models.Comment),
+
+		labels:        make(map[int]models.Label),
+		taskLabels:    make(map[taskLabel]time.Time),
+		projectLabels: make(map[projectLabel]time.Time),
+
+		worklogs:       make(map[int]models.Worklog),
+		recurringTasks: make(map[int]models.RecurringTask),
+		recurringRuns:  make(map[recurringRun]int),
+
+		sprints:    make(map[int]models.Sprint),
+		milestones: make(map[int]models.Milestone),
+		templates:  make(map[int]models.ProjectTemplate),
+
+		deletedEmployees: make(map[int]models.Employee),
+		deletedProjects:  make(map[int]models.Project),
+		deletedTasks:     make(map[int]models.Task),
+	}
+}
+
+// NewRepositories returns in-memory implementations of every repository
+// backed by a fresh Store.
+func NewRepositories() *repository.Repositories {
+	store := NewStore()
+	return &repository.Repositories{
+		Employees:      NewEmployeeRepository(store),
+		Projects:       NewProjectRepository(store),
+		Tasks:          NewTaskRepository(store),
+		Comments:       NewCommentRepository(store),
+		Labels:         NewLabelRepository(store),
+		Worklogs:       NewWorklogRepository(store),
+		RecurringTasks: NewRecurringTaskRepository(store),
+		Sprints:        NewSprintRepository(store),
+		Milestones:     NewMilestoneRepository(store),
+		Templates:      NewTemplateRepository(store),
+	}
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func cloneEmployee(employee models.Employee) models.Employee {
+	if employee.Skills != nil {
+		employee.Skills = append([]string(nil), employee.Skills...)
+	}
+	employee.Projects = nil
+	employee.Tasks = nil
+	employee.DeletedAt = cloneTime(employee.DeletedAt)
+	return employee
+}
+
+// cloneTask copies the parent reference and hour estimates so that the
+// stored task shares no memory with the caller's.
+func cloneTask(task models.Task) models.Task {
+	task.ParentTaskID = cloneInt(task.ParentTaskID)
+	task.SprintID = cloneInt(task.SprintID)
+	task.MilestoneID = cloneInt(task.MilestoneID)
+	task.EstimateHours = cloneFloat(task.EstimateHours)
+	task.RemainingHours = cloneFloat(task.RemainingHours)
+	task.DeletedAt = cloneTime(task.DeletedAt)
+	return task
+}
+
+func cloneInt(i *int) *int {
+	if i == nil {
+		return nil
+	}
+	v := *i
+	return &v
+}
+
+func cloneFloat(f *float64) *float64 {
+	if f == nil {
+		return nil
+	}
+	v := *f
+	return &v
+}
+
+func cloneTransition(transition models.TaskTransition) models.TaskTransition {
+	transition.ActorID = cloneInt(transition.ActorID)
+	return transition
+}
+
+func cloneComment(comment models.Comment) models.Comment {
+	comment.AuthorID = cloneInt(comment.AuthorID)
+	comment.Mentions = slices.Clone(comment.Mentions)
+	return comment
+}
+
+func cloneLabel(label models.Label) models.Label {
+	label.ProjectID = cloneInt(label.ProjectID)
+	return label
+}
+
+func cloneWorklog(worklog models.Worklog) models.Worklog {
+	worklog.DurationSeconds = cloneInt(worklog.DurationSeconds)
+	return worklog
+}
+
+func cloneRecurringTask(recurring models.RecurringTask) models.RecurringTask {
+	recurring.EstimateHours = cloneFloat(recurring.EstimateHours)
+	recurring.DueInDays = cloneInt(recurring.DueInDays)
+	recurring.NextRunAt = cloneTime(recurring.NextRunAt)
+	recurring.LastRunAt = cloneTime(recurring.LastRunAt)
+	return recurring
+}
+
+func cloneSprint(sprint models.Sprint) models.Sprint {
+	sprint.StartedAt = cloneTime(sprint.StartedAt)
+	sprint.ClosedAt = cloneTime(sprint.ClosedAt)
+	sprint.CommittedHours = cloneFloat(sprint.CommittedHours)
+	sprint.CompletedHours = cloneFloat(sprint.CompletedHours)
+	sprint.CompletedTasks = cloneInt(sprint.CompletedTasks)
+	return sprint
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := *t
+	return &v
+}
+
+func cloneAssigneeChange(change models.TaskAssigneeChange) models.TaskAssigneeChange {
+	change.From = cloneInt(change.From)
+	change.To = cloneInt(change.To)
+	return change
+}
+
+func constraintError(kind repository.ConstraintKind, table, field, message string) error {
+	return &repository.ConstraintError{
+		Kind:       kind,
+		Table:      table,
+		Field:      field,
+		Constraint: table + "_" + field,
+		Message:    message,
+	}
+}
+
+// anyEmployee, anyProject and anyTask look a row up whether or not it is
+// soft-deleted, for the reports that keep the history of deleted rows. The
+// caller must hold the store lock.
+func (s *Store) anyEmployee(id int) models.Employee {
+	if employee, ok := s.employees[id]; ok {
+		return employee
+	}
+	return s.deletedEmployees[id]
+}
+
+func (s *Store) anyProject(id int) models.Project {
+	if project, ok := s.projects[id]; ok {
+		return project
+	}
+	return s.deletedProjects[id]
+}
+
+func (s *Store) anyTask(id int) models.Task {
+	if task, ok := s.tasks[id]; ok {
+		return task
+	}
+	return s.deletedTasks[id]
+}
+
+// checkVersion mimics the version condition of a conditional write: a zero
+// expected version always matches.
+func checkVersion(stored, expected int) error {
+	if expected != 0 && stored != expected {
+		return repository.ErrVersionConflict
+	}
+	return nil
+}
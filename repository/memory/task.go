@@ -0,0 +1,534 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+	"nstorm.com/main-backend/validation"
+)
+
+type TaskRepository struct {
+	store *Store
+}
+
+func NewTaskRepository(store *Store) *TaskRepository {
+	return &TaskRepository{store: store}
+}
+
+// checkTask enforces the constraints the tasks table declares.
+// The caller must hold the store lock.
+func (s *Store) checkTask(task *models.Task) error {
+	if !task.Status.Valid() {
+		return constraintError(repository.ConstraintCheck, "tasks", "status", "status has an invalid value")
+	}
+	if !task.Priority.Valid() {
+		return constraintError(repository.ConstraintCheck, "tasks", "priority", "priority has an invalid value")
+	}
+	if !task.StartDate.IsZero() && !task.DueDate.IsZero() && task.DueDate.Before(task.StartDate.Time) {
+		return constraintError(repository.ConstraintCheck, "tasks", "due_date", "due_date has an invalid value")
+	}
+	if _, ok := s.projects[task.ProjectID]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "tasks", "project_id", "project_id refers to a row that does not exist")
+	}
+	if _, ok := s.employees[task.AssignedTo]; !ok {
+		return constraintError(repository.ConstraintForeignKey, "tasks", "assigned_to", "assigned_to refers to a row that does not exist")
+	}
+	if task.SprintID != nil {
+		if _, ok := s.sprints[*task.SprintID]; !ok {
+			return constraintError(repository.ConstraintForeignKey, "tasks", "sprint_id", "sprint_id refers to a row that does not exist")
+		}
+	}
+	if task.MilestoneID != nil {
+		if _, ok := s.milestones[*task.MilestoneID]; !ok {
+			return constraintError(repository.ConstraintForeignKey, "tasks", "milestone_id", "milestone_id refers to a row that does not exist")
+		}
+	}
+	if task.ParentTaskID != nil {
+		if *task.ParentTaskID == task.ID {
+			return constraintError(repository.ConstraintCheck, "tasks", "parent_task_id", "parent_task_id has an invalid value")
+		}
+		if _, ok := s.tasks[*task.ParentTaskID]; !ok {
+			return constraintError(repository.ConstraintForeignKey, "tasks", "parent_task_id", "parent_task_id refers to a row that does not exist")
+		}
+	}
+	return nil
+}
+
+// insertTask assigns an ID, creation time and a rank at the bottom of the
+// task's board column, and stores the task. The caller must hold the store
+// lock.
+func (s *Store) insertTask(task *models.Task) {
+	task.Rank = models.RankAfter(s.lastRank(task.ProjectID, task.Status))
+	s.nextTaskID++
+	task.ID = s.nextTaskID
+	task.Version = 1
+	task.CreatedAt = s.now()
+	task.UpdatedAt = task.CreatedAt
+	s.tasks[task.ID] = cloneTask(*task)
+}
+
+func (r *TaskRepository) Create(ctx context.Context, task *models.Task) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkTask(task); err != nil {
+		return err
+	}
+	s.insertTask(task)
+	return nil
+}
+
+func (r *TaskRepository) GetByID(ctx context.Context, id int) (*models.Task, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task, ok := s.tasks[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &task, nil
+}
+
+func (r *TaskRepository) filter(match func(models.Task) bool) []models.Task {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tasks []models.Task
+	for _, id := range sortedKeys(s.tasks) {
+		if task := s.tasks[id]; match(task) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// listedTasks returns the live tasks, followed by the deleted ones if
+// includeDeleted is set, each ordered by ID. The caller must hold the store
+// lock.
+func (s *Store) listedTasks(includeDeleted bool) []models.Task {
+	var tasks []models.Task
+	for _, id := range sortedKeys(s.tasks) {
+		tasks = append(tasks, s.tasks[id])
+	}
+	if includeDeleted {
+		for _, id := range sortedKeys(s.deletedTasks) {
+			tasks = append(tasks, s.deletedTasks[id])
+		}
+	}
+	return tasks
+}
+
+func (r *TaskRepository) List(ctx context.Context, filter repository.TaskFilter) (*repository.Page[models.Task], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tasks []models.Task
+	for _, task := range s.listedTasks(filter.IncludeDeleted) {
+		switch {
+		case filter.ProjectID != 0 && task.ProjectID != filter.ProjectID,
+			filter.AssignedTo != 0 && task.AssignedTo != filter.AssignedTo,
+			filter.ParentTaskID != 0 && (task.ParentTaskID == nil || *task.ParentTaskID != filter.ParentTaskID),
+			filter.SprintID != 0 && (task.SprintID == nil || *task.SprintID != filter.SprintID),
+			filter.MilestoneID != 0 && (task.MilestoneID == nil || *task.MilestoneID != filter.MilestoneID),
+			filter.LabelID != 0 && !s.hasTaskLabel(task.ID, filter.LabelID),
+			filter.Status != "" && task.Status != filter.Status,
+			filter.CreatedAfter != nil && task.CreatedAt.Before(*filter.CreatedAfter),
+			filter.CreatedBefore != nil && !task.CreatedAt.Before(*filter.CreatedBefore),
+			filter.Priority != "" && task.Priority != filter.Priority,
+			filter.StartAfter != nil && (task.StartDate.IsZero() || task.StartDate.Before(filter.StartAfter.Time)),
+			filter.StartBefore != nil && (task.StartDate.IsZero() || !task.StartDate.Before(filter.StartBefore.Time)),
+			filter.DueAfter != nil && (task.DueDate.IsZero() || task.DueDate.Before(filter.DueAfter.Time)),
+			filter.DueBefore != nil && (task.DueDate.IsZero() || !task.DueDate.Before(filter.DueBefore.Time)),
+			filter.OverdueOn != nil && !task.Overdue(*filter.OverdueOn):
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	return paginate(tasks, filter.Page, repository.TaskSorts, func(t models.Task) int { return t.ID }), nil
+}
+
+func (r *TaskRepository) Update(ctx context.Context, task *models.Task) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.tasks[task.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, task.Version); err != nil {
+		return err
+	}
+	task.Status = existing.Status
+	if err := s.checkTask(task); err != nil {
+		return err
+	}
+
+	s.recordAssigneeChange(task.ID, existing.AssignedTo, task.AssignedTo)
+	existing.ProjectID = task.ProjectID
+	existing.AssignedTo = task.AssignedTo
+	existing.ParentTaskID = task.ParentTaskID
+	existing.SprintID = task.SprintID
+	existing.MilestoneID = task.MilestoneID
+	existing.Title = task.Title
+	existing.Description = task.Description
+	existing.Priority = task.Priority
+	existing.StartDate = task.StartDate
+	existing.DueDate = task.DueDate
+	existing.EstimateHours = task.EstimateHours
+	existing.RemainingHours = task.RemainingHours
+	existing.Version++
+	existing.UpdatedAt = s.now()
+	s.tasks[task.ID] = cloneTask(existing)
+	*task = existing
+	return nil
+}
+
+func (r *TaskRepository) Patch(ctx context.Context, task *models.Task, fields []string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.tasks[task.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, task.Version); err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		switch field {
+		case "project_id":
+			existing.ProjectID = task.ProjectID
+		case "assigned_to":
+			existing.AssignedTo = task.AssignedTo
+		case "parent_task_id":
+			existing.ParentTaskID = task.ParentTaskID
+		case "sprint_id":
+			existing.SprintID = task.SprintID
+		case "milestone_id":
+			existing.MilestoneID = task.MilestoneID
+		case "title":
+			existing.Title = task.Title
+		case "description":
+			existing.Description = task.Description
+		case "priority":
+			existing.Priority = task.Priority
+		case "start_date":
+			existing.StartDate = task.StartDate
+		case "due_date":
+			existing.DueDate = task.DueDate
+		case "estimate_hours":
+			existing.EstimateHours = task.EstimateHours
+		case "remaining_hours":
+			existing.RemainingHours = task.RemainingHours
+		default:
+			return validation.Errors{{Field: field, Message: "cannot be patched"}}
+		}
+	}
+	if err := s.checkTask(&existing); err != nil {
+		return err
+	}
+	s.recordAssigneeChange(task.ID, s.tasks[task.ID].AssignedTo, existing.AssignedTo)
+
+	if len(fields) > 0 {
+		existing.Version++
+		existing.UpdatedAt = s.now()
+	}
+	s.tasks[task.ID] = cloneTask(existing)
+	*task = existing
+	return nil
+}
+
+func (r *TaskRepository) Delete(ctx context.Context, id, version int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.tasks[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return err
+	}
+	s.softDeleteTask(id, s.now())
+	return nil
+}
+
+// softDeleteTask marks a live task and its live subtasks deleted at now.
+// The caller must hold the store lock.
+func (s *Store) softDeleteTask(id int, now time.Time) {
+	task, ok := s.tasks[id]
+	if !ok {
+		return
+	}
+	task.DeletedAt = &now
+	task.Version++
+	task.UpdatedAt = now
+	delete(s.tasks, id)
+	s.deletedTasks[id] = task
+	for childID, child := range s.tasks {
+		if child.ParentTaskID != nil && *child.ParentTaskID == id {
+			s.softDeleteTask(childID, now)
+		}
+	}
+}
+
+func (r *TaskRepository) GetDeleted(ctx context.Context, id int) (*models.Task, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task, ok := s.deletedTasks[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	task = cloneTask(task)
+	return &task, nil
+}
+
+func (r *TaskRepository) Restore(ctx context.Context, id, version int) (*models.Task, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.deletedTasks[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return nil, err
+	}
+	s.restoreTask(id)
+	task := cloneTask(s.tasks[id])
+	return &task, nil
+}
+
+// restoreTask brings back a deleted task and the subtasks deleted together
+// with it, which carry the same deletion time. The caller must hold the
+// store lock.
+func (s *Store) restoreTask(id int) {
+	task, ok := s.deletedTasks[id]
+	if !ok {
+		return
+	}
+	deletedAt := *task.DeletedAt
+	task.DeletedAt = nil
+	task.Version++
+	task.UpdatedAt = s.now()
+	delete(s.deletedTasks, id)
+	s.tasks[id] = task
+	for childID, child := range s.deletedTasks {
+		if child.ParentTaskID != nil && *child.ParentTaskID == id && child.DeletedAt.Equal(deletedAt) {
+			s.restoreTask(childID)
+		}
+	}
+}
+
+func (r *TaskRepository) Purge(ctx context.Context, cutoff time.Time) (int, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int
+	for id, task := range s.deletedTasks {
+		if task.DeletedAt.Before(cutoff) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	purged := 0
+	for _, id := range ids {
+		// A subtask may already have gone with its parent.
+		if _, ok := s.deletedTasks[id]; ok {
+			s.deleteTask(id)
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// deleteTask removes a task, live or deleted, its subtasks and the rows that
+// reference them, mirroring the ON DELETE CASCADE rules of the schema. The
+// caller must hold the store lock.
+func (s *Store) deleteTask(id int) {
+	delete(s.tasks, id)
+	delete(s.deletedTasks, id)
+	for _, child := range s.listedTasks(true) {
+		if child.ParentTaskID != nil && *child.ParentTaskID == id {
+			s.deleteTask(child.ID)
+		}
+	}
+	s.transitions = slices.DeleteFunc(s.transitions, func(t models.TaskTransition) bool {
+		return t.TaskID == id
+	})
+	for d := range s.dependencies {
+		if d.taskID == id || d.dependsOnID == id {
+			delete(s.dependencies, d)
+		}
+	}
+	for commentID, comment := range s.comments {
+		if comment.TaskID == id {
+			delete(s.comments, commentID)
+		}
+	}
+	s.assigneeChanges = slices.DeleteFunc(s.assigneeChanges, func(c models.TaskAssigneeChange) bool {
+		return c.TaskID == id
+	})
+	for l := range s.taskLabels {
+		if l.taskID == id {
+			delete(s.taskLabels, l)
+		}
+	}
+	for worklogID, worklog := range s.worklogs {
+		if worklog.TaskID == id {
+			delete(s.worklogs, worklogID)
+		}
+	}
+	for run, taskID := range s.recurringRuns {
+		if taskID == id {
+			s.recurringRuns[run] = 0
+		}
+	}
+}
+
+// recordAssigneeChange mirrors the trigger that logs reassignments.
+// The caller must hold the store lock.
+func (s *Store) recordAssigneeChange(taskID, from, to int) {
+	if from == to {
+		return
+	}
+	s.nextAssigneeChangeID++
+	s.assigneeChanges = append(s.assigneeChanges, models.TaskAssigneeChange{
+		ID:        s.nextAssigneeChangeID,
+		TaskID:    taskID,
+		From:      &from,
+		To:        &to,
+		CreatedAt: s.now(),
+	})
+}
+
+func (r *TaskRepository) Transition(ctx context.Context, transition *models.TaskTransition, version int) (*models.Task, error) {
+	return r.move(transition, repository.TaskPosition{}, version, true)
+}
+
+func (r *TaskRepository) Transitions(ctx context.Context, taskID int) ([]models.TaskTransition, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var transitions []models.TaskTransition
+	for _, t := range s.transitions {
+		if t.TaskID == taskID {
+			transitions = append(transitions, cloneTransition(t))
+		}
+	}
+	return transitions, nil
+}
+
+func (r *TaskRepository) AssigneeChanges(ctx context.Context, taskID int) ([]models.TaskAssigneeChange, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var changes []models.TaskAssigneeChange
+	for _, c := range s.assigneeChanges {
+		if c.TaskID == taskID {
+			changes = append(changes, cloneAssigneeChange(c))
+		}
+	}
+	return changes, nil
+}
+
+func (r *TaskRepository) ListByAssignee(ctx context.Context, employeeID int) ([]models.Task, error) {
+	return r.filter(func(task models.Task) bool {
+		return task.AssignedTo == employeeID
+	}), nil
+}
+
+func (r *TaskRepository) ListByAssigneeAndStatus(ctx context.Context, employeeID int, status models.TaskStatus) ([]models.Task, error) {
+	return r.filter(func(task models.Task) bool {
+		return task.AssignedTo == employeeID && task.Status == status
+	}), nil
+}
+
+func (r *TaskRepository) ListByProject(ctx context.Context, projectID int) ([]models.Task, error) {
+	return r.filter(func(task models.Task) bool {
+		return task.ProjectID == projectID
+	}), nil
+}
+
+func (r *TaskRepository) Subtree(ctx context.Context, id int) ([]models.Task, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.tasks[id]; !ok {
+		return nil, repository.ErrNotFound
+	}
+	inTree := map[int]bool{id: true}
+	var tasks []models.Task
+	// Add a level of subtasks per pass until no more tasks join the tree.
+	for grown := true; grown; {
+		grown = false
+		for taskID, task := range s.tasks {
+			if !inTree[taskID] && task.ParentTaskID != nil && inTree[*task.ParentTaskID] {
+				inTree[taskID] = true
+				grown = true
+			}
+		}
+	}
+	for _, taskID := range sortedKeys(s.tasks) {
+		if inTree[taskID] {
+			tasks = append(tasks, s.tasks[taskID])
+		}
+	}
+	return tasks, nil
+}
+
+func (r *TaskRepository) CreateAssigned(ctx context.Context, projectID int, assignments []repository.TaskAssignment) ([]models.Task, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.projects[projectID]; !ok {
+		return nil, constraintError(repository.ConstraintForeignKey, "tasks", "project_id", "project_id refers to a row that does not exist")
+	}
+
+	tasks := make([]models.Task, 0, len(assignments))
+	for _, assignment := range assignments {
+		assignee := 0
+		for _, id := range sortedKeys(s.employees) {
+			if s.employees[id].Name == assignment.AssigneeName {
+				assignee = id
+				break
+			}
+		}
+		if assignee == 0 {
+			return nil, fmt.Errorf("no employee named %q: %w", assignment.AssigneeName, repository.ErrNotFound)
+		}
+		tasks = append(tasks, models.Task{
+			ProjectID:  projectID,
+			AssignedTo: assignee,
+			Title:      assignment.Title,
+			Status:     models.StatusTodo,
+			Priority:   models.DefaultPriority,
+		})
+	}
+
+	for i := range tasks {
+		s.insertTask(&tasks[i])
+	}
+	return tasks, nil
+}
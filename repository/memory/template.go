@@ -0,0 +1,275 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type TemplateRepository struct {
+	store *Store
+}
+
+func NewTemplateRepository(store *Store) *TemplateRepository {
+	return &TemplateRepository{store: store}
+}
+
+// cloneTemplate copies the plan of a template so that the stored template
+// shares no memory with the caller's.
+func cloneTemplate(template models.ProjectTemplate) models.ProjectTemplate {
+	template.SourceProjectID = cloneInt(template.SourceProjectID)
+	template.Members = slices.Clone(template.Members)
+	template.Labels = slices.Clone(template.Labels)
+	template.Milestones = slices.Clone(template.Milestones)
+	template.Tasks = slices.Clone(template.Tasks)
+	for i, task := range template.Tasks {
+		task.ParentKey = cloneInt(task.ParentKey)
+		task.MilestoneKey = cloneInt(task.MilestoneKey)
+		task.EstimateHours = cloneFloat(task.EstimateHours)
+		task.StartOffset = cloneInt(task.StartOffset)
+		task.DueOffset = cloneInt(task.DueOffset)
+		task.LabelKeys = slices.Clone(task.LabelKeys)
+		template.Tasks[i] = task
+	}
+	return template
+}
+
+func (r *TemplateRepository) Create(ctx context.Context, template *models.ProjectTemplate) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id := template.SourceProjectID; id != nil {
+		if s.anyProject(*id).ID == 0 {
+			return constraintError(repository.ConstraintForeignKey, "project_templates", "source_project_id", "source_project_id refers to a row that does not exist")
+		}
+	}
+
+	s.nextTemplateID++
+	template.ID = s.nextTemplateID
+	template.Version = 1
+	template.CreatedAt = s.now()
+	template.UpdatedAt = template.CreatedAt
+	s.templates[template.ID] = cloneTemplate(*template)
+	return nil
+}
+
+func (r *TemplateRepository) GetByID(ctx context.Context, id int) (*models.ProjectTemplate, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	template, ok := s.templates[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	template = cloneTemplate(template)
+	return &template, nil
+}
+
+func (r *TemplateRepository) List(ctx context.Context, filter repository.TemplateFilter) (*repository.Page[models.ProjectTemplate], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []models.ProjectTemplate
+	for _, id := range sortedKeys(s.templates) {
+		template := s.templates[id]
+		if filter.SourceProjectID != 0 && (template.SourceProjectID == nil || *template.SourceProjectID != filter.SourceProjectID) {
+			continue
+		}
+		matched = append(matched, cloneTemplate(template))
+	}
+	return paginate(matched, filter.Page, repository.TemplateSorts, func(t models.ProjectTemplate) int { return t.ID }), nil
+}
+
+func (r *TemplateRepository) Update(ctx context.Context, template *models.ProjectTemplate) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.templates[template.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, template.Version); err != nil {
+		return err
+	}
+	updated := existing
+	updated.Name = template.Name
+	updated.Description = template.Description
+	updated.Version++
+	updated.UpdatedAt = s.now()
+	s.templates[template.ID] = updated
+	*template = cloneTemplate(updated)
+	return nil
+}
+
+func (r *TemplateRepository) Delete(ctx context.Context, id, version int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.templates[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return err
+	}
+	delete(s.templates, id)
+	return nil
+}
+
+func (r *TemplateRepository) Instantiate(ctx context.Context, template *models.ProjectTemplate, project *models.Project, start models.Date) (*models.ProjectInstance, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Check everything up front, as nothing may be written if any of it
+	// fails.
+	if err := s.checkProject(project); err != nil {
+		return nil, err
+	}
+	for _, employeeID := range template.Members {
+		if _, ok := s.employees[employeeID]; !ok {
+			return nil, constraintError(repository.ConstraintForeignKey, "employee_projects", "employee_id", "employee_id refers to a row that does not exist")
+		}
+	}
+	for _, t := range template.Tasks {
+		if _, ok := s.employees[t.AssignedTo]; !ok {
+			return nil, constraintError(repository.ConstraintForeignKey, "tasks", "assigned_to", "assigned_to refers to a row that does not exist")
+		}
+	}
+	for _, l := range template.Labels {
+		if l.Name == "" {
+			return nil, constraintError(repository.ConstraintCheck, "labels", "name", "name has an invalid value")
+		}
+		if !validColor(l.Color) {
+			return nil, constraintError(repository.ConstraintCheck, "labels", "color", "color has an invalid value")
+		}
+	}
+
+	s.nextProjectID++
+	project.ID = s.nextProjectID
+	project.Version = 1
+	project.CreatedAt = s.now()
+	project.UpdatedAt = project.CreatedAt
+	project.ArchivedAt = nil
+	project.DeletedAt = nil
+	project.Tasks = nil
+	s.projects[project.ID] = *project
+	instance := &models.ProjectInstance{
+		Project:    *project,
+		Labels:     []models.Label{},
+		Milestones: []models.Milestone{},
+		Tasks:      []models.Task{},
+	}
+
+	for _, employeeID := range template.Members {
+		s.memberships[membership{employeeID: employeeID, projectID: project.ID}] = s.now()
+	}
+
+	labels := s.instantiateLabels(template.Labels, project.ID, instance)
+
+	milestones := make(map[int]int)
+	for _, m := range template.Milestones {
+		milestone := m.Milestone(project.ID, start)
+		s.nextMilestoneID++
+		milestone.ID = s.nextMilestoneID
+		milestone.Version = 1
+		milestone.CreatedAt = s.now()
+		milestone.UpdatedAt = milestone.CreatedAt
+		s.milestones[milestone.ID] = milestone
+		milestones[m.Key] = milestone.ID
+		instance.Milestones = append(instance.Milestones, milestone)
+	}
+
+	tasks := make(map[int]int)
+	for _, t := range template.Tasks {
+		task := t.Task(project.ID, start)
+		task.ParentTaskID = mappedKey(tasks, t.ParentKey)
+		task.MilestoneID = mappedKey(milestones, t.MilestoneKey)
+		s.insertTask(&task)
+		tasks[t.Key] = task.ID
+
+		for _, key := range t.LabelKeys {
+			if labelID, ok := labels[key]; ok {
+				s.taskLabels[taskLabel{taskID: task.ID, labelID: labelID}] = s.now()
+			}
+		}
+		instance.Tasks = append(instance.Tasks, cloneTask(task))
+	}
+	return instance, nil
+}
+
+// instantiateLabels finds or creates the labels of a template for a new
+// project and adds them to instance. It returns the ID of the label each
+// template key became. The caller must hold the store lock.
+func (s *Store) instantiateLabels(labels []models.TemplateLabel, projectID int, instance *models.ProjectInstance) map[int]int {
+	keys := make(map[int]int)
+	byName := make(map[string]models.Label)
+	for _, l := range labels {
+		label, found := models.Label{}, false
+		if l.Global {
+			label, found = s.globalLabel(l.Name)
+		}
+		if !found {
+			// Labels of the project keep their names unique ignoring case,
+			// so a global label recreated here may meet one of the same
+			// name.
+			label, found = byName[strings.ToLower(l.Name)]
+		}
+		if !found {
+			label = models.Label{
+				ProjectID:   &projectID,
+				Name:        l.Name,
+				Color:       l.Color,
+				Description: l.Description,
+			}
+			s.nextLabelID++
+			label.ID = s.nextLabelID
+			label.Version = 1
+			label.CreatedAt = s.now()
+			label.UpdatedAt = label.CreatedAt
+			s.labels[label.ID] = cloneLabel(label)
+			byName[strings.ToLower(label.Name)] = label
+		}
+		if !slices.ContainsFunc(instance.Labels, func(other models.Label) bool { return other.ID == label.ID }) {
+			instance.Labels = append(instance.Labels, cloneLabel(label))
+		}
+		keys[l.Key] = label.ID
+
+		if l.Attached {
+			s.projectLabels[projectLabel{projectID: projectID, labelID: label.ID}] = s.now()
+		}
+	}
+	return keys
+}
+
+// globalLabel looks up a global label by name, ignoring case. The caller
+// must hold the store lock.
+func (s *Store) globalLabel(name string) (models.Label, bool) {
+	for _, label := range s.labels {
+		if label.ProjectID == nil && strings.EqualFold(label.Name, name) {
+			return cloneLabel(label), true
+		}
+	}
+	return models.Label{}, false
+}
+
+// mappedKey returns the ID a template key became, or nil if there is no key
+// or it refers to nothing that was created.
+func mappedKey(ids map[int]int, key *int) *int {
+	if key == nil {
+		return nil
+	}
+	id, ok := ids[*key]
+	if !ok {
+		return nil
+	}
+	return &id
+}
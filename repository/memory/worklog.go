@@ -0,0 +1,246 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type WorklogRepository struct {
+	store *Store
+}
+
+func NewWorklogRepository(store *Store) *WorklogRepository {
+	return &WorklogRepository{store: store}
+}
+
+// checkWorklog enforces the constraints the worklogs table declares and
+// rejects time the employee has already logged. The caller must hold the
+// store lock.
+func (s *Store) checkWorklog(worklog *models.Worklog) error {
+	if worklog.DurationSeconds != nil && *worklog.DurationSeconds < 0 {
+		return constraintError(repository.ConstraintCheck, "worklogs", "duration_seconds", "duration_seconds has an invalid value")
+	}
+	if s.anyTask(worklog.TaskID).ID == 0 {
+		return constraintError(repository.ConstraintForeignKey, "worklogs", "task_id", "task_id refers to a row that does not exist")
+	}
+	if s.anyEmployee(worklog.EmployeeID).ID == 0 {
+		return constraintError(repository.ConstraintForeignKey, "worklogs", "employee_id", "employee_id refers to a row that does not exist")
+	}
+	for id, other := range s.worklogs {
+		if id != worklog.ID && other.EmployeeID == worklog.EmployeeID && other.Overlaps(*worklog) {
+			return repository.ErrWorklogOverlap
+		}
+	}
+	return nil
+}
+
+func (s *Store) insertWorklog(worklog *models.Worklog) error {
+	if err := s.checkWorklog(worklog); err != nil {
+		return err
+	}
+
+	s.nextWorklogID++
+	worklog.ID = s.nextWorklogID
+	worklog.Version = 1
+	worklog.CreatedAt = s.now()
+	worklog.UpdatedAt = worklog.CreatedAt
+	s.worklogs[worklog.ID] = cloneWorklog(*worklog)
+	return nil
+}
+
+func (r *WorklogRepository) Create(ctx context.Context, worklog *models.Worklog) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.insertWorklog(worklog)
+}
+
+func (r *WorklogRepository) GetByID(ctx context.Context, id int) (*models.Worklog, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	worklog, ok := s.worklogs[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	worklog = cloneWorklog(worklog)
+	return &worklog, nil
+}
+
+func (r *WorklogRepository) filter(match func(models.Worklog) bool) []models.Worklog {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var worklogs []models.Worklog
+	for _, id := range sortedKeys(s.worklogs) {
+		if worklog := s.worklogs[id]; match(worklog) {
+			worklogs = append(worklogs, cloneWorklog(worklog))
+		}
+	}
+	return worklogs
+}
+
+// matchWorklog applies every condition of filter except Running and Page.
+// The caller must hold the store lock.
+func (s *Store) matchWorklog(worklog models.Worklog, filter repository.WorklogFilter) bool {
+	switch {
+	case filter.TaskID != 0 && worklog.TaskID != filter.TaskID,
+		filter.EmployeeID != 0 && worklog.EmployeeID != filter.EmployeeID,
+		filter.ProjectID != 0 && s.anyTask(worklog.TaskID).ProjectID != filter.ProjectID,
+		filter.From != nil && worklog.StartedAt.Before(*filter.From),
+		filter.To != nil && !worklog.StartedAt.Before(*filter.To):
+		return false
+	}
+	return true
+}
+
+func (r *WorklogRepository) List(ctx context.Context, filter repository.WorklogFilter) (*repository.Page[models.Worklog], error) {
+	worklogs := r.filter(func(worklog models.Worklog) bool {
+		if filter.Running != nil && worklog.Running() != *filter.Running {
+			return false
+		}
+		return r.store.matchWorklog(worklog, filter)
+	})
+	return paginate(worklogs, filter.Page, repository.WorklogSorts, func(w models.Worklog) int { return w.ID }), nil
+}
+
+func (r *WorklogRepository) Update(ctx context.Context, worklog *models.Worklog) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.worklogs[worklog.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, worklog.Version); err != nil {
+		return err
+	}
+	existing.StartedAt = worklog.StartedAt
+	existing.DurationSeconds = cloneInt(worklog.DurationSeconds)
+	existing.Note = worklog.Note
+	if err := s.checkWorklog(&existing); err != nil {
+		return err
+	}
+
+	existing.Version++
+	existing.UpdatedAt = s.now()
+	s.worklogs[worklog.ID] = cloneWorklog(existing)
+	*worklog = existing
+	return nil
+}
+
+func (r *WorklogRepository) Delete(ctx context.Context, id, version int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.worklogs[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return err
+	}
+	delete(s.worklogs, id)
+	return nil
+}
+
+func (r *WorklogRepository) Start(ctx context.Context, worklog *models.Worklog) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	worklog.StartedAt = s.now()
+	worklog.DurationSeconds = nil
+	return s.insertWorklog(worklog)
+}
+
+// running returns the ID of the employee's running timer, or zero. The
+// caller must hold the store lock.
+func (s *Store) running(employeeID int) int {
+	for id, worklog := range s.worklogs {
+		if worklog.EmployeeID == employeeID && worklog.Running() {
+			return id
+		}
+	}
+	return 0
+}
+
+func (r *WorklogRepository) Stop(ctx context.Context, employeeID int) (*models.Worklog, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.running(employeeID)
+	if id == 0 {
+		return nil, repository.ErrNotFound
+	}
+	worklog := s.worklogs[id]
+	now := s.now()
+	seconds := max(0, int(now.Sub(worklog.StartedAt)/time.Second))
+	worklog.DurationSeconds = &seconds
+	worklog.Version++
+	worklog.UpdatedAt = now
+	s.worklogs[id] = cloneWorklog(worklog)
+	return &worklog, nil
+}
+
+func (r *WorklogRepository) Running(ctx context.Context, employeeID int) (*models.Worklog, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id := s.running(employeeID)
+	if id == 0 {
+		return nil, repository.ErrNotFound
+	}
+	worklog := cloneWorklog(s.worklogs[id])
+	return &worklog, nil
+}
+
+func (r *WorklogRepository) Report(ctx context.Context, group models.WorklogGroup, filter repository.WorklogFilter) ([]models.WorklogTotal, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	totals := make(map[int]models.WorklogTotal)
+	for _, worklog := range s.worklogs {
+		if worklog.Running() || !s.matchWorklog(worklog, filter) {
+			continue
+		}
+		task := s.anyTask(worklog.TaskID)
+		var total models.WorklogTotal
+		switch group {
+		case models.GroupByEmployee:
+			total = totals[worklog.EmployeeID]
+			total.ID, total.Name = worklog.EmployeeID, s.anyEmployee(worklog.EmployeeID).Name
+		case models.GroupByTask:
+			total = totals[task.ID]
+			total.ID, total.Name = task.ID, task.Title
+		case models.GroupByProject:
+			total = totals[task.ProjectID]
+			total.ID, total.Name = task.ProjectID, s.anyProject(task.ProjectID).Name
+		default:
+			return nil, fmt.Errorf("unknown worklog group %q", group)
+		}
+		total.Entries++
+		total.Seconds += *worklog.DurationSeconds
+		totals[total.ID] = total
+	}
+
+	report := []models.WorklogTotal{}
+	for _, id := range sortedKeys(totals) {
+		total := totals[id]
+		total.Hours = models.SecondsToHours(total.Seconds)
+		report = append(report, total)
+	}
+	return report, nil
+}
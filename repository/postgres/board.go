@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+// boardLock serialises rank changes within a project, so that two moves
+// into the same gap cannot pick the same rank.
+const boardLock = 727_151_004
+
+func (r *TaskRepository) Board(ctx context.Context, projectID int) ([]models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE project_id = $1 AND deleted_at IS NULL ORDER BY status, rank, id`
+
+	rows, err := r.db.Query(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return collectTasks(rows)
+}
+
+func (r *TaskRepository) Move(ctx context.Context, transition *models.TaskTransition, position repository.TaskPosition, version int) (*models.Task, error) {
+	return r.move(ctx, transition, position, version, transition.From != transition.To)
+}
+
+// move re-ranks a task and changes its status in one transaction, recording
+// the transition if record is set.
+func (r *TaskRepository) move(ctx context.Context, transition *models.TaskTransition, position repository.TaskPosition, version int, record bool) (*models.Task, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var projectID int
+	query := `SELECT project_id FROM tasks WHERE id = $1 AND status = $2 AND ($3 = 0 OR version = $3) AND deleted_at IS NULL`
+	err = tx.QueryRow(ctx, query, transition.TaskID, transition.From, version).Scan(&projectID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, r.missingOrMoved(ctx, transition.TaskID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, boardLock, projectID); err != nil {
+		return nil, err
+	}
+	rank, err := boardRank(ctx, tx, projectID, transition.TaskID, transition.To, position)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+        UPDATE tasks
+        SET status = $1, rank = $2, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $3 AND project_id = $4 AND status = $5 AND ($6 = 0 OR version = $6)
+        RETURNING ` + taskColumns
+
+	var task models.Task
+	err = scanTask(tx.QueryRow(ctx, query,
+		transition.To,
+		rank,
+		transition.TaskID,
+		projectID,
+		transition.From,
+		version,
+	), &task)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, r.missingOrMoved(ctx, transition.TaskID)
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if record {
+		query = `
+            INSERT INTO task_transitions (task_id, from_status, to_status, actor_id, note)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING ` + transitionColumns
+
+		err = scanTransition(tx.QueryRow(ctx, query,
+			transition.TaskID,
+			transition.From,
+			transition.To,
+			transition.ActorID,
+			transition.Note,
+		), transition)
+		if err != nil {
+			return nil, translateError(err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// missingOrMoved explains why a task could not be moved: it is gone, or it
+// has changed since the caller read it.
+func (r *TaskRepository) missingOrMoved(ctx context.Context, id int) error {
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return repository.ErrVersionConflict
+}
+
+// boardRank returns the rank that puts taskID at position in the project's
+// column for status, first giving the column fresh ranks if there is no
+// room left at that spot. The caller must hold the board lock.
+func boardRank(ctx context.Context, tx pgx.Tx, projectID, taskID int, status models.TaskStatus, position repository.TaskPosition) (string, error) {
+	var before, after string
+	var err error
+	switch {
+	case position.AfterID != 0:
+		query := `
+            SELECT t.rank, COALESCE((
+                SELECT n.rank FROM tasks n
+                WHERE n.project_id = t.project_id AND n.status = t.status AND n.id <> $4 AND n.deleted_at IS NULL
+                    AND (n.rank, n.id) > (t.rank, t.id)
+                ORDER BY n.rank, n.id
+                LIMIT 1), '')
+            FROM tasks t
+            WHERE t.id = $1 AND t.project_id = $2 AND t.status = $3 AND t.id <> $4 AND t.deleted_at IS NULL`
+		err = tx.QueryRow(ctx, query, position.AfterID, projectID, status, taskID).Scan(&before, &after)
+	case position.BeforeID != 0:
+		query := `
+            SELECT t.rank, COALESCE((
+                SELECT n.rank FROM tasks n
+                WHERE n.project_id = t.project_id AND n.status = t.status AND n.id <> $4 AND n.deleted_at IS NULL
+                    AND (n.rank, n.id) < (t.rank, t.id)
+                ORDER BY n.rank DESC, n.id DESC
+                LIMIT 1), '')
+            FROM tasks t
+            WHERE t.id = $1 AND t.project_id = $2 AND t.status = $3 AND t.id <> $4 AND t.deleted_at IS NULL`
+		err = tx.QueryRow(ctx, query, position.BeforeID, projectID, status, taskID).Scan(&after, &before)
+	default:
+		query := `SELECT COALESCE(max(rank), '') FROM tasks WHERE project_id = $1 AND status = $2 AND id <> $3 AND deleted_at IS NULL`
+		err = tx.QueryRow(ctx, query, projectID, status, taskID).Scan(&before)
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", repository.ErrVersionConflict
+	}
+	if err != nil {
+		return "", err
+	}
+
+	rank, err := models.RankBetween(before, after)
+	if err == nil && len(rank) <= models.MaxRankLength {
+		return rank, nil
+	}
+	return rerankColumn(ctx, tx, projectID, taskID, status, position)
+}
+
+// rerankColumn spreads the ranks of a column evenly, leaving room for
+// taskID at position, and returns the rank for taskID. The other tasks keep
+// their version, since their order does not change.
+func rerankColumn(ctx context.Context, tx pgx.Tx, projectID, taskID int, status models.TaskStatus, position repository.TaskPosition) (string, error) {
+	query := `SELECT id FROM tasks WHERE project_id = $1 AND status = $2 AND id <> $3 AND deleted_at IS NULL ORDER BY rank, id`
+
+	rows, err := tx.Query(ctx, query, projectID, status, taskID)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return "", err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	at := len(ids)
+	for i, id := range ids {
+		switch id {
+		case position.AfterID:
+			at = i + 1
+		case position.BeforeID:
+			at = i
+		}
+	}
+	ids = slices.Insert(ids, at, taskID)
+
+	ranks := models.RankSequence(len(ids))
+	for i, id := range ids {
+		if id == taskID {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `UPDATE tasks SET rank = $1 WHERE id = $2`, ranks[i], id); err != nil {
+			return "", err
+		}
+	}
+	return ranks[at], nil
+}
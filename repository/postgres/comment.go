@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type CommentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCommentRepository(db *pgxpool.Pool) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+const commentColumns = `id, task_id, author_id, body,
+    ARRAY(SELECT employee_id FROM comment_mentions m WHERE m.comment_id = task_comments.id ORDER BY employee_id),
+    version, created_at, updated_at`
+
+func scanComment(row pgx.Row, comment *models.Comment) error {
+	return row.Scan(
+		&comment.ID,
+		&comment.TaskID,
+		&comment.AuthorID,
+		&comment.Body,
+		&comment.Mentions,
+		&comment.Version,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+	)
+}
+
+// writeMentions replaces the mentions of a comment.
+func writeMentions(ctx context.Context, tx pgx.Tx, commentID int, mentions []int) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM comment_mentions WHERE comment_id = $1`, commentID); err != nil {
+		return err
+	}
+	query := `
+        INSERT INTO comment_mentions (comment_id, employee_id)
+        SELECT $1, unnest($2::int[])
+        ON CONFLICT DO NOTHING`
+
+	_, err := tx.Exec(ctx, query, commentID, mentions)
+	return translateError(err)
+}
+
+func (r *CommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+        INSERT INTO task_comments (task_id, author_id, body)
+        VALUES ($1, $2, $3)
+        RETURNING id, version, created_at, updated_at`
+
+	err = tx.QueryRow(ctx, query, comment.TaskID, comment.AuthorID, comment.Body).
+		Scan(&comment.ID, &comment.Version, &comment.CreatedAt, &comment.UpdatedAt)
+	if err != nil {
+		return translateError(err)
+	}
+	if err := writeMentions(ctx, tx, comment.ID, comment.Mentions); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *CommentRepository) GetByID(ctx context.Context, id int) (*models.Comment, error) {
+	query := `SELECT ` + commentColumns + ` FROM task_comments WHERE id = $1`
+
+	var comment models.Comment
+	err := scanComment(r.db.QueryRow(ctx, query, id), &comment)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *CommentRepository) List(ctx context.Context, filter repository.CommentFilter) (*repository.Page[models.Comment], error) {
+	var where filterBuilder
+	where.add("task_id = ?", filter.TaskID)
+	if filter.AuthorID != 0 {
+		where.add("author_id = ?", filter.AuthorID)
+	}
+	if filter.MentionedID != 0 {
+		where.add("EXISTS (SELECT 1 FROM comment_mentions m WHERE m.comment_id = task_comments.id AND m.employee_id = ?)", filter.MentionedID)
+	}
+
+	return listPage(ctx, r.db, "task_comments", commentColumns, &where, filter.Page,
+		repository.CommentSorts, scanComment, func(c models.Comment) int { return c.ID })
+}
+
+func (r *CommentRepository) ListByTask(ctx context.Context, taskID int) ([]models.Comment, error) {
+	query := `SELECT ` + commentColumns + ` FROM task_comments WHERE task_id = $1 ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		var comment models.Comment
+		if err := scanComment(rows, &comment); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+func (r *CommentRepository) Update(ctx context.Context, comment *models.Comment) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+        UPDATE task_comments
+        SET body = $1, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $2 AND ($3 = 0 OR version = $3)
+        RETURNING task_id, author_id, version, created_at, updated_at`
+
+	err = tx.QueryRow(ctx, query, comment.Body, comment.ID, comment.Version).
+		Scan(&comment.TaskID, &comment.AuthorID, &comment.Version, &comment.CreatedAt, &comment.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, r.db, "task_comments", comment.ID, comment.Version)
+	}
+	if err != nil {
+		return translateError(err)
+	}
+	if err := writeMentions(ctx, tx, comment.ID, comment.Mentions); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *CommentRepository) Delete(ctx context.Context, id, version int) error {
+	query := `DELETE FROM task_comments WHERE id = $1 AND ($2 = 0 OR version = $2)`
+
+	result, err := r.db.Exec(ctx, query, id, version)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return missingOrConflict(ctx, r.db, "task_comments", id, version)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+// dependencyLock serialises dependency inserts so that two concurrent
+// inserts cannot together close a cycle that neither sees alone.
+const dependencyLock = 727_151_002
+
+func (r *TaskRepository) AddDependency(ctx context.Context, taskID, dependsOnID int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, dependencyLock); err != nil {
+		return err
+	}
+
+	// A cycle forms if taskID is already upstream of dependsOnID.
+	query := `
+        WITH RECURSIVE upstream(id) AS (
+            SELECT depends_on_id FROM task_dependencies WHERE task_id = $1
+            UNION
+            SELECT d.depends_on_id
+            FROM task_dependencies d
+            JOIN upstream u ON d.task_id = u.id
+        )
+        SELECT $1 = $2 OR EXISTS (SELECT 1 FROM upstream WHERE id = $2)`
+
+	var cycle bool
+	if err := tx.QueryRow(ctx, query, dependsOnID, taskID).Scan(&cycle); err != nil {
+		return err
+	}
+	if cycle {
+		return repository.ErrDependencyCycle
+	}
+
+	query = `
+        INSERT INTO task_dependencies (task_id, depends_on_id)
+        VALUES ($1, $2)
+        ON CONFLICT (task_id, depends_on_id) DO NOTHING`
+
+	if _, err := tx.Exec(ctx, query, taskID, dependsOnID); err != nil {
+		return translateError(err)
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *TaskRepository) RemoveDependency(ctx context.Context, taskID, dependsOnID int) error {
+	query := `
+        DELETE FROM task_dependencies
+        WHERE task_id = $1 AND depends_on_id = $2`
+
+	result, err := r.db.Exec(ctx, query, taskID, dependsOnID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *TaskRepository) ListDependencies(ctx context.Context, taskID int) ([]models.Task, error) {
+	query := `
+        SELECT ` + prefixColumns("t", taskColumns) + `
+        FROM tasks t
+        JOIN task_dependencies d ON t.id = d.depends_on_id
+        WHERE d.task_id = $1 AND t.deleted_at IS NULL
+        ORDER BY t.id`
+
+	rows, err := r.db.Query(ctx, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return collectTasks(rows)
+}
+
+func (r *TaskRepository) ListDependents(ctx context.Context, taskID int) ([]models.Task, error) {
+	query := `
+        SELECT ` + prefixColumns("t", taskColumns) + `
+        FROM tasks t
+        JOIN task_dependencies d ON t.id = d.task_id
+        WHERE d.depends_on_id = $1 AND t.deleted_at IS NULL
+        ORDER BY t.id`
+
+	rows, err := r.db.Query(ctx, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return collectTasks(rows)
+}
+
+func (r *TaskRepository) ProjectDependencies(ctx context.Context, projectID int) ([]models.TaskDependency, error) {
+	query := `
+        SELECT d.task_id, d.depends_on_id, d.created_at
+        FROM task_dependencies d
+        JOIN tasks t ON t.id = d.task_id
+        JOIN tasks dt ON dt.id = d.depends_on_id
+        WHERE t.project_id = $1 AND t.deleted_at IS NULL AND dt.deleted_at IS NULL
+        ORDER BY d.task_id, d.depends_on_id`
+
+	rows, err := r.db.Query(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dependencies []models.TaskDependency
+	for rows.Next() {
+		var d models.TaskDependency
+		if err := rows.Scan(&d.TaskID, &d.DependsOnID, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, d)
+	}
+	return dependencies, rows.Err()
+}
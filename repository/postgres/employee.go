@@ -0,0 +1,294 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type EmployeeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewEmployeeRepository(db *pgxpool.Pool) *EmployeeRepository {
+	return &EmployeeRepository{db: db}
+}
+
+const employeeColumns = `id, name, email, role, skills, version, created_at, updated_at, deleted_at`
+
+func scanEmployee(row pgx.Row, employee *models.Employee) error {
+	return row.Scan(
+		&employee.ID,
+		&employee.Name,
+		&employee.Email,
+		&employee.Role,
+		&employee.Skills,
+		&employee.Version,
+		&employee.CreatedAt,
+		&employee.UpdatedAt,
+		&employee.DeletedAt,
+	)
+}
+
+func collectEmployees(rows pgx.Rows) ([]models.Employee, error) {
+	defer rows.Close()
+
+	var employees []models.Employee
+	for rows.Next() {
+		var employee models.Employee
+		if err := scanEmployee(rows, &employee); err != nil {
+			return nil, err
+		}
+		employees = append(employees, employee)
+	}
+	return employees, rows.Err()
+}
+
+func (r *EmployeeRepository) Create(ctx context.Context, employee *models.Employee) error {
+	query := `
+        INSERT INTO employees (name, email, role, skills)
+        VALUES ($1, $2, $3, $4)
+        RETURNING ` + employeeColumns
+
+	err := scanEmployee(r.db.QueryRow(ctx, query,
+		employee.Name,
+		employee.Email,
+		employee.Role,
+		employee.Skills,
+	), employee)
+	return translateError(err)
+}
+
+func (r *EmployeeRepository) GetByID(ctx context.Context, id int) (*models.Employee, error) {
+	query := `SELECT ` + employeeColumns + ` FROM employees WHERE id = $1 AND deleted_at IS NULL`
+
+	var employee models.Employee
+	err := scanEmployee(r.db.QueryRow(ctx, query, id), &employee)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &employee, nil
+}
+
+func (r *EmployeeRepository) List(ctx context.Context, filter repository.EmployeeFilter) (*repository.Page[models.Employee], error) {
+	var where filterBuilder
+	if !filter.IncludeDeleted {
+		where.add("deleted_at IS NULL")
+	}
+	if filter.Role != "" {
+		where.add("role = ?", filter.Role)
+	}
+	if filter.Skill != "" {
+		where.add("? = ANY(skills)", filter.Skill)
+	}
+
+	return listPage(ctx, r.db, "employees", employeeColumns, &where, filter.Page,
+		repository.EmployeeSorts, scanEmployee, func(e models.Employee) int { return e.ID })
+}
+
+func (r *EmployeeRepository) Update(ctx context.Context, employee *models.Employee) error {
+	query := `
+        UPDATE employees
+        SET name = $1, email = $2, role = $3, skills = $4,
+            version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $5 AND ($6 = 0 OR version = $6) AND deleted_at IS NULL
+        RETURNING ` + employeeColumns
+
+	err := scanEmployee(r.db.QueryRow(ctx, query,
+		employee.Name,
+		employee.Email,
+		employee.Role,
+		employee.Skills,
+		employee.ID,
+		employee.Version,
+	), employee)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, r.db, "employees", employee.ID, employee.Version)
+	}
+	return translateError(err)
+}
+
+var employeePatchColumns = map[string]func(*models.Employee) (string, any){
+	"name":   func(e *models.Employee) (string, any) { return "name", e.Name },
+	"email":  func(e *models.Employee) (string, any) { return "email", e.Email },
+	"role":   func(e *models.Employee) (string, any) { return "role", e.Role },
+	"skills": func(e *models.Employee) (string, any) { return "skills", e.Skills },
+}
+
+func (r *EmployeeRepository) Patch(ctx context.Context, employee *models.Employee, fields []string) error {
+	query, args, err := patchQuery("employees", employeeColumns, employee.ID, employee.Version, employee, fields, employeePatchColumns)
+	if err != nil {
+		return err
+	}
+
+	err = scanEmployee(r.db.QueryRow(ctx, query, args...), employee)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, r.db, "employees", employee.ID, employee.Version)
+	}
+	return translateError(err)
+}
+
+func (r *EmployeeRepository) Delete(ctx context.Context, id, version int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the employee so that nothing can be assigned to them while the
+	// references are checked.
+	query := `SELECT id FROM employees WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2) FOR UPDATE`
+	err = tx.QueryRow(ctx, query, id, version).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, r.db, "employees", id, version)
+	}
+	if err != nil {
+		return err
+	}
+
+	var table, field string
+	query = `
+        SELECT t, f FROM (VALUES
+            ('projects', 'lead_id', EXISTS (
+                SELECT 1 FROM projects WHERE lead_id = $1 AND deleted_at IS NULL)),
+            ('tasks', 'assigned_to', EXISTS (
+                SELECT 1 FROM tasks
+                WHERE assigned_to = $1 AND deleted_at IS NULL AND status NOT IN ('DONE', 'CANCELLED'))),
+            ('recurring_tasks', 'assigned_to', EXISTS (
+                SELECT 1 FROM recurring_tasks WHERE assigned_to = $1))
+        ) AS refs(t, f, referenced)
+        WHERE referenced
+        LIMIT 1`
+	err = tx.QueryRow(ctx, query, id).Scan(&table, &field)
+	if err == nil {
+		return &repository.ConstraintError{
+			Kind:    repository.ConstraintInUse,
+			Table:   table,
+			Field:   field,
+			Message: "still referenced by " + table,
+		}
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	query = `
+        UPDATE employees
+        SET deleted_at = CURRENT_TIMESTAMP, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *EmployeeRepository) GetDeleted(ctx context.Context, id int) (*models.Employee, error) {
+	query := `SELECT ` + employeeColumns + ` FROM employees WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	var employee models.Employee
+	err := scanEmployee(r.db.QueryRow(ctx, query, id), &employee)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &employee, nil
+}
+
+func (r *EmployeeRepository) Restore(ctx context.Context, id, version int) (*models.Employee, error) {
+	query := `
+        UPDATE employees
+        SET deleted_at = NULL, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND ($2 = 0 OR version = $2) AND deleted_at IS NOT NULL
+        RETURNING ` + employeeColumns
+
+	var employee models.Employee
+	err := scanEmployee(r.db.QueryRow(ctx, query, id, version), &employee)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, missingOrRestored(ctx, r.db, "employees", id, version)
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &employee, nil
+}
+
+func (r *EmployeeRepository) Purge(ctx context.Context, cutoff time.Time) (int, error) {
+	query := `
+        DELETE FROM employees e
+        WHERE e.deleted_at < $1
+            AND NOT EXISTS (SELECT 1 FROM projects WHERE lead_id = e.id)
+            AND NOT EXISTS (SELECT 1 FROM tasks WHERE assigned_to = e.id)
+            AND NOT EXISTS (SELECT 1 FROM worklogs WHERE employee_id = e.id)
+            AND NOT EXISTS (SELECT 1 FROM recurring_tasks WHERE assigned_to = e.id)`
+
+	result, err := r.db.Exec(ctx, query, cutoff)
+	if err != nil {
+		return 0, translateDeleteError(err)
+	}
+	return int(result.RowsAffected()), nil
+}
+
+func (r *EmployeeRepository) ListByProject(ctx context.Context, projectID int) ([]models.Employee, error) {
+	query := `
+        SELECT ` + prefixColumns("e", employeeColumns) + `
+        FROM employees e
+        JOIN employee_projects ep ON e.id = ep.employee_id
+        WHERE ep.project_id = $1 AND e.deleted_at IS NULL
+        ORDER BY e.id`
+
+	rows, err := r.db.Query(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return collectEmployees(rows)
+}
+
+func (r *EmployeeRepository) AssignToProject(ctx context.Context, employeeID, projectID int) error {
+	query := `
+        INSERT INTO employee_projects (employee_id, project_id)
+        VALUES ($1, $2)
+        ON CONFLICT (employee_id, project_id) DO NOTHING`
+
+	_, err := r.db.Exec(ctx, query, employeeID, projectID)
+	return translateError(err)
+}
+
+func (r *EmployeeRepository) RemoveFromProject(ctx context.Context, employeeID, projectID int) error {
+	query := `
+        DELETE FROM employee_projects
+        WHERE employee_id = $1 AND project_id = $2`
+
+	result, err := r.db.Exec(ctx, query, employeeID, projectID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *EmployeeRepository) ListByHandles(ctx context.Context, handles []string) ([]models.Employee, error) {
+	query := `
+        SELECT ` + employeeColumns + `
+        FROM employees
+        WHERE (lower(email) = ANY($1) OR split_part(lower(email), '@', 1) = ANY($1))
+            AND deleted_at IS NULL
+        ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, handles)
+	if err != nil {
+		return nil, err
+	}
+	return collectEmployees(rows)
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"nstorm.com/main-backend/repository"
+)
+
+// translateError converts constraint violations reported by Postgres into
+// *repository.ConstraintError so that callers never see driver errors.
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	constraint := &repository.ConstraintError{
+		Table:      pgErr.TableName,
+		Constraint: pgErr.ConstraintName,
+		Field:      constraintField(pgErr),
+	}
+	switch pgErr.Code {
+	case "23505":
+		constraint.Kind = repository.ConstraintUnique
+		constraint.Message = fmt.Sprintf("%s already exists", constraint.Field)
+	case "23503":
+		constraint.Kind = repository.ConstraintForeignKey
+		constraint.Message = fmt.Sprintf("%s refers to a row that does not exist", constraint.Field)
+	case "23514":
+		constraint.Kind = repository.ConstraintCheck
+		constraint.Message = fmt.Sprintf("%s has an invalid value", constraint.Field)
+	case "23502":
+		constraint.Kind = repository.ConstraintNotNull
+		constraint.Message = fmt.Sprintf("%s is required", constraint.Field)
+	default:
+		return err
+	}
+	return constraint
+}
+
+// translateDeleteError is translateError for deletes, where a foreign key
+// violation means the row is still referenced rather than that it refers
+// to something missing.
+func translateDeleteError(err error) error {
+	err = translateError(err)
+	var constraint *repository.ConstraintError
+	if errors.As(err, &constraint) && constraint.Kind == repository.ConstraintForeignKey {
+		constraint.Kind = repository.ConstraintInUse
+		constraint.Message = fmt.Sprintf("still referenced by %s", constraint.Table)
+	}
+	return err
+}
+
+// constraintField recovers the column from Postgres' default constraint
+// names, <table>_<column>_<suffix>.
+func constraintField(pgErr *pgconn.PgError) string {
+	if pgErr.ColumnName != "" {
+		return pgErr.ColumnName
+	}
+	name := strings.TrimPrefix(pgErr.ConstraintName, pgErr.TableName+"_")
+	for _, suffix := range []string{"_key", "_fkey", "_check", "_pkey"} {
+		if trimmed, ok := strings.CutSuffix(name, suffix); ok {
+			return trimmed
+		}
+	}
+	return name
+}
+
+// softDeleted lists the tables whose rows are soft-deleted. Their deleted
+// rows are never written to, so to a write they are missing.
+var softDeleted = map[string]bool{"employees": true, "projects": true, "tasks": true}
+
+// liveRow is the extra condition that leaves out the soft-deleted rows of
+// table, if it has any.
+func liveRow(table string) string {
+	if softDeleted[table] {
+		return " AND deleted_at IS NULL"
+	}
+	return ""
+}
+
+// missingOrConflict explains why a conditional write to table matched no
+// row: either the row does not exist or it has moved past version.
+func missingOrConflict(ctx context.Context, db *pgxpool.Pool, table string, id, version int) error {
+	if version == 0 {
+		return repository.ErrNotFound
+	}
+	var exists bool
+	err := db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id = $1`+liveRow(table)+`)`, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return repository.ErrVersionConflict
+	}
+	return repository.ErrNotFound
+}
+
+// missingOrRestored is missingOrConflict for restores, which only match
+// soft-deleted rows.
+func missingOrRestored(ctx context.Context, db *pgxpool.Pool, table string, id, version int) error {
+	if version == 0 {
+		return repository.ErrNotFound
+	}
+	var exists bool
+	err := db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id = $1 AND deleted_at IS NOT NULL)`, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return repository.ErrVersionConflict
+	}
+	return repository.ErrNotFound
+}
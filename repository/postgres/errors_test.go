@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"nstorm.com/main-backend/repository"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name        string
+		pgErr       pgconn.PgError
+		wantKind    repository.ConstraintKind
+		wantField   string
+		wantMessage string
+	}{
+		{
+			name:        "unique",
+			pgErr:       pgconn.PgError{Code: "23505", TableName: "employees", ConstraintName: "employees_email_key"},
+			wantKind:    repository.ConstraintUnique,
+			wantField:   "email",
+			wantMessage: "email already exists",
+		},
+		{
+			name:        "foreign key",
+			pgErr:       pgconn.PgError{Code: "23503", TableName: "tasks", ConstraintName: "tasks_project_id_fkey"},
+			wantKind:    repository.ConstraintForeignKey,
+			wantField:   "project_id",
+			wantMessage: "project_id refers to a row that does not exist",
+		},
+		{
+			name:        "check",
+			pgErr:       pgconn.PgError{Code: "23514", TableName: "employees", ConstraintName: "employees_role_check"},
+			wantKind:    repository.ConstraintCheck,
+			wantField:   "role",
+			wantMessage: "role has an invalid value",
+		},
+		{
+			name:        "not null names the column",
+			pgErr:       pgconn.PgError{Code: "23502", TableName: "tasks", ColumnName: "title"},
+			wantKind:    repository.ConstraintNotNull,
+			wantField:   "title",
+			wantMessage: "title is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pgErr := tt.pgErr
+			err := translateError(fmt.Errorf("exec: %w", &pgErr))
+			var constraint *repository.ConstraintError
+			if !errors.As(err, &constraint) {
+				t.Fatalf("got %v, want a ConstraintError", err)
+			}
+			if constraint.Kind != tt.wantKind || constraint.Field != tt.wantField || constraint.Message != tt.wantMessage {
+				t.Fatalf("got %+v", constraint)
+			}
+		})
+	}
+
+	other := &pgconn.PgError{Code: "42P01"}
+	if err := translateError(other); err != other {
+		t.Fatalf("unrelated Postgres error became %v", err)
+	}
+	plain := errors.New("connection reset")
+	if err := translateError(plain); err != plain {
+		t.Fatalf("non-Postgres error became %v", err)
+	}
+}
+
+func TestTranslateDeleteError(t *testing.T) {
+	err := translateDeleteError(&pgconn.PgError{Code: "23503", TableName: "projects", ConstraintName: "projects_lead_id_fkey"})
+	var constraint *repository.ConstraintError
+	if !errors.As(err, &constraint) {
+		t.Fatalf("got %v, want a ConstraintError", err)
+	}
+	if constraint.Kind != repository.ConstraintInUse || constraint.Message != "still referenced by projects" {
+		t.Fatalf("got %+v", constraint)
+	}
+}
@@ -0,0 +1,237 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type LabelRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLabelRepository(db *pgxpool.Pool) *LabelRepository {
+	return &LabelRepository{db: db}
+}
+
+const labelColumns = `id, project_id, name, color, description, version, created_at, updated_at`
+
+func scanLabel(row pgx.Row, label *models.Label) error {
+	return row.Scan(
+		&label.ID,
+		&label.ProjectID,
+		&label.Name,
+		&label.Color,
+		&label.Description,
+		&label.Version,
+		&label.CreatedAt,
+		&label.UpdatedAt,
+	)
+}
+
+func collectLabels(rows pgx.Rows) ([]models.Label, error) {
+	defer rows.Close()
+
+	var labels []models.Label
+	for rows.Next() {
+		var label models.Label
+		if err := scanLabel(rows, &label); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
+
+func (r *LabelRepository) Create(ctx context.Context, label *models.Label) error {
+	query := `
+        INSERT INTO labels (project_id, name, color, description)
+        VALUES ($1, $2, $3, $4)
+        RETURNING ` + labelColumns
+
+	err := scanLabel(r.db.QueryRow(ctx, query,
+		label.ProjectID,
+		label.Name,
+		label.Color,
+		label.Description,
+	), label)
+	return translateError(err)
+}
+
+func (r *LabelRepository) GetByID(ctx context.Context, id int) (*models.Label, error) {
+	query := `SELECT ` + labelColumns + ` FROM labels WHERE id = $1`
+
+	var label models.Label
+	err := scanLabel(r.db.QueryRow(ctx, query, id), &label)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &label, nil
+}
+
+func (r *LabelRepository) List(ctx context.Context, filter repository.LabelFilter) (*repository.Page[models.Label], error) {
+	var where filterBuilder
+	if filter.ProjectID != 0 {
+		where.add("(project_id IS NULL OR project_id = ?)", filter.ProjectID)
+	}
+	if filter.GlobalOnly {
+		where.add("project_id IS NULL")
+	}
+
+	return listPage(ctx, r.db, "labels", labelColumns, &where, filter.Page,
+		repository.LabelSorts, scanLabel, func(l models.Label) int { return l.ID })
+}
+
+func (r *LabelRepository) Update(ctx context.Context, label *models.Label) error {
+	query := `
+        UPDATE labels
+        SET name = $1, color = $2, description = $3,
+            version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $4 AND ($5 = 0 OR version = $5)
+        RETURNING ` + labelColumns
+
+	err := scanLabel(r.db.QueryRow(ctx, query,
+		label.Name,
+		label.Color,
+		label.Description,
+		label.ID,
+		label.Version,
+	), label)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, r.db, "labels", label.ID, label.Version)
+	}
+	return translateError(err)
+}
+
+func (r *LabelRepository) Delete(ctx context.Context, id, version int) error {
+	query := `DELETE FROM labels WHERE id = $1 AND ($2 = 0 OR version = $2)`
+
+	result, err := r.db.Exec(ctx, query, id, version)
+	if err != nil {
+		return translateDeleteError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return missingOrConflict(ctx, r.db, "labels", id, version)
+	}
+	return nil
+}
+
+func (r *LabelRepository) AttachToTask(ctx context.Context, taskID, labelID int) error {
+	query := `
+        INSERT INTO task_labels (task_id, label_id)
+        VALUES ($1, $2)
+        ON CONFLICT (task_id, label_id) DO NOTHING`
+
+	_, err := r.db.Exec(ctx, query, taskID, labelID)
+	return translateError(err)
+}
+
+func (r *LabelRepository) DetachFromTask(ctx context.Context, taskID, labelID int) error {
+	query := `DELETE FROM task_labels WHERE task_id = $1 AND label_id = $2`
+
+	result, err := r.db.Exec(ctx, query, taskID, labelID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *LabelRepository) TaskLabels(ctx context.Context, taskID int) ([]models.Label, error) {
+	query := `
+        SELECT ` + prefixColumns("l", labelColumns) + `
+        FROM labels l
+        JOIN task_labels tl ON tl.label_id = l.id
+        WHERE tl.task_id = $1
+        ORDER BY l.id`
+
+	rows, err := r.db.Query(ctx, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return collectLabels(rows)
+}
+
+func (r *LabelRepository) AttachToProject(ctx context.Context, projectID, labelID int) error {
+	query := `
+        INSERT INTO project_labels (project_id, label_id)
+        VALUES ($1, $2)
+        ON CONFLICT (project_id, label_id) DO NOTHING`
+
+	_, err := r.db.Exec(ctx, query, projectID, labelID)
+	return translateError(err)
+}
+
+func (r *LabelRepository) DetachFromProject(ctx context.Context, projectID, labelID int) error {
+	query := `DELETE FROM project_labels WHERE project_id = $1 AND label_id = $2`
+
+	result, err := r.db.Exec(ctx, query, projectID, labelID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *LabelRepository) ProjectLabels(ctx context.Context, projectID int) ([]models.Label, error) {
+	query := `
+        SELECT ` + prefixColumns("l", labelColumns) + `
+        FROM labels l
+        JOIN project_labels pl ON pl.label_id = l.id
+        WHERE pl.project_id = $1
+        ORDER BY l.id`
+
+	rows, err := r.db.Query(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return collectLabels(rows)
+}
+
+func (r *LabelRepository) Stats(ctx context.Context, filter repository.LabelFilter) ([]models.LabelStats, error) {
+	query := `
+        SELECT l.id, l.project_id, l.name, l.color,
+            count(t.id),
+            count(t.id) FILTER (WHERE t.status NOT IN ('DONE', 'CANCELLED')),
+            count(t.id) FILTER (WHERE t.status = 'DONE'),
+            (SELECT count(*) FROM project_labels pl WHERE pl.label_id = l.id),
+            COALESCE(sum(t.estimate_hours), 0),
+            COALESCE(sum(COALESCE(t.remaining_hours, t.estimate_hours))
+                FILTER (WHERE t.status NOT IN ('DONE', 'CANCELLED')), 0)
+        FROM labels l
+        LEFT JOIN task_labels tl ON tl.label_id = l.id
+        LEFT JOIN tasks t ON t.id = tl.task_id AND ($1 = 0 OR t.project_id = $1) AND t.deleted_at IS NULL
+        WHERE ($1 = 0 OR l.project_id IS NULL OR l.project_id = $1)
+            AND (NOT $2 OR l.project_id IS NULL)
+        GROUP BY l.id
+        ORDER BY l.id`
+
+	rows, err := r.db.Query(ctx, query, filter.ProjectID, filter.GlobalOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []models.LabelStats
+	for rows.Next() {
+		var s models.LabelStats
+		err := rows.Scan(&s.LabelID, &s.ProjectID, &s.Name, &s.Color,
+			&s.Tasks, &s.OpenTasks, &s.DoneTasks, &s.Projects, &s.EstimateHours, &s.RemainingHours)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
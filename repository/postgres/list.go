@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"nstorm.com/main-backend/repository"
+)
+
+// filterBuilder accumulates WHERE conditions. Conditions are written with
+// ? placeholders, which are numbered as they are added.
+type filterBuilder struct {
+	conditions []string
+	args       []any
+}
+
+func (b *filterBuilder) add(condition string, args ...any) {
+	for _, arg := range args {
+		b.args = append(b.args, arg)
+		condition = strings.Replace(condition, "?", fmt.Sprintf("$%d", len(b.args)), 1)
+	}
+	b.conditions = append(b.conditions, condition)
+}
+
+func (b *filterBuilder) where() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// prefixColumns qualifies each column of a column list with alias, for
+// queries that join several tables.
+func prefixColumns(alias, columns string) string {
+	fields := strings.Split(columns, ",")
+	for i, field := range fields {
+		fields[i] = alias + "." + strings.TrimSpace(field)
+	}
+	return strings.Join(fields, ", ")
+}
+
+// listPage runs a keyset-paginated query over from, which is a table name
+// optionally followed by joins, returning the matching total alongside
+// the page.
+func listPage[T any](
+	ctx context.Context,
+	db *pgxpool.Pool,
+	from, columns string,
+	filter *filterBuilder,
+	page repository.PageRequest,
+	sorts map[string]repository.SortField[T],
+	scan func(pgx.Row, *T) error,
+	id func(T) int,
+) (*repository.Page[T], error) {
+	field, ok := sorts[page.Sort.Field]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", page.Sort.Field)
+	}
+
+	result := &repository.Page[T]{Items: []T{}}
+	countQuery := `SELECT count(*) FROM ` + from + filter.where()
+	if err := db.QueryRow(ctx, countQuery, filter.args...).Scan(&result.Total); err != nil {
+		return nil, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if page.Sort.Desc {
+		direction, comparison = "DESC", "<"
+	}
+	column := field.Column
+	idColumn := "id"
+	if prefix, _, ok := strings.Cut(column, "."); ok {
+		idColumn = prefix + ".id"
+	}
+
+	if page.After != nil {
+		value, err := repository.ParseValue(field.Kind, page.After.Value)
+		if err != nil {
+			return nil, err
+		}
+		filter.add(fmt.Sprintf("(%s, %s) %s (?, ?)", column, idColumn, comparison), value, page.After.ID)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM %s%s ORDER BY %s %s, %s %s LIMIT %d`,
+		columns, from, filter.where(), column, direction, idColumn, direction, page.Limit+1)
+	rows, err := db.Query(ctx, query, filter.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item T
+		if err := scan(rows, &item); err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(result.Items) > page.Limit {
+		result.Items = result.Items[:page.Limit]
+		last := result.Items[page.Limit-1]
+		result.NextCursor = repository.Cursor{Sort: page.Sort, Value: field.Value(last), ID: id(last)}.Encode()
+	}
+	return result, nil
+}
@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type MilestoneRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMilestoneRepository(db *pgxpool.Pool) *MilestoneRepository {
+	return &MilestoneRepository{db: db}
+}
+
+const milestoneColumns = `id, project_id, title, description, target_date, status, version, created_at, updated_at`
+
+func scanMilestone(row pgx.Row, milestone *models.Milestone) error {
+	return row.Scan(
+		&milestone.ID,
+		&milestone.ProjectID,
+		&milestone.Title,
+		&milestone.Description,
+		&milestone.TargetDate,
+		&milestone.Status,
+		&milestone.Version,
+		&milestone.CreatedAt,
+		&milestone.UpdatedAt,
+	)
+}
+
+func (r *MilestoneRepository) Create(ctx context.Context, milestone *models.Milestone) error {
+	query := `
+        INSERT INTO milestones (project_id, title, description, target_date, status)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING ` + milestoneColumns
+
+	err := scanMilestone(r.db.QueryRow(ctx, query,
+		milestone.ProjectID,
+		milestone.Title,
+		milestone.Description,
+		milestone.TargetDate,
+		milestone.Status,
+	), milestone)
+	return translateError(err)
+}
+
+func (r *MilestoneRepository) GetByID(ctx context.Context, id int) (*models.Milestone, error) {
+	query := `SELECT ` + milestoneColumns + ` FROM milestones WHERE id = $1`
+
+	var milestone models.Milestone
+	err := scanMilestone(r.db.QueryRow(ctx, query, id), &milestone)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &milestone, nil
+}
+
+func (r *MilestoneRepository) List(ctx context.Context, filter repository.MilestoneFilter) (*repository.Page[models.Milestone], error) {
+	var where filterBuilder
+	if filter.ProjectID != 0 {
+		where.add("project_id = ?", filter.ProjectID)
+	}
+	if filter.Status != "" {
+		where.add("status = ?", filter.Status)
+	}
+
+	return listPage(ctx, r.db, "milestones", milestoneColumns, &where, filter.Page,
+		repository.MilestoneSorts, scanMilestone, func(m models.Milestone) int { return m.ID })
+}
+
+func (r *MilestoneRepository) ListByProject(ctx context.Context, projectID int) ([]models.Milestone, error) {
+	query := `SELECT ` + milestoneColumns + ` FROM milestones WHERE project_id = $1 ORDER BY target_date, id`
+
+	rows, err := r.db.Query(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var milestones []models.Milestone
+	for rows.Next() {
+		var milestone models.Milestone
+		if err := scanMilestone(rows, &milestone); err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, milestone)
+	}
+	return milestones, rows.Err()
+}
+
+func (r *MilestoneRepository) Update(ctx context.Context, milestone *models.Milestone) error {
+	query := `
+        UPDATE milestones
+        SET title = $1, description = $2, target_date = $3, status = $4,
+            version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $5 AND ($6 = 0 OR version = $6)
+        RETURNING ` + milestoneColumns
+
+	err := scanMilestone(r.db.QueryRow(ctx, query,
+		milestone.Title,
+		milestone.Description,
+		milestone.TargetDate,
+		milestone.Status,
+		milestone.ID,
+		milestone.Version,
+	), milestone)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, r.db, "milestones", milestone.ID, milestone.Version)
+	}
+	return translateError(err)
+}
+
+func (r *MilestoneRepository) Delete(ctx context.Context, id, version int) error {
+	query := `DELETE FROM milestones WHERE id = $1 AND ($2 = 0 OR version = $2)`
+
+	result, err := r.db.Exec(ctx, query, id, version)
+	if err != nil {
+		return translateDeleteError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return missingOrConflict(ctx, r.db, "milestones", id, version)
+	}
+	return nil
+}
+
+func (r *MilestoneRepository) Tasks(ctx context.Context, id int) ([]models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE milestone_id = $1 AND deleted_at IS NULL ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return collectTasks(rows)
+}
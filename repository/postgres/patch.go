@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"nstorm.com/main-backend/validation"
+)
+
+// patchQuery builds an UPDATE that sets only the given fields and bumps the
+// row version, provided the row is still at version (or version is zero)
+// and not soft-deleted.
+// columns maps each patchable JSON field name to its column and value. With
+// no fields it degrades to reading the row back.
+func patchQuery[T any](table, returning string, id, version int, item *T, fields []string, columns map[string]func(*T) (string, any)) (string, []any, error) {
+	if len(fields) == 0 {
+		query := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1 AND ($2 = 0 OR version = $2)%s`, returning, table, liveRow(table))
+		return query, []any{id, version}, nil
+	}
+
+	assignments := make([]string, 0, len(fields))
+	args := make([]any, 0, len(fields)+1)
+	for _, field := range fields {
+		column, ok := columns[field]
+		if !ok {
+			return "", nil, validation.Errors{{Field: field, Message: "cannot be patched"}}
+		}
+		name, value := column(item)
+		args = append(args, value)
+		assignments = append(assignments, fmt.Sprintf("%s = $%d", name, len(args)))
+	}
+	assignments = append(assignments, "version = version + 1", "updated_at = CURRENT_TIMESTAMP")
+	args = append(args, id, version)
+
+	query := fmt.Sprintf(`UPDATE %s SET %s WHERE id = $%d AND ($%d = 0 OR version = $%d)%s RETURNING %s`,
+		table, strings.Join(assignments, ", "), len(args)-1, len(args), len(args), liveRow(table), returning)
+	return query, args, nil
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/validation"
+)
+
+func TestPatchQuery(t *testing.T) {
+	task := &models.Task{ID: 7, Title: "Write the linker", Description: "ELF only"}
+
+	query, args, err := patchQuery("tasks", taskColumns, task.ID, 3, task, []string{"title", "description"}, taskPatchColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `UPDATE tasks SET title = $1, description = $2, version = version + 1, updated_at = CURRENT_TIMESTAMP ` +
+		`WHERE id = $3 AND ($4 = 0 OR version = $4) AND deleted_at IS NULL RETURNING ` + taskColumns
+	if query != want {
+		t.Fatalf("query = %s, want %s", query, want)
+	}
+	if !slices.Equal(args, []any{"Write the linker", "ELF only", 7, 3}) {
+		t.Fatalf("args = %v", args)
+	}
+
+	query, args, err = patchQuery("tasks", taskColumns, task.ID, 3, task, nil, taskPatchColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1 AND ($2 = 0 OR version = $2) AND deleted_at IS NULL`
+	if query != want || !slices.Equal(args, []any{7, 3}) {
+		t.Fatalf("query = %s %v, want %s [7 3]", query, args, want)
+	}
+
+	_, _, err = patchQuery("tasks", taskColumns, task.ID, 0, task, []string{"status"}, taskPatchColumns)
+	var errs validation.Errors
+	if !errors.As(err, &errs) || !slices.Equal(errs, validation.Errors{{Field: "status", Message: "cannot be patched"}}) {
+		t.Fatalf("patching status: err = %v, want a validation error", err)
+	}
+}
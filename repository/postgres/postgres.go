@@ -0,0 +1,23 @@
+package postgres
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"nstorm.com/main-backend/repository"
+)
+
+// NewRepositories returns Postgres-backed implementations of every
+// repository, all sharing the given pool.
+func NewRepositories(db *pgxpool.Pool) *repository.Repositories {
+	return &repository.Repositories{
+		Employees:      NewEmployeeRepository(db),
+		Projects:       NewProjectRepository(db),
+		Tasks:          NewTaskRepository(db),
+		Comments:       NewCommentRepository(db),
+		Labels:         NewLabelRepository(db),
+		Worklogs:       NewWorklogRepository(db),
+		RecurringTasks: NewRecurringTaskRepository(db),
+		Sprints:        NewSprintRepository(db),
+		Milestones:     NewMilestoneRepository(db),
+		Templates:      NewTemplateRepository(db),
+	}
+}
@@ -0,0 +1,280 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type ProjectRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewProjectRepository(db *pgxpool.Pool) *ProjectRepository {
+	return &ProjectRepository{db: db}
+}
+
+const projectColumns = `id, name, description, lead_id, version, created_at, updated_at, archived_at, deleted_at`
+
+func scanProject(row pgx.Row, project *models.Project) error {
+	return row.Scan(
+		&project.ID,
+		&project.Name,
+		&project.Description,
+		&project.LeadID,
+		&project.Version,
+		&project.CreatedAt,
+		&project.UpdatedAt,
+		&project.ArchivedAt,
+		&project.DeletedAt,
+	)
+}
+
+func collectProjects(rows pgx.Rows) ([]models.Project, error) {
+	defer rows.Close()
+
+	var projects []models.Project
+	for rows.Next() {
+		var project models.Project
+		if err := scanProject(rows, &project); err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	return projects, rows.Err()
+}
+
+func (r *ProjectRepository) Create(ctx context.Context, project *models.Project) error {
+	query := `
+        INSERT INTO projects (name, description, lead_id)
+        VALUES ($1, $2, $3)
+        RETURNING ` + projectColumns
+
+	err := scanProject(r.db.QueryRow(ctx, query,
+		project.Name,
+		project.Description,
+		project.LeadID,
+	), project)
+	return translateError(err)
+}
+
+func (r *ProjectRepository) GetByID(ctx context.Context, id int) (*models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1 AND deleted_at IS NULL`
+
+	var project models.Project
+	err := scanProject(r.db.QueryRow(ctx, query, id), &project)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *ProjectRepository) List(ctx context.Context, filter repository.ProjectFilter) (*repository.Page[models.Project], error) {
+	var where filterBuilder
+	if !filter.IncludeDeleted {
+		where.add("deleted_at IS NULL")
+	}
+	if !filter.IncludeArchived {
+		where.add("archived_at IS NULL")
+	}
+	if filter.LeadID != 0 {
+		where.add("lead_id = ?", filter.LeadID)
+	}
+	if filter.LabelID != 0 {
+		where.add("EXISTS (SELECT 1 FROM project_labels pl WHERE pl.project_id = projects.id AND pl.label_id = ?)", filter.LabelID)
+	}
+
+	return listPage(ctx, r.db, "projects", projectColumns, &where, filter.Page,
+		repository.ProjectSorts, scanProject, func(p models.Project) int { return p.ID })
+}
+
+func (r *ProjectRepository) Update(ctx context.Context, project *models.Project) error {
+	query := `
+        UPDATE projects
+        SET name = $1, description = $2, lead_id = $3,
+            version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $4 AND ($5 = 0 OR version = $5) AND deleted_at IS NULL
+        RETURNING ` + projectColumns
+
+	err := scanProject(r.db.QueryRow(ctx, query,
+		project.Name,
+		project.Description,
+		project.LeadID,
+		project.ID,
+		project.Version,
+	), project)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, r.db, "projects", project.ID, project.Version)
+	}
+	return translateError(err)
+}
+
+var projectPatchColumns = map[string]func(*models.Project) (string, any){
+	"name":        func(p *models.Project) (string, any) { return "name", p.Name },
+	"description": func(p *models.Project) (string, any) { return "description", p.Description },
+	"lead_id":     func(p *models.Project) (string, any) { return "lead_id", p.LeadID },
+}
+
+func (r *ProjectRepository) Patch(ctx context.Context, project *models.Project, fields []string) error {
+	query, args, err := patchQuery("projects", projectColumns, project.ID, project.Version, project, fields, projectPatchColumns)
+	if err != nil {
+		return err
+	}
+
+	err = scanProject(r.db.QueryRow(ctx, query, args...), project)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, r.db, "projects", project.ID, project.Version)
+	}
+	return translateError(err)
+}
+
+func (r *ProjectRepository) Delete(ctx context.Context, id, version int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+        UPDATE projects
+        SET deleted_at = CURRENT_TIMESTAMP, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND ($2 = 0 OR version = $2) AND deleted_at IS NULL`
+
+	result, err := tx.Exec(ctx, query, id, version)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return missingOrConflict(ctx, r.db, "projects", id, version)
+	}
+
+	// CURRENT_TIMESTAMP is the same for the whole transaction, which is what
+	// Restore tells the tasks deleted with the project apart by.
+	query = `
+        UPDATE tasks
+        SET deleted_at = CURRENT_TIMESTAMP, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE project_id = $1 AND deleted_at IS NULL`
+	if _, err := tx.Exec(ctx, query, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *ProjectRepository) GetDeleted(ctx context.Context, id int) (*models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	var project models.Project
+	err := scanProject(r.db.QueryRow(ctx, query, id), &project)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *ProjectRepository) Restore(ctx context.Context, id, version int) (*models.Project, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var deletedAt time.Time
+	query := `SELECT deleted_at FROM projects WHERE id = $1 AND ($2 = 0 OR version = $2) AND deleted_at IS NOT NULL FOR UPDATE`
+	err = tx.QueryRow(ctx, query, id, version).Scan(&deletedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, missingOrRestored(ctx, r.db, "projects", id, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+        UPDATE tasks
+        SET deleted_at = NULL, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE project_id = $1 AND deleted_at = $2`
+	if _, err := tx.Exec(ctx, query, id, deletedAt); err != nil {
+		return nil, err
+	}
+
+	query = `
+        UPDATE projects
+        SET deleted_at = NULL, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+        RETURNING ` + projectColumns
+
+	var project models.Project
+	if err := scanProject(tx.QueryRow(ctx, query, id), &project); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *ProjectRepository) Archive(ctx context.Context, id, version int) (*models.Project, error) {
+	return r.setArchived(ctx, id, version, true)
+}
+
+func (r *ProjectRepository) Unarchive(ctx context.Context, id, version int) (*models.Project, error) {
+	return r.setArchived(ctx, id, version, false)
+}
+
+func (r *ProjectRepository) setArchived(ctx context.Context, id, version int, archived bool) (*models.Project, error) {
+	query := `
+        UPDATE projects
+        SET archived_at = CASE WHEN $3 THEN CURRENT_TIMESTAMP END,
+            version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND ($2 = 0 OR version = $2) AND deleted_at IS NULL
+            AND (archived_at IS NULL) = $3
+        RETURNING ` + projectColumns
+
+	var project models.Project
+	err := scanProject(r.db.QueryRow(ctx, query, id, version, archived), &project)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, repository.ErrVersionConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *ProjectRepository) Purge(ctx context.Context, cutoff time.Time) (int, error) {
+	query := `DELETE FROM projects WHERE deleted_at < $1`
+
+	result, err := r.db.Exec(ctx, query, cutoff)
+	if err != nil {
+		return 0, translateDeleteError(err)
+	}
+	return int(result.RowsAffected()), nil
+}
+
+func (r *ProjectRepository) ListByEmployee(ctx context.Context, employeeID int) ([]models.Project, error) {
+	query := `
+        SELECT ` + prefixColumns("p", projectColumns) + `
+        FROM projects p
+        JOIN employee_projects ep ON p.id = ep.project_id
+        WHERE ep.employee_id = $1 AND p.deleted_at IS NULL
+        ORDER BY p.id`
+
+	rows, err := r.db.Query(ctx, query, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	return collectProjects(rows)
+}
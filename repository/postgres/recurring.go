@@ -0,0 +1,264 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type RecurringTaskRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRecurringTaskRepository(db *pgxpool.Pool) *RecurringTaskRepository {
+	return &RecurringTaskRepository{db: db}
+}
+
+const recurringTaskColumns = `id, project_id, assigned_to, title, description, priority, estimate_hours,
+    due_in_days, schedule, timezone, paused, next_run_at, last_run_at, version, created_at, updated_at`
+
+func scanRecurringTask(row pgx.Row, recurring *models.RecurringTask) error {
+	return row.Scan(
+		&recurring.ID,
+		&recurring.ProjectID,
+		&recurring.AssignedTo,
+		&recurring.Title,
+		&recurring.Description,
+		&recurring.Priority,
+		&recurring.EstimateHours,
+		&recurring.DueInDays,
+		&recurring.Schedule,
+		&recurring.Timezone,
+		&recurring.Paused,
+		&recurring.NextRunAt,
+		&recurring.LastRunAt,
+		&recurring.Version,
+		&recurring.CreatedAt,
+		&recurring.UpdatedAt,
+	)
+}
+
+func (r *RecurringTaskRepository) Create(ctx context.Context, recurring *models.RecurringTask) error {
+	query := `
+        INSERT INTO recurring_tasks (project_id, assigned_to, title, description, priority, estimate_hours,
+            due_in_days, schedule, timezone, paused, next_run_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        RETURNING ` + recurringTaskColumns
+
+	err := scanRecurringTask(r.db.QueryRow(ctx, query,
+		recurring.ProjectID,
+		recurring.AssignedTo,
+		recurring.Title,
+		recurring.Description,
+		recurring.Priority,
+		recurring.EstimateHours,
+		recurring.DueInDays,
+		recurring.Schedule,
+		recurring.Timezone,
+		recurring.Paused,
+		recurring.NextRunAt,
+	), recurring)
+	return translateError(err)
+}
+
+func (r *RecurringTaskRepository) GetByID(ctx context.Context, id int) (*models.RecurringTask, error) {
+	query := `SELECT ` + recurringTaskColumns + ` FROM recurring_tasks WHERE id = $1`
+
+	var recurring models.RecurringTask
+	err := scanRecurringTask(r.db.QueryRow(ctx, query, id), &recurring)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &recurring, nil
+}
+
+func (r *RecurringTaskRepository) List(ctx context.Context, filter repository.RecurringTaskFilter) (*repository.Page[models.RecurringTask], error) {
+	var where filterBuilder
+	if filter.ProjectID != 0 {
+		where.add("project_id = ?", filter.ProjectID)
+	}
+	if filter.Paused != nil {
+		where.add("paused = ?", *filter.Paused)
+	}
+
+	return listPage(ctx, r.db, "recurring_tasks", recurringTaskColumns, &where, filter.Page,
+		repository.RecurringTaskSorts, scanRecurringTask, func(r models.RecurringTask) int { return r.ID })
+}
+
+func (r *RecurringTaskRepository) Update(ctx context.Context, recurring *models.RecurringTask) error {
+	query := `
+        UPDATE recurring_tasks
+        SET assigned_to = $1, title = $2, description = $3, priority = $4, estimate_hours = $5,
+            due_in_days = $6, schedule = $7, timezone = $8, paused = $9, next_run_at = $10,
+            version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $11 AND ($12 = 0 OR version = $12)
+        RETURNING ` + recurringTaskColumns
+
+	err := scanRecurringTask(r.db.QueryRow(ctx, query,
+		recurring.AssignedTo,
+		recurring.Title,
+		recurring.Description,
+		recurring.Priority,
+		recurring.EstimateHours,
+		recurring.DueInDays,
+		recurring.Schedule,
+		recurring.Timezone,
+		recurring.Paused,
+		recurring.NextRunAt,
+		recurring.ID,
+		recurring.Version,
+	), recurring)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, r.db, "recurring_tasks", recurring.ID, recurring.Version)
+	}
+	return translateError(err)
+}
+
+func (r *RecurringTaskRepository) Delete(ctx context.Context, id, version int) error {
+	query := `DELETE FROM recurring_tasks WHERE id = $1 AND ($2 = 0 OR version = $2)`
+
+	result, err := r.db.Exec(ctx, query, id, version)
+	if err != nil {
+		return translateDeleteError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return missingOrConflict(ctx, r.db, "recurring_tasks", id, version)
+	}
+	return nil
+}
+
+func (r *RecurringTaskRepository) RunDue(ctx context.Context, now time.Time) ([]models.Task, error) {
+	query := `
+        SELECT id FROM recurring_tasks
+        WHERE NOT paused AND next_run_at <= $1` + liveProject + `
+        ORDER BY next_run_at, id`
+
+	rows, err := r.db.Query(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var created []models.Task
+	var errs []error
+	for _, id := range ids {
+		task, err := r.run(ctx, id, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recurring task %d: %w", id, err))
+			continue
+		}
+		if task != nil {
+			created = append(created, *task)
+		}
+	}
+	return created, errors.Join(errs...)
+}
+
+// liveProject limits recurring task definitions to those of projects that
+// are neither deleted nor archived, which take no new tasks.
+const liveProject = `
+            AND project_id IN (SELECT id FROM projects WHERE deleted_at IS NULL AND archived_at IS NULL)`
+
+// run creates the task for one due definition and advances it, returning
+// nil if another caller got there first.
+func (r *RecurringTaskRepository) run(ctx context.Context, id int, now time.Time) (*models.Task, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// SKIP LOCKED leaves a definition another instance is running to it.
+	query := `
+        SELECT ` + recurringTaskColumns + ` FROM recurring_tasks
+        WHERE id = $1 AND NOT paused AND next_run_at <= $2` + liveProject + `
+        FOR UPDATE SKIP LOCKED`
+
+	var recurring models.RecurringTask
+	err = scanRecurringTask(tx.QueryRow(ctx, query, id, now), &recurring)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	occurrence, next, err := recurring.Catchup(now)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+        INSERT INTO recurring_task_runs (recurring_task_id, occurrence_at)
+        VALUES ($1, $2)
+        ON CONFLICT (recurring_task_id, occurrence_at) DO NOTHING`
+
+	result, err := tx.Exec(ctx, query, id, occurrence)
+	if err != nil {
+		return nil, err
+	}
+	var created *models.Task
+	if result.RowsAffected() == 1 {
+		task := recurring.NewTask(occurrence)
+		if err := insertTask(ctx, tx, &task); err != nil {
+			return nil, translateError(err)
+		}
+		query = `
+            UPDATE recurring_task_runs SET task_id = $3
+            WHERE recurring_task_id = $1 AND occurrence_at = $2`
+
+		if _, err := tx.Exec(ctx, query, id, occurrence, task.ID); err != nil {
+			return nil, err
+		}
+		created = &task
+	}
+
+	var nextRun *time.Time
+	if !next.IsZero() {
+		nextRun = &next
+	}
+	query = `
+        UPDATE recurring_tasks
+        SET next_run_at = $2, last_run_at = $3, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1`
+
+	if _, err := tx.Exec(ctx, query, id, nextRun, occurrence); err != nil {
+		return nil, err
+	}
+	return created, tx.Commit(ctx)
+}
+
+func (r *RecurringTaskRepository) Tasks(ctx context.Context, id int) ([]models.Task, error) {
+	query := `
+        SELECT ` + prefixColumns("t", taskColumns) + `
+        FROM tasks t
+        JOIN recurring_task_runs r ON r.task_id = t.id
+        WHERE r.recurring_task_id = $1 AND t.deleted_at IS NULL
+        ORDER BY r.occurrence_at`
+
+	rows, err := r.db.Query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return collectTasks(rows)
+}
@@ -0,0 +1,226 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type SprintRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSprintRepository(db *pgxpool.Pool) *SprintRepository {
+	return &SprintRepository{db: db}
+}
+
+const sprintColumns = `id, project_id, name, goal, start_date, end_date, state, started_at, closed_at,
+    committed_hours, completed_hours, completed_tasks, version, created_at, updated_at`
+
+func scanSprint(row pgx.Row, sprint *models.Sprint) error {
+	return row.Scan(
+		&sprint.ID,
+		&sprint.ProjectID,
+		&sprint.Name,
+		&sprint.Goal,
+		&sprint.StartDate,
+		&sprint.EndDate,
+		&sprint.State,
+		&sprint.StartedAt,
+		&sprint.ClosedAt,
+		&sprint.CommittedHours,
+		&sprint.CompletedHours,
+		&sprint.CompletedTasks,
+		&sprint.Version,
+		&sprint.CreatedAt,
+		&sprint.UpdatedAt,
+	)
+}
+
+func (r *SprintRepository) Create(ctx context.Context, sprint *models.Sprint) error {
+	query := `
+        INSERT INTO sprints (project_id, name, goal, start_date, end_date)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING ` + sprintColumns
+
+	err := scanSprint(r.db.QueryRow(ctx, query,
+		sprint.ProjectID,
+		sprint.Name,
+		sprint.Goal,
+		sprint.StartDate,
+		sprint.EndDate,
+	), sprint)
+	return translateError(err)
+}
+
+func (r *SprintRepository) GetByID(ctx context.Context, id int) (*models.Sprint, error) {
+	query := `SELECT ` + sprintColumns + ` FROM sprints WHERE id = $1`
+
+	var sprint models.Sprint
+	err := scanSprint(r.db.QueryRow(ctx, query, id), &sprint)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sprint, nil
+}
+
+func (r *SprintRepository) List(ctx context.Context, filter repository.SprintFilter) (*repository.Page[models.Sprint], error) {
+	var where filterBuilder
+	if filter.ProjectID != 0 {
+		where.add("project_id = ?", filter.ProjectID)
+	}
+	if filter.State != "" {
+		where.add("state = ?", filter.State)
+	}
+
+	return listPage(ctx, r.db, "sprints", sprintColumns, &where, filter.Page,
+		repository.SprintSorts, scanSprint, func(s models.Sprint) int { return s.ID })
+}
+
+func (r *SprintRepository) Update(ctx context.Context, sprint *models.Sprint) error {
+	query := `
+        UPDATE sprints
+        SET name = $1, goal = $2, start_date = $3, end_date = $4,
+            version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $5 AND ($6 = 0 OR version = $6)
+        RETURNING ` + sprintColumns
+
+	err := scanSprint(r.db.QueryRow(ctx, query,
+		sprint.Name,
+		sprint.Goal,
+		sprint.StartDate,
+		sprint.EndDate,
+		sprint.ID,
+		sprint.Version,
+	), sprint)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, r.db, "sprints", sprint.ID, sprint.Version)
+	}
+	return translateError(err)
+}
+
+func (r *SprintRepository) Delete(ctx context.Context, id, version int) error {
+	query := `DELETE FROM sprints WHERE id = $1 AND ($2 = 0 OR version = $2)`
+
+	result, err := r.db.Exec(ctx, query, id, version)
+	if err != nil {
+		return translateDeleteError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return missingOrConflict(ctx, r.db, "sprints", id, version)
+	}
+	return nil
+}
+
+func (r *SprintRepository) Start(ctx context.Context, id, version int) (*models.Sprint, error) {
+	query := `
+        UPDATE sprints
+        SET state = 'ACTIVE', started_at = CURRENT_TIMESTAMP,
+            committed_hours = (
+                SELECT COALESCE(sum(estimate_hours), 0) FROM tasks
+                WHERE sprint_id = sprints.id AND status <> 'CANCELLED' AND deleted_at IS NULL),
+            version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND state = 'PLANNED' AND ($2 = 0 OR version = $2)
+        RETURNING ` + sprintColumns
+
+	var sprint models.Sprint
+	err := scanSprint(r.db.QueryRow(ctx, query, id, version), &sprint)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, r.missingOrChanged(ctx, id)
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &sprint, nil
+}
+
+func (r *SprintRepository) Close(ctx context.Context, id, nextID, version int) (*models.Sprint, []models.Task, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+        UPDATE sprints
+        SET state = 'CLOSED', closed_at = CURRENT_TIMESTAMP,
+            completed_hours = (
+                SELECT COALESCE(sum(estimate_hours), 0) FROM tasks
+                WHERE sprint_id = sprints.id AND status = 'DONE' AND deleted_at IS NULL),
+            completed_tasks = (
+                SELECT count(*) FROM tasks
+                WHERE sprint_id = sprints.id AND status = 'DONE' AND deleted_at IS NULL),
+            version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND state = 'ACTIVE' AND ($2 = 0 OR version = $2)
+        RETURNING ` + sprintColumns
+
+	var sprint models.Sprint
+	err = scanSprint(tx.QueryRow(ctx, query, id, version), &sprint)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, r.missingOrChanged(ctx, id)
+	}
+	if err != nil {
+		return nil, nil, translateError(err)
+	}
+
+	var next *int
+	if nextID != 0 {
+		// Lock the next sprint so it cannot start or go away before the
+		// tasks land in it.
+		query = `SELECT id FROM sprints WHERE id = $1 AND project_id = $2 AND state = 'PLANNED' FOR UPDATE`
+		err := tx.QueryRow(ctx, query, nextID, sprint.ProjectID).Scan(&nextID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, repository.ErrVersionConflict
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		next = &nextID
+	}
+
+	query = `
+        UPDATE tasks
+        SET sprint_id = $1, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE sprint_id = $2 AND status NOT IN ('DONE', 'CANCELLED') AND deleted_at IS NULL
+        RETURNING ` + taskColumns
+
+	rows, err := tx.Query(ctx, query, next, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	moved, err := collectTasks(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return &sprint, moved, nil
+}
+
+// missingOrChanged explains why a state change matched no sprint: it is
+// gone, or no longer in the state or at the version the caller expected.
+func (r *SprintRepository) missingOrChanged(ctx context.Context, id int) error {
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return repository.ErrVersionConflict
+}
+
+func (r *SprintRepository) Tasks(ctx context.Context, id int) ([]models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE sprint_id = $1 AND deleted_at IS NULL ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return collectTasks(rows)
+}
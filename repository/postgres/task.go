@@ -0,0 +1,477 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type TaskRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTaskRepository(db *pgxpool.Pool) *TaskRepository {
+	return &TaskRepository{db: db}
+}
+
+const taskColumns = `id, project_id, assigned_to, parent_task_id, sprint_id, milestone_id, title, description,
+    status, priority, rank, start_date, due_date, estimate_hours, remaining_hours, version, created_at, updated_at, deleted_at`
+
+func scanTask(row pgx.Row, task *models.Task) error {
+	return row.Scan(
+		&task.ID,
+		&task.ProjectID,
+		&task.AssignedTo,
+		&task.ParentTaskID,
+		&task.SprintID,
+		&task.MilestoneID,
+		&task.Title,
+		&task.Description,
+		&task.Status,
+		&task.Priority,
+		&task.Rank,
+		&task.StartDate,
+		&task.DueDate,
+		&task.EstimateHours,
+		&task.RemainingHours,
+		&task.Version,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+		&task.DeletedAt,
+	)
+}
+
+func collectTasks(rows pgx.Rows) ([]models.Task, error) {
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		var task models.Task
+		if err := scanTask(rows, &task); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+func (r *TaskRepository) Create(ctx context.Context, task *models.Task) error {
+	return insertTask(ctx, r.db, task)
+}
+
+// rowQuerier is satisfied by both the pool and a transaction.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// insertTask inserts task at the bottom of its board column and reads back
+// the stored row.
+func insertTask(ctx context.Context, db rowQuerier, task *models.Task) error {
+	rank, err := lastRank(ctx, db, task.ProjectID, task.Status)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO tasks (project_id, assigned_to, parent_task_id, sprint_id, milestone_id, title,
+            description, status, priority, rank, start_date, due_date, estimate_hours, remaining_hours)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+        RETURNING ` + taskColumns
+
+	err = scanTask(db.QueryRow(ctx, query,
+		task.ProjectID,
+		task.AssignedTo,
+		task.ParentTaskID,
+		task.SprintID,
+		task.MilestoneID,
+		task.Title,
+		task.Description,
+		task.Status,
+		task.Priority,
+		models.RankAfter(rank),
+		task.StartDate,
+		task.DueDate,
+		task.EstimateHours,
+		task.RemainingHours,
+	), task)
+	return translateError(err)
+}
+
+// lastRank returns the rank at the bottom of a board column, or "" if the
+// column is empty. Tasks inserted concurrently may end up with the same
+// rank; they are ordered by ID until one of them is moved.
+func lastRank(ctx context.Context, db rowQuerier, projectID int, status models.TaskStatus) (string, error) {
+	query := `SELECT COALESCE(max(rank), '') FROM tasks WHERE project_id = $1 AND status = $2`
+
+	var rank string
+	err := db.QueryRow(ctx, query, projectID, status).Scan(&rank)
+	return rank, err
+}
+
+func (r *TaskRepository) GetByID(ctx context.Context, id int) (*models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1 AND deleted_at IS NULL`
+
+	var task models.Task
+	err := scanTask(r.db.QueryRow(ctx, query, id), &task)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *TaskRepository) List(ctx context.Context, filter repository.TaskFilter) (*repository.Page[models.Task], error) {
+	var where filterBuilder
+	if !filter.IncludeDeleted {
+		where.add("deleted_at IS NULL")
+	}
+	if filter.ProjectID != 0 {
+		where.add("project_id = ?", filter.ProjectID)
+	}
+	if filter.AssignedTo != 0 {
+		where.add("assigned_to = ?", filter.AssignedTo)
+	}
+	if filter.ParentTaskID != 0 {
+		where.add("parent_task_id = ?", filter.ParentTaskID)
+	}
+	if filter.SprintID != 0 {
+		where.add("sprint_id = ?", filter.SprintID)
+	}
+	if filter.MilestoneID != 0 {
+		where.add("milestone_id = ?", filter.MilestoneID)
+	}
+	if filter.LabelID != 0 {
+		where.add("EXISTS (SELECT 1 FROM task_labels tl WHERE tl.task_id = tasks.id AND tl.label_id = ?)", filter.LabelID)
+	}
+	if filter.Status != "" {
+		where.add("status = ?", filter.Status)
+	}
+	if filter.CreatedAfter != nil {
+		where.add("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		where.add("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.Priority != "" {
+		where.add("priority = ?", filter.Priority)
+	}
+	if filter.StartAfter != nil {
+		where.add("start_date >= ?", *filter.StartAfter)
+	}
+	if filter.StartBefore != nil {
+		where.add("start_date < ?", *filter.StartBefore)
+	}
+	if filter.DueAfter != nil {
+		where.add("due_date >= ?", *filter.DueAfter)
+	}
+	if filter.DueBefore != nil {
+		where.add("due_date < ?", *filter.DueBefore)
+	}
+	if filter.OverdueOn != nil {
+		where.add("due_date < ? AND status NOT IN ('DONE', 'CANCELLED')", *filter.OverdueOn)
+	}
+
+	return listPage(ctx, r.db, "tasks", taskColumns, &where, filter.Page,
+		repository.TaskSorts, scanTask, func(t models.Task) int { return t.ID })
+}
+
+func (r *TaskRepository) Update(ctx context.Context, task *models.Task) error {
+	query := `
+        UPDATE tasks
+        SET project_id = $1, assigned_to = $2, parent_task_id = $3, sprint_id = $4, milestone_id = $5,
+            title = $6, description = $7, priority = $8, start_date = $9, due_date = $10,
+            estimate_hours = $11, remaining_hours = $12, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $13 AND ($14 = 0 OR version = $14) AND deleted_at IS NULL
+        RETURNING ` + taskColumns
+
+	err := scanTask(r.db.QueryRow(ctx, query,
+		task.ProjectID,
+		task.AssignedTo,
+		task.ParentTaskID,
+		task.SprintID,
+		task.MilestoneID,
+		task.Title,
+		task.Description,
+		task.Priority,
+		task.StartDate,
+		task.DueDate,
+		task.EstimateHours,
+		task.RemainingHours,
+		task.ID,
+		task.Version,
+	), task)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, r.db, "tasks", task.ID, task.Version)
+	}
+	return translateError(err)
+}
+
+var taskPatchColumns = map[string]func(*models.Task) (string, any){
+	"project_id":      func(t *models.Task) (string, any) { return "project_id", t.ProjectID },
+	"assigned_to":     func(t *models.Task) (string, any) { return "assigned_to", t.AssignedTo },
+	"parent_task_id":  func(t *models.Task) (string, any) { return "parent_task_id", t.ParentTaskID },
+	"sprint_id":       func(t *models.Task) (string, any) { return "sprint_id", t.SprintID },
+	"milestone_id":    func(t *models.Task) (string, any) { return "milestone_id", t.MilestoneID },
+	"title":           func(t *models.Task) (string, any) { return "title", t.Title },
+	"description":     func(t *models.Task) (string, any) { return "description", t.Description },
+	"priority":        func(t *models.Task) (string, any) { return "priority", t.Priority },
+	"start_date":      func(t *models.Task) (string, any) { return "start_date", t.StartDate },
+	"due_date":        func(t *models.Task) (string, any) { return "due_date", t.DueDate },
+	"estimate_hours":  func(t *models.Task) (string, any) { return "estimate_hours", t.EstimateHours },
+	"remaining_hours": func(t *models.Task) (string, any) { return "remaining_hours", t.RemainingHours },
+}
+
+func (r *TaskRepository) Patch(ctx context.Context, task *models.Task, fields []string) error {
+	query, args, err := patchQuery("tasks", taskColumns, task.ID, task.Version, task, fields, taskPatchColumns)
+	if err != nil {
+		return err
+	}
+
+	err = scanTask(r.db.QueryRow(ctx, query, args...), task)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, r.db, "tasks", task.ID, task.Version)
+	}
+	return translateError(err)
+}
+
+func (r *TaskRepository) Delete(ctx context.Context, id, version int) error {
+	query := `
+        WITH RECURSIVE subtree AS (
+            SELECT id FROM tasks WHERE id = $1 AND ($2 = 0 OR version = $2) AND deleted_at IS NULL
+            UNION
+            SELECT t.id FROM tasks t JOIN subtree s ON t.parent_task_id = s.id
+            WHERE t.deleted_at IS NULL
+        )
+        UPDATE tasks
+        SET deleted_at = CURRENT_TIMESTAMP, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id IN (SELECT id FROM subtree)`
+
+	result, err := r.db.Exec(ctx, query, id, version)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return missingOrConflict(ctx, r.db, "tasks", id, version)
+	}
+	return nil
+}
+
+func (r *TaskRepository) GetDeleted(ctx context.Context, id int) (*models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	var task models.Task
+	err := scanTask(r.db.QueryRow(ctx, query, id), &task)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *TaskRepository) Restore(ctx context.Context, id, version int) (*models.Task, error) {
+	// The subtasks deleted together with the task carry its deleted_at;
+	// those deleted before it stay deleted.
+	query := `
+        WITH RECURSIVE subtree AS (
+            SELECT id, deleted_at FROM tasks WHERE id = $1 AND ($2 = 0 OR version = $2) AND deleted_at IS NOT NULL
+            UNION
+            SELECT t.id, t.deleted_at FROM tasks t JOIN subtree s ON t.parent_task_id = s.id
+            WHERE t.deleted_at = s.deleted_at
+        )
+        UPDATE tasks
+        SET deleted_at = NULL, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id IN (SELECT id FROM subtree)
+        RETURNING ` + taskColumns
+
+	rows, err := r.db.Query(ctx, query, id, version)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := collectTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		if task.ID == id {
+			return &task, nil
+		}
+	}
+	return nil, missingOrRestored(ctx, r.db, "tasks", id, version)
+}
+
+func (r *TaskRepository) Purge(ctx context.Context, cutoff time.Time) (int, error) {
+	query := `DELETE FROM tasks WHERE deleted_at < $1`
+
+	result, err := r.db.Exec(ctx, query, cutoff)
+	if err != nil {
+		return 0, translateDeleteError(err)
+	}
+	return int(result.RowsAffected()), nil
+}
+
+const transitionColumns = `id, task_id, from_status, to_status, actor_id, note, created_at`
+
+func scanTransition(row pgx.Row, transition *models.TaskTransition) error {
+	return row.Scan(
+		&transition.ID,
+		&transition.TaskID,
+		&transition.From,
+		&transition.To,
+		&transition.ActorID,
+		&transition.Note,
+		&transition.CreatedAt,
+	)
+}
+
+func (r *TaskRepository) Transition(ctx context.Context, transition *models.TaskTransition, version int) (*models.Task, error) {
+	return r.move(ctx, transition, repository.TaskPosition{}, version, true)
+}
+
+func (r *TaskRepository) Transitions(ctx context.Context, taskID int) ([]models.TaskTransition, error) {
+	query := `SELECT ` + transitionColumns + ` FROM task_transitions WHERE task_id = $1 ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transitions []models.TaskTransition
+	for rows.Next() {
+		var transition models.TaskTransition
+		if err := scanTransition(rows, &transition); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, transition)
+	}
+	return transitions, rows.Err()
+}
+
+func (r *TaskRepository) AssigneeChanges(ctx context.Context, taskID int) ([]models.TaskAssigneeChange, error) {
+	query := `
+        SELECT id, task_id, from_assignee, to_assignee, created_at
+        FROM task_assignee_changes
+        WHERE task_id = $1
+        ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []models.TaskAssigneeChange
+	for rows.Next() {
+		var change models.TaskAssigneeChange
+		if err := rows.Scan(&change.ID, &change.TaskID, &change.From, &change.To, &change.CreatedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+func (r *TaskRepository) ListByAssignee(ctx context.Context, employeeID int) ([]models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE assigned_to = $1 AND deleted_at IS NULL ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	return collectTasks(rows)
+}
+
+func (r *TaskRepository) ListByAssigneeAndStatus(ctx context.Context, employeeID int, status models.TaskStatus) ([]models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE assigned_to = $1 AND status = $2 AND deleted_at IS NULL ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, employeeID, status)
+	if err != nil {
+		return nil, err
+	}
+	return collectTasks(rows)
+}
+
+func (r *TaskRepository) ListByProject(ctx context.Context, projectID int) ([]models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE project_id = $1 AND deleted_at IS NULL ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return collectTasks(rows)
+}
+
+func (r *TaskRepository) Subtree(ctx context.Context, id int) ([]models.Task, error) {
+	query := `
+        WITH RECURSIVE subtree AS (
+            SELECT id FROM tasks WHERE id = $1 AND deleted_at IS NULL
+            UNION
+            SELECT t.id FROM tasks t JOIN subtree s ON t.parent_task_id = s.id
+            WHERE t.deleted_at IS NULL
+        )
+        SELECT ` + taskColumns + ` FROM tasks WHERE id IN (SELECT id FROM subtree) ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := collectTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return tasks, nil
+}
+
+func (r *TaskRepository) CreateAssigned(ctx context.Context, projectID int, assignments []repository.TaskAssignment) ([]models.Task, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rank, err := lastRank(ctx, tx, projectID, models.StatusTodo)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+        INSERT INTO tasks (project_id, title, assigned_to, status, rank)
+        SELECT $1, $2, e.id, 'TODO', $4
+        FROM employees e
+        WHERE e.name = $3 AND e.deleted_at IS NULL
+        LIMIT 1
+        RETURNING ` + taskColumns
+
+	tasks := make([]models.Task, 0, len(assignments))
+	for _, assignment := range assignments {
+		rank = models.RankAfter(rank)
+
+		var task models.Task
+		err := scanTask(tx.QueryRow(ctx, query, projectID, assignment.Title, assignment.AssigneeName, rank), &task)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("no employee named %q: %w", assignment.AssigneeName, repository.ErrNotFound)
+		}
+		if err != nil {
+			return nil, translateError(err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
@@ -0,0 +1,292 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+type TemplateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTemplateRepository(db *pgxpool.Pool) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+const templateColumns = `id, name, description, source_project_id, content, version, created_at, updated_at`
+
+// templateContent is the plan of a template as stored in its content
+// column.
+type templateContent struct {
+	StartDate  models.Date                `json:"start_date"`
+	LeadID     int                        `json:"lead_id"`
+	Members    []int                      `json:"members"`
+	Labels     []models.TemplateLabel     `json:"labels"`
+	Milestones []models.TemplateMilestone `json:"milestones"`
+	Tasks      []models.TemplateTask      `json:"tasks"`
+}
+
+func scanTemplate(row pgx.Row, template *models.ProjectTemplate) error {
+	var content templateContent
+	err := row.Scan(
+		&template.ID,
+		&template.Name,
+		&template.Description,
+		&template.SourceProjectID,
+		&content,
+		&template.Version,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	template.StartDate = content.StartDate
+	template.LeadID = content.LeadID
+	template.Members = content.Members
+	template.Labels = content.Labels
+	template.Milestones = content.Milestones
+	template.Tasks = content.Tasks
+	return nil
+}
+
+func (r *TemplateRepository) Create(ctx context.Context, template *models.ProjectTemplate) error {
+	query := `
+        INSERT INTO project_templates (name, description, source_project_id, content)
+        VALUES ($1, $2, $3, $4)
+        RETURNING ` + templateColumns
+
+	err := scanTemplate(r.db.QueryRow(ctx, query,
+		template.Name,
+		template.Description,
+		template.SourceProjectID,
+		templateContent{
+			StartDate:  template.StartDate,
+			LeadID:     template.LeadID,
+			Members:    template.Members,
+			Labels:     template.Labels,
+			Milestones: template.Milestones,
+			Tasks:      template.Tasks,
+		},
+	), template)
+	return translateError(err)
+}
+
+func (r *TemplateRepository) GetByID(ctx context.Context, id int) (*models.ProjectTemplate, error) {
+	query := `SELECT ` + templateColumns + ` FROM project_templates WHERE id = $1`
+
+	var template models.ProjectTemplate
+	err := scanTemplate(r.db.QueryRow(ctx, query, id), &template)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *TemplateRepository) List(ctx context.Context, filter repository.TemplateFilter) (*repository.Page[models.ProjectTemplate], error) {
+	var where filterBuilder
+	if filter.SourceProjectID != 0 {
+		where.add("source_project_id = ?", filter.SourceProjectID)
+	}
+
+	return listPage(ctx, r.db, "project_templates", templateColumns, &where, filter.Page,
+		repository.TemplateSorts, scanTemplate, func(t models.ProjectTemplate) int { return t.ID })
+}
+
+func (r *TemplateRepository) Update(ctx context.Context, template *models.ProjectTemplate) error {
+	query := `
+        UPDATE project_templates
+        SET name = $1, description = $2,
+            version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $3 AND ($4 = 0 OR version = $4)
+        RETURNING ` + templateColumns
+
+	err := scanTemplate(r.db.QueryRow(ctx, query,
+		template.Name,
+		template.Description,
+		template.ID,
+		template.Version,
+	), template)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, r.db, "project_templates", template.ID, template.Version)
+	}
+	return translateError(err)
+}
+
+func (r *TemplateRepository) Delete(ctx context.Context, id, version int) error {
+	query := `DELETE FROM project_templates WHERE id = $1 AND ($2 = 0 OR version = $2)`
+
+	result, err := r.db.Exec(ctx, query, id, version)
+	if err != nil {
+		return translateDeleteError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return missingOrConflict(ctx, r.db, "project_templates", id, version)
+	}
+	return nil
+}
+
+func (r *TemplateRepository) Instantiate(ctx context.Context, template *models.ProjectTemplate, project *models.Project, start models.Date) (*models.ProjectInstance, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+        INSERT INTO projects (name, description, lead_id)
+        VALUES ($1, $2, $3)
+        RETURNING ` + projectColumns
+
+	err = scanProject(tx.QueryRow(ctx, query, project.Name, project.Description, project.LeadID), project)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	instance := &models.ProjectInstance{
+		Project:    *project,
+		Labels:     []models.Label{},
+		Milestones: []models.Milestone{},
+		Tasks:      []models.Task{},
+	}
+
+	for _, employeeID := range template.Members {
+		query = `
+            INSERT INTO employee_projects (employee_id, project_id)
+            VALUES ($1, $2)
+            ON CONFLICT (employee_id, project_id) DO NOTHING`
+		if _, err := tx.Exec(ctx, query, employeeID, project.ID); err != nil {
+			return nil, translateError(err)
+		}
+	}
+
+	labels, err := instantiateLabels(ctx, tx, template.Labels, project.ID, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	milestones := make(map[int]int)
+	for _, m := range template.Milestones {
+		milestone := m.Milestone(project.ID, start)
+		query = `
+            INSERT INTO milestones (project_id, title, description, target_date, status)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING ` + milestoneColumns
+		err := scanMilestone(tx.QueryRow(ctx, query,
+			milestone.ProjectID,
+			milestone.Title,
+			milestone.Description,
+			milestone.TargetDate,
+			milestone.Status,
+		), &milestone)
+		if err != nil {
+			return nil, translateError(err)
+		}
+		milestones[m.Key] = milestone.ID
+		instance.Milestones = append(instance.Milestones, milestone)
+	}
+
+	tasks := make(map[int]int)
+	for _, t := range template.Tasks {
+		task := t.Task(project.ID, start)
+		task.ParentTaskID = mappedKey(tasks, t.ParentKey)
+		task.MilestoneID = mappedKey(milestones, t.MilestoneKey)
+		if err := insertTask(ctx, tx, &task); err != nil {
+			return nil, err
+		}
+		tasks[t.Key] = task.ID
+
+		for _, key := range t.LabelKeys {
+			labelID, ok := labels[key]
+			if !ok {
+				continue
+			}
+			query = `
+                INSERT INTO task_labels (task_id, label_id)
+                VALUES ($1, $2)
+                ON CONFLICT (task_id, label_id) DO NOTHING`
+			if _, err := tx.Exec(ctx, query, task.ID, labelID); err != nil {
+				return nil, translateError(err)
+			}
+		}
+		instance.Tasks = append(instance.Tasks, task)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+// instantiateLabels finds or creates the labels of a template for a new
+// project and adds them to instance. It returns the ID of the label each
+// template key became.
+func instantiateLabels(ctx context.Context, tx pgx.Tx, labels []models.TemplateLabel, projectID int, instance *models.ProjectInstance) (map[int]int, error) {
+	keys := make(map[int]int)
+	byName := make(map[string]models.Label)
+	for _, l := range labels {
+		label, found := models.Label{}, false
+		if l.Global {
+			query := `SELECT ` + labelColumns + ` FROM labels WHERE project_id IS NULL AND lower(name) = lower($1)`
+			err := scanLabel(tx.QueryRow(ctx, query, l.Name), &label)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return nil, err
+			}
+			found = err == nil
+		}
+		if !found {
+			// Labels of the project keep their names unique ignoring case,
+			// so a global label recreated here may meet one of the same
+			// name.
+			label, found = byName[strings.ToLower(l.Name)]
+		}
+		if !found {
+			query := `
+                INSERT INTO labels (project_id, name, color, description)
+                VALUES ($1, $2, $3, $4)
+                RETURNING ` + labelColumns
+			err := scanLabel(tx.QueryRow(ctx, query, projectID, l.Name, l.Color, l.Description), &label)
+			if err != nil {
+				return nil, translateError(err)
+			}
+			byName[strings.ToLower(label.Name)] = label
+		}
+		if !slices.ContainsFunc(instance.Labels, func(other models.Label) bool { return other.ID == label.ID }) {
+			instance.Labels = append(instance.Labels, label)
+		}
+		keys[l.Key] = label.ID
+
+		if l.Attached {
+			query := `
+                INSERT INTO project_labels (project_id, label_id)
+                VALUES ($1, $2)
+                ON CONFLICT (project_id, label_id) DO NOTHING`
+			if _, err := tx.Exec(ctx, query, projectID, label.ID); err != nil {
+				return nil, translateError(err)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// mappedKey returns the ID a template key became, or nil if there is no key
+// or it refers to nothing that was created.
+func mappedKey(ids map[int]int, key *int) *int {
+	if key == nil {
+		return nil
+	}
+	id, ok := ids[*key]
+	if !ok {
+		return nil
+	}
+	return &id
+}
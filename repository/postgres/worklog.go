@@ -0,0 +1,318 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"nstorm.com/main-backend/models"
+	"nstorm.com/main-backend/repository"
+)
+
+// worklogLock, paired with an employee ID, serialises writes to that
+// employee's worklogs so that two concurrent writes cannot both pass the
+// overlap check.
+const worklogLock = 727_151_003
+
+type WorklogRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWorklogRepository(db *pgxpool.Pool) *WorklogRepository {
+	return &WorklogRepository{db: db}
+}
+
+const worklogColumns = `id, task_id, employee_id, started_at, duration_seconds, note, version, created_at, updated_at`
+
+func scanWorklog(row pgx.Row, worklog *models.Worklog) error {
+	return row.Scan(
+		&worklog.ID,
+		&worklog.TaskID,
+		&worklog.EmployeeID,
+		&worklog.StartedAt,
+		&worklog.DurationSeconds,
+		&worklog.Note,
+		&worklog.Version,
+		&worklog.CreatedAt,
+		&worklog.UpdatedAt,
+	)
+}
+
+// lockWorklogs takes the employee's worklog lock and reports whether the
+// employee has logged any time in [start, start+duration) other than the
+// worklog excludeID. A nil duration never ends, like a running timer.
+func lockWorklogs(ctx context.Context, tx pgx.Tx, employeeID, excludeID int, start time.Time, duration *int) (bool, error) {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, worklogLock, employeeID); err != nil {
+		return false, err
+	}
+
+	var end *time.Time
+	if duration != nil {
+		t := start.Add(time.Duration(*duration) * time.Second)
+		end = &t
+	}
+	query := `
+        SELECT EXISTS (
+            SELECT 1 FROM worklogs
+            WHERE employee_id = $1 AND id <> $2
+              AND started_at < COALESCE($4, 'infinity'::timestamptz)
+              AND COALESCE(started_at + duration_seconds * INTERVAL '1 second', 'infinity'::timestamptz) > $3
+        )`
+
+	var overlap bool
+	err := tx.QueryRow(ctx, query, employeeID, excludeID, start, end).Scan(&overlap)
+	return overlap, err
+}
+
+func (r *WorklogRepository) Create(ctx context.Context, worklog *models.Worklog) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	overlap, err := lockWorklogs(ctx, tx, worklog.EmployeeID, 0, worklog.StartedAt, worklog.DurationSeconds)
+	if err != nil {
+		return err
+	}
+	if overlap {
+		return repository.ErrWorklogOverlap
+	}
+
+	query := `
+        INSERT INTO worklogs (task_id, employee_id, started_at, duration_seconds, note)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING ` + worklogColumns
+
+	err = scanWorklog(tx.QueryRow(ctx, query,
+		worklog.TaskID,
+		worklog.EmployeeID,
+		worklog.StartedAt,
+		worklog.DurationSeconds,
+		worklog.Note,
+	), worklog)
+	if err != nil {
+		return translateError(err)
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *WorklogRepository) GetByID(ctx context.Context, id int) (*models.Worklog, error) {
+	query := `SELECT ` + worklogColumns + ` FROM worklogs WHERE id = $1`
+
+	var worklog models.Worklog
+	err := scanWorklog(r.db.QueryRow(ctx, query, id), &worklog)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &worklog, nil
+}
+
+// worklogFilter builds the conditions of a worklog filter. The column
+// names are unambiguous in the report's join as well.
+func worklogFilter(filter repository.WorklogFilter) *filterBuilder {
+	var where filterBuilder
+	if filter.TaskID != 0 {
+		where.add("task_id = ?", filter.TaskID)
+	}
+	if filter.EmployeeID != 0 {
+		where.add("employee_id = ?", filter.EmployeeID)
+	}
+	if filter.ProjectID != 0 {
+		where.add("task_id IN (SELECT id FROM tasks WHERE project_id = ?)", filter.ProjectID)
+	}
+	if filter.From != nil {
+		where.add("started_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		where.add("started_at < ?", *filter.To)
+	}
+	return &where
+}
+
+func (r *WorklogRepository) List(ctx context.Context, filter repository.WorklogFilter) (*repository.Page[models.Worklog], error) {
+	where := worklogFilter(filter)
+	if filter.Running != nil {
+		if *filter.Running {
+			where.add("duration_seconds IS NULL")
+		} else {
+			where.add("duration_seconds IS NOT NULL")
+		}
+	}
+
+	return listPage(ctx, r.db, "worklogs", worklogColumns, where, filter.Page,
+		repository.WorklogSorts, scanWorklog, func(w models.Worklog) int { return w.ID })
+}
+
+func (r *WorklogRepository) Update(ctx context.Context, worklog *models.Worklog) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var employeeID int
+	err = tx.QueryRow(ctx, `SELECT employee_id FROM worklogs WHERE id = $1`, worklog.ID).Scan(&employeeID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	overlap, err := lockWorklogs(ctx, tx, employeeID, worklog.ID, worklog.StartedAt, worklog.DurationSeconds)
+	if err != nil {
+		return err
+	}
+	if overlap {
+		return repository.ErrWorklogOverlap
+	}
+
+	query := `
+        UPDATE worklogs
+        SET started_at = $1, duration_seconds = $2, note = $3,
+            version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $4 AND ($5 = 0 OR version = $5)
+        RETURNING ` + worklogColumns
+
+	err = scanWorklog(tx.QueryRow(ctx, query,
+		worklog.StartedAt,
+		worklog.DurationSeconds,
+		worklog.Note,
+		worklog.ID,
+		worklog.Version,
+	), worklog)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, r.db, "worklogs", worklog.ID, worklog.Version)
+	}
+	if err != nil {
+		return translateError(err)
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *WorklogRepository) Delete(ctx context.Context, id, version int) error {
+	query := `DELETE FROM worklogs WHERE id = $1 AND ($2 = 0 OR version = $2)`
+
+	result, err := r.db.Exec(ctx, query, id, version)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return missingOrConflict(ctx, r.db, "worklogs", id, version)
+	}
+	return nil
+}
+
+func (r *WorklogRepository) Start(ctx context.Context, worklog *models.Worklog) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// CURRENT_TIMESTAMP is the start of the transaction, so the overlap
+	// check and the insert agree on when the timer started.
+	var now time.Time
+	if err := tx.QueryRow(ctx, `SELECT CURRENT_TIMESTAMP`).Scan(&now); err != nil {
+		return err
+	}
+	overlap, err := lockWorklogs(ctx, tx, worklog.EmployeeID, 0, now, nil)
+	if err != nil {
+		return err
+	}
+	if overlap {
+		return repository.ErrWorklogOverlap
+	}
+
+	query := `
+        INSERT INTO worklogs (task_id, employee_id, started_at, note)
+        VALUES ($1, $2, CURRENT_TIMESTAMP, $3)
+        RETURNING ` + worklogColumns
+
+	err = scanWorklog(tx.QueryRow(ctx, query, worklog.TaskID, worklog.EmployeeID, worklog.Note), worklog)
+	if err != nil {
+		return translateError(err)
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *WorklogRepository) Stop(ctx context.Context, employeeID int) (*models.Worklog, error) {
+	query := `
+        UPDATE worklogs
+        SET duration_seconds = GREATEST(0, floor(EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - started_at)))::int,
+            version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE employee_id = $1 AND duration_seconds IS NULL
+        RETURNING ` + worklogColumns
+
+	var worklog models.Worklog
+	err := scanWorklog(r.db.QueryRow(ctx, query, employeeID), &worklog)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &worklog, nil
+}
+
+func (r *WorklogRepository) Running(ctx context.Context, employeeID int) (*models.Worklog, error) {
+	query := `SELECT ` + worklogColumns + ` FROM worklogs WHERE employee_id = $1 AND duration_seconds IS NULL`
+
+	var worklog models.Worklog
+	err := scanWorklog(r.db.QueryRow(ctx, query, employeeID), &worklog)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &worklog, nil
+}
+
+// reportGroups holds the ID and name columns each report groups by.
+var reportGroups = map[models.WorklogGroup]string{
+	models.GroupByEmployee: "e.id, e.name",
+	models.GroupByTask:     "t.id, t.title",
+	models.GroupByProject:  "p.id, p.name",
+}
+
+func (r *WorklogRepository) Report(ctx context.Context, group models.WorklogGroup, filter repository.WorklogFilter) ([]models.WorklogTotal, error) {
+	key, ok := reportGroups[group]
+	if !ok {
+		return nil, fmt.Errorf("unknown worklog group %q", group)
+	}
+	where := worklogFilter(filter)
+	where.add("duration_seconds IS NOT NULL")
+
+	query := `
+        SELECT ` + key + `, count(*), sum(duration_seconds)
+        FROM worklogs w
+        JOIN tasks t ON t.id = w.task_id
+        JOIN projects p ON p.id = t.project_id
+        JOIN employees e ON e.id = w.employee_id` + where.where() + `
+        GROUP BY ` + key + `
+        ORDER BY 1`
+
+	rows, err := r.db.Query(ctx, query, where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []models.WorklogTotal{}
+	for rows.Next() {
+		var total models.WorklogTotal
+		if err := rows.Scan(&total.ID, &total.Name, &total.Entries, &total.Seconds); err != nil {
+			return nil, err
+		}
+		total.Hours = models.SecondsToHours(total.Seconds)
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
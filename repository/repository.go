@@ -0,0 +1,363 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"nstorm.com/main-backend/models"
+)
+
+// ErrNotFound is returned when the requested row does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrDependencyCycle is returned when a new task dependency would make a
+// task depend, directly or transitively, on itself.
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// ErrWorklogOverlap is returned when a worklog or timer would cover time
+// its employee has already logged.
+var ErrWorklogOverlap = errors.New("worklog overlaps another")
+
+// ErrVersionConflict is returned by a conditional write when the row has
+// been modified since the version the caller expected.
+//
+// Update and Patch write only if the stored row still has the Version of
+// their argument, and Delete only if it has the given version; a version of
+// zero makes the write unconditional. Every write increments the version.
+var ErrVersionConflict = errors.New("version conflict")
+
+type ConstraintKind string
+
+const (
+	// ConstraintUnique means the value is already taken by another row.
+	ConstraintUnique ConstraintKind = "unique"
+	// ConstraintForeignKey means a referenced row does not exist.
+	ConstraintForeignKey ConstraintKind = "foreign_key"
+	// ConstraintCheck means the value is outside the allowed set.
+	ConstraintCheck ConstraintKind = "check"
+	// ConstraintNotNull means a required value is missing.
+	ConstraintNotNull ConstraintKind = "not_null"
+	// ConstraintInUse means the row cannot be deleted because other rows
+	// still reference it.
+	ConstraintInUse ConstraintKind = "in_use"
+)
+
+// ConstraintError reports a write rejected by a schema constraint. Field is
+// the JSON name of the offending column when it is known.
+type ConstraintError struct {
+	Kind       ConstraintKind
+	Table      string
+	Field      string
+	Constraint string
+	Message    string
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Message
+}
+
+// TaskAssignment is a task title paired with the name of the employee it
+// should be assigned to, as produced by the task generation service.
+type TaskAssignment struct {
+	Title        string
+	AssigneeName string
+}
+
+// TaskPosition places a task in a board column directly after AfterID or
+// directly before BeforeID, both tasks of that column. At most one is set;
+// with neither the task goes to the bottom of the column.
+type TaskPosition struct {
+	AfterID  int
+	BeforeID int
+}
+
+type EmployeeRepository interface {
+	Create(ctx context.Context, employee *models.Employee) error
+	GetByID(ctx context.Context, id int) (*models.Employee, error)
+	List(ctx context.Context, filter EmployeeFilter) (*Page[models.Employee], error)
+	Update(ctx context.Context, employee *models.Employee) error
+	// Patch writes only the named fields of employee, given by JSON name, and
+	// reloads the rest from the stored row.
+	Patch(ctx context.Context, employee *models.Employee, fields []string) error
+	// Delete soft-deletes an employee, keeping their project memberships
+	// for a restore. It returns an in-use ConstraintError while the employee
+	// leads a project or has open tasks or recurring tasks assigned.
+	Delete(ctx context.Context, id, version int) error
+	// GetDeleted returns a soft-deleted employee, or ErrNotFound if there
+	// is no deleted employee with that ID.
+	GetDeleted(ctx context.Context, id int) (*models.Employee, error)
+	// Restore undoes Delete. It returns ErrNotFound if the employee is not
+	// deleted, and a unique ConstraintError if their email has been taken
+	// since.
+	Restore(ctx context.Context, id, version int) (*models.Employee, error)
+	// Purge hard-deletes the employees deleted before cutoff that nothing
+	// references any more and returns how many there were.
+	Purge(ctx context.Context, cutoff time.Time) (int, error)
+
+	// ListByProject returns the employees assigned to a project, including
+	// their skills.
+	ListByProject(ctx context.Context, projectID int) ([]models.Employee, error)
+	AssignToProject(ctx context.Context, employeeID, projectID int) error
+	RemoveFromProject(ctx context.Context, employeeID, projectID int) error
+	// ListByHandles returns the employees whose email, or the part of it
+	// before the @, equals one of handles, ignoring case.
+	ListByHandles(ctx context.Context, handles []string) ([]models.Employee, error)
+}
+
+type ProjectRepository interface {
+	Create(ctx context.Context, project *models.Project) error
+	GetByID(ctx context.Context, id int) (*models.Project, error)
+	List(ctx context.Context, filter ProjectFilter) (*Page[models.Project], error)
+	Update(ctx context.Context, project *models.Project) error
+	// Patch writes only the named fields of project, given by JSON name, and
+	// reloads the rest from the stored row.
+	Patch(ctx context.Context, project *models.Project, fields []string) error
+	// Delete soft-deletes a project together with its tasks.
+	Delete(ctx context.Context, id, version int) error
+	// GetDeleted returns a soft-deleted project, or ErrNotFound if there is
+	// no deleted project with that ID.
+	GetDeleted(ctx context.Context, id int) (*models.Project, error)
+	// Restore undoes Delete, bringing back the tasks deleted with the
+	// project. It returns ErrNotFound if the project is not deleted.
+	Restore(ctx context.Context, id, version int) (*models.Project, error)
+	// Archive and Unarchive set and clear a project's ArchivedAt. They
+	// return ErrVersionConflict if the project is no longer at version or
+	// already in the requested state.
+	Archive(ctx context.Context, id, version int) (*models.Project, error)
+	Unarchive(ctx context.Context, id, version int) (*models.Project, error)
+	// Purge hard-deletes the projects deleted before cutoff, with
+	// everything that belongs to them, and returns how many there were.
+	Purge(ctx context.Context, cutoff time.Time) (int, error)
+
+	// ListByEmployee returns the projects an employee is assigned to.
+	ListByEmployee(ctx context.Context, employeeID int) ([]models.Project, error)
+}
+
+type TaskRepository interface {
+	Create(ctx context.Context, task *models.Task) error
+	GetByID(ctx context.Context, id int) (*models.Task, error)
+	List(ctx context.Context, filter TaskFilter) (*Page[models.Task], error)
+	// Update and Patch never change the status; that is what Transition is
+	// for.
+	Update(ctx context.Context, task *models.Task) error
+	// Patch writes only the named fields of task, given by JSON name, and
+	// reloads the rest from the stored row.
+	Patch(ctx context.Context, task *models.Task, fields []string) error
+	// Delete soft-deletes a task together with its subtasks. Deleted tasks
+	// are left out of every read but GetDeleted and lists with
+	// IncludeDeleted, and no longer hold up the tasks depending on them.
+	Delete(ctx context.Context, id, version int) error
+	// GetDeleted returns a soft-deleted task, or ErrNotFound if there is no
+	// deleted task with that ID.
+	GetDeleted(ctx context.Context, id int) (*models.Task, error)
+	// Restore undoes Delete, bringing back the subtasks deleted with the
+	// task. It returns ErrNotFound if the task is not deleted.
+	Restore(ctx context.Context, id, version int) (*models.Task, error)
+	// Purge hard-deletes the tasks deleted before cutoff, with their
+	// history, and returns how many there were.
+	Purge(ctx context.Context, cutoff time.Time) (int, error)
+
+	// Transition moves a task from transition.From to transition.To, at the
+	// bottom of its new board column, and records the move, filling in its
+	// ID and CreatedAt. It returns ErrVersionConflict if the task is no
+	// longer at version, or no longer in transition.From.
+	Transition(ctx context.Context, transition *models.TaskTransition, version int) (*models.Task, error)
+	// Move places a task in the board column of transition.To, as given by
+	// position. If transition.To differs from transition.From the status
+	// changes and is recorded as by Transition, in the same transaction.
+	// Besides the errors of Transition it returns ErrVersionConflict if the
+	// task named by position is no longer in that column.
+	Move(ctx context.Context, transition *models.TaskTransition, position TaskPosition, version int) (*models.Task, error)
+	// Transitions returns a task's status history, oldest first.
+	Transitions(ctx context.Context, taskID int) ([]models.TaskTransition, error)
+	// AssigneeChanges returns every reassignment of a task, oldest first.
+	AssigneeChanges(ctx context.Context, taskID int) ([]models.TaskAssigneeChange, error)
+
+	ListByAssignee(ctx context.Context, employeeID int) ([]models.Task, error)
+	ListByAssigneeAndStatus(ctx context.Context, employeeID int, status models.TaskStatus) ([]models.Task, error)
+	ListByProject(ctx context.Context, projectID int) ([]models.Task, error)
+	// Board returns a project's tasks in rank order within each status.
+	Board(ctx context.Context, projectID int) ([]models.Task, error)
+	// Subtree returns a task followed by all of its subtasks, at any depth,
+	// ordered by ID.
+	Subtree(ctx context.Context, id int) ([]models.Task, error)
+
+	// AddDependency records that taskID depends on dependsOnID. Adding an
+	// existing dependency is not an error; one that would close a cycle
+	// fails with ErrDependencyCycle.
+	AddDependency(ctx context.Context, taskID, dependsOnID int) error
+	RemoveDependency(ctx context.Context, taskID, dependsOnID int) error
+	// ListDependencies returns the tasks taskID depends on.
+	ListDependencies(ctx context.Context, taskID int) ([]models.Task, error)
+	// ListDependents returns the tasks that depend on taskID.
+	ListDependents(ctx context.Context, taskID int) ([]models.Task, error)
+	// ProjectDependencies returns the dependencies between tasks of a
+	// project.
+	ProjectDependencies(ctx context.Context, projectID int) ([]models.TaskDependency, error)
+
+	// CreateAssigned inserts every assignment for the project in a single
+	// transaction, resolving assignees by employee name. Nothing is inserted
+	// if any assignee cannot be resolved.
+	CreateAssigned(ctx context.Context, projectID int, assignments []TaskAssignment) ([]models.Task, error)
+}
+
+type CommentRepository interface {
+	// Create stores a comment together with its mentions.
+	Create(ctx context.Context, comment *models.Comment) error
+	GetByID(ctx context.Context, id int) (*models.Comment, error)
+	List(ctx context.Context, filter CommentFilter) (*Page[models.Comment], error)
+	// ListByTask returns every comment on a task, oldest first.
+	ListByTask(ctx context.Context, taskID int) ([]models.Comment, error)
+	// Update replaces a comment's body and mentions; the task and author
+	// never change.
+	Update(ctx context.Context, comment *models.Comment) error
+	Delete(ctx context.Context, id, version int) error
+}
+
+type LabelRepository interface {
+	Create(ctx context.Context, label *models.Label) error
+	GetByID(ctx context.Context, id int) (*models.Label, error)
+	List(ctx context.Context, filter LabelFilter) (*Page[models.Label], error)
+	// Update writes the name, color and description; a label's project
+	// never changes.
+	Update(ctx context.Context, label *models.Label) error
+	Delete(ctx context.Context, id, version int) error
+
+	// AttachToTask is a no-op if the label is already attached.
+	AttachToTask(ctx context.Context, taskID, labelID int) error
+	DetachFromTask(ctx context.Context, taskID, labelID int) error
+	// TaskLabels returns the labels attached to a task.
+	TaskLabels(ctx context.Context, taskID int) ([]models.Label, error)
+	// AttachToProject is a no-op if the label is already attached.
+	AttachToProject(ctx context.Context, projectID, labelID int) error
+	DetachFromProject(ctx context.Context, projectID, labelID int) error
+	// ProjectLabels returns the labels attached to a project.
+	ProjectLabels(ctx context.Context, projectID int) ([]models.Label, error)
+
+	// Stats counts the attachments of every label the filter selects,
+	// ordered by label ID. With a ProjectID only that project's tasks are
+	// counted.
+	Stats(ctx context.Context, filter LabelFilter) ([]models.LabelStats, error)
+}
+
+type WorklogRepository interface {
+	// Create stores a finished worklog. It fails with ErrWorklogOverlap if
+	// the employee has already logged any of its time.
+	Create(ctx context.Context, worklog *models.Worklog) error
+	GetByID(ctx context.Context, id int) (*models.Worklog, error)
+	List(ctx context.Context, filter WorklogFilter) (*Page[models.Worklog], error)
+	// Update writes the start, duration and note; the task and employee
+	// never change. Giving a running timer a duration stops it.
+	Update(ctx context.Context, worklog *models.Worklog) error
+	Delete(ctx context.Context, id, version int) error
+
+	// Start begins a timer for the employee at the current time, filling in
+	// StartedAt. It fails with ErrWorklogOverlap if the employee already has
+	// a running timer or has logged time after now.
+	Start(ctx context.Context, worklog *models.Worklog) error
+	// Stop ends the employee's running timer, setting its duration to the
+	// time elapsed since it started, or returns ErrNotFound.
+	Stop(ctx context.Context, employeeID int) (*models.Worklog, error)
+	// Running returns the employee's running timer, or ErrNotFound.
+	Running(ctx context.Context, employeeID int) (*models.Worklog, error)
+
+	// Report totals the finished worklogs the filter selects per employee,
+	// task or project, ordered by ID. The filter's Running and Page are
+	// ignored.
+	Report(ctx context.Context, group models.WorklogGroup, filter WorklogFilter) ([]models.WorklogTotal, error)
+}
+
+type RecurringTaskRepository interface {
+	Create(ctx context.Context, recurring *models.RecurringTask) error
+	GetByID(ctx context.Context, id int) (*models.RecurringTask, error)
+	List(ctx context.Context, filter RecurringTaskFilter) (*Page[models.RecurringTask], error)
+	// Update writes every field except the project, which never changes,
+	// and LastRunAt, which only RunDue writes.
+	Update(ctx context.Context, recurring *models.RecurringTask) error
+	Delete(ctx context.Context, id, version int) error
+
+	// RunDue creates a task for every unpaused definition whose NextRunAt
+	// is not after now, for the occurrence models.RecurringTask.Catchup
+	// picks, and advances NextRunAt past now. No occurrence ever produces a
+	// second task, even across restarts or concurrent callers. It returns
+	// the tasks created; a definition that fails does not stop the others.
+	RunDue(ctx context.Context, now time.Time) ([]models.Task, error)
+	// Tasks returns the tasks created from a definition that still exist,
+	// oldest occurrence first.
+	Tasks(ctx context.Context, id int) ([]models.Task, error)
+}
+
+type SprintRepository interface {
+	Create(ctx context.Context, sprint *models.Sprint) error
+	GetByID(ctx context.Context, id int) (*models.Sprint, error)
+	List(ctx context.Context, filter SprintFilter) (*Page[models.Sprint], error)
+	// Update writes the name, goal and dates. The project never changes and
+	// the state only through Start and Close.
+	Update(ctx context.Context, sprint *models.Sprint) error
+	// Delete removes a sprint, returning its tasks to the backlog.
+	Delete(ctx context.Context, id, version int) error
+
+	// Start makes a planned sprint active and records the estimate of its
+	// tasks that are not cancelled as its committed hours. It returns
+	// ErrVersionConflict if the sprint is no longer planned or at version,
+	// and a unique ConstraintError if the project already has an active
+	// sprint.
+	Start(ctx context.Context, id, version int) (*models.Sprint, error)
+	// Close closes an active sprint, recording the estimate and number of
+	// its done tasks, and moves its open tasks to the planned sprint nextID
+	// of the same project, or to the backlog if nextID is zero, in one
+	// transaction. It returns the closed sprint and the tasks moved, and
+	// ErrVersionConflict if the sprint is no longer active or at version, or
+	// nextID is no longer a planned sprint of the project.
+	Close(ctx context.Context, id, nextID, version int) (*models.Sprint, []models.Task, error)
+	// Tasks returns the tasks in a sprint, ordered by ID.
+	Tasks(ctx context.Context, id int) ([]models.Task, error)
+}
+
+type MilestoneRepository interface {
+	Create(ctx context.Context, milestone *models.Milestone) error
+	GetByID(ctx context.Context, id int) (*models.Milestone, error)
+	List(ctx context.Context, filter MilestoneFilter) (*Page[models.Milestone], error)
+	// ListByProject returns a project's milestones, earliest target date
+	// first.
+	ListByProject(ctx context.Context, projectID int) ([]models.Milestone, error)
+	// Update writes the title, description, target date and status. The
+	// project never changes.
+	Update(ctx context.Context, milestone *models.Milestone) error
+	// Delete removes a milestone, unlinking its tasks.
+	Delete(ctx context.Context, id, version int) error
+	// Tasks returns the tasks linked to a milestone, ordered by ID.
+	Tasks(ctx context.Context, id int) ([]models.Task, error)
+}
+
+type TemplateRepository interface {
+	Create(ctx context.Context, template *models.ProjectTemplate) error
+	GetByID(ctx context.Context, id int) (*models.ProjectTemplate, error)
+	List(ctx context.Context, filter TemplateFilter) (*Page[models.ProjectTemplate], error)
+	// Update writes the name and description; the plan a template holds
+	// never changes.
+	Update(ctx context.Context, template *models.ProjectTemplate) error
+	Delete(ctx context.Context, id, version int) error
+
+	// Instantiate creates project, led by its LeadID, from template in one
+	// transaction: its members, labels, open milestones and tasks to do,
+	// with every date laid out from start. Global labels are reused by name
+	// and created as labels of the project if they no longer exist. The
+	// template need not be stored.
+	Instantiate(ctx context.Context, template *models.ProjectTemplate, project *models.Project, start models.Date) (*models.ProjectInstance, error)
+}
+
+// Repositories bundles the repositories the handlers depend on.
+type Repositories struct {
+	Employees      EmployeeRepository
+	Projects       ProjectRepository
+	Tasks          TaskRepository
+	Comments       CommentRepository
+	Labels         LabelRepository
+	Worklogs       WorklogRepository
+	RecurringTasks RecurringTaskRepository
+	Sprints        SprintRepository
+	Milestones     MilestoneRepository
+	Templates      TemplateRepository
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"nstorm.com/main-backend/repository"
+)
+
+// runScheduler creates the tasks of due recurring task definitions now and
+// then every interval until ctx is cancelled. Which occurrences have been
+// created is recorded in the database, so restarting the server or running
+// several instances never creates a task twice.
+func runScheduler(ctx context.Context, interval time.Duration, recurring repository.RecurringTaskRepository) {
+	slog.Info("scheduler starting", "interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runDue(ctx, interval, recurring)
+		select {
+		case <-ctx.Done():
+			slog.Info("scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDue creates the tasks due now, giving up after interval so that a
+// slow database cannot make runs pile up.
+func runDue(ctx context.Context, interval time.Duration, recurring repository.RecurringTaskRepository) {
+	ctx, cancel := context.WithTimeout(ctx, interval)
+	defer cancel()
+
+	created, err := recurring.RunDue(ctx, time.Now())
+	for _, task := range created {
+		slog.Info("recurring task created", "task_id", task.ID, "project_id", task.ProjectID, "title", task.Title)
+	}
+	if err != nil && ctx.Err() == nil {
+		slog.Error("creating recurring tasks failed", "error", err)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"nstorm.com/main-backend/config"
+)
+
+// freePort returns a port that nothing is listening on.
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// startServer runs serve in the background with a handler that signals
+// started and then blocks until release is closed.
+func startServer(t *testing.T, cfg config.ServerConfig) (url string, started chan struct{}, release chan struct{}, cancel context.CancelFunc, done chan error) {
+	t.Helper()
+	port := freePort(t)
+	started = make(chan struct{}, 1)
+	release = make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		io.WriteString(w, "done")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done = make(chan error, 1)
+	go func() { done <- serve(ctx, cfg, port, handler) }()
+
+	url = fmt.Sprintf("http://127.0.0.1:%d/", port)
+	for i := 0; ; i++ {
+		conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if i == 100 {
+			t.Fatalf("server did not start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return url, started, release, cancel, done
+}
+
+func TestServeFinishesInFlightRequestsOnShutdown(t *testing.T) {
+	cfg := config.Default().Server
+	cfg.ShutdownTimeout = 5 * time.Second
+	url, started, release, cancel, done := startServer(t, cfg)
+
+	type result struct {
+		body string
+		err  error
+	}
+	response := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		response <- result{string(body), err}
+	}()
+
+	<-started
+	cancel()
+	select {
+	case err := <-done:
+		t.Fatalf("serve returned %v with a request in flight", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	if r := <-response; r.err != nil || r.body != "done" {
+		t.Fatalf("in-flight request got %q, %v", r.body, r.err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("serve returned %v", err)
+	}
+}
+
+func TestServeClosesConnectionsAfterShutdownTimeout(t *testing.T) {
+	cfg := config.Default().Server
+	cfg.ShutdownTimeout = 50 * time.Millisecond
+	url, started, release, cancel, done := startServer(t, cfg)
+	defer close(release)
+
+	response := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		response <- err
+	}()
+
+	<-started
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("serve returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not give up on the in-flight request")
+	}
+	if err := <-response; err == nil {
+		t.Fatal("in-flight request succeeded after its connection was closed")
+	}
+}
@@ -0,0 +1,184 @@
+// Package validation checks request payloads against rules declared in
+// `validate` struct tags, collecting every failure instead of stopping at
+// the first one.
+//
+// Supported rules, comma separated:
+//
+//	required   string non-blank, number non-zero, pointer non-nil, slice non-empty
+//	max=N      string at most N characters, slice at most N items, number at most N
+//	min=N      string at least N characters, slice at least N items, number at least N
+//	email      string is a plain address such as name@example.com
+//	oneof=A B  string is one of the space-separated values
+//	enum       value's Valid() method returns true
+//
+// Rules other than required are skipped for zero values, so optional
+// fields are only checked when present. Fields are reported by their JSON
+// name.
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError describes a problem with one field of a payload.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors is every field error found in a payload.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + " " + fe.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Add records a field error.
+func (e *Errors) Add(field, message string) {
+	*e = append(*e, FieldError{Field: field, Message: message})
+}
+
+// Has reports whether field already has an error.
+func (e Errors) Has(field string) bool {
+	for _, fe := range e {
+		if fe.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns e as an error, or nil when it is empty.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+type validatable interface {
+	Valid() bool
+}
+
+// Struct validates the exported fields of v, which must be a struct or a
+// pointer to one.
+func Struct(v any) Errors {
+	var errs Errors
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: %T is not a struct", v))
+	}
+
+	structType := value.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || !field.IsExported() {
+			continue
+		}
+		name := jsonName(field)
+		for _, rule := range strings.Split(tag, ",") {
+			if message := check(value.Field(i), rule); message != "" {
+				errs.Add(name, message)
+				break
+			}
+		}
+	}
+	return errs
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func check(value reflect.Value, rule string) string {
+	name, arg, _ := strings.Cut(rule, "=")
+
+	if name == "required" {
+		if isBlank(value) {
+			return "is required"
+		}
+		return ""
+	}
+	if value.IsZero() {
+		return ""
+	}
+	if value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+
+	switch name {
+	case "max", "min":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validation: bad %s limit %q", name, arg))
+		}
+		size, unit := measure(value)
+		if name == "max" && size > limit {
+			return fmt.Sprintf("must be at most %s%s", arg, unit)
+		}
+		if name == "min" && size < limit {
+			return fmt.Sprintf("must be at least %s%s", arg, unit)
+		}
+	case "email":
+		address, err := mail.ParseAddress(value.String())
+		if err != nil || address.Address != value.String() || address.Name != "" {
+			return "must be a valid email address"
+		}
+	case "oneof":
+		allowed := strings.Fields(arg)
+		for _, option := range allowed {
+			if value.String() == option {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(allowed, ", ")
+	case "enum":
+		if v, ok := value.Interface().(validatable); ok && !v.Valid() {
+			return fmt.Sprintf("%v is not a valid value", value.Interface())
+		}
+	default:
+		panic(fmt.Sprintf("validation: unknown rule %q", rule))
+	}
+	return ""
+}
+
+func isBlank(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+// measure returns the size that max and min compare against.
+func measure(value reflect.Value) (float64, string) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), " characters"
+	case reflect.Slice, reflect.Map:
+		return float64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), ""
+	}
+	panic(fmt.Sprintf("validation: cannot measure %s", value.Kind()))
+}
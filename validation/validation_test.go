@@ -0,0 +1,113 @@
+package validation
+
+import (
+	"slices"
+	"testing"
+)
+
+type color string
+
+func (c color) Valid() bool { return c == "red" || c == "blue" }
+
+type payload struct {
+	Name     string   `json:"name" validate:"required,max=5"`
+	Nickname string   `json:"nickname,omitempty" validate:"min=2"`
+	Email    string   `json:"email" validate:"email"`
+	Size     string   `json:"size" validate:"oneof=S M L"`
+	Color    color    `json:"color" validate:"enum"`
+	Count    int      `json:"count" validate:"max=10"`
+	Ratio    *float64 `json:"ratio" validate:"required,min=0.5"`
+	Tags     []string `json:"tags" validate:"max=2"`
+	NoJSON   string   `validate:"required"`
+	Ignored  string   `json:"ignored"`
+}
+
+func valid() payload {
+	ratio := 1.0
+	return payload{Name: "Ada", Ratio: &ratio, NoJSON: "x"}
+}
+
+func TestStructValid(t *testing.T) {
+	p := valid()
+	if errs := Struct(&p); errs.Err() != nil {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+
+	// Optional fields are only checked when set.
+	ratio := 0.75
+	p = payload{
+		Name: "Ada", Nickname: "Al", Email: "ada@example.com", Size: "M", Color: "red",
+		Count: 10, Ratio: &ratio, Tags: []string{"a", "b"}, NoJSON: "x",
+	}
+	if errs := Struct(p); errs.Err() != nil {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+}
+
+func TestStructRules(t *testing.T) {
+	small := 0.25
+	tests := []struct {
+		name   string
+		modify func(*payload)
+		want   Errors
+	}{
+		{"blank required string", func(p *payload) { p.Name = "  " }, Errors{{"name", "is required"}}},
+		{"nil required pointer", func(p *payload) { p.Ratio = nil }, Errors{{"ratio", "is required"}}},
+		{"string too long", func(p *payload) { p.Name = "Adaline" }, Errors{{"name", "must be at most 5 characters"}}},
+		{"max counts runes", func(p *payload) { p.Name = "Zoë" }, nil},
+		{"string too short", func(p *payload) { p.Nickname = "A" }, Errors{{"nickname", "must be at least 2 characters"}}},
+		{"bad email", func(p *payload) { p.Email = "ada" }, Errors{{"email", "must be a valid email address"}}},
+		{"named email", func(p *payload) { p.Email = "Ada <ada@example.com>" }, Errors{{"email", "must be a valid email address"}}},
+		{"not one of", func(p *payload) { p.Size = "XL" }, Errors{{"size", "must be one of S, M, L"}}},
+		{"invalid enum", func(p *payload) { p.Color = "green" }, Errors{{"color", "green is not a valid value"}}},
+		{"number too big", func(p *payload) { p.Count = 11 }, Errors{{"count", "must be at most 10"}}},
+		{"pointer too small", func(p *payload) { p.Ratio = &small }, Errors{{"ratio", "must be at least 0.5"}}},
+		{"too many items", func(p *payload) { p.Tags = []string{"a", "b", "c"} }, Errors{{"tags", "must be at most 2 items"}}},
+		{"no json name", func(p *payload) { p.NoJSON = "" }, Errors{{"NoJSON", "is required"}}},
+		{
+			"every failure reported",
+			func(p *payload) { p.Name = ""; p.Size = "XL"; p.Count = 11 },
+			Errors{{"name", "is required"}, {"size", "must be one of S, M, L"}, {"count", "must be at most 10"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid()
+			tt.modify(&p)
+			if got := Struct(&p); !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var errs Errors
+	if errs.Err() != nil {
+		t.Fatal("empty Errors is not nil")
+	}
+	errs.Add("name", "is required")
+	errs.Add("email", "must be a valid email address")
+	if !errs.Has("email") || errs.Has("role") {
+		t.Fatalf("Has is wrong for %v", errs)
+	}
+	if got, want := errs.Err().Error(), "name is required; email must be a valid email address"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestStructPanicsOnMisuse(t *testing.T) {
+	type badRule struct {
+		Name string `validate:"shiny"`
+	}
+	for name, v := range map[string]any{"not a struct": 42, "unknown rule": badRule{Name: "x"}} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("did not panic")
+				}
+			}()
+			Struct(v)
+		})
+	}
+}
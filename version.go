@@ -0,0 +1,40 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"nstorm.com/main-backend/handlers"
+)
+
+// Set at build time, e.g.
+// go build -ldflags "-X main.commit=$(git rev-parse HEAD) -X main.buildTime=$(date -u +%FT%TZ)"
+var (
+	commit    string
+	buildTime string
+)
+
+// buildInfo falls back to the VCS stamp the Go toolchain embeds when the
+// linker flags were not given.
+func buildInfo() handlers.BuildInfo {
+	info := handlers.BuildInfo{
+		Commit:    commit,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = setting.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	return info
+}